@@ -0,0 +1,211 @@
+// Package chat formats event announcements for Slack and Discord incoming webhooks.
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported chat platforms
+const (
+	PlatformSlack   = "slack"
+	PlatformDiscord = "discord"
+)
+
+// Announcement kinds
+const (
+	KindEventCreated    = "event_created"
+	KindLineupFinalized = "lineup_finalized"
+	KindTonight         = "tonight"
+	KindTest            = "test"
+)
+
+// IsValidPlatform checks if the platform has a formatter
+func IsValidPlatform(platform string) bool {
+	return platform == PlatformSlack || platform == PlatformDiscord
+}
+
+// LineupGame is one game in an announced lineup
+type LineupGame struct {
+	Name    string
+	Players []string
+}
+
+// Announcement is everything a chat post says about an event
+type Announcement struct {
+	Kind      string
+	GroupName string
+	Title     string
+	StartTime time.Time
+	Location  string
+	MCName    string
+	Attending int
+	Maybe     int
+	Lineup    []LineupGame
+	URL       string
+}
+
+// Headline is the one line summary shown in notifications and as the fallback text
+func (a Announcement) Headline() string {
+	switch a.Kind {
+	case KindLineupFinalized:
+		return fmt.Sprintf("The lineup for %s is set!", a.Title)
+	case KindTonight:
+		return fmt.Sprintf("Tonight: %s", a.Title)
+	case KindTest:
+		return fmt.Sprintf("Test announcement from %s", a.GroupName)
+	default:
+		return fmt.Sprintf("New event: %s", a.Title)
+	}
+}
+
+func (a Announcement) rsvpSummary() string {
+	return fmt.Sprintf("%d attending, %d maybe", a.Attending, a.Maybe)
+}
+
+func (a Announcement) mcName() string {
+	if a.MCName == "" {
+		return "TBD"
+	}
+	return a.MCName
+}
+
+// Format renders the announcement as the JSON body for the platform's incoming webhook
+func Format(platform string, a Announcement) ([]byte, error) {
+	switch platform {
+	case PlatformSlack:
+		return json.Marshal(SlackMessage(a))
+	case PlatformDiscord:
+		return json.Marshal(DiscordMessage(a))
+	default:
+		return nil, fmt.Errorf("unsupported chat platform: %s", platform)
+	}
+}
+
+// slackEscape escapes the characters Slack treats as control sequences in mrkdwn
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func slackText(textType, text string) map[string]interface{} {
+	return map[string]interface{}{"type": textType, "text": text}
+}
+
+// SlackMessage builds a Block Kit message. Times use Slack's date formatting so
+// everyone sees them in their own time zone.
+func SlackMessage(a Announcement) map[string]interface{} {
+	when := fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s>", a.StartTime.Unix(), a.StartTime.UTC().Format("Mon Jan 2 15:04 UTC"))
+
+	fields := []interface{}{
+		slackText("mrkdwn", "*When*\n"+when),
+		slackText("mrkdwn", "*MC*\n"+slackEscape(a.mcName())),
+		slackText("mrkdwn", "*RSVPs*\n"+a.rsvpSummary()),
+	}
+	if a.Location != "" {
+		fields = append(fields, slackText("mrkdwn", "*Where*\n"+slackEscape(a.Location)))
+	}
+
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": slackText("plain_text", a.Headline()),
+		},
+		map[string]interface{}{
+			"type":   "section",
+			"text":   slackText("mrkdwn", fmt.Sprintf("*%s* · %s", slackEscape(a.Title), slackEscape(a.GroupName))),
+			"fields": fields,
+		},
+	}
+
+	if len(a.Lineup) > 0 {
+		var lineup strings.Builder
+		lineup.WriteString("*Lineup*")
+		for i, game := range a.Lineup {
+			fmt.Fprintf(&lineup, "\n%d. %s", i+1, slackEscape(game.Name))
+			if len(game.Players) > 0 {
+				fmt.Fprintf(&lineup, " — %s", slackEscape(strings.Join(game.Players, ", ")))
+			}
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": slackText("mrkdwn", lineup.String()),
+		})
+	}
+
+	if a.URL != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{
+				map[string]interface{}{
+					"type": "button",
+					"text": slackText("plain_text", "View event"),
+					"url":  a.URL,
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"text":   a.Headline(),
+		"blocks": blocks,
+	}
+}
+
+// discordColor is the embed accent color
+const discordColor = 0x7E57C2
+
+// Discord rejects embed field values longer than this
+const discordFieldLimit = 1024
+
+const truncatedMarker = "\n…"
+
+// DiscordMessage builds a message with a single embed. Times use Discord's
+// timestamp markup so everyone sees them in their own time zone.
+func DiscordMessage(a Announcement) map[string]interface{} {
+	fields := []interface{}{
+		map[string]interface{}{"name": "When", "value": fmt.Sprintf("<t:%d:F> (<t:%d:R>)", a.StartTime.Unix(), a.StartTime.Unix()), "inline": false},
+		map[string]interface{}{"name": "MC", "value": a.mcName(), "inline": true},
+		map[string]interface{}{"name": "RSVPs", "value": a.rsvpSummary(), "inline": true},
+	}
+	if a.Location != "" {
+		fields = append(fields, map[string]interface{}{"name": "Where", "value": a.Location, "inline": true})
+	}
+
+	if len(a.Lineup) > 0 {
+		var lineup strings.Builder
+		for i, game := range a.Lineup {
+			line := fmt.Sprintf("%d. **%s**", i+1, game.Name)
+			if len(game.Players) > 0 {
+				line += " — " + strings.Join(game.Players, ", ")
+			}
+			// Leave room for the ellipsis that marks a cut-off lineup
+			if lineup.Len()+len(line)+1 > discordFieldLimit-len(truncatedMarker) {
+				lineup.WriteString(truncatedMarker)
+				break
+			}
+			if i > 0 {
+				lineup.WriteString("\n")
+			}
+			lineup.WriteString(line)
+		}
+		fields = append(fields, map[string]interface{}{"name": "Lineup", "value": lineup.String(), "inline": false})
+	}
+
+	embed := map[string]interface{}{
+		"title":       a.Title,
+		"description": a.GroupName,
+		"color":       discordColor,
+		"fields":      fields,
+		"timestamp":   a.StartTime.UTC().Format(time.RFC3339),
+	}
+	if a.URL != "" {
+		embed["url"] = a.URL
+	}
+
+	return map[string]interface{}{
+		"content": a.Headline(),
+		"embeds":  []interface{}{embed},
+	}
+}
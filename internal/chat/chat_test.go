@@ -0,0 +1,192 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAnnouncement() Announcement {
+	return Announcement{
+		Kind:      KindLineupFinalized,
+		GroupName: "Harold Night",
+		Title:     "Friday <Show> & Jam",
+		StartTime: time.Date(2026, 3, 6, 20, 0, 0, 0, time.UTC),
+		Location:  "The Basement",
+		MCName:    "Jane Doe",
+		Attending: 8,
+		Maybe:     2,
+		Lineup: []LineupGame{
+			{Name: "Freeze Tag", Players: []string{"Jane Doe", "John Smith"}},
+			{Name: "Party Quirks"},
+		},
+		URL: "https://improv.example.com/events/event123",
+	}
+}
+
+func TestFormat_UnknownPlatform(t *testing.T) {
+	if _, err := Format("teams", testAnnouncement()); err == nil {
+		t.Errorf("Expected an error for an unsupported platform")
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	body, err := Format(PlatformSlack, testAnnouncement())
+	if err != nil {
+		t.Fatalf("Error formatting Slack message: %v", err)
+	}
+
+	var message struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"text"`
+			Fields []struct {
+				Text string `json:"text"`
+			} `json:"fields"`
+			Elements []struct {
+				URL string `json:"url"`
+			} `json:"elements"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(body, &message); err != nil {
+		t.Fatalf("Error decoding Slack message: %v", err)
+	}
+
+	if message.Text != "The lineup for Friday <Show> & Jam is set!" {
+		t.Errorf("Unexpected fallback text: %s", message.Text)
+	}
+	if len(message.Blocks) != 4 {
+		t.Fatalf("Expected header, details, lineup and actions blocks, got %d", len(message.Blocks))
+	}
+	if message.Blocks[0].Type != "header" || message.Blocks[0].Text.Type != "plain_text" {
+		t.Errorf("Expected a plain text header, got %+v", message.Blocks[0])
+	}
+
+	details := message.Blocks[1]
+	if !strings.Contains(details.Text.Text, "Friday &lt;Show&gt; &amp; Jam") {
+		t.Errorf("Expected the title to be escaped, got %s", details.Text.Text)
+	}
+	fields := []string{}
+	for _, field := range details.Fields {
+		fields = append(fields, field.Text)
+	}
+	joined := strings.Join(fields, "\n")
+	for _, want := range []string{"<!date^1772827200^", "Jane Doe", "8 attending, 2 maybe", "The Basement"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Expected fields to contain %q, got %s", want, joined)
+		}
+	}
+
+	lineup := message.Blocks[2].Text.Text
+	if !strings.Contains(lineup, "1. Freeze Tag — Jane Doe, John Smith") || !strings.Contains(lineup, "2. Party Quirks") {
+		t.Errorf("Unexpected lineup: %s", lineup)
+	}
+
+	if len(message.Blocks[3].Elements) != 1 || message.Blocks[3].Elements[0].URL != "https://improv.example.com/events/event123" {
+		t.Errorf("Expected a link button to the event, got %+v", message.Blocks[3])
+	}
+}
+
+func TestSlackMessage_OmitsEmptySections(t *testing.T) {
+	a := testAnnouncement()
+	a.Kind = KindEventCreated
+	a.Location = ""
+	a.MCName = ""
+	a.Lineup = nil
+	a.URL = ""
+
+	message := SlackMessage(a)
+	blocks := message["blocks"].([]interface{})
+	if len(blocks) != 2 {
+		t.Fatalf("Expected only header and details blocks, got %d", len(blocks))
+	}
+
+	fields := blocks[1].(map[string]interface{})["fields"].([]interface{})
+	if len(fields) != 3 {
+		t.Errorf("Expected no location field, got %d fields", len(fields))
+	}
+	if mc := fields[1].(map[string]interface{})["text"]; mc != "*MC*\nTBD" {
+		t.Errorf("Expected MC to be TBD, got %v", mc)
+	}
+}
+
+func TestDiscordMessage(t *testing.T) {
+	body, err := Format(PlatformDiscord, testAnnouncement())
+	if err != nil {
+		t.Fatalf("Error formatting Discord message: %v", err)
+	}
+
+	var message struct {
+		Content string `json:"content"`
+		Embeds  []struct {
+			Title     string `json:"title"`
+			URL       string `json:"url"`
+			Color     int    `json:"color"`
+			Timestamp string `json:"timestamp"`
+			Fields    []struct {
+				Name   string `json:"name"`
+				Value  string `json:"value"`
+				Inline bool   `json:"inline"`
+			} `json:"fields"`
+		} `json:"embeds"`
+	}
+	if err := json.Unmarshal(body, &message); err != nil {
+		t.Fatalf("Error decoding Discord message: %v", err)
+	}
+
+	if message.Content != "The lineup for Friday <Show> & Jam is set!" {
+		t.Errorf("Unexpected content: %s", message.Content)
+	}
+	if len(message.Embeds) != 1 {
+		t.Fatalf("Expected 1 embed, got %d", len(message.Embeds))
+	}
+
+	embed := message.Embeds[0]
+	if embed.Title != "Friday <Show> & Jam" || embed.URL != "https://improv.example.com/events/event123" || embed.Color != discordColor {
+		t.Errorf("Unexpected embed: %+v", embed)
+	}
+	if embed.Timestamp != "2026-03-06T20:00:00Z" {
+		t.Errorf("Expected RFC3339 timestamp, got %s", embed.Timestamp)
+	}
+
+	values := map[string]string{}
+	for _, field := range embed.Fields {
+		values[field.Name] = field.Value
+	}
+	expected := map[string]string{
+		"When":   "<t:1772827200:F> (<t:1772827200:R>)",
+		"MC":     "Jane Doe",
+		"RSVPs":  "8 attending, 2 maybe",
+		"Where":  "The Basement",
+		"Lineup": "1. **Freeze Tag** — Jane Doe, John Smith\n2. **Party Quirks**",
+	}
+	for name, want := range expected {
+		if values[name] != want {
+			t.Errorf("Expected %s field %q, got %q", name, want, values[name])
+		}
+	}
+}
+
+func TestDiscordMessage_TruncatesLongLineups(t *testing.T) {
+	a := testAnnouncement()
+	a.Lineup = nil
+	for i := 0; i < 100; i++ {
+		a.Lineup = append(a.Lineup, LineupGame{Name: "Questions Only", Players: []string{"Jane Doe", "John Smith"}})
+	}
+
+	embed := DiscordMessage(a)["embeds"].([]interface{})[0].(map[string]interface{})
+	fields := embed["fields"].([]interface{})
+	lineup := fields[len(fields)-1].(map[string]interface{})["value"].(string)
+
+	if len(lineup) > discordFieldLimit {
+		t.Errorf("Expected lineup within %d bytes, got %d", discordFieldLimit, len(lineup))
+	}
+	if !strings.HasSuffix(lineup, "\n…") {
+		t.Errorf("Expected truncated lineup to end with an ellipsis")
+	}
+}
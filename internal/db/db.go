@@ -4,10 +4,15 @@ import (
 	"database/sql"
 	"log"
 	"os"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// mcRolePermissions are the permissions the MC role is created with, matching
+// services.DefaultEventRoles
+const mcRolePermissions = "edit_lineup,view_lineup,take_attendance,run_show"
+
 func InitDB() *sql.DB {
 	dbPath := os.Getenv("DATABASE_PATH")
 	if dbPath == "" {
@@ -254,6 +259,478 @@ func InitDB() *sql.DB {
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_non_registered_attendees_event_id ON non_registered_attendees(event_id);`)
 	// Ignore error - it will fail if index already exists, which is fine
 
+	// Add event reminders log so reminders are only sent once per offset, even across restarts
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_reminders (
+			event_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			offset_minutes INTEGER NOT NULL,
+			sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (event_id, user_id, offset_minutes),
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+
+	// Add notification preferences, keyed by user, group ('' for all groups), type and channel
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id TEXT NOT NULL,
+			group_id TEXT NOT NULL DEFAULT '',
+			notification_type TEXT NOT NULL,
+			channel TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, group_id, notification_type, channel),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+
+	// Add in-app notifications table
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			content TEXT NOT NULL,
+			related_id TEXT,
+			is_read BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at);`)
+
+	// Add weekly digest log so each user gets one digest per week
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS digest_log (
+			user_id TEXT NOT NULL,
+			week_start TEXT NOT NULL,
+			sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, week_start),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+
+	// Add outbound webhooks and their delivery log
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_webhooks (
+			id TEXT PRIMARY KEY,
+			group_id TEXT NOT NULL,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (group_id) REFERENCES improv_groups(id),
+			FOREIGN KEY (created_by) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_status_code INTEGER,
+			last_error TEXT,
+			next_attempt_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP,
+			FOREIGN KEY (webhook_id) REFERENCES group_webhooks(id) ON DELETE CASCADE
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status, next_attempt_at);`)
+
+	// Add chat integrations that post event announcements to Slack or Discord
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_chat_integrations (
+			id TEXT PRIMARY KEY,
+			group_id TEXT NOT NULL,
+			platform TEXT NOT NULL,
+			webhook_url TEXT NOT NULL,
+			announce_events BOOLEAN NOT NULL DEFAULT TRUE,
+			announce_lineups BOOLEAN NOT NULL DEFAULT TRUE,
+			daily_post BOOLEAN NOT NULL DEFAULT FALSE,
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (group_id) REFERENCES improv_groups(id),
+			FOREIGN KEY (created_by) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS chat_posts (
+			integration_id TEXT NOT NULL,
+			event_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			posted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (integration_id, event_id, kind),
+			FOREIGN KEY (integration_id) REFERENCES group_chat_integrations(id) ON DELETE CASCADE,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_group_chat_integrations_group_id ON group_chat_integrations(group_id);`)
+
+	// Track when an event's lineup was finalized
+	db.Exec(`ALTER TABLE events ADD COLUMN lineup_finalized_at TIMESTAMP;`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Add recurring event series. Occurrences are expanded from the rule when listed and only
+	// saved as events once someone opens, edits or RSVPs to them.
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_series (
+			id TEXT PRIMARY KEY,
+			group_id TEXT NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT,
+			location TEXT,
+			start_time TIMESTAMP NOT NULL,
+			end_time TIMESTAMP NOT NULL,
+			rrule TEXT NOT NULL,
+			mc_id TEXT,
+			visibility TEXT NOT NULL DEFAULT 'private',
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (group_id) REFERENCES improv_groups(id),
+			FOREIGN KEY (mc_id) REFERENCES users(id),
+			FOREIGN KEY (created_by) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_series_exceptions (
+			series_id TEXT NOT NULL,
+			original_start TIMESTAMP NOT NULL,
+			event_id TEXT,
+			cancelled BOOLEAN NOT NULL DEFAULT FALSE,
+			PRIMARY KEY (series_id, original_start),
+			FOREIGN KEY (series_id) REFERENCES event_series(id) ON DELETE CASCADE,
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_series_group_id ON event_series(group_id);`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_series_exceptions_event_id ON event_series_exceptions(event_id);`)
+
+	// Link saved occurrences back to their series
+	db.Exec(`ALTER TABLE events ADD COLUMN series_id TEXT REFERENCES event_series(id);`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Calendar feed tokens. group_id is empty for a user's feed of all their events.
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS calendar_feeds (
+			token TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			group_id TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (user_id, group_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+
+	// Calendar apps only pick up changes to an event when its sequence goes up
+	db.Exec(`ALTER TABLE events ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;`)
+	db.Exec(`ALTER TABLE event_series ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Series expand in their own zone so weekly events keep their weekday and time across DST
+	db.Exec(`ALTER TABLE event_series ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Remember where imported events came from, so importing the same calendar twice is caught
+	db.Exec(`ALTER TABLE events ADD COLUMN import_uid TEXT;`)
+	db.Exec(`ALTER TABLE event_series ADD COLUMN import_uid TEXT;`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Optional cap on attendees, with a waitlist for RSVPs beyond it
+	db.Exec(`ALTER TABLE events ADD COLUMN capacity INTEGER;`)
+	db.Exec(`ALTER TABLE event_series ADD COLUMN capacity INTEGER;`)
+	db.Exec(`ALTER TABLE event_rsvps ADD COLUMN waitlist_position INTEGER;`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Events used to be visible to followers as "private", so the first time the visibility
+	// options and group defaults are added, existing events keep being shown to followers
+	if _, err := db.Exec(`ALTER TABLE improv_groups ADD COLUMN default_event_visibility TEXT NOT NULL DEFAULT 'members-and-followers';`); err == nil {
+		db.Exec(`UPDATE events SET visibility = 'members-and-followers' WHERE visibility = 'private';`)
+		db.Exec(`UPDATE event_series SET visibility = 'members-and-followers' WHERE visibility = 'private';`)
+	}
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Members opt in to their name showing on public event pages
+	db.Exec(`ALTER TABLE users ADD COLUMN show_name_publicly BOOLEAN NOT NULL DEFAULT FALSE;`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Events move through draft, scheduled, cancelled and completed
+	db.Exec(`ALTER TABLE events ADD COLUMN status TEXT NOT NULL DEFAULT 'scheduled';`)
+	db.Exec(`ALTER TABLE events ADD COLUMN cancellation_reason TEXT;`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Named templates for a group's recurring show formats, with their game lineup
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_templates (
+			id TEXT PRIMARY KEY,
+			group_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			title_pattern TEXT NOT NULL,
+			duration_minutes INTEGER NOT NULL DEFAULT 120,
+			location TEXT NOT NULL DEFAULT '',
+			visibility TEXT,
+			capacity INTEGER,
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (group_id) REFERENCES improv_groups(id),
+			FOREIGN KEY (created_by) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_template_games (
+			template_id TEXT NOT NULL,
+			game_id TEXT NOT NULL,
+			order_index INTEGER NOT NULL,
+			target_players INTEGER,
+			PRIMARY KEY (template_id, game_id),
+			FOREIGN KEY (template_id) REFERENCES event_templates(id) ON DELETE CASCADE,
+			FOREIGN KEY (game_id) REFERENCES games(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_templates_group_id ON event_templates(group_id);`)
+
+	// How many players a game in the lineup is meant to have, when the lineup came from a template
+	db.Exec(`ALTER TABLE event_games ADD COLUMN target_players INTEGER;`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// A group's catalogue of the places it performs
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS venues (
+			id TEXT PRIMARY KEY,
+			group_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			address TEXT NOT NULL DEFAULT '',
+			latitude REAL,
+			longitude REAL,
+			capacity INTEGER,
+			accessibility_notes TEXT NOT NULL DEFAULT '',
+			load_in_instructions TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (group_id) REFERENCES improv_groups(id),
+			FOREIGN KEY (created_by) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_venues_group_id ON venues(group_id);`)
+
+	// Events and series can be at a venue, keeping location as free text for places without one
+	db.Exec(`ALTER TABLE events ADD COLUMN venue_id TEXT REFERENCES venues(id);`)
+	db.Exec(`ALTER TABLE event_series ADD COLUMN venue_id TEXT REFERENCES venues(id);`)
+	// Ignore error - it will fail if column already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_events_venue_id ON events(venue_id);`)
+
+	// Groups and events have an IANA time zone. Event times are stored in UTC, so the first time
+	// the zone is added, rewrite times that were saved with the offset they arrived with.
+	db.Exec(`ALTER TABLE improv_groups ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';`)
+	// Ignore error - it will fail if column already exists, which is fine
+	if _, err := db.Exec(`ALTER TABLE events ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';`); err == nil {
+		db.Exec(`
+			UPDATE events SET time_zone = (SELECT time_zone FROM event_series WHERE id = events.series_id)
+			WHERE series_id IS NOT NULL
+		`)
+		if err := normalizeEventTimes(db); err != nil {
+			log.Printf("Error normalizing event times to UTC: %v", err)
+		}
+	}
+
+	// Groups choose whether scheduling conflicts are warnings ('warn') or refused ('block')
+	db.Exec(`ALTER TABLE improv_groups ADD COLUMN conflict_policy TEXT NOT NULL DEFAULT 'warn';`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Attendance records who actually came, separately from RSVPs. attendee_id is a user
+	// for members and a non_registered_attendees row for walk-ins.
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_attendance (
+			event_id TEXT NOT NULL,
+			attendee_id TEXT NOT NULL,
+			attendee_type TEXT NOT NULL,
+			status TEXT NOT NULL,
+			checked_in_at TIMESTAMP,
+			method TEXT NOT NULL,
+			recorded_by TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (event_id, attendee_id),
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+			FOREIGN KEY (recorded_by) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_attendance_attendee_id ON event_attendance(attendee_id);`)
+	// Ignore error - it will fail if index already exists, which is fine
+
+	// Walk-ins whose email matches an account are linked to it, straight away ('auto')
+	// or once an admin or organizer confirms the match ('confirm')
+	db.Exec(`ALTER TABLE improv_groups ADD COLUMN walk_in_linking TEXT NOT NULL DEFAULT 'auto';`)
+	// Ignore error - it will fail if column already exists, which is fine
+
+	// Walk-in links keep the walk-in's details, since the walk-in is removed once it's linked
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS walk_in_links (
+			attendee_id TEXT PRIMARY KEY,
+			event_id TEXT NOT NULL,
+			group_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			first_name TEXT NOT NULL,
+			last_name TEXT NOT NULL,
+			email TEXT NOT NULL,
+			status TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			resolved_by TEXT,
+			resolved_at TIMESTAMP,
+			FOREIGN KEY (event_id) REFERENCES events(id),
+			FOREIGN KEY (group_id) REFERENCES improv_groups(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_walk_in_links_user_id ON walk_in_links(user_id);`)
+	// Ignore error - it will fail if index already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_walk_in_links_group_id ON walk_in_links(group_id, status);`)
+	// Ignore error - it will fail if index already exists, which is fine
+
+	// Event crew: each group has a set of roles (MC, director, tech...) whose permissions are a
+	// comma-separated list, and events assign members to them. The built-in roles have the
+	// ID <group_id>:<role_key>. The default roles beyond the MC are added once per group.
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_roles (
+			id TEXT PRIMARY KEY,
+			group_id TEXT NOT NULL,
+			role_key TEXT,
+			name TEXT NOT NULL,
+			permissions TEXT NOT NULL DEFAULT '',
+			position INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (group_id, role_key),
+			FOREIGN KEY (group_id) REFERENCES improv_groups(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`ALTER TABLE improv_groups ADD COLUMN event_roles_seeded BOOLEAN NOT NULL DEFAULT 0;`)
+	// Ignore error - it will fail if column already exists, which is fine
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_crew (
+			event_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			role_id TEXT NOT NULL,
+			assigned_by TEXT,
+			assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (event_id, user_id, role_id),
+			FOREIGN KEY (event_id) REFERENCES events(id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (role_id) REFERENCES event_roles(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_crew_user_id ON event_crew(user_id);`)
+	// Ignore error - it will fail if index already exists, which is fine
+
+	// events.mc_id stays as the event's primary MC for older clients and the code reading it,
+	// so these triggers keep the MC's crew assignment in step with it whoever writes it.
+	// They're recreated on startup so they pick up changes to the MC role's permissions.
+	_, err = db.Exec(`
+		DROP TRIGGER IF EXISTS events_mc_ai;
+		DROP TRIGGER IF EXISTS events_mc_au;
+
+		CREATE TRIGGER IF NOT EXISTS events_mc_ai AFTER INSERT ON events WHEN new.mc_id IS NOT NULL BEGIN
+			INSERT OR IGNORE INTO event_roles (id, group_id, role_key, name, permissions, position)
+			VALUES (new.group_id || ':mc', new.group_id, 'mc', 'MC', '` + mcRolePermissions + `', 0);
+			INSERT OR IGNORE INTO event_crew (event_id, user_id, role_id) VALUES (new.id, new.mc_id, new.group_id || ':mc');
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS events_mc_au AFTER UPDATE OF mc_id ON events WHEN old.mc_id IS NOT new.mc_id BEGIN
+			DELETE FROM event_crew WHERE event_id = old.id AND user_id = old.mc_id AND role_id = old.group_id || ':mc';
+			INSERT OR IGNORE INTO event_roles (id, group_id, role_key, name, permissions, position)
+			SELECT new.group_id || ':mc', new.group_id, 'mc', 'MC', '` + mcRolePermissions + `', 0
+			WHERE new.mc_id IS NOT NULL;
+			INSERT OR IGNORE INTO event_crew (event_id, user_id, role_id)
+			SELECT new.id, new.mc_id, new.group_id || ':mc'
+			WHERE new.mc_id IS NOT NULL;
+		END;
+	`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Move MCs chosen before crews existed into the crew
+	db.Exec(`
+		INSERT OR IGNORE INTO event_roles (id, group_id, role_key, name, permissions, position)
+		SELECT DISTINCT group_id || ':mc', group_id, 'mc', 'MC', '` + mcRolePermissions + `', 0
+		FROM events WHERE mc_id IS NOT NULL
+	`)
+	db.Exec(`
+		INSERT OR IGNORE INTO event_crew (event_id, user_id, role_id)
+		SELECT id, mc_id, group_id || ':mc' FROM events WHERE mc_id IS NOT NULL
+	`)
+
+	// Run-of-show: each lineup game has a planned length and a segment type, and intros, breaks
+	// and audience bits sit between the games, before the game at before_game in the lineup.
+	// started_at and ended_at are the actual timings from running the show live.
+	db.Exec(`ALTER TABLE event_games ADD COLUMN planned_minutes INTEGER;`)
+	db.Exec(`ALTER TABLE event_games ADD COLUMN segment_type TEXT NOT NULL DEFAULT 'game';`)
+	db.Exec(`ALTER TABLE event_games ADD COLUMN started_at TIMESTAMP;`)
+	db.Exec(`ALTER TABLE event_games ADD COLUMN ended_at TIMESTAMP;`)
+	// Ignore error - it will fail if column already exists, which is fine
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_segments (
+			id TEXT PRIMARY KEY,
+			event_id TEXT NOT NULL,
+			segment_type TEXT NOT NULL,
+			title TEXT NOT NULL,
+			planned_minutes INTEGER,
+			before_game INTEGER NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0,
+			started_at TIMESTAMP,
+			ended_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (event_id) REFERENCES events(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_segments_event_id ON event_segments(event_id);`)
+	// Ignore error - it will fail if index already exists, which is fine
+
+	// Casting rules for an event's lineup. player_ids is a comma-separated list of members and
+	// walk-ins; game_id and limit_value are only used by the kinds of rule that need them.
+	db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_lineup_constraints (
+			id TEXT PRIMARY KEY,
+			event_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			player_ids TEXT NOT NULL DEFAULT '',
+			game_id TEXT,
+			limit_value INTEGER NOT NULL DEFAULT 0,
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (event_id) REFERENCES events(id),
+			FOREIGN KEY (created_by) REFERENCES users(id)
+		);
+	`)
+	// Ignore error - it will fail if table already exists, which is fine
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_lineup_constraints_event_id ON event_lineup_constraints(event_id);`)
+	// Ignore error - it will fail if index already exists, which is fine
+
 	// Resync games_fts table on startup to ensure FTS is up to date
 	db.Exec(`
 		-- Clear existing FTS entries
@@ -267,3 +744,35 @@ func InitDB() *sql.DB {
 
 	return db
 }
+
+// normalizeEventTimes rewrites event start and end times that were stored with an offset in UTC
+func normalizeEventTimes(db *sql.DB) error {
+	rows, err := db.Query(`SELECT id, start_time, end_time FROM events`)
+	if err != nil {
+		return err
+	}
+	type eventTimes struct {
+		id         string
+		start, end time.Time
+	}
+	var offset []eventTimes
+	for rows.Next() {
+		var event eventTimes
+		if err := rows.Scan(&event.id, &event.start, &event.end); err != nil {
+			rows.Close()
+			return err
+		}
+		if event.start.Location() != time.UTC || event.end.Location() != time.UTC {
+			offset = append(offset, event)
+		}
+	}
+	rows.Close()
+
+	for _, event := range offset {
+		_, err := db.Exec(`UPDATE events SET start_time = $1, end_time = $2 WHERE id = $3`, event.start.UTC(), event.end.UTC(), event.id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,22 +1,30 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 
 	"improv-app/internal/config"
+	"improv-app/internal/lineup"
 	"improv-app/internal/services"
 )
 
 type AuthHandler struct {
-	emailService *services.EmailService
+	emailService            *services.EmailService
+	publicEventService      *services.PublicEventService
+	walkInLinkService       *services.WalkInLinkService
+	notificationPreferences *services.NotificationPreferenceService
 }
 
-func NewAuthHandler(emailService *services.EmailService) *AuthHandler {
+func NewAuthHandler(db *sql.DB, emailService *services.EmailService) *AuthHandler {
 	return &AuthHandler{
-		emailService: emailService,
+		emailService:            emailService,
+		publicEventService:      services.NewPublicEventService(db),
+		walkInLinkService:       services.NewWalkInLinkService(db),
+		notificationPreferences: services.NewNotificationPreferenceService(db),
 	}
 }
 
@@ -27,6 +35,10 @@ type ApiResponse struct {
 	Data       interface{}         `json:"data,omitempty"`
 	Error      string              `json:"error,omitempty"`
 	Pagination *PaginationMetadata `json:"pagination,omitempty"`
+	// Warnings are scheduling conflicts that didn't stop the change
+	Warnings []services.Conflict `json:"warnings,omitempty"`
+	// Violations are the event's casting rules the lineup breaks
+	Violations []lineup.Violation `json:"violations,omitempty"`
 }
 
 // PaginationMetadata contains information about pagination results
@@ -114,8 +126,17 @@ func (h *AuthHandler) Verify(w http.ResponseWriter, r *http.Request) {
 	session.Values["user_id"] = user.ID
 	session.Save(r, w)
 
-	fmt.Println("Redirecting to:", redirectURL+"/")
-	http.Redirect(w, r, redirectURL+"/", http.StatusSeeOther)
+	// Walk-ins with this email become the user's RSVPs, and the frontend offers to join their groups
+	destination := redirectURL + "/"
+	linked, _, err := h.walkInLinkService.MatchUser(user.ID)
+	if err != nil {
+		fmt.Println("Error linking walk-ins:", err)
+	} else if linked > 0 {
+		destination += "?walkIns=linked"
+	}
+
+	fmt.Println("Redirecting to:", destination)
+	http.Redirect(w, r, destination, http.StatusSeeOther)
 }
 
 // GetCurrentUser returns the currently authenticated user
@@ -162,11 +183,25 @@ func (h *AuthHandler) Profile(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		eventReminders, err := h.notificationPreferences.IsEnabled(userID, "", services.NotificationReminder, services.ChannelEmail)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Error retrieving user profile")
+			return
+		}
+
+		showNamePublicly, err := h.publicEventService.ShowsNamePublicly(userID)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Error retrieving user profile")
+			return
+		}
+
 		userData := map[string]interface{}{
-			"id":        user.ID,
-			"email":     user.Email,
-			"firstName": user.FirstName,
-			"lastName":  user.LastName,
+			"id":               user.ID,
+			"email":            user.Email,
+			"firstName":        user.FirstName,
+			"lastName":         user.LastName,
+			"eventReminders":   eventReminders,
+			"showNamePublicly": showNamePublicly,
 		}
 
 		RespondWithJSON(w, http.StatusOK, ApiResponse{
@@ -178,8 +213,10 @@ func (h *AuthHandler) Profile(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method == "PUT" {
 		var profileRequest struct {
-			FirstName string `json:"firstName"`
-			LastName  string `json:"lastName"`
+			FirstName        string `json:"firstName"`
+			LastName         string `json:"lastName"`
+			EventReminders   *bool  `json:"eventReminders,omitempty"`
+			ShowNamePublicly *bool  `json:"showNamePublicly,omitempty"`
 		}
 
 		decoder := json.NewDecoder(r.Body)
@@ -195,17 +232,48 @@ func (h *AuthHandler) Profile(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// Only touch the reminder opt-out when the client sends it
+		if profileRequest.EventReminders != nil {
+			err = h.notificationPreferences.Set(userID, "", services.NotificationReminder, services.ChannelEmail, *profileRequest.EventReminders)
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, "Error updating reminder preference")
+				return
+			}
+		}
+
+		if profileRequest.ShowNamePublicly != nil {
+			err = h.publicEventService.SetShowsNamePublicly(userID, *profileRequest.ShowNamePublicly)
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, "Error updating public name preference")
+				return
+			}
+		}
+
 		user, err := h.emailService.GetUserByID(userID)
 		if err != nil {
 			RespondWithError(w, http.StatusInternalServerError, "Error retrieving updated user profile")
 			return
 		}
 
+		eventReminders, err := h.notificationPreferences.IsEnabled(userID, "", services.NotificationReminder, services.ChannelEmail)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Error retrieving updated user profile")
+			return
+		}
+
+		showNamePublicly, err := h.publicEventService.ShowsNamePublicly(userID)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Error retrieving updated user profile")
+			return
+		}
+
 		userData := map[string]interface{}{
-			"id":        user.ID,
-			"email":     user.Email,
-			"firstName": user.FirstName,
-			"lastName":  user.LastName,
+			"id":               user.ID,
+			"email":            user.Email,
+			"firstName":        user.FirstName,
+			"lastName":         user.LastName,
+			"eventReminders":   eventReminders,
+			"showNamePublicly": showNamePublicly,
 		}
 
 		RespondWithJSON(w, http.StatusOK, ApiResponse{
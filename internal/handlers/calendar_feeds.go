@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CalendarFeedHandler hands out iCalendar feed URLs and serves the feeds
+type CalendarFeedHandler struct {
+	db *sql.DB
+}
+
+// NewCalendarFeedHandler creates a new CalendarFeedHandler
+func NewCalendarFeedHandler(db *sql.DB) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		db: db,
+	}
+}
+
+// calendarFeedResponse is a feed's subscription URLs. webcal:// opens the
+// calendar app directly, and the https URL can be pasted into one.
+type calendarFeedResponse struct {
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcalUrl"`
+}
+
+func newCalendarFeedResponse(token string) calendarFeedResponse {
+	feedURL := services.CalendarFeedURL(token)
+	webcalURL := feedURL
+	if i := strings.Index(feedURL, "://"); i >= 0 {
+		webcalURL = "webcal" + feedURL[i:]
+	}
+	return calendarFeedResponse{URL: feedURL, WebcalURL: webcalURL}
+}
+
+// GetUserFeed returns the URL of the feed of all the user's events
+func (h *CalendarFeedHandler) GetUserFeed(w http.ResponseWriter, r *http.Request) {
+	h.respondWithFeed(w, r, "", false)
+}
+
+// RegenerateUserFeed replaces the user's feed URL, revoking the old one
+func (h *CalendarFeedHandler) RegenerateUserFeed(w http.ResponseWriter, r *http.Request) {
+	h.respondWithFeed(w, r, "", true)
+}
+
+// GetGroupFeed returns the URL of the user's feed of the group's events
+func (h *CalendarFeedHandler) GetGroupFeed(w http.ResponseWriter, r *http.Request) {
+	h.respondWithGroupFeed(w, r, false)
+}
+
+// RegenerateGroupFeed replaces the user's feed URL for the group, revoking the old one
+func (h *CalendarFeedHandler) RegenerateGroupFeed(w http.ResponseWriter, r *http.Request) {
+	h.respondWithGroupFeed(w, r, true)
+}
+
+func (h *CalendarFeedHandler) respondWithGroupFeed(w http.ResponseWriter, r *http.Request, regenerate bool) {
+	groupID := mux.Vars(r)["id"]
+
+	var exists bool
+	err := h.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM improv_groups WHERE id = $1)`, groupID).Scan(&exists)
+	if err != nil {
+		log.Printf("Error checking group %s for calendar feed: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching group")
+		return
+	}
+	if !exists {
+		RespondWithError(w, http.StatusNotFound, "Group not found")
+		return
+	}
+
+	h.respondWithFeed(w, r, groupID, regenerate)
+}
+
+func (h *CalendarFeedHandler) respondWithFeed(w http.ResponseWriter, r *http.Request, groupID string, regenerate bool) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	feedService := services.NewCalendarFeedService(h.db)
+
+	var token string
+	var err error
+	if regenerate {
+		token, err = feedService.RegenerateFeedToken(user.ID, groupID)
+	} else {
+		token, err = feedService.FeedToken(user.ID, groupID)
+	}
+	if err != nil {
+		log.Printf("Error getting calendar feed for user %s (group %q): %v", user.ID, groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error getting calendar feed")
+		return
+	}
+
+	message := ""
+	if regenerate {
+		message = "Calendar feed URL regenerated, the old URL no longer works"
+	}
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: message,
+		Data:    newCalendarFeedResponse(token),
+	})
+}
+
+// Serve renders a feed for calendar apps. The token in the URL is the only credential,
+// since calendar apps can't sign in.
+func (h *CalendarFeedHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+
+	calendar, err := services.NewCalendarFeedService(h.db).Feed(token)
+	if err == services.ErrFeedNotFound {
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error rendering calendar feed: %v", err)
+		http.Error(w, "Error rendering calendar feed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="events.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write([]byte(calendar.String()))
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"improv-app/internal/chat"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ChatIntegrationHandler lets group admins connect Slack and Discord channels
+type ChatIntegrationHandler struct {
+	db *sql.DB
+}
+
+// NewChatIntegrationHandler creates a new ChatIntegrationHandler
+func NewChatIntegrationHandler(db *sql.DB) *ChatIntegrationHandler {
+	return &ChatIntegrationHandler{
+		db: db,
+	}
+}
+
+type chatIntegrationRequest struct {
+	Platform        string `json:"platform"`
+	WebhookURL      string `json:"webhookUrl"`
+	AnnounceEvents  *bool  `json:"announceEvents,omitempty"`
+	AnnounceLineups *bool  `json:"announceLineups,omitempty"`
+	DailyPost       *bool  `json:"dailyPost,omitempty"`
+}
+
+// validate checks the platform is supported and the webhook URL is an absolute http(s) URL
+// that isn't on the server's own network
+func (req chatIntegrationRequest) validate() error {
+	if !chat.IsValidPlatform(req.Platform) {
+		return fmt.Errorf("Platform must be %s or %s", chat.PlatformSlack, chat.PlatformDiscord)
+	}
+	parsed, err := url.ParseRequestURI(req.WebhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("Webhook URL must be an absolute http or https URL")
+	}
+	if err := services.CheckWebhookHost(parsed.Hostname()); err != nil {
+		return fmt.Errorf("Webhook URL must not point at a private or local address")
+	}
+	return nil
+}
+
+// apply copies the request onto the integration, keeping existing flags that weren't sent
+func (req chatIntegrationRequest) apply(integration *services.ChatIntegration) {
+	integration.Platform = req.Platform
+	integration.WebhookURL = req.WebhookURL
+	if req.AnnounceEvents != nil {
+		integration.AnnounceEvents = *req.AnnounceEvents
+	}
+	if req.AnnounceLineups != nil {
+		integration.AnnounceLineups = *req.AnnounceLineups
+	}
+	if req.DailyPost != nil {
+		integration.DailyPost = *req.DailyPost
+	}
+}
+
+// getIntegration loads the integration named in the route, responding with 404 when it isn't the group's
+func (h *ChatIntegrationHandler) getIntegration(w http.ResponseWriter, groupID, integrationID string) *services.ChatIntegration {
+	integration, err := services.NewChatService(h.db).Get(groupID, integrationID)
+	if err != nil {
+		log.Printf("Error fetching chat integration %s: %v", integrationID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching chat integration")
+		return nil
+	}
+	if integration == nil {
+		RespondWithError(w, http.StatusNotFound, "Chat integration not found")
+		return nil
+	}
+	return integration
+}
+
+// List returns the group's chat integrations
+func (h *ChatIntegrationHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "chat integrations") {
+		return
+	}
+
+	integrations, err := services.NewChatService(h.db).List(groupID)
+	if err != nil {
+		log.Printf("Error fetching chat integrations for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching chat integrations")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    integrations,
+	})
+}
+
+// Create connects a Slack or Discord incoming webhook to the group.
+// New integrations announce events and lineups but don't post daily unless asked.
+func (h *ChatIntegrationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "chat integrations") {
+		return
+	}
+
+	var request chatIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding chat integration request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := request.validate(); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	integration := services.ChatIntegration{
+		GroupID:         groupID,
+		AnnounceEvents:  true,
+		AnnounceLineups: true,
+		CreatedBy:       user.ID,
+	}
+	request.apply(&integration)
+
+	created, err := services.NewChatService(h.db).Save(integration)
+	if err != nil {
+		log.Printf("Error creating chat integration for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error creating chat integration")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, ApiResponse{
+		Success: true,
+		Message: "Chat integration created successfully",
+		Data:    created,
+	})
+}
+
+// Update changes an integration's webhook URL or which announcements it posts
+func (h *ChatIntegrationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	integrationID := vars["integrationId"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "chat integrations") {
+		return
+	}
+
+	integration := h.getIntegration(w, groupID, integrationID)
+	if integration == nil {
+		return
+	}
+
+	var request chatIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding chat integration request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := request.validate(); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	request.apply(integration)
+
+	updated, err := services.NewChatService(h.db).Save(*integration)
+	if err != nil {
+		log.Printf("Error updating chat integration %s: %v", integrationID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating chat integration")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Chat integration updated successfully",
+		Data:    updated,
+	})
+}
+
+// Delete disconnects a chat integration
+func (h *ChatIntegrationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	integrationID := vars["integrationId"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "chat integrations") {
+		return
+	}
+
+	if h.getIntegration(w, groupID, integrationID) == nil {
+		return
+	}
+
+	if err := services.NewChatService(h.db).Delete(groupID, integrationID); err != nil {
+		log.Printf("Error deleting chat integration %s: %v", integrationID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error deleting chat integration")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Chat integration deleted successfully",
+	})
+}
+
+// SendTest posts a test announcement so admins can check the channel is connected
+func (h *ChatIntegrationHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	integrationID := vars["integrationId"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "chat integrations") {
+		return
+	}
+
+	integration := h.getIntegration(w, groupID, integrationID)
+	if integration == nil {
+		return
+	}
+
+	err := services.NewChatService(h.db).SendTest(*integration)
+	if errors.Is(err, services.ErrChatPostRejected) {
+		log.Printf("Chat integration %s rejected the test post: %v", integrationID, err)
+		RespondWithError(w, http.StatusBadGateway, fmt.Sprintf("Test post failed: %v", err))
+		return
+	}
+	if err != nil {
+		// Connection errors aren't passed on, so the test can't be used to probe the network
+		log.Printf("Error sending test to chat integration %s: %v", integrationID, err)
+		RespondWithError(w, http.StatusBadGateway, "Test post failed: the webhook URL couldn't be reached")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Test announcement posted",
+	})
+}
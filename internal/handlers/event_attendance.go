@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// requireAttendanceTaker responds with an error and returns false unless the user has a role on
+// the event's crew that takes attendance, or is an admin or organizer of its group. It returns the
+// event's group.
+func (h *EventHandler) requireAttendanceTaker(w http.ResponseWriter, r *http.Request, eventID, userID string) (string, bool) {
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err == sql.ErrNoRows {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return "", false
+	}
+
+	allowed, err := h.crewOrOrganizer(r, eventID, groupID, userID, services.PermissionTakeAttendance)
+	if err != nil {
+		log.Printf("Error checking attendance permissions for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
+		return "", false
+	}
+	if !allowed {
+		log.Printf("User %s not authorized to take attendance for event %s", userID, eventID)
+		RespondWithError(w, http.StatusForbidden, "Only the event MC, crew who take attendance and group organizers can take attendance")
+		return "", false
+	}
+	return groupID, true
+}
+
+// respondAttendanceError maps attendance errors to responses
+func respondAttendanceError(w http.ResponseWriter, eventID string, err error) {
+	switch {
+	case errors.Is(err, services.ErrEventNotFound):
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+	case errors.Is(err, services.ErrNotAnAttendee):
+		RespondWithError(w, http.StatusBadRequest, "Not a member of this group or a walk-in at this event")
+	case errors.Is(err, services.ErrAttendanceClosed):
+		RespondWithError(w, http.StatusConflict, "Attendance can't be taken for this event right now")
+	case errors.Is(err, services.ErrInvalidCheckInCode):
+		RespondWithError(w, http.StatusBadRequest, "That check-in code is wrong or has expired")
+	default:
+		log.Printf("Error recording attendance for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error recording attendance")
+	}
+}
+
+// GetAttendance lists who checked in to an event, with a summary against the RSVPs
+func (h *EventHandler) GetAttendance(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	if _, ok := h.requireAttendanceTaker(w, r, eventID, user.ID); !ok {
+		return
+	}
+
+	attendanceService := services.NewAttendanceService(h.db)
+	attendance, err := attendanceService.List(eventID)
+	if err != nil {
+		log.Printf("Error fetching attendance for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching attendance")
+		return
+	}
+	summary, err := attendanceService.Summary(eventID)
+	if err != nil {
+		log.Printf("Error summarizing attendance for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching attendance")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"attendance": attendance,
+			"summary":    summary,
+		},
+	})
+}
+
+// RecordAttendance checks a member or walk-in in, or marks them late or a no-show.
+// Without a status they're checked in, as late if the event started a while ago.
+func (h *EventHandler) RecordAttendance(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+	attendeeID := vars["attendeeId"]
+
+	var request struct {
+		Status string `json:"status,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding attendance request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if request.Status != "" && !services.IsValidAttendanceStatus(request.Status) {
+		RespondWithError(w, http.StatusBadRequest, "Status must be checked-in, late or no-show")
+		return
+	}
+
+	if _, ok := h.requireAttendanceTaker(w, r, eventID, user.ID); !ok {
+		return
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	attendance, err := services.NewAttendanceService(h.db).Record(eventID, attendeeID, request.Status, services.AttendanceMethodOrganizer, user.ID, time.Now())
+	if err != nil {
+		respondAttendanceError(w, eventID, err)
+		return
+	}
+
+	publishEventUpdate(eventID, EventUpdateAttendanceChanged, user.ID, attendance)
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Attendance recorded successfully",
+		Data:    attendance,
+	})
+}
+
+// ClearAttendance removes a member's or walk-in's attendance, for when it was taken by mistake
+func (h *EventHandler) ClearAttendance(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+	attendeeID := vars["attendeeId"]
+
+	if _, ok := h.requireAttendanceTaker(w, r, eventID, user.ID); !ok {
+		return
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	if err := services.NewAttendanceService(h.db).Clear(eventID, attendeeID); err != nil {
+		log.Printf("Error clearing attendance of %s for event %s: %v", attendeeID, eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error clearing attendance")
+		return
+	}
+
+	publishEventUpdate(eventID, EventUpdateAttendanceChanged, user.ID, map[string]string{"attendeeId": attendeeID})
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Attendance cleared successfully",
+	})
+}
+
+// MarkNoShows records members who RSVP'd attending but weren't checked in as no-shows
+func (h *EventHandler) MarkNoShows(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	if _, ok := h.requireAttendanceTaker(w, r, eventID, user.ID); !ok {
+		return
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	marked, err := services.NewAttendanceService(h.db).MarkNoShows(eventID, user.ID, time.Now())
+	if err != nil {
+		log.Printf("Error marking no-shows for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error marking no-shows")
+		return
+	}
+
+	if marked > 0 {
+		publishEventUpdate(eventID, EventUpdateAttendanceChanged, user.ID, map[string]int64{"noShows": marked})
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "No-shows marked successfully",
+		Data:    map[string]int64{"marked": marked},
+	})
+}
+
+// GetCheckInCode returns the rotating code and QR payload to show at the venue for self check-in.
+// The code is only good for the event it was made for, so an occurrence is saved to make one.
+func (h *EventHandler) GetCheckInCode(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	if _, ok := h.requireAttendanceTaker(w, r, eventID, user.ID); !ok {
+		return
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    services.NewCheckInCode(eventID, time.Now()),
+	})
+}
+
+// SelfCheckIn checks the user in with the code shown at the venue
+func (h *EventHandler) SelfCheckIn(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	var request struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding check-in request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	attendance, err := services.NewAttendanceService(h.db).SelfCheckIn(eventID, user.ID, request.Code, time.Now())
+	if errors.Is(err, services.ErrNotAnAttendee) {
+		RespondWithError(w, http.StatusForbidden, "Not a member of this group")
+		return
+	}
+	if err != nil {
+		respondAttendanceError(w, eventID, err)
+		return
+	}
+
+	publishEventUpdate(eventID, EventUpdateAttendanceChanged, user.ID, attendance)
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Checked in successfully",
+		Data:    attendance,
+	})
+}
+
+// GroupAttendance totals each member's attendance across the group's events, for reporting.
+// The optional from and to query parameters limit the events by start time.
+func (h *EventHandler) GroupAttendance(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	var role string
+	err := h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, user.ID).Scan(&role)
+	if err != nil || (role != auth.RoleAdmin && role != auth.RoleOrganizer) {
+		log.Printf("User %s not authorized to view attendance for group %s (role: %s, error: %v)", user.ID, groupID, role, err)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can view attendance")
+		return
+	}
+
+	from, to, err := occurrenceWindow(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := services.NewAttendanceService(h.db).GroupReport(groupID, from, to)
+	if err != nil {
+		log.Printf("Error fetching attendance report for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching attendance")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    report,
+	})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// Clone copies an event with its game lineup to a new date, optionally keeping the MC and cast.
+// RSVPs start over.
+func (h *EventHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	var request struct {
+		StartTime          string `json:"startTime"`
+		EndTime            string `json:"endTime,omitempty"`            // Keeps the original event's length when left out
+		IncludeMC          bool   `json:"includeMc,omitempty"`          // Keep the same MC
+		IncludeCrew        bool   `json:"includeCrew,omitempty"`        // Keep the rest of the crew in their roles
+		IncludeAssignments bool   `json:"includeAssignments,omitempty"` // Keep the players assigned to each game
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding clone request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err != nil {
+		log.Printf("Event not found during clone: %s (%v)", eventID, err)
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+
+	var role string
+	err = h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, user.ID).Scan(&role)
+	if err != nil || (role != auth.RoleAdmin && role != auth.RoleOrganizer) {
+		log.Printf("User %s not authorized to clone event %s (role: %s, error: %v)", user.ID, eventID, role, err)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can clone events")
+		return
+	}
+
+	// Times without an offset are in the original event's zone, which the clone keeps
+	var source struct {
+		startTime, endTime time.Time
+		mcID, venueID      *string
+		timeZone           string
+	}
+	occurrence := unsavedOccurrence(r)
+	if occurrence != nil {
+		source.startTime, source.endTime = occurrence.StartTime, occurrence.EndTime
+		source.mcID, source.venueID, source.timeZone = occurrence.MCID, occurrence.VenueID, occurrence.TimeZone
+	} else {
+		err = h.db.QueryRow(`
+			SELECT start_time, end_time, mc_id, venue_id, time_zone FROM events WHERE id = $1
+		`, eventID).Scan(&source.startTime, &source.endTime, &source.mcID, &source.venueID, &source.timeZone)
+		if err != nil {
+			log.Printf("Error fetching event %s to clone: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error cloning event")
+			return
+		}
+	}
+	loc := services.EventLocation(source.timeZone)
+
+	startTime, err := services.ParseEventTime(request.StartTime, loc)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid start time format")
+		return
+	}
+	endTime := startTime.Add(source.endTime.Sub(source.startTime))
+	if request.EndTime != "" {
+		endTime, err = services.ParseEventTime(request.EndTime, loc)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid end time format")
+			return
+		}
+		if endTime.Before(startTime) {
+			RespondWithError(w, http.StatusBadRequest, "End time must be after start time")
+			return
+		}
+	}
+
+	cloneService := services.NewEventCloneService(h.db)
+	options := services.CloneOptions{
+		StartTime:          startTime,
+		EndTime:            endTime,
+		IncludeMC:          request.IncludeMC,
+		IncludeCrew:        request.IncludeCrew,
+		IncludeAssignments: request.IncludeAssignments,
+		CreatedBy:          user.ID,
+	}
+
+	// Whoever comes along to the new date is checked for clashes there. An unsaved
+	// occurrence has no crew or cast of its own yet, only the series' MC.
+	var performers []string
+	if occurrence == nil {
+		performers, err = cloneService.Performers(eventID, options)
+		if err != nil {
+			log.Printf("Error fetching performers of event %s: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error cloning event")
+			return
+		}
+	} else if request.IncludeMC && source.mcID != nil {
+		performers = []string{*source.mcID}
+	}
+	warnings, ok := h.checkConflicts(w, services.EventSlot{
+		GroupID:    groupID,
+		VenueID:    source.venueID,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Performers: performers,
+		ViewerID:   user.ID,
+	}, false)
+	if !ok {
+		return
+	}
+
+	var cloneID string
+	if occurrence != nil {
+		cloneID, err = cloneService.CloneOccurrence(*occurrence, options)
+	} else {
+		cloneID, err = cloneService.Clone(eventID, options)
+	}
+	if err == services.ErrEventNotFound {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error cloning event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error cloning event")
+		return
+	}
+
+	var event Event
+	err = h.db.QueryRow(`
+		SELECT id, group_id, title, description, location, start_time, end_time, created_at, created_by, mc_id, series_id, capacity, visibility,
+		       status, cancellation_reason, venue_id, time_zone
+		FROM events
+		WHERE id = $1
+	`, cloneID).Scan(&event.ID, &event.GroupID, &event.Title, &event.Description, &event.Location, &event.StartTime, &event.EndTime, &event.CreatedAt, &event.CreatedBy, &event.MCID, &event.SeriesID, &event.Capacity, &event.Visibility,
+		&event.Status, &event.CancellationReason, &event.VenueID, &event.TimeZone)
+	if err != nil {
+		log.Printf("Error fetching cloned event %s: %v", cloneID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching cloned event")
+		return
+	}
+
+	h.announceCreated(event)
+
+	RespondWithJSON(w, http.StatusCreated, ApiResponse{
+		Success:  true,
+		Message:  "Event cloned successfully",
+		Data:     event,
+		Warnings: warnings,
+	})
+}
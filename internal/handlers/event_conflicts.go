@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+)
+
+// checkConflicts looks for events clashing with the slot. When the group blocks conflicts it
+// responds with 409 and the conflicts, and returns false; otherwise the conflicts are warnings
+// for the response. performersOnly skips the group and venue checks.
+func (h *EventHandler) checkConflicts(w http.ResponseWriter, slot services.EventSlot, performersOnly bool) ([]services.Conflict, bool) {
+	conflictService := services.NewConflictService(h.db)
+	var conflicts []services.Conflict
+	var err error
+	if performersOnly {
+		conflicts, err = conflictService.CheckPerformers(slot)
+	} else {
+		conflicts, err = conflictService.Check(slot)
+	}
+	if err != nil {
+		log.Printf("Error checking conflicts for group %s: %v", slot.GroupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking for scheduling conflicts")
+		return nil, false
+	}
+	if len(conflicts) == 0 {
+		return nil, true
+	}
+
+	policy, err := services.GroupConflictPolicy(h.db, slot.GroupID)
+	if err != nil {
+		log.Printf("Error fetching conflict policy for group %s: %v", slot.GroupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking for scheduling conflicts")
+		return nil, false
+	}
+	if policy == services.ConflictPolicyBlock {
+		RespondWithJSON(w, http.StatusConflict, ApiResponse{
+			Success: false,
+			Error:   conflicts[0].Message,
+			Data:    conflicts,
+		})
+		return nil, false
+	}
+	return conflicts, true
+}
+
+// MyConflicts lists clashes between the upcoming events of the user's groups. The window
+// runs from now, or from, to the to query parameter, 90 days ahead by default.
+func (h *EventHandler) MyConflicts(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+
+	from, to, err := occurrenceWindow(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Get("from") == "" {
+		from = time.Now()
+	}
+
+	pairs, err := services.NewConflictService(h.db).ForUser(user.ID, from, to)
+	if err != nil {
+		log.Printf("Error fetching conflicts for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching conflicts")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    pairs,
+	})
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// hasCrewPermission reports whether the user has the permission through their roles on the event's
+// crew. The only crew at an occurrence that hasn't been saved is its series' MC.
+func (h *EventHandler) hasCrewPermission(r *http.Request, eventID, userID, permission string) (bool, error) {
+	if occurrence := unsavedOccurrence(r); occurrence != nil {
+		if occurrence.MCID == nil || *occurrence.MCID != userID {
+			return false, nil
+		}
+		return services.NewCrewService(h.db).MCHasPermission(occurrence.GroupID, permission)
+	}
+	return services.NewCrewService(h.db).HasPermission(eventID, userID, permission)
+}
+
+// crewOrOrganizer reports whether the user has the permission through their roles on the event's
+// crew, or is an admin or organizer of its group
+func (h *EventHandler) crewOrOrganizer(r *http.Request, eventID, groupID, userID, permission string) (bool, error) {
+	allowed, err := h.hasCrewPermission(r, eventID, userID, permission)
+	if err != nil || allowed {
+		return allowed, err
+	}
+	var isOrganizer bool
+	err = h.db.QueryRow(`
+		SELECT role IN ('admin', 'organizer')
+		FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, userID).Scan(&isOrganizer)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return isOrganizer, err
+}
+
+// requireEventOrganizer responds with an error and returns false unless the event exists and the
+// user is an admin or organizer of its group. It returns the event's group.
+func (h *EventHandler) requireEventOrganizer(w http.ResponseWriter, r *http.Request, eventID, userID string) (string, bool) {
+	var role string
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err == sql.ErrNoRows {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return "", false
+	}
+	err = h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, userID).Scan(&role)
+	if err != nil || (role != auth.RoleAdmin && role != auth.RoleOrganizer) {
+		log.Printf("User %s not authorized to manage the crew of event %s (role: %s, error: %v)", userID, eventID, role, err)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can manage the event crew")
+		return "", false
+	}
+	return groupID, true
+}
+
+// GetCrew lists who is working the event and in what role
+func (h *EventHandler) GetCrew(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	// Nobody has been given a role at an occurrence that hasn't been saved
+	if unsavedOccurrence(r) != nil {
+		RespondWithJSON(w, http.StatusOK, ApiResponse{
+			Success: true,
+			Data:    []services.CrewMember{},
+		})
+		return
+	}
+
+	var isMember bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM events e
+			JOIN group_members m ON e.group_id = m.group_id
+			WHERE e.id = $1 AND m.user_id = $2
+		)
+	`, eventID, user.ID).Scan(&isMember)
+	if err != nil {
+		log.Printf("Error checking membership for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event crew")
+		return
+	}
+	if !isMember {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+
+	crew, err := services.NewCrewService(h.db).Crew(eventID)
+	if err != nil {
+		log.Printf("Error fetching crew for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event crew")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    crew,
+	})
+}
+
+// AssignCrew puts a group member on the event's crew in one of the group's event roles.
+// Giving the MC role to someone when the event has no MC makes them its MC.
+func (h *EventHandler) AssignCrew(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	var request struct {
+		UserID string `json:"userId"`
+		RoleID string `json:"roleId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding crew request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	groupID, ok := h.requireEventOrganizer(w, r, eventID, user.ID)
+	if !ok {
+		return
+	}
+	eventID, ok = saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	// Crew are busy for the whole show, like the MC
+	var startTime, endTime time.Time
+	err := h.db.QueryRow(`SELECT start_time, end_time FROM events WHERE id = $1`, eventID).Scan(&startTime, &endTime)
+	if err != nil {
+		log.Printf("Error fetching times of event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error assigning crew")
+		return
+	}
+	warnings, ok := h.checkConflicts(w, services.EventSlot{
+		EventID:    eventID,
+		GroupID:    groupID,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Performers: []string{request.UserID},
+		ViewerID:   user.ID,
+	}, true)
+	if !ok {
+		return
+	}
+
+	err = services.NewCrewService(h.db).Assign(eventID, request.UserID, request.RoleID, user.ID)
+	if h.respondCrewError(w, eventID, err) {
+		return
+	}
+	publishEventUpdate(eventID, EventUpdateCrewChanged, user.ID, map[string]string{
+		"userId": request.UserID,
+		"roleId": request.RoleID,
+		"action": "assigned",
+	})
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success:  true,
+		Message:  "Crew member assigned successfully",
+		Warnings: warnings,
+	})
+}
+
+// RemoveCrew takes a member off one of their roles on the event's crew
+func (h *EventHandler) RemoveCrew(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+
+	if _, ok := h.requireEventOrganizer(w, r, eventID, user.ID); !ok {
+		return
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	err := services.NewCrewService(h.db).Unassign(eventID, vars["userId"], vars["roleId"])
+	if h.respondCrewError(w, eventID, err) {
+		return
+	}
+	publishEventUpdate(eventID, EventUpdateCrewChanged, user.ID, map[string]string{
+		"userId": vars["userId"],
+		"roleId": vars["roleId"],
+		"action": "removed",
+	})
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Crew member removed successfully",
+	})
+}
+
+// respondCrewError responds to a crew change that failed and reports whether it did
+func (h *EventHandler) respondCrewError(w http.ResponseWriter, eventID string, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrEventNotFound):
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+	case errors.Is(err, services.ErrEventRoleNotFound):
+		RespondWithError(w, http.StatusBadRequest, "Not one of this group's event roles")
+	case errors.Is(err, services.ErrNotGroupMember):
+		RespondWithError(w, http.StatusBadRequest, "Crew must be members of this group")
+	default:
+		log.Printf("Error updating crew of event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating event crew")
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// maxImportSize caps how big an uploaded calendar can be
+const maxImportSize = 5 << 20
+
+// Import previews the events in an iCalendar file, or imports the selected ones.
+// The client sends the file's contents both times, with commit and the selected keys the second time.
+func (h *EventHandler) Import(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	var role string
+	err := h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, user.ID).Scan(&role)
+	if err != nil || (role != auth.RoleAdmin && role != auth.RoleOrganizer) {
+		log.Printf("User %s not authorized to import events into group %s (role: %s, error: %v)", user.ID, groupID, role, err)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can import events")
+		return
+	}
+
+	var importRequest struct {
+		Calendar string   `json:"calendar"`
+		Commit   bool     `json:"commit"`
+		Selected []string `json:"selected"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := json.NewDecoder(r.Body).Decode(&importRequest); err != nil {
+		log.Printf("Error decoding import request for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if strings.TrimSpace(importRequest.Calendar) == "" {
+		RespondWithError(w, http.StatusBadRequest, "Calendar file is required")
+		return
+	}
+
+	importService := services.NewEventImportService(h.db)
+	if !importRequest.Commit {
+		candidates, err := importService.Preview(groupID, strings.NewReader(importRequest.Calendar))
+		if errors.Is(err, services.ErrInvalidImport) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("Error previewing import for group %s: %v", groupID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error reading calendar")
+			return
+		}
+		if candidates == nil {
+			candidates = []services.ImportCandidate{}
+		}
+		RespondWithJSON(w, http.StatusOK, ApiResponse{
+			Success: true,
+			Data:    candidates,
+		})
+		return
+	}
+
+	if len(importRequest.Selected) == 0 {
+		RespondWithError(w, http.StatusBadRequest, "Select at least one event to import")
+		return
+	}
+	result, err := importService.Commit(groupID, user.ID, strings.NewReader(importRequest.Calendar), importRequest.Selected)
+	if errors.Is(err, services.ErrInvalidImport) {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error importing events into group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error importing events")
+		return
+	}
+
+	log.Printf("User %s imported %d events and %d series into group %s", user.ID, len(result.EventIDs), len(result.SeriesIDs), groupID)
+	RespondWithJSON(w, http.StatusCreated, ApiResponse{
+		Success: true,
+		Message: "Events imported successfully",
+		Data:    result,
+	})
+}
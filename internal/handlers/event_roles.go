@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// EventRoleHandler manages the crew roles a group's events can be staffed with
+type EventRoleHandler struct {
+	db *sql.DB
+}
+
+// NewEventRoleHandler creates a new EventRoleHandler
+func NewEventRoleHandler(db *sql.DB) *EventRoleHandler {
+	return &EventRoleHandler{
+		db: db,
+	}
+}
+
+type eventRoleRequest struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// requireGroupRole responds with an error and returns false unless the user is a member of the group,
+// and an admin or organizer when organizersOnly is set
+func (h *EventRoleHandler) requireGroupRole(w http.ResponseWriter, groupID, userID string, organizersOnly bool) bool {
+	var role string
+	err := h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, userID).Scan(&role)
+	if err != nil {
+		log.Printf("User %s is not a member of group %s: %v", userID, groupID, err)
+		RespondWithError(w, http.StatusForbidden, "Not a member of this group")
+		return false
+	}
+	if organizersOnly && role != auth.RoleAdmin && role != auth.RoleOrganizer {
+		log.Printf("User %s is not an organizer of group %s (role=%s)", userID, groupID, role)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can manage event roles")
+		return false
+	}
+	return true
+}
+
+// getRole loads the role named in the route, responding with 404 when it isn't the group's
+func (h *EventRoleHandler) getRole(w http.ResponseWriter, groupID, roleID string) *services.EventRole {
+	role, err := services.NewCrewService(h.db).Role(groupID, roleID)
+	if err != nil {
+		log.Printf("Error fetching event role %s: %v", roleID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event role")
+		return nil
+	}
+	if role == nil {
+		RespondWithError(w, http.StatusNotFound, "Event role not found")
+		return nil
+	}
+	return role
+}
+
+// save saves the role from the request
+func (h *EventRoleHandler) save(w http.ResponseWriter, r *http.Request, role services.EventRole) (*services.EventRole, bool) {
+	var request eventRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding event role request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return nil, false
+	}
+	defer r.Body.Close()
+	role.Name = request.Name
+	role.Permissions = request.Permissions
+
+	saved, err := services.NewCrewService(h.db).SaveRole(role)
+	if errors.Is(err, services.ErrInvalidEventRole) {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error saving event role for group %s: %v", role.GroupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error saving event role")
+		return nil, false
+	}
+	return saved, true
+}
+
+// List returns the group's event roles, starting it off with the default roles
+func (h *EventRoleHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, false) {
+		return
+	}
+
+	roles, err := services.NewCrewService(h.db).Roles(groupID)
+	if err != nil {
+		log.Printf("Error fetching event roles for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event roles")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    roles,
+	})
+}
+
+// Create adds an event role to the group
+func (h *EventRoleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	created, ok := h.save(w, r, services.EventRole{GroupID: groupID})
+	if !ok {
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, ApiResponse{
+		Success: true,
+		Message: "Event role created successfully",
+		Data:    created,
+	})
+}
+
+// Update renames an event role and replaces its permissions
+func (h *EventRoleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	role := h.getRole(w, groupID, vars["roleId"])
+	if role == nil {
+		return
+	}
+
+	updated, ok := h.save(w, r, *role)
+	if !ok {
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Event role updated successfully",
+		Data:    updated,
+	})
+}
+
+// Delete removes an event role, taking everyone in it off their events' crews
+func (h *EventRoleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	roleID := vars["roleId"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	err := services.NewCrewService(h.db).DeleteRole(groupID, roleID)
+	if errors.Is(err, services.ErrEventRoleNotFound) {
+		RespondWithError(w, http.StatusNotFound, "Event role not found")
+		return
+	}
+	if errors.Is(err, services.ErrInvalidEventRole) {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting event role %s: %v", roleID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error deleting event role")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Event role deleted successfully",
+	})
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/recurrence"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// occurrenceHorizon is how far ahead event lists expand recurring series by default
+const occurrenceHorizon = 90 * 24 * time.Hour
+
+// maxOccurrenceWindow is the furthest to can be past from, or past now when from isn't given,
+// so a request can't make a list expand a series for centuries
+const maxOccurrenceWindow = 366 * 24 * time.Hour
+
+// unsavedOccurrenceKey is the request context key for an occurrence that hasn't been saved as an event
+type unsavedOccurrenceKey struct{}
+
+// ResolveOccurrence lets event routes take the ID of an occurrence that hasn't been saved yet.
+// An occurrence that was saved continues with its event's ID. One that wasn't continues with its
+// own ID, and handlers read it from its series with unsavedOccurrence. Nothing is saved here:
+// routes that change the event check the user may do so against the series' group first, and
+// only then save the occurrence with saveOccurrence.
+func (h *EventHandler) ResolveOccurrence(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		seriesID, originalStart, ok := recurrence.ParseOccurrenceID(vars["id"])
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		user := r.Context().Value(middleware.UserContextKey).(*models.User)
+
+		// Only members can open an occurrence, the same as any other event
+		var isMember bool
+		err := h.db.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM event_series s
+				JOIN group_members m ON s.group_id = m.group_id
+				WHERE s.id = $1 AND m.user_id = $2
+			)
+		`, seriesID, user.ID).Scan(&isMember)
+		if err != nil {
+			log.Printf("Error checking access to series %s for user %s: %v", seriesID, user.ID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+			return
+		}
+		if !isMember {
+			log.Printf("Series %s not found for user %s", seriesID, user.ID)
+			RespondWithError(w, http.StatusNotFound, "Event not found")
+			return
+		}
+
+		eventID, occurrence, err := services.NewEventSeriesService(h.db).FindOccurrence(seriesID, originalStart)
+		if err == services.ErrOccurrenceNotFound || err == services.ErrOccurrenceCancelled {
+			log.Printf("Occurrence %s of series %s: %v", originalStart, seriesID, err)
+			RespondWithError(w, http.StatusNotFound, "Event not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Error resolving occurrence %s of series %s: %v", originalStart, seriesID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+			return
+		}
+
+		if occurrence != nil {
+			next(w, r.WithContext(context.WithValue(r.Context(), unsavedOccurrenceKey{}, occurrence)))
+			return
+		}
+		vars["id"] = eventID
+		next(w, mux.SetURLVars(r, vars))
+	}
+}
+
+// saveOccurrence saves the route's occurrence as an event so the handler can change it, and
+// returns the event ID to continue with. Handlers call it only after checking the user may make
+// the change. The ID of an event that was already saved comes back as it is.
+func saveOccurrence(db *sql.DB, w http.ResponseWriter, r *http.Request, eventID string) (string, bool) {
+	occurrence := unsavedOccurrence(r)
+	if occurrence == nil {
+		return eventID, true
+	}
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+
+	savedID, err := services.NewEventSeriesService(db).Materialize(occurrence.SeriesID, occurrence.StartTime)
+	if err == services.ErrOccurrenceNotFound || err == services.ErrOccurrenceCancelled {
+		log.Printf("Occurrence %s: %v", occurrence.ID, err)
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Error saving occurrence %s: %v", occurrence.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error saving event")
+		return "", false
+	}
+	publishEventUpdate(occurrence.ID, EventUpdateOccurrenceSaved, user.ID, map[string]string{"id": savedID})
+	return savedID, true
+}
+
+// unsavedOccurrence returns the occurrence the route was given if it hasn't been saved as an
+// event. It has no RSVPs, lineup or crew of its own yet.
+func unsavedOccurrence(r *http.Request) *services.Occurrence {
+	occurrence, _ := r.Context().Value(unsavedOccurrenceKey{}).(*services.Occurrence)
+	return occurrence
+}
+
+// eventGroupID returns the group of the route's event, which for an unsaved occurrence is its
+// series' group. It returns sql.ErrNoRows if there's no such event.
+func eventGroupID(db *sql.DB, r *http.Request, eventID string) (string, error) {
+	if occurrence := unsavedOccurrence(r); occurrence != nil {
+		return occurrence.GroupID, nil
+	}
+	var groupID string
+	err := db.QueryRow(`SELECT group_id FROM events WHERE id = $1`, eventID).Scan(&groupID)
+	return groupID, err
+}
+
+// CancelOccurrence removes an occurrence of a recurring series from the calendar.
+// The scope query parameter extends it to the following occurrences or the whole series.
+// An occurrence that was saved as an event is cancelled the way UpdateStatus cancels any
+// event, so its attendees are told; one that wasn't has no attendees and is skipped.
+func (h *EventHandler) CancelOccurrence(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		scope = services.ScopeThis
+	}
+	if !services.IsValidScope(scope) {
+		RespondWithError(w, http.StatusBadRequest, "Scope must be this, following or all")
+		return
+	}
+
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err != nil {
+		log.Printf("Event not found during occurrence cancel: %s (%v)", eventID, err)
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+
+	var role string
+	err = h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, user.ID).Scan(&role)
+	if err != nil || (role != auth.RoleAdmin && role != auth.RoleOrganizer) {
+		log.Printf("User %s not authorized to cancel event %s (role: %s, error: %v)", user.ID, eventID, role, err)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can cancel events")
+		return
+	}
+
+	seriesService := services.NewEventSeriesService(h.db)
+	var series *services.EventSeries
+	var originalStart time.Time
+	occurrence := unsavedOccurrence(r)
+	if occurrence != nil {
+		series, err = seriesService.Get(occurrence.SeriesID)
+		originalStart = occurrence.StartTime
+	} else {
+		series, originalStart, err = seriesService.SeriesOf(eventID)
+	}
+	if err == services.ErrNotInSeries || (err == nil && series == nil) {
+		RespondWithError(w, http.StatusBadRequest, "Event is not part of a recurring series")
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching series of event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error cancelling event")
+		return
+	}
+
+	var saved []string
+	switch {
+	case scope == services.ScopeThis && occurrence != nil:
+		err = seriesService.Skip(series.ID, originalStart)
+		if err == services.ErrOccurrenceNotFound {
+			RespondWithError(w, http.StatusConflict, "The occurrence was just saved as an event, try again")
+			return
+		}
+		if err == nil {
+			publishEventUpdate(occurrence.ID, EventUpdateStatusChanged, user.ID, map[string]interface{}{
+				"status": services.EventStatusCancelled,
+			})
+		}
+	case scope == services.ScopeThis:
+		saved = []string{eventID}
+	default:
+		from := originalStart
+		if scope == services.ScopeAll {
+			from = series.StartTime
+		}
+		saved, err = seriesService.End(series.ID, from)
+	}
+	if err != nil {
+		log.Printf("Error cancelling event %s (scope %s): %v", eventID, scope, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error cancelling event")
+		return
+	}
+
+	for _, savedID := range saved {
+		from, _, err := h.changeStatus(savedID, groupID, user.ID, services.EventStatusCancelled, "")
+		if errors.Is(err, services.ErrInvalidTransition) && scope == services.ScopeThis {
+			RespondWithError(w, http.StatusConflict, fmt.Sprintf("A %s event can't be cancelled", from))
+			return
+		}
+		if err != nil {
+			log.Printf("Error cancelling event %s: %v", savedID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error cancelling event")
+			return
+		}
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Event cancelled successfully",
+	})
+}
+
+// occurrenceWindow reads the optional from and to query parameters that bound how far
+// recurring series are expanded. By default every past occurrence and the next 90 days are included.
+// A window running more than maxOccurrenceWindow ahead is an error.
+func occurrenceWindow(r *http.Request) (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Time{}
+	to := now.Add(occurrenceHorizon)
+
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return from, to, fmt.Errorf("Invalid from time format")
+		}
+		from = parsed
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return from, to, fmt.Errorf("Invalid to time format")
+		}
+		to = parsed
+	}
+
+	if r.URL.Query().Get("from") == "" {
+		if to.Sub(now) > maxOccurrenceWindow {
+			return from, to, fmt.Errorf("The to time can be at most 366 days from now")
+		}
+	} else if to.Sub(from) > maxOccurrenceWindow {
+		return from, to, fmt.Errorf("The from and to times can be at most 366 days apart")
+	}
+	return from, to, nil
+}
+
+// expandSeries returns the unsaved occurrences of each series within the window
+func (h *EventHandler) expandSeries(seriesList []services.EventSeries, from, to time.Time) ([]services.Occurrence, error) {
+	seriesService := services.NewEventSeriesService(h.db)
+	var occurrences []services.Occurrence
+	for _, series := range seriesList {
+		expanded, err := seriesService.Occurrences(series, from, to)
+		if err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, expanded...)
+	}
+	return occurrences, nil
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"improv-app/internal/recurrence"
+	"improv-app/internal/services"
+)
+
+// seedJam creates group123's weekly jam on Tuesdays, which nobody has saved an occurrence of,
+// and returns the ID of its second occurrence
+func seedJam(t *testing.T, testDB *sql.DB) string {
+	t.Helper()
+	start := time.Date(2026, 1, 6, 19, 0, 0, 0, time.UTC)
+	capacity, mcID := 12, "user123"
+	series, err := services.NewEventSeriesService(testDB).Create(services.EventSeries{
+		GroupID:   "group123",
+		Title:     "Weekly Jam",
+		Location:  "Main Stage",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=4",
+		MCID:      &mcID,
+		Capacity:  &capacity,
+		CreatedBy: "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error creating series: %v", err)
+	}
+	return recurrence.OccurrenceID(series.ID, start.AddDate(0, 0, 7))
+}
+
+// savedEvents counts the events saved from series
+func savedEvents(t *testing.T, testDB *sql.DB) int {
+	t.Helper()
+	var count int
+	if err := testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE series_id IS NOT NULL`).Scan(&count); err != nil {
+		t.Fatalf("Error counting events: %v", err)
+	}
+	return count
+}
+
+func TestResolveOccurrence_ReadsWithoutSaving(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers)
+	occurrenceID := seedJam(t, testDB)
+	h := NewEventHandler(testDB)
+
+	recorder, response := serve(t, testDB, "dana", "/events/{id}", h.ResolveOccurrence(h.Get), http.MethodGet, "/events/"+occurrenceID, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	data := response.Data.(map[string]interface{})
+	event := data["event"].(map[string]interface{})
+	if event["ID"] != occurrenceID || event["Title"] != "Weekly Jam" || event["StartTime"] != "2026-01-13T19:00:00Z" {
+		t.Errorf("Expected the occurrence read from its series, got %v", event)
+	}
+	if data["groupName"] != "Test Group" || data["spotsLeft"] != float64(12) || len(data["rsvps"].([]interface{})) != 2 {
+		t.Errorf("Expected Test Group with 12 spots and both members awaiting a response, got %v", data)
+	}
+
+	reads := []struct {
+		pattern string
+		handler http.HandlerFunc
+	}{
+		{"/events/{id}/games", h.GetEventGames},
+		{"/events/{id}/players", h.GetEventPlayers},
+		{"/events/{id}/preferences", h.GetUserGamePreferences},
+		{"/events/{id}/non-registered-attendees", h.GetNonRegisteredAttendees},
+		{"/events/{id}/lineup/constraints", h.GetLineupConstraints},
+		{"/events/{id}/health", h.GetEventHealth},
+		{"/events/{id}/run-of-show", h.GetRunOfShow},
+		{"/events/{id}/attendance", h.GetAttendance},
+		{"/events/{id}/crew", h.GetCrew},
+	}
+	for _, read := range reads {
+		path := "/events/" + occurrenceID + read.pattern[len("/events/{id}"):]
+		recorder, _ := serve(t, testDB, "user123", read.pattern, h.ResolveOccurrence(read.handler), http.MethodGet, path, nil)
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d: %s", read.pattern, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	// Reads still check who can see the lineup
+	recorder, _ = serve(t, testDB, "dana", "/events/{id}/health", h.ResolveOccurrence(h.GetEventHealth), http.MethodGet, "/events/"+occurrenceID+"/health", nil)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a member viewing lineup health, got %d", recorder.Code)
+	}
+	recorder, _ = serve(t, testDB, "outsider", "/events/{id}", h.ResolveOccurrence(h.Get), http.MethodGet, "/events/"+occurrenceID, nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an outsider, got %d", recorder.Code)
+	}
+
+	if saved := savedEvents(t, testDB); saved != 0 {
+		t.Fatalf("Expected reading the occurrence not to save it, got %d events", saved)
+	}
+
+	// Adding a game saves it, and reads then go to the saved event
+	seed(t, testDB, `INSERT INTO games (id, name, description, min_players, max_players, created_by, group_id) VALUES ('game1', 'Freeze Tag', '', 2, 6, 'user123', 'group123')`)
+	recorder, _ = serve(t, testDB, "user123", "/events/{id}/games", h.ResolveOccurrence(h.AddGameToEvent), http.MethodPost, "/events/"+occurrenceID+"/games",
+		map[string]string{"gameId": "game1"})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 adding a game, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if saved := savedEvents(t, testDB); saved != 1 {
+		t.Fatalf("Expected the write to save the occurrence, got %d events", saved)
+	}
+	recorder, response = serve(t, testDB, "dana", "/events/{id}", h.ResolveOccurrence(h.Get), http.MethodGet, "/events/"+occurrenceID, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	event = response.Data.(map[string]interface{})["event"].(map[string]interface{})
+	if event["ID"] == occurrenceID {
+		t.Errorf("Expected the saved event, got the occurrence %v", event)
+	}
+}
+
+func TestResolveOccurrence_RefusedWritesDontSave(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers)
+	occurrenceID := seedJam(t, testDB)
+	h := NewEventHandler(testDB)
+
+	writes := []struct {
+		method  string
+		pattern string
+		handler http.HandlerFunc
+		body    interface{}
+	}{
+		{http.MethodPut, "/events/{id}", h.Update, map[string]string{"title": "Renamed", "startTime": "2026-01-13T19:00:00Z"}},
+		{http.MethodPut, "/events/{id}/status", h.UpdateStatus, map[string]string{"status": services.EventStatusCancelled}},
+		{http.MethodPost, "/events/{id}/clone", h.Clone, map[string]string{"startTime": "2026-03-03T19:00:00Z"}},
+		{http.MethodPost, "/events/{id}/lineup/generate", h.GenerateLineup, nil},
+		{http.MethodPost, "/events/{id}/lineup/constraints", h.CreateLineupConstraint, map[string]string{"type": "max-games"}},
+		{http.MethodPost, "/events/{id}/crew", h.AssignCrew, map[string]string{"userId": "dana", "roleId": "group123:mc"}},
+		{http.MethodPost, "/events/{id}/run-of-show/start", h.StartShow, nil},
+		{http.MethodPost, "/events/{id}/attendance/no-shows", h.MarkNoShows, nil},
+		{http.MethodDelete, "/events/{id}/occurrence", h.CancelOccurrence, nil},
+	}
+	for _, write := range writes {
+		path := "/events/" + occurrenceID + write.pattern[len("/events/{id}"):]
+		recorder, _ := serve(t, testDB, "dana", write.pattern, h.ResolveOccurrence(write.handler), write.method, path, write.body)
+		if recorder.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403 for a member, got %d: %s", write.method, write.pattern, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	if saved := savedEvents(t, testDB); saved != 0 {
+		t.Fatalf("Expected refused writes not to save the occurrence, got %d events", saved)
+	}
+}
+
+func TestResolveOccurrence_FailedWritesDontSave(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers)
+	occurrenceID := seedJam(t, testDB)
+	h := NewEventHandler(testDB)
+
+	writes := []struct {
+		method  string
+		pattern string
+		handler http.HandlerFunc
+		body    interface{}
+		code    int
+	}{
+		{http.MethodPut, "/events/{id}", h.Update, map[string]string{"title": "Renamed", "startTime": "soon"}, http.StatusBadRequest},
+		{http.MethodPut, "/events/{id}/status", h.UpdateStatus, map[string]string{"status": services.EventStatusScheduled}, http.StatusConflict},
+		{http.MethodPost, "/events/{id}/games", h.AddGameToEvent, map[string]string{"gameId": "missing"}, http.StatusBadRequest},
+		{http.MethodDelete, "/events/{id}/games/{gameId}", h.RemoveGameFromEvent, nil, http.StatusNotFound},
+		{http.MethodPost, "/events/{id}/lineup/finalize", h.FinalizeLineup, nil, http.StatusBadRequest},
+		{http.MethodPost, "/events/{id}/lineup/constraints", h.CreateLineupConstraint, map[string]string{"type": "max-games"}, http.StatusBadRequest},
+		{http.MethodPost, "/events/{id}/lineup/generate", h.GenerateLineup, nil, http.StatusOK},
+		{http.MethodPost, "/events/{id}/clone", h.Clone, map[string]string{"startTime": "2026-11-03T19:00:00Z"}, http.StatusCreated},
+	}
+	for _, write := range writes {
+		path := strings.Replace("/events/"+occurrenceID+write.pattern[len("/events/{id}"):], "{gameId}", "game1", 1)
+		recorder, _ := serve(t, testDB, "user123", write.pattern, h.ResolveOccurrence(write.handler), write.method, path, write.body)
+		if recorder.Code != write.code {
+			t.Errorf("%s %s: expected %d, got %d: %s", write.method, write.pattern, write.code, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	// The clone is read from the occurrence, so it's the only new event
+	if saved := savedEvents(t, testDB); saved != 0 {
+		t.Fatalf("Expected failed writes and the clone not to save the occurrence, got %d events", saved)
+	}
+	var title string
+	if err := testDB.QueryRow(`SELECT title FROM events WHERE series_id IS NULL AND group_id = 'group123' AND title = 'Weekly Jam'`).Scan(&title); err != nil {
+		t.Errorf("Expected the clone saved on its own: %v", err)
+	}
+}
+
+func TestCancelOccurrence(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers)
+	occurrenceID := seedJam(t, testDB)
+	h := NewEventHandler(testDB)
+	seriesID, originalStart, _ := recurrence.ParseOccurrenceID(occurrenceID)
+
+	// Dana is going to the third jam, which was saved for her RSVP
+	third, err := services.NewEventSeriesService(testDB).Materialize(seriesID, originalStart.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("Error saving occurrence: %v", err)
+	}
+	seed(t, testDB, `INSERT INTO event_rsvps (event_id, user_id, status) VALUES ('`+third+`', 'dana', 'attending')`)
+
+	// The unsaved second jam is skipped without saving it
+	recorder, _ := serve(t, testDB, "user123", "/events/{id}/occurrence", h.ResolveOccurrence(h.CancelOccurrence), http.MethodDelete, "/events/"+occurrenceID+"/occurrence", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 skipping, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if saved := savedEvents(t, testDB); saved != 1 {
+		t.Errorf("Expected only the third jam saved, got %d events", saved)
+	}
+
+	// Cancelling from the third jam on cancels the saved one like any event, and tells Dana
+	recorder, _ = serve(t, testDB, "user123", "/events/{id}/occurrence", h.ResolveOccurrence(h.CancelOccurrence), http.MethodDelete, "/events/"+third+"/occurrence?scope=following", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 cancelling, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var status string
+	testDB.QueryRow(`SELECT status FROM events WHERE id = $1`, third).Scan(&status)
+	if status != services.EventStatusCancelled {
+		t.Errorf("Expected the saved jam cancelled, got %q", status)
+	}
+	if notifications := notificationsFor(t, testDB, "dana"); len(notifications) != 1 {
+		t.Errorf("Expected Dana told about the cancellation, got %v", notifications)
+	}
+}
+
+func TestOccurrenceWindow(t *testing.T) {
+	cases := []struct {
+		query string
+		ok    bool
+	}{
+		{"", true},
+		{"?from=2026-01-01T00:00:00Z&to=2026-12-31T00:00:00Z", true},
+		{"?from=2026-01-01T00:00:00Z&to=2028-01-01T00:00:00Z", false},
+		{"?from=0001-01-01T00:00:00Z&to=9999-01-01T00:00:00Z", false},
+		{"?to=9999-01-01T00:00:00Z", false},
+		{"?from=yesterday", false},
+	}
+	for _, c := range cases {
+		_, _, err := occurrenceWindow(httptest.NewRequest(http.MethodGet, "/events"+c.query, nil))
+		if (err == nil) != c.ok {
+			t.Errorf("For %q expected ok=%v, got %v", c.query, c.ok, err)
+		}
+	}
+}
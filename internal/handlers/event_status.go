@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// UpdateStatus moves an event through its lifecycle: scheduling a draft, cancelling
+// with a reason, reinstating a cancelled event, or marking it completed early
+func (h *EventHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	var request struct {
+		Status string `json:"status"`
+		Reason string `json:"reason,omitempty"` // Optional reason shown to attendees when cancelling
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding event status request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !services.IsValidEventStatus(request.Status) {
+		RespondWithError(w, http.StatusBadRequest, "Status must be draft, scheduled, cancelled or completed")
+		return
+	}
+
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err != nil {
+		log.Printf("Event not found during status change: %s (%v)", eventID, err)
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+
+	var role string
+	err = h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, user.ID).Scan(&role)
+	if err != nil || (role != auth.RoleAdmin && role != auth.RoleOrganizer) {
+		log.Printf("User %s not authorized to change status of event %s (role: %s, error: %v)", user.ID, eventID, role, err)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can change an event's status")
+		return
+	}
+	// An occurrence is saved as scheduled, so it's only saved for a change a scheduled event can make
+	if unsavedOccurrence(r) != nil && !services.CanTransition(services.EventStatusScheduled, request.Status) {
+		RespondWithError(w, http.StatusConflict, fmt.Sprintf("A %s event can't be moved to %s", services.EventStatusScheduled, request.Status))
+		return
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	from, event, err := h.changeStatus(eventID, groupID, user.ID, request.Status, request.Reason)
+	if err == services.ErrEventNotFound {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+	if errors.Is(err, services.ErrInvalidTransition) {
+		RespondWithError(w, http.StatusConflict, fmt.Sprintf("A %s event can't be moved to %s", from, request.Status))
+		return
+	}
+	if err != nil {
+		log.Printf("Error changing status of event %s to %s: %v", eventID, request.Status, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating event status")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Event status updated successfully",
+		Data:    event,
+	})
+}
+
+// changeStatus moves an event to the status and tells everyone who should know: a published
+// draft is announced like a new event, a cancellation goes to the attendees, and webhooks and
+// live viewers hear about any change. It returns the status the event was in and the updated event.
+func (h *EventHandler) changeStatus(eventID, groupID, changedBy, status, reason string) (string, *Event, error) {
+	from, err := services.NewEventStatusService(h.db).Transition(eventID, status, reason)
+	if err != nil {
+		return from, nil, err
+	}
+
+	var event Event
+	err = h.db.QueryRow(`
+		SELECT id, group_id, title, description, location, start_time, end_time, created_at, created_by, mc_id, series_id, capacity, visibility,
+		       status, cancellation_reason, venue_id, time_zone
+		FROM events
+		WHERE id = $1
+	`, eventID).Scan(&event.ID, &event.GroupID, &event.Title, &event.Description, &event.Location, &event.StartTime, &event.EndTime, &event.CreatedAt, &event.CreatedBy, &event.MCID, &event.SeriesID, &event.Capacity, &event.Visibility,
+		&event.Status, &event.CancellationReason, &event.VenueID, &event.TimeZone)
+	if err != nil {
+		return from, nil, fmt.Errorf("error fetching event after status change: %v", err)
+	}
+
+	switch {
+	case from == services.EventStatusDraft && event.Status == services.EventStatusScheduled:
+		// A published draft is announced like a new event
+		h.announceCreated(event)
+	case event.Status == services.EventStatusCancelled:
+		content := fmt.Sprintf("%s on %s was cancelled", event.Title, event.StartTime.In(services.EventLocation(event.TimeZone)).Format("Mon Jan 2 at 3:04 PM MST"))
+		if reason != "" {
+			content += ": " + reason
+		}
+		h.notifyAttendees(eventID, groupID, changedBy, content)
+	}
+
+	// A draft that's cancelled was never published, so subscribers never heard of it
+	if from != services.EventStatusDraft || event.Status != services.EventStatusCancelled {
+		emitWebhook(h.db, groupID, services.WebhookEventStatusChanged, map[string]interface{}{
+			"eventId":            eventID,
+			"previousStatus":     from,
+			"status":             event.Status,
+			"cancellationReason": event.CancellationReason,
+		})
+	}
+	publishEventUpdate(eventID, EventUpdateStatusChanged, changedBy, map[string]interface{}{
+		"status":             event.Status,
+		"cancellationReason": event.CancellationReason,
+	})
+	return from, &event, nil
+}
+
+// notifyAttendees notifies the members who are attending, might attend or are waitlisted,
+// except whoever made the change
+func (h *EventHandler) notifyAttendees(eventID, groupID, changedBy, content string) {
+	rows, err := h.db.Query(`
+		SELECT user_id FROM event_rsvps
+		WHERE event_id = $1 AND user_id != $2 AND status IN ('attending', 'maybe', 'waitlisted')
+	`, eventID, changedBy)
+	if err != nil {
+		log.Printf("Error fetching attendees of event %s: %v", eventID, err)
+		return
+	}
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			log.Printf("Error scanning attendee of event %s: %v", eventID, err)
+			rows.Close()
+			return
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+
+	notificationService := services.NewNotificationService(h.db)
+	for _, userID := range userIDs {
+		if err := notificationService.Notify(userID, groupID, services.NotificationEventChanged, content, eventID); err != nil {
+			log.Printf("Error creating cancellation notification for user %s: %v", userID, err)
+		}
+	}
+}
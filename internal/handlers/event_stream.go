@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/pubsub"
+
+	"github.com/gorilla/mux"
+)
+
+// Live update types pushed to event streams
+const (
+	EventUpdateStatusChanged            = "status.changed"
+	EventUpdateRSVPChanged              = "rsvp.changed"
+	EventUpdateAttendeeAdded            = "attendee.added"
+	EventUpdateAttendeeUpdated          = "attendee.updated"
+	EventUpdateAttendeeRemoved          = "attendee.removed"
+	EventUpdateGameAdded                = "game.added"
+	EventUpdateGameRemoved              = "game.removed"
+	EventUpdateGamesReordered           = "games.reordered"
+	EventUpdateAssignmentAdded          = "assignment.added"
+	EventUpdateAssignmentRemoved        = "assignment.removed"
+	EventUpdateLineupFinalized          = "lineup.finalized"
+	EventUpdateLineupAccepted           = "lineup.accepted"
+	EventUpdateLineupConstraintsChanged = "lineup-constraints.changed"
+	EventUpdateAttendanceChanged        = "attendance.changed"
+	EventUpdateCrewChanged              = "crew.changed"
+	EventUpdateRunOfShowChanged         = "run-of-show.changed"
+	// EventUpdateOccurrenceSaved goes to the streams of an unsaved occurrence once it's saved
+	// as an event, which is where its changes are pushed from then on
+	EventUpdateOccurrenceSaved = "occurrence.saved"
+)
+
+const (
+	// eventStreamBuffer is how many updates a stream may fall behind before it is dropped
+	eventStreamBuffer = 32
+	// eventStreamKeepAlive keeps proxies from closing idle streams
+	eventStreamKeepAlive = 25 * time.Second
+	// eventStreamRetry tells browsers how long to wait before reconnecting, in milliseconds
+	eventStreamRetry = 3000
+)
+
+// eventUpdates carries live changes to the clients streaming each event, keyed by event ID
+var eventUpdates = pubsub.NewBroker(eventStreamBuffer)
+
+// eventUpdate is the JSON sent as the data of each stream message
+type eventUpdate struct {
+	EventID string      `json:"eventId"`
+	UserID  string      `json:"userId"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// publishEventUpdate pushes a change to everyone streaming the event. userID is whoever made the change.
+func publishEventUpdate(eventID, updateType, userID string, data interface{}) {
+	eventUpdates.Publish(eventID, updateType, eventUpdate{
+		EventID: eventID,
+		UserID:  userID,
+		Data:    data,
+	})
+}
+
+// Stream sends the event's RSVP, walk-in and lineup changes as Server-Sent Events
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	// Same access rule as Get: the user must be a member of the event's group. ResolveOccurrence
+	// has checked that for an occurrence that hasn't been saved.
+	if unsavedOccurrence(r) == nil {
+		var canView bool
+		err := h.db.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM events e
+				JOIN group_members m ON e.group_id = m.group_id
+				WHERE e.id = $1 AND m.user_id = $2
+				  AND (e.status != 'draft' OR m.role IN ('admin', 'organizer'))
+			)
+		`, eventID, user.ID).Scan(&canView)
+		if err != nil {
+			log.Printf("Error checking access to event %s stream for user %s: %v", eventID, user.ID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+			return
+		}
+		if !canView {
+			log.Printf("Event %s not found for user %s stream", eventID, user.ID)
+			RespondWithError(w, http.StatusNotFound, "Event not found")
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Printf("Streaming not supported for event %s", eventID)
+		RespondWithError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	subscription := eventUpdates.Subscribe(eventID)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetry)
+	fmt.Fprintf(w, "event: connected\ndata: {\"eventId\":%q}\n\n", eventID)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case message, ok := <-subscription.C:
+			if !ok {
+				// Dropped for falling behind; the browser reconnects and refetches
+				return
+			}
+			data, err := json.Marshal(message.Data)
+			if err != nil {
+				log.Printf("Error encoding %s update for event %s: %v", message.Type, eventID, err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", message.ID, message.Type, data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// EventTemplateHandler lets organizers keep named show formats that prefill new events
+type EventTemplateHandler struct {
+	db *sql.DB
+}
+
+// NewEventTemplateHandler creates a new EventTemplateHandler
+func NewEventTemplateHandler(db *sql.DB) *EventTemplateHandler {
+	return &EventTemplateHandler{
+		db: db,
+	}
+}
+
+type eventTemplateRequest struct {
+	Name            string                  `json:"name"`
+	TitlePattern    string                  `json:"titlePattern"`
+	DurationMinutes int                     `json:"durationMinutes"`
+	Location        string                  `json:"location"`
+	Visibility      *string                 `json:"visibility,omitempty"`
+	Capacity        *int                    `json:"capacity,omitempty"`
+	Games           []services.TemplateGame `json:"games"`
+}
+
+// apply copies the request onto the template
+func (req eventTemplateRequest) apply(template *services.EventTemplate) {
+	template.Name = req.Name
+	template.TitlePattern = req.TitlePattern
+	template.DurationMinutes = req.DurationMinutes
+	template.Location = req.Location
+	template.Visibility = req.Visibility
+	template.Capacity = req.Capacity
+	template.Games = req.Games
+}
+
+// requireGroupRole responds with an error and returns false unless the user is a member of the group,
+// and an admin or organizer when organizersOnly is set
+func (h *EventTemplateHandler) requireGroupRole(w http.ResponseWriter, groupID, userID string, organizersOnly bool) bool {
+	var role string
+	err := h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, userID).Scan(&role)
+	if err != nil {
+		log.Printf("User %s is not a member of group %s: %v", userID, groupID, err)
+		RespondWithError(w, http.StatusForbidden, "Not a member of this group")
+		return false
+	}
+	if organizersOnly && role != auth.RoleAdmin && role != auth.RoleOrganizer {
+		log.Printf("User %s is not an organizer of group %s (role=%s)", userID, groupID, role)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can manage event templates")
+		return false
+	}
+	return true
+}
+
+// getTemplate loads the template named in the route, responding with 404 when it isn't the group's
+func (h *EventTemplateHandler) getTemplate(w http.ResponseWriter, groupID, templateID string) *services.EventTemplate {
+	template, err := services.NewEventTemplateService(h.db).Get(groupID, templateID)
+	if err != nil {
+		log.Printf("Error fetching event template %s: %v", templateID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event template")
+		return nil
+	}
+	if template == nil {
+		RespondWithError(w, http.StatusNotFound, "Event template not found")
+		return nil
+	}
+	return template
+}
+
+// List returns the group's event templates
+func (h *EventTemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, false) {
+		return
+	}
+
+	templates, err := services.NewEventTemplateService(h.db).List(groupID)
+	if err != nil {
+		log.Printf("Error fetching event templates for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event templates")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    templates,
+	})
+}
+
+// Get returns one of the group's event templates
+func (h *EventTemplateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, false) {
+		return
+	}
+
+	template := h.getTemplate(w, groupID, vars["templateId"])
+	if template == nil {
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    template,
+	})
+}
+
+// Create adds an event template to the group
+func (h *EventTemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	var request eventTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding event template request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	template := services.EventTemplate{
+		GroupID:   groupID,
+		CreatedBy: user.ID,
+	}
+	request.apply(&template)
+
+	created, err := services.NewEventTemplateService(h.db).Save(template)
+	if errors.Is(err, services.ErrInvalidTemplate) {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating event template for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error creating event template")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, ApiResponse{
+		Success: true,
+		Message: "Event template created successfully",
+		Data:    created,
+	})
+}
+
+// Update replaces an event template's details and lineup
+func (h *EventTemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	templateID := vars["templateId"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	template := h.getTemplate(w, groupID, templateID)
+	if template == nil {
+		return
+	}
+
+	var request eventTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding event template request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	request.apply(template)
+
+	updated, err := services.NewEventTemplateService(h.db).Save(*template)
+	if errors.Is(err, services.ErrInvalidTemplate) {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating event template %s: %v", templateID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating event template")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Event template updated successfully",
+		Data:    updated,
+	})
+}
+
+// Delete removes an event template. Events created from it keep their details.
+func (h *EventTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	templateID := vars["templateId"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	if h.getTemplate(w, groupID, templateID) == nil {
+		return
+	}
+
+	if err := services.NewEventTemplateService(h.db).Delete(groupID, templateID); err != nil {
+		log.Printf("Error deleting event template %s: %v", templateID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error deleting event template")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Event template deleted successfully",
+	})
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"improv-app/internal/services"
+)
+
+// MarshalJSON writes the event with its times in UTC, and again in the event's own time zone
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	plain := event(e)
+	plain.StartTime = e.StartTime.UTC()
+	plain.EndTime = e.EndTime.UTC()
+	if plain.TimeZone == "" {
+		plain.TimeZone = "UTC"
+	}
+	local := services.NewLocalTimes(e.StartTime, e.EndTime, plain.TimeZone)
+	return json.Marshal(struct {
+		event
+		StartTimeLocal string
+		EndTimeLocal   string
+	}{plain, local.StartTimeLocal, local.EndTimeLocal})
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/query"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// getVenue loads a venue an event is being put at, responding with 400 when it isn't the group's
+func (h *EventHandler) getVenue(w http.ResponseWriter, groupID, venueID string) *services.Venue {
+	venue, err := services.NewVenueService(h.db).Get(groupID, venueID)
+	if err != nil {
+		log.Printf("Error fetching venue %s: %v", venueID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching venue")
+		return nil
+	}
+	if venue == nil {
+		RespondWithError(w, http.StatusBadRequest, "Venue not found in this group")
+		return nil
+	}
+	return venue
+}
+
+// ListForVenue lists the group's events at a venue, including occurrences of
+// recurring events there. Like List, from and to limit the occurrences.
+func (h *EventHandler) ListForVenue(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	venueID := vars["venueId"]
+
+	// Verify user is a member of the group
+	var role string
+	err := h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, user.ID).Scan(&role)
+	if err != nil {
+		log.Printf("Error verifying group membership for user %s in group %s: %v", user.ID, groupID, err)
+		RespondWithError(w, http.StatusForbidden, "Not a member of this group")
+		return
+	}
+
+	venue, err := services.NewVenueService(h.db).Get(groupID, venueID)
+	if err != nil {
+		log.Printf("Error fetching venue %s: %v", venueID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching venue")
+		return
+	}
+	if venue == nil {
+		RespondWithError(w, http.StatusNotFound, "Venue not found")
+		return
+	}
+
+	from, to, err := occurrenceWindow(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Drafts are only listed for admins and organizers
+	rows, err := h.db.Query(`
+		SELECT e.id, e.title, e.description, e.location, e.start_time, e.end_time, e.created_at, e.created_by, e.mc_id, e.series_id, e.visibility,
+		       e.status, e.cancellation_reason, e.venue_id, e.time_zone
+		FROM events e
+		WHERE e.group_id = $1 AND e.venue_id = $2 AND ($3 OR e.status != 'draft') AND `+query.NotCancelledOccurrenceCondition+`
+		ORDER BY e.start_time DESC
+	`, groupID, venueID, role == auth.RoleAdmin || role == auth.RoleOrganizer)
+	if err != nil {
+		log.Printf("Error fetching events at venue %s: %v", venueID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
+		return
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		err := rows.Scan(&event.ID, &event.Title, &event.Description, &event.Location, &event.StartTime, &event.EndTime, &event.CreatedAt, &event.CreatedBy, &event.MCID, &event.SeriesID, &event.Visibility,
+			&event.Status, &event.CancellationReason, &event.VenueID, &event.TimeZone)
+		if err != nil {
+			log.Printf("Error scanning events row: %v", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error scanning events")
+			return
+		}
+		event.GroupID = groupID
+		events = append(events, event)
+	}
+	rows.Close()
+
+	seriesList, err := services.NewEventSeriesService(h.db).ListForGroup(groupID)
+	if err != nil {
+		log.Printf("Error fetching event series for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
+		return
+	}
+	var atVenue []services.EventSeries
+	for _, series := range seriesList {
+		if series.VenueID != nil && *series.VenueID == venueID {
+			atVenue = append(atVenue, series)
+		}
+	}
+	occurrences, err := h.expandSeries(atVenue, from, to)
+	if err != nil {
+		log.Printf("Error expanding event series at venue %s: %v", venueID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
+		return
+	}
+	for _, occurrence := range occurrences {
+		seriesID := occurrence.SeriesID
+		events = append(events, Event{
+			ID:          occurrence.ID,
+			GroupID:     occurrence.GroupID,
+			Title:       occurrence.Title,
+			Description: occurrence.Description,
+			Location:    occurrence.Location,
+			StartTime:   occurrence.StartTime,
+			EndTime:     occurrence.EndTime,
+			CreatedAt:   occurrence.CreatedAt,
+			CreatedBy:   occurrence.CreatedBy,
+			MCID:        occurrence.MCID,
+			SeriesID:    &seriesID,
+			Visibility:  occurrence.Visibility,
+			Status:      occurrence.Status,
+			VenueID:     occurrence.VenueID,
+			TimeZone:    occurrence.TimeZone,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartTime.After(events[j].StartTime) })
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    events,
+	})
+}
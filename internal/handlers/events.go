@@ -3,15 +3,22 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"improv-app/internal/auth"
+	"improv-app/internal/chat"
+	"improv-app/internal/lineup"
 	"improv-app/internal/middleware"
 	"improv-app/internal/models"
+	"improv-app/internal/query"
+	"improv-app/internal/recurrence"
+	"improv-app/internal/services"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -45,13 +52,20 @@ func (h *EventHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// GET: List events for the group
+	from, to, err := occurrenceWindow(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// GET: List events for the group. Drafts are only listed for admins and organizers.
 	rows, err := h.db.Query(`
-		SELECT id, title, description, location, start_time, end_time, created_at, created_by, mc_id
-		FROM events
-		WHERE group_id = $1
-		ORDER BY start_time DESC
-	`, groupID)
+		SELECT e.id, e.title, e.description, e.location, e.start_time, e.end_time, e.created_at, e.created_by, e.mc_id, e.series_id, e.capacity, e.visibility,
+		       e.status, e.cancellation_reason, e.venue_id, e.time_zone
+		FROM events e
+		WHERE e.group_id = $1 AND ($2 OR e.status != 'draft') AND `+query.NotCancelledOccurrenceCondition+`
+		ORDER BY e.start_time DESC
+	`, groupID, role == auth.RoleAdmin || role == auth.RoleOrganizer)
 	if err != nil {
 		log.Printf("Error fetching events for group %s: %v", groupID, err)
 		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
@@ -62,7 +76,8 @@ func (h *EventHandler) List(w http.ResponseWriter, r *http.Request) {
 	events := []Event{}
 	for rows.Next() {
 		var event Event
-		err := rows.Scan(&event.ID, &event.Title, &event.Description, &event.Location, &event.StartTime, &event.EndTime, &event.CreatedAt, &event.CreatedBy, &event.MCID)
+		err := rows.Scan(&event.ID, &event.Title, &event.Description, &event.Location, &event.StartTime, &event.EndTime, &event.CreatedAt, &event.CreatedBy, &event.MCID, &event.SeriesID, &event.Capacity, &event.Visibility,
+			&event.Status, &event.CancellationReason, &event.VenueID, &event.TimeZone)
 		if err != nil {
 			log.Printf("Error scanning events row: %v", err)
 			RespondWithError(w, http.StatusInternalServerError, "Error scanning events")
@@ -71,6 +86,43 @@ func (h *EventHandler) List(w http.ResponseWriter, r *http.Request) {
 		event.GroupID = groupID // Add the group ID
 		events = append(events, event)
 	}
+
+	// Add the recurring series' occurrences that haven't been saved as events
+	seriesList, err := services.NewEventSeriesService(h.db).ListForGroup(groupID)
+	if err != nil {
+		log.Printf("Error fetching event series for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
+		return
+	}
+	occurrences, err := h.expandSeries(seriesList, from, to)
+	if err != nil {
+		log.Printf("Error expanding event series for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
+		return
+	}
+	for _, occurrence := range occurrences {
+		seriesID := occurrence.SeriesID
+		events = append(events, Event{
+			ID:          occurrence.ID,
+			GroupID:     occurrence.GroupID,
+			Title:       occurrence.Title,
+			Description: occurrence.Description,
+			Location:    occurrence.Location,
+			StartTime:   occurrence.StartTime,
+			EndTime:     occurrence.EndTime,
+			CreatedAt:   occurrence.CreatedAt,
+			CreatedBy:   occurrence.CreatedBy,
+			MCID:        occurrence.MCID,
+			SeriesID:    &seriesID,
+			Capacity:    occurrence.Capacity,
+			Visibility:  occurrence.Visibility,
+			Status:      occurrence.Status,
+			VenueID:     occurrence.VenueID,
+			TimeZone:    occurrence.TimeZone,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartTime.After(events[j].StartTime) })
+
 	if events == nil {
 		events = []Event{}
 	}
@@ -96,8 +148,15 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Description string `json:"description"`
 		Location    string `json:"location"`
 		StartTime   string `json:"startTime"`
-		EndTime     string `json:"endTime,omitempty"` // Make EndTime optional
-		MCID        string `json:"mcId,omitempty"` // Optional MC ID
+		EndTime     string `json:"endTime,omitempty"`    // Make EndTime optional
+		MCID        string `json:"mcId,omitempty"`       // Optional MC ID
+		RRule       string `json:"rrule,omitempty"`      // Optional recurrence rule, e.g. FREQ=WEEKLY;INTERVAL=2
+		TimeZone    string `json:"timeZone,omitempty"`   // IANA zone the event is in, and a recurring event repeats in, the group's by default
+		Capacity    *int   `json:"capacity,omitempty"`   // Optional limit on attendees, beyond which RSVPs are waitlisted
+		Visibility  string `json:"visibility,omitempty"` // private, members-and-followers or public, the group's default if left out
+		Status      string `json:"status,omitempty"`     // draft or scheduled, scheduled by default
+		TemplateID  string `json:"templateId,omitempty"` // Optional template that fills in whatever is left out, and the lineup
+		VenueID     string `json:"venueId,omitempty"`    // Optional venue from the group's catalogue, whose name is the location if none is given
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -108,6 +167,26 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	if eventRequest.Capacity != nil && *eventRequest.Capacity < 1 {
+		RespondWithError(w, http.StatusBadRequest, "Capacity must be at least 1")
+		return
+	}
+	if eventRequest.Visibility != "" && !services.IsValidVisibility(eventRequest.Visibility) {
+		RespondWithError(w, http.StatusBadRequest, "Visibility must be private, members-and-followers or public")
+		return
+	}
+	if eventRequest.Status == "" {
+		eventRequest.Status = services.EventStatusScheduled
+	}
+	if eventRequest.Status != services.EventStatusDraft && eventRequest.Status != services.EventStatusScheduled {
+		RespondWithError(w, http.StatusBadRequest, "New events must be draft or scheduled")
+		return
+	}
+	if eventRequest.Status == services.EventStatusDraft && eventRequest.RRule != "" {
+		RespondWithError(w, http.StatusBadRequest, "Recurring events can't be drafts")
+		return
+	}
+
 	// Verify user is a member of the group
 	var role string
 	err := h.db.QueryRow(`
@@ -119,14 +198,74 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 		RespondWithError(w, http.StatusForbidden, "Not a member of this group")
 		return
 	}
+	// Only admins and organizers can see drafts, so only they can create them
+	if eventRequest.Status == services.EventStatusDraft && role != auth.RoleAdmin && role != auth.RoleOrganizer {
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can create drafts")
+		return
+	}
+
+	// Times without an offset are wall-clock times in the event's zone
+	if eventRequest.TimeZone == "" {
+		eventRequest.TimeZone, err = services.GroupTimeZone(h.db, eventRequest.GroupID)
+		if err != nil {
+			log.Printf("Error fetching time zone for group %s: %v", eventRequest.GroupID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error creating event")
+			return
+		}
+	}
+	loc, err := services.LoadTimeZone(eventRequest.TimeZone)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Time zone must be an IANA zone name, like America/Chicago")
+		return
+	}
 
-	startTime, err := time.Parse(time.RFC3339, eventRequest.StartTime)
+	startTime, err := services.ParseEventTime(eventRequest.StartTime, loc)
 	if err != nil {
 		log.Printf("Error parsing start time %s: %v", eventRequest.StartTime, err)
 		RespondWithError(w, http.StatusBadRequest, "Invalid start time format")
 		return
 	}
 
+	if eventRequest.TemplateID != "" {
+		template, err := services.NewEventTemplateService(h.db).Get(eventRequest.GroupID, eventRequest.TemplateID)
+		if err != nil {
+			log.Printf("Error fetching event template %s: %v", eventRequest.TemplateID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching event template")
+			return
+		}
+		if template == nil {
+			RespondWithError(w, http.StatusBadRequest, "Event template not found in this group")
+			return
+		}
+		if eventRequest.Title == "" {
+			eventRequest.Title = template.Title(startTime.In(loc))
+		}
+		if eventRequest.EndTime == "" {
+			eventRequest.EndTime = startTime.Add(time.Duration(template.DurationMinutes) * time.Minute).Format(time.RFC3339)
+		}
+		if eventRequest.Location == "" {
+			eventRequest.Location = template.Location
+		}
+		if eventRequest.Visibility == "" && template.Visibility != nil {
+			eventRequest.Visibility = *template.Visibility
+		}
+		if eventRequest.Capacity == nil {
+			eventRequest.Capacity = template.Capacity
+		}
+	}
+
+	var venueID *string
+	if eventRequest.VenueID != "" {
+		venue := h.getVenue(w, eventRequest.GroupID, eventRequest.VenueID)
+		if venue == nil {
+			return
+		}
+		if eventRequest.Location == "" {
+			eventRequest.Location = venue.Name
+		}
+		venueID = &venue.ID
+	}
+
 	// Use startTime as endTime if endTime is not provided or empty
 	var endTime time.Time
 	if eventRequest.EndTime == "" {
@@ -134,7 +273,7 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 		endTime = startTime
 	} else {
 		var err error
-		endTime, err = time.Parse(time.RFC3339, eventRequest.EndTime)
+		endTime, err = services.ParseEventTime(eventRequest.EndTime, loc)
 		if err != nil {
 			log.Printf("Error parsing end time %s: %v", eventRequest.EndTime, err)
 			RespondWithError(w, http.StatusBadRequest, "Invalid end time format")
@@ -163,6 +302,27 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if eventRequest.Visibility == "" {
+		eventRequest.Visibility, err = services.GroupDefaultVisibility(h.db, eventRequest.GroupID)
+		if err != nil {
+			log.Printf("Error fetching default visibility for group %s: %v", eventRequest.GroupID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error creating event")
+			return
+		}
+	}
+
+	warnings, ok := h.checkConflicts(w, services.EventSlot{
+		GroupID:    eventRequest.GroupID,
+		VenueID:    venueID,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Performers: []string{eventRequest.MCID},
+		ViewerID:   user.ID,
+	}, false)
+	if !ok {
+		return
+	}
+
 	eventID := uuid.New().String()
 	// Support nullable MC_ID field
 	var mcID interface{} = nil
@@ -170,34 +330,112 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 		mcID = eventRequest.MCID
 	}
 
-	err = h.db.QueryRow(`
-		INSERT INTO events (id, group_id, title, description, location, start_time, end_time, created_by, mc_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id
-	`, eventID, eventRequest.GroupID, eventRequest.Title, eventRequest.Description, eventRequest.Location, startTime, endTime, user.ID, mcID).Scan(&eventID)
-	if err != nil {
-		log.Printf("Error creating event: %v", err)
-		RespondWithError(w, http.StatusInternalServerError, "Error creating event")
-		return
+	// The template's lineup is saved with the event, so the event is never saved without it
+	addTemplateGames := func(tx *sql.Tx, eventID string) error {
+		if eventRequest.TemplateID == "" {
+			return nil
+		}
+		return services.NewEventTemplateService(h.db).AddGames(tx, eventRequest.TemplateID, eventID)
+	}
+
+	if eventRequest.RRule != "" {
+		// A recurring event is saved as a series, and its first occurrence as the event
+		if _, err := recurrence.Parse(eventRequest.RRule); err != nil {
+			log.Printf("Invalid recurrence rule %s: %v", eventRequest.RRule, err)
+			RespondWithError(w, http.StatusBadRequest, "Invalid recurrence rule: "+err.Error())
+			return
+		}
+		var seriesMCID *string
+		if eventRequest.MCID != "" {
+			seriesMCID = &eventRequest.MCID
+		}
+		seriesService := services.NewEventSeriesService(h.db)
+		series, err := seriesService.Create(services.EventSeries{
+			GroupID:     eventRequest.GroupID,
+			Title:       eventRequest.Title,
+			Description: eventRequest.Description,
+			Location:    eventRequest.Location,
+			StartTime:   startTime,
+			EndTime:     endTime,
+			RRule:       eventRequest.RRule,
+			MCID:        seriesMCID,
+			TimeZone:    eventRequest.TimeZone,
+			Capacity:    eventRequest.Capacity,
+			Visibility:  eventRequest.Visibility,
+			VenueID:     venueID,
+			CreatedBy:   user.ID,
+		})
+		if err != nil {
+			log.Printf("Error creating event series: %v", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error creating event")
+			return
+		}
+		eventID, err = seriesService.MaterializeFirst(*series, addTemplateGames)
+		if err == services.ErrOccurrenceNotFound {
+			RespondWithError(w, http.StatusBadRequest, "Recurrence rule has no occurrences")
+			return
+		}
+		if err != nil {
+			log.Printf("Error creating first occurrence of series %s: %v", series.ID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error creating event")
+			return
+		}
+	} else {
+		tx, err := h.db.Begin()
+		if err != nil {
+			log.Printf("Error starting transaction: %v", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error creating event")
+			return
+		}
+		defer tx.Rollback()
+
+		err = tx.QueryRow(`
+			INSERT INTO events (id, group_id, title, description, location, start_time, end_time, created_by, mc_id, capacity, visibility, status, venue_id, time_zone)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			RETURNING id
+		`, eventID, eventRequest.GroupID, eventRequest.Title, eventRequest.Description, eventRequest.Location, startTime, endTime, user.ID, mcID, eventRequest.Capacity, eventRequest.Visibility, eventRequest.Status, venueID, eventRequest.TimeZone).Scan(&eventID)
+		if err != nil {
+			log.Printf("Error creating event: %v", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error creating event")
+			return
+		}
+		if err := addTemplateGames(tx, eventID); err != nil {
+			log.Printf("Error adding template %s games to event %s: %v", eventRequest.TemplateID, eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error adding the template's games")
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			log.Printf("Error committing event %s: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error creating event")
+			return
+		}
 	}
 
 	// Fetch the newly created event
 	var event Event
 	err = h.db.QueryRow(`
-		SELECT id, group_id, title, description, location, start_time, end_time, created_at, created_by, mc_id
+		SELECT id, group_id, title, description, location, start_time, end_time, created_at, created_by, mc_id, series_id, capacity, visibility,
+		       status, cancellation_reason, venue_id, time_zone
 		FROM events
 		WHERE id = $1
-	`, eventID).Scan(&event.ID, &event.GroupID, &event.Title, &event.Description, &event.Location, &event.StartTime, &event.EndTime, &event.CreatedAt, &event.CreatedBy, &event.MCID)
+	`, eventID).Scan(&event.ID, &event.GroupID, &event.Title, &event.Description, &event.Location, &event.StartTime, &event.EndTime, &event.CreatedAt, &event.CreatedBy, &event.MCID, &event.SeriesID, &event.Capacity, &event.Visibility,
+		&event.Status, &event.CancellationReason, &event.VenueID, &event.TimeZone)
 	if err != nil {
 		log.Printf("Error fetching created event %s: %v", eventID, err)
 		RespondWithError(w, http.StatusInternalServerError, "Error fetching created event")
 		return
 	}
 
+	// Drafts are announced when they're scheduled
+	if event.Status != services.EventStatusDraft {
+		h.announceCreated(event)
+	}
+
 	RespondWithJSON(w, http.StatusCreated, ApiResponse{
-		Success: true,
-		Message: "Event created successfully",
-		Data:    event,
+		Success:  true,
+		Message:  "Event created successfully",
+		Data:     event,
+		Warnings: warnings,
 	})
 }
 
@@ -205,15 +443,17 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 func (h *EventHandler) ListAll(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey).(*models.User)
 
+	from, to, err := occurrenceWindow(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	rows, err := h.db.Query(`
 		SELECT DISTINCT e.id, e.group_id, e.title, e.description, e.location, e.start_time, e.end_time, e.created_at, e.created_by,
-		       g.name as group_name, e.mc_id
-		FROM events e
-		JOIN improv_groups g ON e.group_id = g.id
-		LEFT JOIN group_members m ON e.group_id = m.group_id AND m.user_id = $1
-		LEFT JOIN group_followers f ON e.group_id = f.group_id AND f.user_id = $1
-		WHERE e.visibility = 'public'
-		   OR (e.visibility = 'private' AND (m.user_id IS NOT NULL OR f.user_id IS NOT NULL))
+		       g.name as group_name, e.mc_id, e.series_id, e.visibility, e.status, e.cancellation_reason, e.venue_id, e.time_zone
+	`+query.VisibleEventsFrom+`
+		WHERE `+query.VisibleEventsCondition+` AND `+query.NotCancelledOccurrenceCondition+`
 		ORDER BY e.start_time DESC
 	`, user.ID)
 	if err != nil {
@@ -235,6 +475,15 @@ func (h *EventHandler) ListAll(w http.ResponseWriter, r *http.Request) {
 		CreatedAt   time.Time `json:"createdAt"`
 		CreatedBy   string    `json:"createdBy"`
 		MCID        *string   `json:"mcId,omitempty"`
+		SeriesID    *string   `json:"seriesId,omitempty"`
+		Visibility  string    `json:"visibility"`
+		Status      string    `json:"status"`
+		// CancellationReason is set when the event was cancelled with a reason
+		CancellationReason *string `json:"cancellationReason,omitempty"`
+		VenueID            *string `json:"venueId,omitempty"`
+		// TimeZone is the IANA zone the event is in. StartTime and EndTime are UTC.
+		TimeZone string `json:"timeZone"`
+		services.LocalTimes
 	}
 
 	var events []EventWithGroup
@@ -243,16 +492,56 @@ func (h *EventHandler) ListAll(w http.ResponseWriter, r *http.Request) {
 		err := rows.Scan(
 			&event.ID, &event.GroupID, &event.Title, &event.Description,
 			&event.Location, &event.StartTime, &event.EndTime,
-			&event.CreatedAt, &event.CreatedBy, &event.GroupName, &event.MCID,
+			&event.CreatedAt, &event.CreatedBy, &event.GroupName, &event.MCID, &event.SeriesID, &event.Visibility,
+			&event.Status, &event.CancellationReason, &event.VenueID, &event.TimeZone,
 		)
 		if err != nil {
 			log.Printf("Error scanning event row in ListAll: %v", err)
 			RespondWithError(w, http.StatusInternalServerError, "Error scanning events")
 			return
 		}
+		event.StartTime, event.EndTime = event.StartTime.UTC(), event.EndTime.UTC()
+		event.LocalTimes = services.NewLocalTimes(event.StartTime, event.EndTime, event.TimeZone)
 		events = append(events, event)
 	}
 
+	// Add the recurring series' occurrences that haven't been saved as events
+	seriesList, err := services.NewEventSeriesService(h.db).ListVisible(user.ID)
+	if err != nil {
+		log.Printf("Error fetching event series for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
+		return
+	}
+	occurrences, err := h.expandSeries(seriesList, from, to)
+	if err != nil {
+		log.Printf("Error expanding event series for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
+		return
+	}
+	for _, occurrence := range occurrences {
+		seriesID := occurrence.SeriesID
+		events = append(events, EventWithGroup{
+			ID:          occurrence.ID,
+			GroupID:     occurrence.GroupID,
+			GroupName:   occurrence.GroupName,
+			Title:       occurrence.Title,
+			Description: occurrence.Description,
+			Location:    occurrence.Location,
+			StartTime:   occurrence.StartTime.UTC(),
+			EndTime:     occurrence.EndTime.UTC(),
+			CreatedAt:   occurrence.CreatedAt,
+			CreatedBy:   occurrence.CreatedBy,
+			MCID:        occurrence.MCID,
+			SeriesID:    &seriesID,
+			Visibility:  occurrence.Visibility,
+			Status:      occurrence.Status,
+			VenueID:     occurrence.VenueID,
+			TimeZone:    occurrence.TimeZone,
+			LocalTimes:  services.NewLocalTimes(occurrence.StartTime, occurrence.EndTime, occurrence.TimeZone),
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartTime.After(events[j].StartTime) })
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Data:    events,
@@ -268,31 +557,69 @@ func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
 	var event Event
 	var groupName string
 	var mcFirstName, mcLastName sql.NullString
-	err := h.db.QueryRow(`
-		SELECT e.id, e.group_id, e.title, e.description, e.location, e.start_time, e.end_time, e.created_at, e.created_by,
-		       g.name as group_name, e.mc_id,
-		       CASE WHEN e.mc_id IS NOT NULL THEN mc.first_name ELSE NULL END as mc_first_name,
-		       CASE WHEN e.mc_id IS NOT NULL THEN mc.last_name ELSE NULL END as mc_last_name
-		FROM events e
-		JOIN improv_groups g ON e.group_id = g.id
-		JOIN group_members m ON e.group_id = m.group_id
-		LEFT JOIN users mc ON e.mc_id = mc.id
-		WHERE e.id = $1 AND m.user_id = $2
-	`, eventID, user.ID).Scan(
-		&event.ID, &event.GroupID, &event.Title, &event.Description,
-		&event.Location, &event.StartTime, &event.EndTime,
-		&event.CreatedAt, &event.CreatedBy, &groupName, &event.MCID,
-		&mcFirstName, &mcLastName,
-	)
+	var lineupFinalizedAt sql.NullTime
+	var err error
+	// An occurrence that hasn't been saved is read from its series
+	occurrence := unsavedOccurrence(r)
+	if occurrence != nil {
+		event = Event{
+			ID:          occurrence.ID,
+			GroupID:     occurrence.GroupID,
+			Title:       occurrence.Title,
+			Description: occurrence.Description,
+			Location:    occurrence.Location,
+			StartTime:   occurrence.StartTime,
+			EndTime:     occurrence.EndTime,
+			CreatedAt:   occurrence.CreatedAt,
+			CreatedBy:   occurrence.CreatedBy,
+			MCID:        occurrence.MCID,
+			SeriesID:    &occurrence.SeriesID,
+			Capacity:    occurrence.Capacity,
+			Visibility:  occurrence.Visibility,
+			Status:      occurrence.Status,
+			VenueID:     occurrence.VenueID,
+			TimeZone:    occurrence.TimeZone,
+		}
+		err = h.db.QueryRow(`
+			SELECT g.name, mc.first_name, mc.last_name
+			FROM improv_groups g
+			LEFT JOIN users mc ON mc.id = $1
+			WHERE g.id = $2
+		`, occurrence.MCID, occurrence.GroupID).Scan(&groupName, &mcFirstName, &mcLastName)
+	} else {
+		err = h.db.QueryRow(`
+			SELECT e.id, e.group_id, e.title, e.description, e.location, e.start_time, e.end_time, e.created_at, e.created_by,
+			       g.name as group_name, e.mc_id,
+			       CASE WHEN e.mc_id IS NOT NULL THEN mc.first_name ELSE NULL END as mc_first_name,
+			       CASE WHEN e.mc_id IS NOT NULL THEN mc.last_name ELSE NULL END as mc_last_name,
+			       e.lineup_finalized_at, e.series_id, e.capacity, e.visibility, e.status, e.cancellation_reason, e.venue_id, e.time_zone
+			FROM events e
+			JOIN improv_groups g ON e.group_id = g.id
+			JOIN group_members m ON e.group_id = m.group_id
+			LEFT JOIN users mc ON e.mc_id = mc.id
+			WHERE e.id = $1 AND m.user_id = $2
+			  AND (e.status != 'draft' OR m.role IN ('admin', 'organizer'))
+		`, eventID, user.ID).Scan(
+			&event.ID, &event.GroupID, &event.Title, &event.Description,
+			&event.Location, &event.StartTime, &event.EndTime,
+			&event.CreatedAt, &event.CreatedBy, &groupName, &event.MCID,
+			&mcFirstName, &mcLastName, &lineupFinalizedAt, &event.SeriesID, &event.Capacity, &event.Visibility,
+			&event.Status, &event.CancellationReason, &event.VenueID, &event.TimeZone,
+		)
+	}
 	if err != nil {
 		log.Printf("Error fetching event %s for user %s: %v", eventID, user.ID, err)
 		RespondWithError(w, http.StatusNotFound, "Event not found")
 		return
 	}
 
-	// Get RSVPs
+	// Get RSVPs, with waitlisted members' place in line
 	rsvpRows, err := h.db.Query(`
-		SELECT u.id, u.first_name, u.last_name, r.status
+		SELECT u.id, u.first_name, u.last_name, r.status,
+		       CASE WHEN r.status = 'waitlisted' THEN (
+		           SELECT COUNT(*) FROM event_rsvps w
+		           WHERE w.event_id = r.event_id AND w.status = 'waitlisted' AND w.waitlist_position <= r.waitlist_position
+		       ) ELSE 0 END
 		FROM event_rsvps r
 		JOIN users u ON r.user_id = u.id
 		WHERE r.event_id = $1
@@ -305,16 +632,17 @@ func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
 	defer rsvpRows.Close()
 
 	type RSVP struct {
-		UserID    string `json:"userId"`
-		FirstName string `json:"firstName"`
-		LastName  string `json:"lastName"`
-		Status    string `json:"status"`
+		UserID           string `json:"userId"`
+		FirstName        string `json:"firstName"`
+		LastName         string `json:"lastName"`
+		Status           string `json:"status"`
+		WaitlistPosition int    `json:"waitlistPosition,omitempty"`
 	}
 
 	var rsvpMap = make(map[string]RSVP)
 	for rsvpRows.Next() {
 		var rsvp RSVP
-		err := rsvpRows.Scan(&rsvp.UserID, &rsvp.FirstName, &rsvp.LastName, &rsvp.Status)
+		err := rsvpRows.Scan(&rsvp.UserID, &rsvp.FirstName, &rsvp.LastName, &rsvp.Status, &rsvp.WaitlistPosition)
 		if err != nil {
 			log.Printf("Error scanning RSVP row: %v", err)
 			RespondWithError(w, http.StatusInternalServerError, "Error scanning RSVPs")
@@ -363,7 +691,7 @@ func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	// Get assigned games
 	gameRows, err := h.db.Query(`
-		SELECT g.id, g.name, g.description, g.min_players, g.max_players, eg.order_index,
+		SELECT g.id, g.name, g.description, g.min_players, g.max_players, eg.order_index, eg.target_players,
 		       GROUP_CONCAT(DISTINCT t.name) as tags
 		FROM event_games eg
 		JOIN games g ON eg.game_id = g.id
@@ -381,13 +709,14 @@ func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
 	defer gameRows.Close()
 
 	type GameWithOrder struct {
-		ID          string   `json:"id"`
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		MinPlayers  int      `json:"minPlayers"`
-		MaxPlayers  int      `json:"maxPlayers"`
-		OrderIndex  int      `json:"orderIndex"`
-		Tags        []string `json:"tags"`
+		ID            string   `json:"id"`
+		Name          string   `json:"name"`
+		Description   string   `json:"description"`
+		MinPlayers    int      `json:"minPlayers"`
+		MaxPlayers    int      `json:"maxPlayers"`
+		OrderIndex    int      `json:"orderIndex"`
+		TargetPlayers *int     `json:"targetPlayers,omitempty"`
+		Tags          []string `json:"tags"`
 	}
 
 	var games []GameWithOrder
@@ -396,7 +725,7 @@ func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
 		var tagsStr sql.NullString
 		err := gameRows.Scan(
 			&game.ID, &game.Name, &game.Description,
-			&game.MinPlayers, &game.MaxPlayers, &game.OrderIndex, &tagsStr,
+			&game.MinPlayers, &game.MaxPlayers, &game.OrderIndex, &game.TargetPlayers, &tagsStr,
 		)
 		if err != nil {
 			log.Printf("Error scanning game row: %v", err)
@@ -430,19 +759,72 @@ func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// The crew has the MC alongside everyone else working the show
+	crew, err := services.NewCrewService(h.db).Crew(eventID)
+	if err != nil {
+		log.Printf("Error fetching crew for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event crew")
+		return
+	}
+
+	// Recurring events include their series so the client can offer scoped edits
+	var series *services.EventSeries
+	if event.SeriesID != nil {
+		series, err = services.NewEventSeriesService(h.db).Get(*event.SeriesID)
+		if err != nil {
+			log.Printf("Error fetching series %s for event %s: %v", *event.SeriesID, eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching event series")
+			return
+		}
+	}
+
+	// Nobody has taken a spot at an occurrence that hasn't been saved
+	spotsLeft := event.Capacity
+	if occurrence == nil {
+		spotsLeft, err = services.NewRSVPService(h.db).SpotsLeft(eventID)
+		if err != nil {
+			log.Printf("Error fetching spots left for event %s: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching RSVPs")
+			return
+		}
+	}
+
+	// Events at a venue include its address and notes for the cast
+	var venue *services.Venue
+	if event.VenueID != nil {
+		venue, err = services.NewVenueService(h.db).Get(event.GroupID, *event.VenueID)
+		if err != nil {
+			log.Printf("Error fetching venue %s for event %s: %v", *event.VenueID, eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching venue")
+			return
+		}
+	}
+
 	// Include the member data in the response
 	eventData := struct {
-		Event     Event          `json:"event"`
-		GroupName string         `json:"groupName"`
-		RSVPs     []RSVP         `json:"rsvps"`
-		Games     []GameWithOrder `json:"games"`
-		MC        *MCInfo        `json:"mc,omitempty"`
+		Event             Event                 `json:"event"`
+		GroupName         string                `json:"groupName"`
+		RSVPs             []RSVP                `json:"rsvps"`
+		Games             []GameWithOrder       `json:"games"`
+		MC                *MCInfo               `json:"mc,omitempty"`
+		Crew              []services.CrewMember `json:"crew"`
+		LineupFinalizedAt *time.Time            `json:"lineupFinalizedAt,omitempty"`
+		Series            *services.EventSeries `json:"series,omitempty"`
+		SpotsLeft         *int                  `json:"spotsLeft,omitempty"`
+		Venue             *services.Venue       `json:"venue,omitempty"`
 	}{
 		Event:     event,
 		GroupName: groupName,
 		RSVPs:     rsvps,
 		Games:     games,
 		MC:        mc,
+		Crew:      crew,
+		Series:    series,
+		SpotsLeft: spotsLeft,
+		Venue:     venue,
+	}
+	if lineupFinalizedAt.Valid {
+		eventData.LineupFinalizedAt = &lineupFinalizedAt.Time
 	}
 
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
@@ -464,7 +846,15 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 		Location    string `json:"location"`
 		StartTime   string `json:"startTime"`
 		EndTime     string `json:"endTime,omitempty"` // Make EndTime optional
-		MCID        string `json:"mcId,omitempty"` // Optional MC ID
+		MCID        string `json:"mcId,omitempty"`    // Optional MC ID
+		// Scope applies the edit to this occurrence of a recurring event only (the default),
+		// to this and the following occurrences, or to all of them
+		Scope      string          `json:"scope,omitempty"`
+		RRule      string          `json:"rrule,omitempty"`      // Optional new recurrence rule for the following or all scopes
+		Capacity   json.RawMessage `json:"capacity,omitempty"`   // Optional limit on attendees, unchanged when left out and removed when null or 0
+		Visibility string          `json:"visibility,omitempty"` // Optional new visibility, unchanged when left out
+		VenueID    json.RawMessage `json:"venueId,omitempty"`    // Optional venue from the group's catalogue, unchanged when left out and removed when null or empty
+		TimeZone   string          `json:"timeZone,omitempty"`   // Optional new IANA zone, unchanged when left out
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -475,12 +865,47 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	if eventRequest.Scope == "" {
+		eventRequest.Scope = services.ScopeThis
+	}
+	if !services.IsValidScope(eventRequest.Scope) {
+		RespondWithError(w, http.StatusBadRequest, "Scope must be this, following or all")
+		return
+	}
+	if eventRequest.RRule != "" && eventRequest.Scope == services.ScopeThis {
+		RespondWithError(w, http.StatusBadRequest, "A recurrence rule can only be changed for the following or all occurrences")
+		return
+	}
+	// Capacity is a raw message so leaving it out can be told apart from removing it with null
+	var capacity *int
+	if eventRequest.Capacity != nil {
+		if err := json.Unmarshal(eventRequest.Capacity, &capacity); err != nil || (capacity != nil && *capacity < 0) {
+			RespondWithError(w, http.StatusBadRequest, "Capacity must be at least 1, or 0 to remove it")
+			return
+		}
+		if capacity != nil && *capacity == 0 {
+			capacity = nil
+		}
+	}
+	// The venue is a raw message for the same reason
+	var requestedVenueID string
+	if eventRequest.VenueID != nil {
+		var venue *string
+		if err := json.Unmarshal(eventRequest.VenueID, &venue); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Venue must be a venue ID, or null to remove it")
+			return
+		}
+		if venue != nil {
+			requestedVenueID = *venue
+		}
+	}
+	if eventRequest.Visibility != "" && !services.IsValidVisibility(eventRequest.Visibility) {
+		RespondWithError(w, http.StatusBadRequest, "Visibility must be private, members-and-followers or public")
+		return
+	}
+
 	// First, check if the event exists and get its group ID
-	var groupID string
-	err := h.db.QueryRow(`
-		SELECT group_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID)
+	groupID, err := eventGroupID(h.db, r, eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Event not found during update: %s", eventID)
@@ -504,7 +929,48 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	startTime, err := time.Parse(time.RFC3339, eventRequest.StartTime)
+	// Get the event's current details. An unsaved occurrence's come from its series.
+	var previous struct {
+		Title      string
+		Location   string
+		StartTime  time.Time
+		Visibility string
+		TimeZone   string
+		Capacity   *int
+		VenueID    *string
+	}
+	if occurrence := unsavedOccurrence(r); occurrence != nil {
+		previous.Title, previous.Location, previous.StartTime = occurrence.Title, occurrence.Location, occurrence.StartTime
+		previous.Visibility, previous.TimeZone = occurrence.Visibility, occurrence.TimeZone
+		previous.Capacity, previous.VenueID = occurrence.Capacity, occurrence.VenueID
+	} else {
+		err = h.db.QueryRow(`
+			SELECT title, COALESCE(location, ''), start_time, visibility, time_zone, capacity, venue_id FROM events
+			WHERE id = $1
+		`, eventID).Scan(&previous.Title, &previous.Location, &previous.StartTime, &previous.Visibility, &previous.TimeZone, &previous.Capacity, &previous.VenueID)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Printf("Event not found during update: %s", eventID)
+			RespondWithError(w, http.StatusNotFound, "Event not found")
+		} else {
+			log.Printf("Error fetching event %s during update: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		}
+		return
+	}
+
+	// Times without an offset are wall-clock times in the event's zone
+	if eventRequest.TimeZone == "" {
+		eventRequest.TimeZone = previous.TimeZone
+	}
+	loc, err := services.LoadTimeZone(eventRequest.TimeZone)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Time zone must be an IANA zone name, like America/Chicago")
+		return
+	}
+
+	startTime, err := services.ParseEventTime(eventRequest.StartTime, loc)
 	if err != nil {
 		log.Printf("Error parsing start time during update %s: %v", eventRequest.StartTime, err)
 		RespondWithError(w, http.StatusBadRequest, "Invalid start time format")
@@ -518,7 +984,7 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 		endTime = startTime
 	} else {
 		var err error
-		endTime, err = time.Parse(time.RFC3339, eventRequest.EndTime)
+		endTime, err = services.ParseEventTime(eventRequest.EndTime, loc)
 		if err != nil {
 			log.Printf("Error parsing end time during update %s: %v", eventRequest.EndTime, err)
 			RespondWithError(w, http.StatusBadRequest, "Invalid end time format")
@@ -553,12 +1019,94 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 		mcID = eventRequest.MCID
 	}
 
+	if eventRequest.Visibility == "" {
+		eventRequest.Visibility = previous.Visibility
+	}
+	if eventRequest.Capacity == nil {
+		capacity = previous.Capacity
+	}
+
+	venueID := previous.VenueID
+	if eventRequest.VenueID != nil {
+		venueID = nil
+	}
+	if requestedVenueID != "" {
+		venue := h.getVenue(w, groupID, requestedVenueID)
+		if venue == nil {
+			return
+		}
+		if eventRequest.Location == "" {
+			eventRequest.Location = venue.Name
+		}
+		venueID = &venue.ID
+	}
+
+	// The MC may be changing, so they're checked alongside the players already assigned
+	performers, err := services.NewConflictService(h.db).Performers(eventID)
+	if err != nil {
+		log.Printf("Error fetching performers of event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating event")
+		return
+	}
+	warnings, ok := h.checkConflicts(w, services.EventSlot{
+		EventID:    eventID,
+		GroupID:    groupID,
+		VenueID:    venueID,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Performers: append(performers, eventRequest.MCID),
+		ViewerID:   user.ID,
+	}, false)
+	if !ok {
+		return
+	}
+
+	eventID, ok = saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	// Carry the edit over to the rest of the series before updating this occurrence
+	if eventRequest.Scope != services.ScopeThis {
+		var seriesMCID *string
+		if eventRequest.MCID != "" {
+			seriesMCID = &eventRequest.MCID
+		}
+		err = services.NewEventSeriesService(h.db).ApplyEdit(eventID, eventRequest.Scope, services.SeriesEdit{
+			Title:       eventRequest.Title,
+			Description: eventRequest.Description,
+			Location:    eventRequest.Location,
+			MCID:        seriesMCID,
+			Shift:       startTime.Sub(previous.StartTime),
+			Duration:    endTime.Sub(startTime),
+			RRule:       eventRequest.RRule,
+			Capacity:    capacity,
+			Visibility:  eventRequest.Visibility,
+			VenueID:     venueID,
+		})
+		if err == services.ErrNotInSeries {
+			RespondWithError(w, http.StatusBadRequest, "Event is not part of a recurring series")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidEdit) {
+			log.Printf("Invalid series edit for event %s: %v", eventID, err)
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("Error updating series of event %s: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error updating event")
+			return
+		}
+	}
+
 	// Update the event
 	_, err = h.db.Exec(`
 		UPDATE events
-		SET title = $1, description = $2, location = $3, start_time = $4, end_time = $5, mc_id = $6
-		WHERE id = $7
-	`, eventRequest.Title, eventRequest.Description, eventRequest.Location, startTime, endTime, mcID, eventID)
+		SET title = $1, description = $2, location = $3, start_time = $4, end_time = $5, mc_id = $6, capacity = $7,
+			visibility = $8, venue_id = $9, time_zone = $10, sequence = sequence + 1
+		WHERE id = $11
+	`, eventRequest.Title, eventRequest.Description, eventRequest.Location, startTime, endTime, mcID, capacity, eventRequest.Visibility, venueID, eventRequest.TimeZone, eventID)
 
 	if err != nil {
 		log.Printf("Error updating event %s: %v", eventID, err)
@@ -566,19 +1114,27 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A bigger or removed limit opens spots for the waitlist
+	promoted, err := services.NewRSVPService(h.db).PromoteWaitlist(eventID)
+	if err != nil {
+		log.Printf("Error promoting waitlist for event %s: %v", eventID, err)
+	}
+	announcePromotions(h.db, eventID, groupID, promoted)
+
 	// Fetch the updated event details
 	var event Event
 	var groupName string
 	err = h.db.QueryRow(`
 		SELECT e.id, e.group_id, e.title, e.description, e.location, e.start_time, e.end_time, e.created_at, e.created_by,
-			g.name as group_name, e.mc_id
+			g.name as group_name, e.mc_id, e.series_id, e.capacity, e.visibility, e.status, e.cancellation_reason, e.venue_id, e.time_zone
 		FROM events e
 		JOIN improv_groups g ON e.group_id = g.id
 		WHERE e.id = $1
 	`, eventID).Scan(
 		&event.ID, &event.GroupID, &event.Title, &event.Description,
 		&event.Location, &event.StartTime, &event.EndTime,
-		&event.CreatedAt, &event.CreatedBy, &groupName, &event.MCID,
+		&event.CreatedAt, &event.CreatedBy, &groupName, &event.MCID, &event.SeriesID, &event.Capacity, &event.Visibility,
+		&event.Status, &event.CancellationReason, &event.VenueID, &event.TimeZone,
 	)
 	if err != nil {
 		log.Printf("Error fetching updated event %s: %v", eventID, err)
@@ -586,13 +1142,81 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Let everyone who RSVP'd know about changes that affect their plans
+	var changes []string
+	if event.Title != previous.Title {
+		changes = append(changes, fmt.Sprintf("renamed to %s", event.Title))
+	}
+	if !event.StartTime.Equal(previous.StartTime) {
+		changes = append(changes, fmt.Sprintf("moved to %s", event.StartTime.In(loc).Format("Mon Jan 2 at 3:04 PM MST")))
+	}
+	if event.Location != previous.Location {
+		changes = append(changes, fmt.Sprintf("location changed to %s", event.Location))
+	}
+	if len(changes) > 0 {
+		h.notifyEventChanged(event.ID, groupID, user.ID, fmt.Sprintf("%s was %s", previous.Title, strings.Join(changes, ", ")))
+	}
+
+	// Drafts stay private to the group until they're scheduled
+	if event.Status != services.EventStatusDraft {
+		emitWebhook(h.db, groupID, services.WebhookEventUpdated, event)
+	}
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
-		Success: true,
-		Message: "Event updated successfully",
-		Data:    event,
+		Success:  true,
+		Message:  "Event updated successfully",
+		Data:     event,
+		Warnings: warnings,
 	})
 }
 
+// notifyEventChanged notifies every member who RSVP'd to the event, except whoever made the change
+func (h *EventHandler) notifyEventChanged(eventID, groupID, changedBy, content string) {
+	h.notifyRSVPs(eventID, groupID, changedBy, services.NotificationEventChanged, content)
+}
+
+// notifyRSVPs sends a notification to every member who RSVP'd to the event, except the given user
+func (h *EventHandler) notifyRSVPs(eventID, groupID, exceptUserID, notificationType, content string) {
+	rows, err := h.db.Query(`
+		SELECT user_id FROM event_rsvps
+		WHERE event_id = $1 AND user_id != $2
+	`, eventID, exceptUserID)
+	if err != nil {
+		log.Printf("Error fetching RSVPs for event %s %s notification: %v", eventID, notificationType, err)
+		return
+	}
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			log.Printf("Error scanning RSVP for event %s %s notification: %v", eventID, notificationType, err)
+			rows.Close()
+			return
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+
+	notificationService := services.NewNotificationService(h.db)
+	for _, userID := range userIDs {
+		if err := notificationService.Notify(userID, groupID, notificationType, content, eventID); err != nil {
+			log.Printf("Error creating %s notification for user %s: %v", notificationType, userID, err)
+		}
+	}
+}
+
+// announceCreated sends the event.created webhook and posts the new event to the group's chats
+func (h *EventHandler) announceCreated(event Event) {
+	emitWebhook(h.db, event.GroupID, services.WebhookEventCreated, event)
+
+	go func() {
+		if err := services.NewChatService(h.db).Announce(event.ID, chat.KindEventCreated); err != nil {
+			log.Printf("Error announcing event %s to chat: %v", event.ID, err)
+		}
+	}()
+}
+
 // GetEventGames gets all games associated with an event
 func (h *EventHandler) GetEventGames(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey).(*models.User)
@@ -600,11 +1224,7 @@ func (h *EventHandler) GetEventGames(w http.ResponseWriter, r *http.Request) {
 	eventID := vars["id"]
 
 	// First, check if the event exists and get its group ID
-	var groupID string
-	err := h.db.QueryRow(`
-		SELECT group_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID)
+	groupID, err := eventGroupID(h.db, r, eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Event not found: %s", eventID)
@@ -638,7 +1258,7 @@ func (h *EventHandler) GetEventGames(w http.ResponseWriter, r *http.Request) {
 
 	// Get assigned games with tags
 	gameRows, err := h.db.Query(`
-		SELECT g.id, g.name, g.description, g.min_players, g.max_players, eg.order_index,
+		SELECT g.id, g.name, g.description, g.min_players, g.max_players, eg.order_index, eg.target_players,
 		       GROUP_CONCAT(DISTINCT t.name) as tags
 		FROM event_games eg
 		JOIN games g ON eg.game_id = g.id
@@ -656,13 +1276,14 @@ func (h *EventHandler) GetEventGames(w http.ResponseWriter, r *http.Request) {
 	defer gameRows.Close()
 
 	type GameWithOrder struct {
-		ID          string   `json:"id"`
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		MinPlayers  int      `json:"minPlayers"`
-		MaxPlayers  int      `json:"maxPlayers"`
-		OrderIndex  int      `json:"orderIndex"`
-		Tags        []string `json:"tags"`
+		ID            string   `json:"id"`
+		Name          string   `json:"name"`
+		Description   string   `json:"description"`
+		MinPlayers    int      `json:"minPlayers"`
+		MaxPlayers    int      `json:"maxPlayers"`
+		OrderIndex    int      `json:"orderIndex"`
+		TargetPlayers *int     `json:"targetPlayers,omitempty"`
+		Tags          []string `json:"tags"`
 	}
 
 	var games []GameWithOrder
@@ -671,7 +1292,7 @@ func (h *EventHandler) GetEventGames(w http.ResponseWriter, r *http.Request) {
 		var tagsStr sql.NullString
 		err := gameRows.Scan(
 			&game.ID, &game.Name, &game.Description,
-			&game.MinPlayers, &game.MaxPlayers, &game.OrderIndex, &tagsStr,
+			&game.MinPlayers, &game.MaxPlayers, &game.OrderIndex, &game.TargetPlayers, &tagsStr,
 		)
 		if err != nil {
 			log.Printf("Error scanning game row: %v", err)
@@ -717,30 +1338,11 @@ func (h *EventHandler) AddGameToEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify the event exists and get group ID
-	var groupID string
-	var mcID sql.NullString
-	err = h.db.QueryRow(`
-		SELECT group_id, mc_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID, &mcID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("Event not found: %s", eventID)
-			RespondWithError(w, http.StatusNotFound, "Event not found")
-		} else {
-			log.Printf("Error fetching event %s: %v", eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
-		}
-		return
-	}
-
-	// Check if the user is the MC for this event
-	if !mcID.Valid || mcID.String != user.ID {
-		log.Printf("User %s is not the MC for event %s", user.ID, eventID)
-		RespondWithError(w, http.StatusForbidden, "Only the event's MC can manage games")
+	event, ok := h.requireGameEditor(w, r, eventID, user.ID)
+	if !ok {
 		return
 	}
+	groupID := event.groupID
 
 	// Verify the game exists and belongs to the group or its library
 	var gameExists bool
@@ -795,6 +1397,10 @@ func (h *EventHandler) AddGameToEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	eventID, ok = saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
 	// Add the game to the event
 	_, err = h.db.Exec(`
 		INSERT INTO event_games (event_id, game_id, order_index)
@@ -806,6 +1412,13 @@ func (h *EventHandler) AddGameToEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	emitWebhook(h.db, groupID, services.WebhookLineupChanged, map[string]string{
+		"eventId": eventID,
+		"change":  "game_added",
+		"gameId":  request.GameID,
+	})
+	publishEventUpdate(eventID, EventUpdateGameAdded, user.ID, map[string]string{"gameId": request.GameID})
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Game added to event successfully",
@@ -819,32 +1432,20 @@ func (h *EventHandler) RemoveGameFromEvent(w http.ResponseWriter, r *http.Reques
 	eventID := vars["id"]
 	gameID := vars["gameId"]
 
-	// Verify the event exists and get MC ID
-	var mcID sql.NullString
-	err := h.db.QueryRow(`
-		SELECT mc_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&mcID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("Event not found: %s", eventID)
-			RespondWithError(w, http.StatusNotFound, "Event not found")
-		} else {
-			log.Printf("Error fetching event %s: %v", eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
-		}
+	event, ok := h.requireGameEditor(w, r, eventID, user.ID)
+	if !ok {
 		return
 	}
+	groupID := event.groupID
 
-	// Check if the user is the MC for this event
-	if !mcID.Valid || mcID.String != user.ID {
-		log.Printf("User %s is not the MC for event %s", user.ID, eventID)
-		RespondWithError(w, http.StatusForbidden, "Only the event's MC can manage games")
+	// An occurrence that hasn't been saved has no games to remove
+	if unsavedOccurrence(r) != nil {
+		RespondWithError(w, http.StatusNotFound, "Game not found in event")
 		return
 	}
 
 	// Remove the game from the event
-	_, err = h.db.Exec(`
+	_, err := h.db.Exec(`
 		DELETE FROM event_games
 		WHERE event_id = $1 AND game_id = $2
 	`, eventID, gameID)
@@ -871,6 +1472,13 @@ func (h *EventHandler) RemoveGameFromEvent(w http.ResponseWriter, r *http.Reques
 		// Don't return an error to the client, just log it
 	}
 
+	emitWebhook(h.db, groupID, services.WebhookLineupChanged, map[string]string{
+		"eventId": eventID,
+		"change":  "game_removed",
+		"gameId":  gameID,
+	})
+	publishEventUpdate(eventID, EventUpdateGameRemoved, user.ID, map[string]string{"gameId": gameID})
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Game removed from event successfully",
@@ -895,29 +1503,11 @@ func (h *EventHandler) UpdateGameOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify the event exists and get MC ID
-	var mcID sql.NullString
-	err = h.db.QueryRow(`
-		SELECT mc_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&mcID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("Event not found: %s", eventID)
-			RespondWithError(w, http.StatusNotFound, "Event not found")
-		} else {
-			log.Printf("Error fetching event %s: %v", eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
-		}
-		return
-	}
-
-	// Check if the user is the MC for this event
-	if !mcID.Valid || mcID.String != user.ID {
-		log.Printf("User %s is not the MC for event %s", user.ID, eventID)
-		RespondWithError(w, http.StatusForbidden, "Only the event's MC can manage games")
+	event, ok := h.requireGameEditor(w, r, eventID, user.ID)
+	if !ok {
 		return
 	}
+	groupID := event.groupID
 
 	// Get the current order index for the target game
 	var currentIndex int
@@ -976,6 +1566,10 @@ func (h *EventHandler) UpdateGameOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	eventID, ok = saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
 	// Begin transaction for the swap
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -1061,6 +1655,17 @@ func (h *EventHandler) UpdateGameOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	emitWebhook(h.db, groupID, services.WebhookLineupChanged, map[string]interface{}{
+		"eventId":    eventID,
+		"change":     "games_reordered",
+		"gameId":     gameID,
+		"orderIndex": request.OrderIndex,
+	})
+	publishEventUpdate(eventID, EventUpdateGamesReordered, user.ID, map[string]interface{}{
+		"gameId":     gameID,
+		"orderIndex": request.OrderIndex,
+	})
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Game order updated successfully",
@@ -1074,12 +1679,7 @@ func (h *EventHandler) GetEventPlayers(w http.ResponseWriter, r *http.Request) {
 	eventID := vars["id"]
 
 	// First, check if the event exists and get its group ID
-	var groupID string
-	var mcID sql.NullString
-	err := h.db.QueryRow(`
-		SELECT group_id, mc_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID, &mcID)
+	groupID, err := eventGroupID(h.db, r, eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Event not found: %s", eventID)
@@ -1091,35 +1691,26 @@ func (h *EventHandler) GetEventPlayers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user is authorized (is MC or has admin/organizer role)
-	var isAuthorized bool
-	if mcID.Valid && mcID.String == user.ID {
-		isAuthorized = true
-	} else {
-		err = h.db.QueryRow(`
-			SELECT role IN ('admin', 'organizer')
-			FROM group_members
-			WHERE group_id = $1 AND user_id = $2
-		`, groupID, user.ID).Scan(&isAuthorized)
-		if err != nil {
-			log.Printf("Error checking user authorization: %v", err)
-			RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
-			return
-		}
+	// Check if user is authorized (has a crew role allowing it or has admin/organizer role)
+	isAuthorized, err := h.crewOrOrganizer(r, eventID, groupID, user.ID, services.PermissionViewLineup)
+	if err != nil {
+		log.Printf("Error checking user authorization: %v", err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
+		return
 	}
 
 	if !isAuthorized {
 		log.Printf("User %s is not authorized to view player assignments for event %s", user.ID, eventID)
-		RespondWithError(w, http.StatusForbidden, "Only the event MC or group organizers can view player assignments")
+		RespondWithError(w, http.StatusForbidden, "Only the event MC, crew who can see the lineup and group organizers can view player assignments")
 		return
 	}
 
 	type PlayerAssignment struct {
-		UserID    string `json:"userId"`
-		GameID    string `json:"gameId"`
-		EventID   string `json:"eventId"`
-		Name      string `json:"name"`
-		IsWalkIn  bool   `json:"isWalkIn"`
+		UserID   string `json:"userId"`
+		GameID   string `json:"gameId"`
+		EventID  string `json:"eventId"`
+		Name     string `json:"name"`
+		IsWalkIn bool   `json:"isWalkIn"`
 	}
 
 	var assignments []PlayerAssignment
@@ -1194,9 +1785,21 @@ func (h *EventHandler) GetEventPlayers(w http.ResponseWriter, r *http.Request) {
 		assignments = append(assignments, assignment)
 	}
 
+	// An occurrence that hasn't been saved has no lineup to check yet
+	var violations []lineup.Violation
+	if unsavedOccurrence(r) == nil {
+		violations, err = services.NewLineupService(h.db).Violations(eventID)
+		if err != nil {
+			log.Printf("Error checking lineup constraints for event %s: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error checking lineup constraints")
+			return
+		}
+	}
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
-		Success: true,
-		Data:    assignments,
+		Success:    true,
+		Data:       assignments,
+		Violations: violations,
 	})
 }
 
@@ -1218,46 +1821,11 @@ func (h *EventHandler) AssignPlayerToGame(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Verify the event exists and get group ID
-	var groupID string
-	var mcID sql.NullString
-	err = h.db.QueryRow(`
-		SELECT group_id, mc_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID, &mcID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("Event not found: %s", eventID)
-			RespondWithError(w, http.StatusNotFound, "Event not found")
-		} else {
-			log.Printf("Error fetching event %s: %v", eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
-		}
-		return
-	}
-
-	// Check if user is authorized (is MC or has admin/organizer role)
-	var isAuthorized bool
-	if mcID.Valid && mcID.String == user.ID {
-		isAuthorized = true
-	} else {
-		err = h.db.QueryRow(`
-			SELECT role IN ('admin', 'organizer')
-			FROM group_members
-			WHERE group_id = $1 AND user_id = $2
-		`, groupID, user.ID).Scan(&isAuthorized)
-		if err != nil {
-			log.Printf("Error checking user authorization: %v", err)
-			RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
-			return
-		}
-	}
-
-	if !isAuthorized {
-		log.Printf("User %s is not authorized to assign players for event %s", user.ID, eventID)
-		RespondWithError(w, http.StatusForbidden, "Only the event MC or group organizers can assign players")
+	event, ok := h.requireLineupEditor(w, r, eventID, user.ID)
+	if !ok {
 		return
 	}
+	groupID := event.groupID
 
 	// Verify the game exists and is part of the event
 	var gameExists bool
@@ -1380,6 +1948,43 @@ func (h *EventHandler) AssignPlayerToGame(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	// The event's casting rules can rule the assignment out
+	violations, err := services.NewLineupService(h.db).CheckAssignment(eventID, gameID, request.UserID)
+	if err != nil {
+		log.Printf("Error checking lineup constraints for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking lineup constraints")
+		return
+	}
+	if len(violations) > 0 {
+		RespondWithJSON(w, http.StatusConflict, ApiResponse{
+			Success:    false,
+			Error:      violations[0].Message,
+			Violations: violations,
+		})
+		return
+	}
+
+	// A registered player may be in another group's show at the same time
+	var warnings []services.Conflict
+	if isRegisteredUser {
+		var ok bool
+		warnings, ok = h.checkConflicts(w, services.EventSlot{
+			EventID:    eventID,
+			GroupID:    groupID,
+			StartTime:  event.startTime,
+			EndTime:    event.endTime,
+			Performers: []string{request.UserID},
+			ViewerID:   user.ID,
+		}, true)
+		if !ok {
+			return
+		}
+	}
+
+	eventID, ok = saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
 	// Assign player to the game
 	_, err = h.db.Exec(`
 		INSERT INTO event_player_assignments (event_id, game_id, user_id)
@@ -1391,9 +1996,26 @@ func (h *EventHandler) AssignPlayerToGame(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Walk-in attendees don't have accounts to notify
+	if isRegisteredUser && request.UserID != user.ID {
+		h.notifyGameAssignment(request.UserID, groupID, eventID, gameID, "You've been assigned to %s in %s")
+	}
+
+	emitWebhook(h.db, groupID, services.WebhookLineupChanged, map[string]string{
+		"eventId": eventID,
+		"change":  "player_assigned",
+		"gameId":  gameID,
+		"userId":  request.UserID,
+	})
+	publishEventUpdate(eventID, EventUpdateAssignmentAdded, user.ID, map[string]string{
+		"gameId":   gameID,
+		"playerId": request.UserID,
+	})
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
-		Success: true,
-		Message: "Player assigned to game successfully",
+		Success:  true,
+		Message:  "Player assigned to game successfully",
+		Warnings: warnings,
 	})
 }
 
@@ -1405,53 +2027,18 @@ func (h *EventHandler) RemovePlayerFromGame(w http.ResponseWriter, r *http.Reque
 	gameID := vars["gameId"]
 	targetUserID := vars["userId"]
 
-	// Verify the event exists and get group ID
-	var groupID string
-	var mcID sql.NullString
-	err := h.db.QueryRow(`
-		SELECT group_id, mc_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID, &mcID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("Event not found: %s", eventID)
-			RespondWithError(w, http.StatusNotFound, "Event not found")
-		} else {
-			log.Printf("Error fetching event %s: %v", eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
-		}
-		return
-	}
-
-	// Check if user is authorized (is MC or has admin/organizer role)
-	var isAuthorized bool
-	if mcID.Valid && mcID.String == user.ID {
-		isAuthorized = true
-	} else {
-		err = h.db.QueryRow(`
-			SELECT role IN ('admin', 'organizer')
-			FROM group_members
-			WHERE group_id = $1 AND user_id = $2
-		`, groupID, user.ID).Scan(&isAuthorized)
-		if err != nil {
-			log.Printf("Error checking user authorization: %v", err)
-			RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
-			return
-		}
-	}
-
-	if !isAuthorized {
-		log.Printf("User %s is not authorized to remove players for event %s", user.ID, eventID)
-		RespondWithError(w, http.StatusForbidden, "Only the event MC or group organizers can remove players")
+	event, ok := h.requireLineupEditor(w, r, eventID, user.ID)
+	if !ok {
 		return
 	}
+	groupID := event.groupID
 
 	// Check if the player is a registered user or a walk-in attendee
 	var isRegisteredUser bool
 	var isWalkInAttendee bool
 
 	// Check if it's a registered user
-	err = h.db.QueryRow(`
+	err := h.db.QueryRow(`
 		SELECT EXISTS(
 			SELECT 1 FROM users
 			WHERE id = $1
@@ -1505,6 +2092,10 @@ func (h *EventHandler) RemovePlayerFromGame(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	eventID, ok = saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
 	// Remove the player from the game
 	_, err = h.db.Exec(`
 		DELETE FROM event_player_assignments
@@ -1516,12 +2107,115 @@ func (h *EventHandler) RemovePlayerFromGame(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if isRegisteredUser && targetUserID != user.ID {
+		h.notifyGameAssignment(targetUserID, groupID, eventID, gameID, "You've been removed from %s in %s")
+	}
+
+	emitWebhook(h.db, groupID, services.WebhookLineupChanged, map[string]string{
+		"eventId": eventID,
+		"change":  "player_removed",
+		"gameId":  gameID,
+		"userId":  targetUserID,
+	})
+	publishEventUpdate(eventID, EventUpdateAssignmentRemoved, user.ID, map[string]string{
+		"gameId":   gameID,
+		"playerId": targetUserID,
+	})
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Player removed from game successfully",
 	})
 }
 
+// notifyGameAssignment tells a player about a change to their game assignments.
+// format receives the game name and the event title.
+func (h *EventHandler) notifyGameAssignment(userID, groupID, eventID, gameID, format string) {
+	var gameName, eventTitle string
+	err := h.db.QueryRow(`
+		SELECT g.name, e.title
+		FROM games g, events e
+		WHERE g.id = $1 AND e.id = $2
+	`, gameID, eventID).Scan(&gameName, &eventTitle)
+	if err != nil {
+		log.Printf("Error fetching game assignment details for notification: %v", err)
+		return
+	}
+
+	err = services.NewNotificationService(h.db).Notify(
+		userID,
+		groupID,
+		services.NotificationGameAssignment,
+		fmt.Sprintf(format, gameName, eventTitle),
+		eventID,
+	)
+	if err != nil {
+		log.Printf("Error creating game assignment notification for user %s: %v", userID, err)
+	}
+}
+
+// FinalizeLineup marks the event's lineup as final and announces it to the cast and the group's chat
+func (h *EventHandler) FinalizeLineup(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	event, ok := h.requireLineupEditor(w, r, eventID, user.ID)
+	if !ok {
+		return
+	}
+	groupID := event.groupID
+
+	var gameCount int
+	err := h.db.QueryRow(`SELECT COUNT(*) FROM event_games WHERE event_id = $1`, eventID).Scan(&gameCount)
+	if err != nil {
+		log.Printf("Error counting games for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking lineup")
+		return
+	}
+	if gameCount == 0 {
+		RespondWithError(w, http.StatusBadRequest, "Add games to the lineup before finalizing it")
+		return
+	}
+
+	eventID, ok = saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+	finalizedAt := time.Now().UTC()
+	_, err = h.db.Exec(`
+		UPDATE events SET lineup_finalized_at = $1 WHERE id = $2
+	`, finalizedAt, eventID)
+	if err != nil {
+		log.Printf("Error finalizing lineup for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error finalizing lineup")
+		return
+	}
+
+	h.notifyRSVPs(eventID, groupID, user.ID, services.NotificationLineupPublished, fmt.Sprintf("The lineup for %s is set", event.title))
+
+	emitWebhook(h.db, groupID, services.WebhookLineupChanged, map[string]string{
+		"eventId": eventID,
+		"change":  "finalized",
+	})
+	publishEventUpdate(eventID, EventUpdateLineupFinalized, user.ID, map[string]interface{}{
+		"lineupFinalizedAt": finalizedAt,
+	})
+
+	go func() {
+		if err := services.NewChatService(h.db).Announce(eventID, chat.KindLineupFinalized); err != nil {
+			log.Printf("Error announcing lineup for event %s to chat: %v", eventID, err)
+		}
+	}()
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Lineup finalized",
+		Data: map[string]interface{}{
+			"lineupFinalizedAt": finalizedAt,
+		},
+	})
+}
+
 // GetUserGamePreferences gets user preferences for games in an event
 func (h *EventHandler) GetUserGamePreferences(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey).(*models.User)
@@ -1535,12 +2229,7 @@ func (h *EventHandler) GetUserGamePreferences(w http.ResponseWriter, r *http.Req
 	}
 
 	// Verify the event exists and get group ID
-	var groupID string
-	var mcID sql.NullString
-	err := h.db.QueryRow(`
-		SELECT group_id, mc_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID, &mcID)
+	groupID, err := eventGroupID(h.db, r, eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Event not found: %s", eventID)
@@ -1552,26 +2241,17 @@ func (h *EventHandler) GetUserGamePreferences(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Check if user is authorized (is MC or has admin/organizer role)
-	var isAuthorized bool
-	if mcID.Valid && mcID.String == user.ID {
-		isAuthorized = true
-	} else {
-		err = h.db.QueryRow(`
-			SELECT role IN ('admin', 'organizer')
-			FROM group_members
-			WHERE group_id = $1 AND user_id = $2
-		`, groupID, user.ID).Scan(&isAuthorized)
-		if err != nil {
-			log.Printf("Error checking user authorization: %v", err)
-			RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
-			return
-		}
+	// Check if user is authorized (has a crew role allowing it or has admin/organizer role)
+	isAuthorized, err := h.crewOrOrganizer(r, eventID, groupID, user.ID, services.PermissionViewLineup)
+	if err != nil {
+		log.Printf("Error checking user authorization: %v", err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
+		return
 	}
 
 	if !isAuthorized {
 		log.Printf("User %s is not authorized to view game preferences for event %s", user.ID, eventID)
-		RespondWithError(w, http.StatusForbidden, "Only the event MC or group organizers can view game preferences")
+		RespondWithError(w, http.StatusForbidden, "Only the event MC, crew who can see the lineup and group organizers can view game preferences")
 		return
 	}
 
@@ -1641,11 +2321,7 @@ func (h *EventHandler) GetNonRegisteredAttendees(w http.ResponseWriter, r *http.
 	eventID := vars["id"]
 
 	// Verify the event exists and get group ID
-	var groupID string
-	err := h.db.QueryRow(`
-		SELECT group_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID)
+	groupID, err := eventGroupID(h.db, r, eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Event not found: %s", eventID)
@@ -1747,6 +2423,8 @@ func (h *EventHandler) AddNonRegisteredAttendee(w http.ResponseWriter, r *http.R
 		FirstName string `json:"firstName"`
 		LastName  string `json:"lastName"`
 		Email     string `json:"email,omitempty"`
+		// AllowDuplicate adds the walk-in even if one with the same email or name is already at the event
+		AllowDuplicate bool `json:"allowDuplicate,omitempty"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -1764,11 +2442,7 @@ func (h *EventHandler) AddNonRegisteredAttendee(w http.ResponseWriter, r *http.R
 	}
 
 	// Verify the event exists and get group ID
-	var groupID string
-	err := h.db.QueryRow(`
-		SELECT group_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID)
+	groupID, err := eventGroupID(h.db, r, eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Event not found: %s", eventID)
@@ -1791,6 +2465,42 @@ func (h *EventHandler) AddNonRegisteredAttendee(w http.ResponseWriter, r *http.R
 		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can add non-registered attendees")
 		return
 	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	var eventStatus string
+	err = h.db.QueryRow(`
+		SELECT status FROM events
+		WHERE id = $1
+	`, eventID).Scan(&eventStatus)
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return
+	}
+	if eventStatus == services.EventStatusCancelled {
+		RespondWithError(w, http.StatusConflict, rsvpClosedMessage(eventStatus))
+		return
+	}
+
+	if !attendeeRequest.AllowDuplicate {
+		duplicates, err := services.NewWalkInLinkService(h.db).FindDuplicates(eventID, attendeeRequest.FirstName, attendeeRequest.LastName, attendeeRequest.Email)
+		if err != nil {
+			log.Printf("Error checking for duplicate walk-ins at event %s: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error creating non-registered attendee")
+			return
+		}
+		if len(duplicates) > 0 {
+			RespondWithJSON(w, http.StatusConflict, ApiResponse{
+				Success: false,
+				Error:   "A walk-in with the same email or name is already at this event",
+				Data:    duplicates,
+			})
+			return
+		}
+	}
 
 	// Create a new ID for the attendee
 	attendeeID := uuid.New().String()
@@ -1845,6 +2555,8 @@ func (h *EventHandler) AddNonRegisteredAttendee(w http.ResponseWriter, r *http.R
 		attendee.Email = &emailValue.String
 	}
 
+	publishEventUpdate(eventID, EventUpdateAttendeeAdded, user.ID, attendee)
+
 	RespondWithJSON(w, http.StatusCreated, ApiResponse{
 		Success: true,
 		Message: "Non-registered attendee added successfully",
@@ -1885,6 +2597,12 @@ func (h *EventHandler) UpdateNonRegisteredAttendee(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Nobody has been added to an occurrence that hasn't been saved
+	if unsavedOccurrence(r) != nil {
+		RespondWithError(w, http.StatusNotFound, "Attendee not found")
+		return
+	}
+
 	// First, check if the attendee exists and belongs to the specified event
 	var exists bool
 	var groupID string
@@ -1971,6 +2689,8 @@ func (h *EventHandler) UpdateNonRegisteredAttendee(w http.ResponseWriter, r *htt
 		attendee.Email = &emailValue.String
 	}
 
+	publishEventUpdate(eventID, EventUpdateAttendeeUpdated, user.ID, attendee)
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Non-registered attendee updated successfully",
@@ -1990,6 +2710,12 @@ func (h *EventHandler) DeleteNonRegisteredAttendee(w http.ResponseWriter, r *htt
 	eventID := vars["id"]
 	attendeeID := vars["attendeeId"]
 
+	// Nobody has been added to an occurrence that hasn't been saved
+	if unsavedOccurrence(r) != nil {
+		RespondWithError(w, http.StatusNotFound, "Attendee not found")
+		return
+	}
+
 	// First, check if the attendee exists and belongs to the specified event
 	var exists bool
 	var groupID string
@@ -2035,6 +2761,21 @@ func (h *EventHandler) DeleteNonRegisteredAttendee(w http.ResponseWriter, r *htt
 		RespondWithError(w, http.StatusInternalServerError, "Error deleting attendee")
 		return
 	}
+	if err := services.NewAttendanceService(h.db).Clear(eventID, attendeeID); err != nil {
+		log.Printf("Error clearing attendance of attendee %s: %v", attendeeID, err)
+	}
+	if err := services.NewWalkInLinkService(h.db).DiscardPending(attendeeID); err != nil {
+		log.Printf("Error discarding walk-in match of attendee %s: %v", attendeeID, err)
+	}
+
+	publishEventUpdate(eventID, EventUpdateAttendeeRemoved, user.ID, map[string]string{"attendeeId": attendeeID})
+
+	// The walk-in's spot goes to the waitlist
+	promoted, err := services.NewRSVPService(h.db).PromoteWaitlist(eventID)
+	if err != nil {
+		log.Printf("Error promoting waitlist for event %s: %v", eventID, err)
+	}
+	announcePromotions(h.db, eventID, groupID, promoted)
 
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// showUpdate is an update to show1 that leaves its details as they are
+func showUpdate(fields map[string]interface{}) map[string]interface{} {
+	update := map[string]interface{}{"title": "Friday Show", "location": "Main Stage", "startTime": "2026-02-06T19:00:00Z", "endTime": "2026-02-06T21:00:00Z"}
+	for field, value := range fields {
+		update[field] = value
+	}
+	return update
+}
+
+func TestEventUpdate_Capacity(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	seed(t, testDB, `UPDATE events SET capacity = 10 WHERE id = 'show1'`)
+	h := NewEventHandler(testDB)
+
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		want   sql.NullInt64
+	}{
+		{"left out", nil, sql.NullInt64{Int64: 10, Valid: true}},
+		{"changed", map[string]interface{}{"capacity": 20}, sql.NullInt64{Int64: 20, Valid: true}},
+		{"left out again", map[string]interface{}{"description": "Now with snacks"}, sql.NullInt64{Int64: 20, Valid: true}},
+		{"null", map[string]interface{}{"capacity": nil}, sql.NullInt64{}},
+		{"set again", map[string]interface{}{"capacity": 8}, sql.NullInt64{Int64: 8, Valid: true}},
+		{"zero", map[string]interface{}{"capacity": 0}, sql.NullInt64{}},
+	}
+	for _, tt := range tests {
+		recorder, _ := serve(t, testDB, "user123", "/events/{id}", h.Update, http.MethodPut, "/events/show1", showUpdate(tt.fields))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tt.name, recorder.Code, recorder.Body.String())
+		}
+		var capacity sql.NullInt64
+		if err := testDB.QueryRow(`SELECT capacity FROM events WHERE id = 'show1'`).Scan(&capacity); err != nil {
+			t.Fatalf("Error fetching capacity: %v", err)
+		}
+		if capacity != tt.want {
+			t.Errorf("%s: expected capacity %v, got %v", tt.name, tt.want, capacity)
+		}
+	}
+
+	recorder, _ := serve(t, testDB, "user123", "/events/{id}", h.Update, http.MethodPut, "/events/show1", showUpdate(map[string]interface{}{"capacity": -1}))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative capacity, got %d", recorder.Code)
+	}
+}
+
+func TestEventUpdate_Venue(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	seed(t, testDB,
+		`INSERT INTO venues (id, group_id, name, created_by) VALUES ('stage', 'group123', 'Main Stage', 'user123'), ('loft', 'group123', 'The Loft', 'user123')`,
+		`UPDATE events SET venue_id = 'stage' WHERE id = 'show1'`)
+	h := NewEventHandler(testDB)
+
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		want   sql.NullString
+	}{
+		{"left out", nil, sql.NullString{String: "stage", Valid: true}},
+		{"changed", map[string]interface{}{"venueId": "loft"}, sql.NullString{String: "loft", Valid: true}},
+		{"left out again", map[string]interface{}{"description": "Now with snacks"}, sql.NullString{String: "loft", Valid: true}},
+		{"null", map[string]interface{}{"venueId": nil}, sql.NullString{}},
+		{"set again", map[string]interface{}{"venueId": "stage"}, sql.NullString{String: "stage", Valid: true}},
+		{"empty", map[string]interface{}{"venueId": ""}, sql.NullString{}},
+	}
+	for _, tt := range tests {
+		recorder, _ := serve(t, testDB, "user123", "/events/{id}", h.Update, http.MethodPut, "/events/show1", showUpdate(tt.fields))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tt.name, recorder.Code, recorder.Body.String())
+		}
+		var venueID sql.NullString
+		if err := testDB.QueryRow(`SELECT venue_id FROM events WHERE id = 'show1'`).Scan(&venueID); err != nil {
+			t.Fatalf("Error fetching venue: %v", err)
+		}
+		if venueID != tt.want {
+			t.Errorf("%s: expected venue %v, got %v", tt.name, tt.want, venueID)
+		}
+	}
+
+	recorder, _ := serve(t, testDB, "user123", "/events/{id}", h.Update, http.MethodPut, "/events/show1", showUpdate(map[string]interface{}{"venueId": "elsewhere"}))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a venue outside the catalogue, got %d", recorder.Code)
+	}
+}
+
+func TestEventList_IncludesCapacity(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	seed(t, testDB, `UPDATE events SET capacity = 10, description = '' WHERE id = 'show1'`)
+	h := NewEventHandler(testDB)
+
+	recorder, response := serve(t, testDB, "dana", "/groups/{id}/events", h.List, http.MethodGet, "/groups/group123/events", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	events := response.Data.([]interface{})
+	if len(events) != 1 || events[0].(map[string]interface{})["Capacity"] != float64(10) {
+		t.Errorf("Expected show1 with a capacity of 10, got %v", events)
+	}
+}
+
+func TestEventCreate_TemplateLineupSavedWithEvent(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	seed(t, testDB,
+		`INSERT INTO event_templates (id, group_id, name, title_pattern, created_by) VALUES ('jam', 'group123', 'Jam', 'Jam', 'user123')`,
+		`INSERT INTO event_template_games (template_id, game_id, order_index) VALUES ('jam', 'game3', 0), ('jam', 'game1', 1)`)
+	h := NewEventHandler(testDB)
+
+	create := func(title, rrule string) (int, string) {
+		t.Helper()
+		recorder, response := serve(t, testDB, "user123", "/events", h.Create, http.MethodPost, "/events", map[string]string{
+			"groupId": "group123", "title": title, "startTime": "2026-03-06T19:00:00Z", "templateId": "jam", "rrule": rrule,
+		})
+		if recorder.Code != http.StatusCreated {
+			return recorder.Code, ""
+		}
+		return recorder.Code, response.Data.(map[string]interface{})["ID"].(string)
+	}
+	lineupOf := func(eventID string) string {
+		t.Helper()
+		var lineup sql.NullString
+		err := testDB.QueryRow(`
+			SELECT GROUP_CONCAT(game_id) FROM (SELECT game_id FROM event_games WHERE event_id = $1 ORDER BY order_index)
+		`, eventID).Scan(&lineup)
+		if err != nil {
+			t.Fatalf("Error fetching lineup: %v", err)
+		}
+		return lineup.String
+	}
+
+	for _, rrule := range []string{"", "FREQ=WEEKLY;COUNT=2"} {
+		code, eventID := create("Jam", rrule)
+		if code != http.StatusCreated {
+			t.Fatalf("Expected 201 creating with rule %q, got %d", rrule, code)
+		}
+		if lineup := lineupOf(eventID); lineup != "game3,game1" {
+			t.Errorf("Expected the template's lineup with rule %q, got %q", rrule, lineup)
+		}
+	}
+
+	// If the lineup can't be saved, neither is the event
+	seed(t, testDB, `CREATE TRIGGER no_lineups BEFORE INSERT ON event_games BEGIN SELECT RAISE(ABORT, 'no lineups'); END`)
+	for _, rrule := range []string{"", "FREQ=WEEKLY;COUNT=2"} {
+		if code, _ := create("Broken Jam", rrule); code != http.StatusInternalServerError {
+			t.Errorf("Expected 500 with rule %q, got %d", rrule, code)
+		}
+	}
+	var saved int
+	if err := testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE title = 'Broken Jam'`).Scan(&saved); err != nil || saved != 0 {
+		t.Errorf("Expected no event saved without its lineup, got %d (%v)", saved, err)
+	}
+}
+
+func TestEventClone_TimesAndConflicts(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	seed(t, testDB,
+		`UPDATE events SET time_zone = 'America/Chicago', description = '' WHERE id = 'show1'`,
+		`UPDATE improv_groups SET conflict_policy = 'block' WHERE id = 'group123'`,
+		`INSERT INTO events (id, group_id, title, start_time, end_time, created_by)
+		 VALUES ('jam1', 'group123', 'Jam', '2026-02-21 01:00:00', '2026-02-21 03:00:00', 'user123')`)
+	h := NewEventHandler(testDB)
+
+	// A time without an offset is in the show's zone, and the clone keeps the show's length
+	recorder, response := serve(t, testDB, "user123", "/events/{id}/clone", h.Clone, http.MethodPost, "/events/show1/clone",
+		map[string]interface{}{"startTime": "2026-02-13T19:00"})
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	cloneID := response.Data.(map[string]interface{})["ID"]
+	var start, end time.Time
+	if err := testDB.QueryRow(`SELECT start_time, end_time FROM events WHERE id = $1`, cloneID).Scan(&start, &end); err != nil {
+		t.Fatalf("Error fetching clone: %v", err)
+	}
+	if !start.Equal(time.Date(2026, 2, 14, 1, 0, 0, 0, time.UTC)) || end.Sub(start) != 2*time.Hour {
+		t.Errorf("Expected the clone from 7 to 9 PM Chicago time, got %v to %v", start, end)
+	}
+
+	// The group blocks clashes, so a clone on top of the jam isn't saved
+	recorder, _ = serve(t, testDB, "user123", "/events/{id}/clone", h.Clone, http.MethodPost, "/events/show1/clone",
+		map[string]interface{}{"startTime": "2026-02-20T19:30"})
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a clashing clone, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var events int
+	testDB.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&events)
+	if events != 3 {
+		t.Errorf("Expected only the first clone saved, got %d events", events)
+	}
+}
@@ -483,6 +483,7 @@ func (h *GameHandler) Get(w http.ResponseWriter, r *http.Request) {
 		JOIN improv_groups g ON e.group_id = g.id
 		JOIN group_members gm ON g.id = gm.group_id
 		WHERE eg.game_id = $1 AND gm.user_id = $2 AND e.start_time > CURRENT_TIMESTAMP
+			AND (e.status = 'scheduled' OR (e.status = 'draft' AND gm.role IN ('admin', 'organizer')))
 		ORDER BY e.start_time
 		LIMIT 5
 	`, gameID, user.ID)
@@ -764,61 +765,8 @@ func (h *GameHandler) GetUnratedGames(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey).(*models.User)
 	searchQuery := r.URL.Query().Get("search")
 
-	var queryStr string
-	var params []interface{}
-
-	// If search query is provided, use FTS4
-	if searchQuery != "" {
-		// Add wildcard for partial word matching
-		searchTermWithWildcard := searchQuery + "*"
-
-		queryStr = `
-			SELECT g.id, g.name, g.description, g.min_players, g.max_players, g.created_at, g.created_by, g.group_id, g.public,
-				GROUP_CONCAT(DISTINCT t.name) as tags,
-				(CASE
-				  WHEN g.name LIKE ? THEN 3
-				  WHEN g.description LIKE ? THEN 1
-				  ELSE 0
-				END) AS relevance_score,
-				COUNT(DISTINCT eg.event_id) AS event_count
-			FROM games g
-			JOIN games_fts ON games_fts.docid = g.rowid
-			JOIN group_game_libraries ggl ON g.id = ggl.game_id
-			JOIN group_members gm ON ggl.group_id = gm.group_id
-			LEFT JOIN game_tag_associations gta ON g.id = gta.game_id
-			LEFT JOIN game_tags t ON gta.tag_id = t.id
-			LEFT JOIN user_game_preferences ugp ON g.id = ugp.game_id AND ugp.user_id = ?
-			LEFT JOIN event_games eg ON g.id = eg.game_id
-			WHERE gm.user_id = ? AND ugp.status IS NULL
-			AND games_fts MATCH ?
-			GROUP BY g.id
-			ORDER BY relevance_score DESC, event_count DESC, g.created_at DESC
-			LIMIT 10
-		`
-		likePattern := "%" + searchQuery + "%"
-		params = append(params, likePattern, likePattern, user.ID, user.ID, searchTermWithWildcard)
-	} else {
-		// Regular query without search
-		queryStr = `
-			SELECT g.id, g.name, g.description, g.min_players, g.max_players, g.created_at, g.created_by, g.group_id, g.public,
-				GROUP_CONCAT(DISTINCT t.name) as tags,
-				COUNT(DISTINCT eg.event_id) AS event_count
-			FROM games g
-			JOIN group_game_libraries ggl ON g.id = ggl.game_id
-			JOIN group_members gm ON ggl.group_id = gm.group_id
-			LEFT JOIN game_tag_associations gta ON g.id = gta.game_id
-			LEFT JOIN game_tags t ON gta.tag_id = t.id
-			LEFT JOIN user_game_preferences ugp ON g.id = ugp.game_id AND ugp.user_id = ?
-			LEFT JOIN event_games eg ON g.id = eg.game_id
-			WHERE gm.user_id = ? AND ugp.status IS NULL
-			GROUP BY g.id
-			ORDER BY event_count DESC, g.created_at DESC
-			LIMIT 10
-		`
-		params = append(params, user.ID, user.ID)
-	}
-
-	rows, err := h.db.Query(queryStr, params...)
+	queryResult := query.BuildUnratedGamesQuery(user.ID, searchQuery, 10)
+	rows, err := h.db.Query(queryResult.Query, queryResult.Params...)
 
 	if err != nil {
 		log.Printf("Error fetching unrated games: %v", err)
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"improv-app/internal/auth"
+)
+
+// requireGroupAdmin responds with an error and returns false unless the user is an admin of the
+// group. What names what only admins can manage, for the error message.
+func requireGroupAdmin(db *sql.DB, w http.ResponseWriter, groupID, userID, what string) bool {
+	var role string
+	err := db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, userID).Scan(&role)
+	if err != nil {
+		log.Printf("User %s is not a member of group %s: %v", userID, groupID, err)
+		RespondWithError(w, http.StatusForbidden, "Not a member of this group")
+		return false
+	}
+	if role != auth.RoleAdmin {
+		log.Printf("User %s is not an admin of group %s (role=%s)", userID, groupID, role)
+		RespondWithError(w, http.StatusForbidden, "Only admins can manage "+what)
+		return false
+	}
+	return true
+}
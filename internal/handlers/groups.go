@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"improv-app/internal/middleware"
 	"improv-app/internal/models"
+	"improv-app/internal/services"
 
 	"improv-app/internal/auth"
 
@@ -33,6 +35,8 @@ func (h *GroupHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var groupRequest struct {
 		Name        string `json:"name" validate:"required,min=3,max=100"`
 		Description string `json:"description" validate:"omitempty,max=500"`
+		// Optional IANA zone the group's events are in, UTC when left out
+		TimeZone string `json:"timeZone,omitempty"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -73,12 +77,20 @@ func (h *GroupHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if groupRequest.TimeZone == "" {
+		groupRequest.TimeZone = "UTC"
+	}
+	if _, err := services.LoadTimeZone(groupRequest.TimeZone); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Time zone must be an IANA zone name, like America/Chicago")
+		return
+	}
+
 	groupID := uuid.New().String()
 	err := h.db.QueryRow(`
-	INSERT INTO improv_groups (id, name, description, created_by)
-	VALUES ($1, $2, $3, $4)
+	INSERT INTO improv_groups (id, name, description, created_by, time_zone)
+	VALUES ($1, $2, $3, $4, $5)
 	RETURNING id
-`, groupID, groupRequest.Name, groupRequest.Description, user.ID).Scan(&groupID)
+`, groupID, groupRequest.Name, groupRequest.Description, user.ID, groupRequest.TimeZone).Scan(&groupID)
 	if err != nil {
 		fmt.Printf("Error creating group: %v\n", err)
 		RespondWithError(w, http.StatusInternalServerError, "Error creating group")
@@ -159,11 +171,12 @@ func (h *GroupHandler) Get(w http.ResponseWriter, r *http.Request) {
 	groupID := vars["id"]
 
 	var group ImprovGroup
+	var defaultEventVisibility, timeZone, conflictPolicy, walkInLinking string
 	err := h.db.QueryRow(`
-		SELECT id, name, description, created_at, created_by
+		SELECT id, name, description, created_at, created_by, default_event_visibility, time_zone, conflict_policy, walk_in_linking
 		FROM improv_groups
 		WHERE id = $1
-	`, groupID).Scan(&group.ID, &group.Name, &group.Description, &group.CreatedAt, &group.CreatedBy)
+	`, groupID).Scan(&group.ID, &group.Name, &group.Description, &group.CreatedAt, &group.CreatedBy, &defaultEventVisibility, &timeZone, &conflictPolicy, &walkInLinking)
 	if err != nil {
 		fmt.Printf("Group not found: %v\n", err)
 		RespondWithError(w, http.StatusNotFound, "Group not found")
@@ -227,10 +240,22 @@ func (h *GroupHandler) Get(w http.ResponseWriter, r *http.Request) {
 		Group    ImprovGroup `json:"group"`
 		Members  []Member    `json:"members"`
 		UserRole string      `json:"userRole"`
+		// DefaultEventVisibility is the visibility new events get when none is chosen
+		DefaultEventVisibility string `json:"defaultEventVisibility"`
+		// TimeZone is the IANA zone new events are in when none is chosen
+		TimeZone string `json:"timeZone"`
+		// ConflictPolicy is whether scheduling conflicts are warnings or refused
+		ConflictPolicy string `json:"conflictPolicy"`
+		// WalkInLinking is whether walk-ins are linked to matching accounts straight away or once confirmed
+		WalkInLinking string `json:"walkInLinking"`
 	}{
-		Group:    group,
-		Members:  members,
-		UserRole: role,
+		Group:                  group,
+		Members:                members,
+		UserRole:               role,
+		DefaultEventVisibility: defaultEventVisibility,
+		TimeZone:               timeZone,
+		ConflictPolicy:         conflictPolicy,
+		WalkInLinking:          walkInLinking,
 	}
 
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
@@ -267,6 +292,14 @@ func (h *GroupHandler) Update(w http.ResponseWriter, r *http.Request) {
 	var groupRequest struct {
 		Name        string `json:"name" validate:"required,min=3,max=100"`
 		Description string `json:"description" validate:"omitempty,max=500"`
+		// Optional visibility for new events, unchanged when left out
+		DefaultEventVisibility string `json:"defaultEventVisibility,omitempty"`
+		// Optional IANA zone for new events, unchanged when left out
+		TimeZone string `json:"timeZone,omitempty"`
+		// Optional conflict policy, warn or block, unchanged when left out
+		ConflictPolicy string `json:"conflictPolicy,omitempty"`
+		// Optional walk-in linking policy, auto or confirm, unchanged when left out
+		WalkInLinking string `json:"walkInLinking,omitempty"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -307,6 +340,25 @@ func (h *GroupHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if groupRequest.DefaultEventVisibility != "" && !services.IsValidVisibility(groupRequest.DefaultEventVisibility) {
+		RespondWithError(w, http.StatusBadRequest, "Default event visibility must be private, members-and-followers or public")
+		return
+	}
+	if groupRequest.TimeZone != "" {
+		if _, err := services.LoadTimeZone(groupRequest.TimeZone); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Time zone must be an IANA zone name, like America/Chicago")
+			return
+		}
+	}
+	if groupRequest.ConflictPolicy != "" && !services.IsValidConflictPolicy(groupRequest.ConflictPolicy) {
+		RespondWithError(w, http.StatusBadRequest, "Conflict policy must be warn or block")
+		return
+	}
+	if groupRequest.WalkInLinking != "" && !services.IsValidWalkInLinking(groupRequest.WalkInLinking) {
+		RespondWithError(w, http.StatusBadRequest, "Walk-in linking must be auto or confirm")
+		return
+	}
+
 	// Update the group
 	_, err = h.db.Exec(`
 		UPDATE improv_groups
@@ -319,6 +371,51 @@ func (h *GroupHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if groupRequest.DefaultEventVisibility != "" {
+		_, err = h.db.Exec(`
+			UPDATE improv_groups SET default_event_visibility = $1 WHERE id = $2
+		`, groupRequest.DefaultEventVisibility, groupID)
+		if err != nil {
+			fmt.Printf("Error updating group default event visibility: %v\n", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error updating group")
+			return
+		}
+	}
+
+	// Existing events keep their own zone
+	if groupRequest.TimeZone != "" {
+		_, err = h.db.Exec(`
+			UPDATE improv_groups SET time_zone = $1 WHERE id = $2
+		`, groupRequest.TimeZone, groupID)
+		if err != nil {
+			fmt.Printf("Error updating group time zone: %v\n", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error updating group")
+			return
+		}
+	}
+
+	if groupRequest.ConflictPolicy != "" {
+		_, err = h.db.Exec(`
+			UPDATE improv_groups SET conflict_policy = $1 WHERE id = $2
+		`, groupRequest.ConflictPolicy, groupID)
+		if err != nil {
+			fmt.Printf("Error updating group conflict policy: %v\n", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error updating group")
+			return
+		}
+	}
+
+	if groupRequest.WalkInLinking != "" {
+		_, err = h.db.Exec(`
+			UPDATE improv_groups SET walk_in_linking = $1 WHERE id = $2
+		`, groupRequest.WalkInLinking, groupID)
+		if err != nil {
+			fmt.Printf("Error updating group walk-in linking: %v\n", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error updating group")
+			return
+		}
+	}
+
 	// Fetch the updated group
 	var group ImprovGroup
 	err = h.db.QueryRow(`
@@ -558,6 +655,11 @@ func (h *GroupHandler) AddGameToLibrary(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	emitWebhook(h.db, groupID, services.WebhookGameAddedToLibrary, map[string]string{
+		"gameId":  gameID,
+		"addedBy": user.ID,
+	})
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Game added to library successfully",
@@ -819,6 +921,25 @@ func (h *GroupHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Let the member know their role changed
+	if targetUserID != user.ID {
+		var groupName string
+		if err := h.db.QueryRow(`SELECT name FROM improv_groups WHERE id = $1`, groupID).Scan(&groupName); err != nil {
+			fmt.Printf("Error fetching group name for notification: %v\n", err)
+		} else {
+			err = services.NewNotificationService(h.db).Notify(
+				targetUserID,
+				groupID,
+				services.NotificationRoleChanged,
+				fmt.Sprintf("Your role in %s is now %s", groupName, member.Role),
+				groupID,
+			)
+			if err != nil {
+				fmt.Printf("Error creating role change notification: %v\n", err)
+			}
+		}
+	}
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Member role updated successfully",
@@ -985,6 +1106,13 @@ func (h *GroupHandler) CreateInviteLink(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Store the expiry in UTC so it compares correctly whatever offset it arrived with
+	expiresAt, err := time.Parse(time.RFC3339, inviteRequest.ExpiresAt)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid expiry time format")
+		return
+	}
+
 	// Generate a unique code
 	code := uuid.New().String()[:8]
 
@@ -993,7 +1121,7 @@ func (h *GroupHandler) CreateInviteLink(w http.ResponseWriter, r *http.Request)
 	_, err = h.db.Exec(`
 		INSERT INTO group_invite_links (id, group_id, description, code, expires_at, active, created_by)
 		VALUES ($1, $2, $3, $4, $5, true, $6)
-	`, inviteLinkID, groupID, inviteRequest.Description, code, inviteRequest.ExpiresAt, user.ID)
+	`, inviteLinkID, groupID, inviteRequest.Description, code, expiresAt.UTC(), user.ID)
 	if err != nil {
 		fmt.Printf("Error creating invite link: %v\n", err)
 		RespondWithError(w, http.StatusInternalServerError, "Error creating invite link")
@@ -1168,7 +1296,7 @@ func (h *GroupHandler) JoinViaInviteLink(w http.ResponseWriter, r *http.Request)
 	// Find the invite link by code
 	var linkID, groupID string
 	var active bool
-	var expiresAt string
+	var expiresAt time.Time
 	err := h.db.QueryRow(`
 		SELECT id, group_id, active, expires_at
 		FROM group_invite_links
@@ -1192,20 +1320,9 @@ func (h *GroupHandler) JoinViaInviteLink(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Check if the link has expired
-	var expired bool
-	err = h.db.QueryRow(`
-		SELECT expires_at < datetime('now')
-		FROM group_invite_links
-		WHERE id = $1
-	`, linkID).Scan(&expired)
-	if err != nil {
-		fmt.Printf("Error checking link expiration: %v\n", err)
-		RespondWithError(w, http.StatusInternalServerError, "Error checking link expiration")
-		return
-	}
-
-	if expired {
+	// Check if the link has expired. Compared in Go, since SQLite's datetime('now') is
+	// UTC text and expires_at keeps whatever offset it was saved with.
+	if !expiresAt.After(time.Now()) {
 		fmt.Printf("Invite link has expired: %s\n", linkID)
 		RespondWithError(w, http.StatusForbidden, "This invite link has expired")
 		return
@@ -1256,6 +1373,12 @@ func (h *GroupHandler) JoinViaInviteLink(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	emitWebhook(h.db, groupID, services.WebhookMemberJoined, map[string]string{
+		"userId": user.ID,
+		"role":   auth.RoleMember,
+		"via":    "invite_link",
+	})
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: fmt.Sprintf("You have successfully joined %s", group.Name),
@@ -1271,7 +1394,7 @@ func (h *GroupHandler) VerifyInviteLink(w http.ResponseWriter, r *http.Request)
 	// Find the invite link by code
 	var linkID, groupID string
 	var active bool
-	var expiresAt string
+	var expiresAt time.Time
 	err := h.db.QueryRow(`
 		SELECT id, group_id, active, expires_at
 		FROM group_invite_links
@@ -1295,20 +1418,9 @@ func (h *GroupHandler) VerifyInviteLink(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Check if the link has expired
-	var expired bool
-	err = h.db.QueryRow(`
-		SELECT expires_at < datetime('now')
-		FROM group_invite_links
-		WHERE id = $1
-	`, linkID).Scan(&expired)
-	if err != nil {
-		fmt.Printf("Error checking link expiration: %v\n", err)
-		RespondWithError(w, http.StatusInternalServerError, "Error checking link expiration")
-		return
-	}
-
-	if expired {
+	// Check if the link has expired. Compared in Go, since SQLite's datetime('now') is
+	// UTC text and expires_at keeps whatever offset it was saved with.
+	if !expiresAt.After(time.Now()) {
 		fmt.Printf("Invite link has expired: %s\n", linkID)
 		RespondWithError(w, http.StatusForbidden, "This invite link has expired")
 		return
@@ -172,6 +172,20 @@ func (h *InvitationHandler) InviteMember(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Let existing users know in the app as well
+	if userExists {
+		err = services.NewNotificationService(h.db).Notify(
+			userID,
+			groupID,
+			services.NotificationInvitation,
+			fmt.Sprintf("%s invited you to join %s as %s", inviterName, groupName, inviteRequest.Role),
+			groupID,
+		)
+		if err != nil {
+			fmt.Printf("Error creating invitation notification: %v\n", err)
+		}
+	}
+
 	// For now, just respond with success
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
@@ -315,6 +329,12 @@ func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	emitWebhook(h.db, groupID, services.WebhookMemberJoined, map[string]string{
+		"userId": user.ID,
+		"role":   role,
+		"via":    "invitation",
+	})
+
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Successfully joined group",
@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/lineup"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// lineupEvent is the event whose lineup is being planned
+type lineupEvent struct {
+	// id is the route's event ID. For an unsaved occurrence, saveOccurrence gives the ID to write to.
+	id        string
+	groupID   string
+	title     string
+	startTime time.Time
+	endTime   time.Time
+}
+
+// requireLineupEditor responds with an error and returns false unless the user can edit the
+// event's lineup and the event is still going ahead
+func (h *EventHandler) requireLineupEditor(w http.ResponseWriter, r *http.Request, eventID, userID string) (*lineupEvent, bool) {
+	groupID, ok := h.requireEventGroup(w, r, eventID)
+	if !ok {
+		return nil, false
+	}
+
+	allowed, err := h.crewOrOrganizer(r, eventID, groupID, userID, services.PermissionEditLineup)
+	if err != nil {
+		log.Printf("Error checking lineup permissions for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
+		return nil, false
+	}
+	if !allowed {
+		log.Printf("User %s is not authorized to change the lineup for event %s", userID, eventID)
+		RespondWithError(w, http.StatusForbidden, "Only the event MC, crew who can edit the lineup and group organizers can change the lineup")
+		return nil, false
+	}
+	return h.requireOpenLineup(w, r, eventID, groupID)
+}
+
+// requireGameEditor is requireLineupEditor for the event's games, which only its MC and crew
+// who can edit the lineup manage
+func (h *EventHandler) requireGameEditor(w http.ResponseWriter, r *http.Request, eventID, userID string) (*lineupEvent, bool) {
+	groupID, ok := h.requireEventGroup(w, r, eventID)
+	if !ok {
+		return nil, false
+	}
+
+	canEdit, err := h.hasCrewPermission(r, eventID, userID, services.PermissionEditLineup)
+	if err != nil {
+		log.Printf("Error checking crew permissions: %v", err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
+		return nil, false
+	}
+	if !canEdit {
+		log.Printf("User %s can't edit the lineup of event %s", userID, eventID)
+		RespondWithError(w, http.StatusForbidden, "Only the event's MC and crew who can edit the lineup can manage games")
+		return nil, false
+	}
+	return h.requireOpenLineup(w, r, eventID, groupID)
+}
+
+// requireEventGroup responds with an error and returns false unless the event exists. It returns the event's group.
+func (h *EventHandler) requireEventGroup(w http.ResponseWriter, r *http.Request, eventID string) (string, bool) {
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err == sql.ErrNoRows {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return "", false
+	}
+	return groupID, true
+}
+
+// requireOpenLineup responds with an error and returns false once the event is cancelled or over,
+// since its lineup can't change any more. An unsaved occurrence is read without saving it, and
+// is open since it's saved as scheduled.
+func (h *EventHandler) requireOpenLineup(w http.ResponseWriter, r *http.Request, eventID, groupID string) (*lineupEvent, bool) {
+	event := &lineupEvent{id: eventID, groupID: groupID}
+	var eventStatus string
+	if occurrence := unsavedOccurrence(r); occurrence != nil {
+		event.title, event.startTime, event.endTime = occurrence.Title, occurrence.StartTime, occurrence.EndTime
+		eventStatus = services.EventStatusScheduled
+	} else {
+		err := h.db.QueryRow(`
+			SELECT title, status, start_time, end_time FROM events WHERE id = $1
+		`, eventID).Scan(&event.title, &eventStatus, &event.startTime, &event.endTime)
+		if err == sql.ErrNoRows {
+			RespondWithError(w, http.StatusNotFound, "Event not found")
+			return nil, false
+		}
+		if err != nil {
+			log.Printf("Error fetching event %s: %v", eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+			return nil, false
+		}
+	}
+	if eventStatus == services.EventStatusCancelled || eventStatus == services.EventStatusCompleted {
+		RespondWithError(w, http.StatusConflict, "The lineup can't be changed once an event is "+eventStatus)
+		return nil, false
+	}
+	return event, true
+}
+
+// GenerateLineup proposes players for every game in the lineup from their game preferences,
+// keeping everyone's number of games even. Nothing changes until the proposal is accepted.
+func (h *EventHandler) GenerateLineup(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	if _, ok := h.requireLineupEditor(w, r, eventID, user.ID); !ok {
+		return
+	}
+
+	// An occurrence that hasn't been saved has no games or RSVPs to cast from yet
+	if unsavedOccurrence(r) != nil {
+		RespondWithJSON(w, http.StatusOK, ApiResponse{
+			Success: true,
+			Data:    services.EmptyLineupProposal(eventID),
+		})
+		return
+	}
+
+	proposal, err := services.NewLineupService(h.db).Generate(eventID)
+	if err != nil {
+		log.Printf("Error generating lineup for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error generating lineup")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    proposal,
+	})
+}
+
+// AcceptLineup replaces every player assignment in the event with a generated proposal's,
+// all at once. It fails if the assignments changed after the proposal was generated.
+func (h *EventHandler) AcceptLineup(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	var request struct {
+		Version     string              `json:"version"`
+		Assignments []lineup.Assignment `json:"assignments"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding lineup proposal: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	event, ok := h.requireLineupEditor(w, r, eventID, user.ID)
+	if !ok {
+		return
+	}
+
+	// Cast players may be in another group's show at the same time
+	performers := make([]string, len(request.Assignments))
+	for i, assignment := range request.Assignments {
+		performers[i] = assignment.PlayerID
+	}
+	warnings, ok := h.checkConflicts(w, services.EventSlot{
+		EventID:    eventID,
+		GroupID:    event.groupID,
+		StartTime:  event.startTime,
+		EndTime:    event.endTime,
+		Performers: performers,
+		ViewerID:   user.ID,
+	}, true)
+	if !ok {
+		return
+	}
+
+	eventID, ok = saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+	added, err := services.NewLineupService(h.db).Accept(eventID, request.Version, request.Assignments)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrEventNotFound):
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	case errors.Is(err, services.ErrLineupChanged):
+		RespondWithError(w, http.StatusConflict, "The lineup has changed since this proposal was generated")
+		return
+	case errors.Is(err, services.ErrInvalidLineup):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	default:
+		log.Printf("Error accepting lineup for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error accepting lineup")
+		return
+	}
+
+	// Walk-in attendees don't have accounts to notify
+	for _, assignment := range added {
+		if !assignment.IsWalkIn && assignment.UserID != user.ID {
+			h.notifyGameAssignment(assignment.UserID, event.groupID, eventID, assignment.GameID, "You've been assigned to %s in %s")
+		}
+	}
+
+	emitWebhook(h.db, event.groupID, services.WebhookLineupChanged, map[string]string{
+		"eventId": eventID,
+		"change":  "proposal_accepted",
+	})
+	publishEventUpdate(eventID, EventUpdateLineupAccepted, user.ID, map[string]interface{}{
+		"assignments": len(request.Assignments),
+	})
+
+	violations, err := services.NewLineupService(h.db).Violations(eventID)
+	if err != nil {
+		log.Printf("Error checking lineup constraints for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking lineup constraints")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success:    true,
+		Message:    "Lineup accepted",
+		Data:       map[string]interface{}{"added": added},
+		Warnings:   warnings,
+		Violations: violations,
+	})
+}
+
+// GetLineupConstraints lists the event's casting rules, with the ones the lineup breaks
+func (h *EventHandler) GetLineupConstraints(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err == sql.ErrNoRows {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return
+	}
+
+	allowed, err := h.crewOrOrganizer(r, eventID, groupID, user.ID, services.PermissionViewLineup)
+	if err != nil {
+		log.Printf("Error checking lineup permissions for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
+		return
+	}
+	if !allowed {
+		log.Printf("User %s is not authorized to view lineup constraints for event %s", user.ID, eventID)
+		RespondWithError(w, http.StatusForbidden, "Only the event MC, crew who can see the lineup and group organizers can view lineup constraints")
+		return
+	}
+
+	// An occurrence that hasn't been saved has no lineup to check yet
+	if unsavedOccurrence(r) != nil {
+		RespondWithJSON(w, http.StatusOK, ApiResponse{
+			Success:    true,
+			Data:       []lineup.Constraint{},
+			Violations: []lineup.Violation{},
+		})
+		return
+	}
+
+	lineupService := services.NewLineupService(h.db)
+	constraints, err := lineupService.Constraints(eventID)
+	if err != nil {
+		log.Printf("Error fetching lineup constraints for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching lineup constraints")
+		return
+	}
+	violations, err := lineupService.Violations(eventID)
+	if err != nil {
+		log.Printf("Error checking lineup constraints for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking lineup constraints")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success:    true,
+		Data:       constraints,
+		Violations: violations,
+	})
+}
+
+// respondLineupConstraint reports the outcome of changing a casting rule, with the rules the lineup now breaks
+func (h *EventHandler) respondLineupConstraint(w http.ResponseWriter, eventID, userID, message string, constraint *lineup.Constraint, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrEventNotFound):
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	case errors.Is(err, services.ErrConstraintNotFound):
+		RespondWithError(w, http.StatusNotFound, "Lineup constraint not found")
+		return
+	case errors.Is(err, lineup.ErrInvalidConstraint):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	default:
+		log.Printf("Error saving lineup constraint for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error saving lineup constraint")
+		return
+	}
+
+	violations, err := services.NewLineupService(h.db).Violations(eventID)
+	if err != nil {
+		log.Printf("Error checking lineup constraints for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking lineup constraints")
+		return
+	}
+	publishEventUpdate(eventID, EventUpdateLineupConstraintsChanged, userID, map[string]interface{}{
+		"violations": len(violations),
+	})
+
+	response := ApiResponse{
+		Success:    true,
+		Message:    message,
+		Violations: violations,
+	}
+	if constraint != nil {
+		response.Data = constraint
+	}
+	RespondWithJSON(w, http.StatusOK, response)
+}
+
+// CreateLineupConstraint adds a casting rule to the event
+func (h *EventHandler) CreateLineupConstraint(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	var request lineup.Constraint
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding lineup constraint: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if _, ok := h.requireLineupEditor(w, r, eventID, user.ID); !ok {
+		return
+	}
+	if err := request.Validate(); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	request.ID = ""
+	constraint, err := services.NewLineupService(h.db).SaveConstraint(eventID, request, user.ID)
+	h.respondLineupConstraint(w, eventID, user.ID, "Lineup constraint added", constraint, err)
+}
+
+// UpdateLineupConstraint replaces one of the event's casting rules
+func (h *EventHandler) UpdateLineupConstraint(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+
+	var request lineup.Constraint
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding lineup constraint: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if _, ok := h.requireLineupEditor(w, r, eventID, user.ID); !ok {
+		return
+	}
+	// An occurrence that hasn't been saved has no casting rules to change
+	if unsavedOccurrence(r) != nil {
+		RespondWithError(w, http.StatusNotFound, "Lineup constraint not found")
+		return
+	}
+
+	request.ID = vars["constraintId"]
+	constraint, err := services.NewLineupService(h.db).SaveConstraint(eventID, request, user.ID)
+	h.respondLineupConstraint(w, eventID, user.ID, "Lineup constraint updated", constraint, err)
+}
+
+// DeleteLineupConstraint removes one of the event's casting rules
+func (h *EventHandler) DeleteLineupConstraint(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+
+	if _, ok := h.requireLineupEditor(w, r, eventID, user.ID); !ok {
+		return
+	}
+	if unsavedOccurrence(r) != nil {
+		RespondWithError(w, http.StatusNotFound, "Lineup constraint not found")
+		return
+	}
+
+	err := services.NewLineupService(h.db).DeleteConstraint(eventID, vars["constraintId"])
+	h.respondLineupConstraint(w, eventID, user.ID, "Lineup constraint deleted", nil, err)
+}
+
+// GetEventHealth scores the event's assignments against its players' game preferences: each
+// player's happiness and problems, an overall score and suggested changes
+func (h *EventHandler) GetEventHealth(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err == sql.ErrNoRows {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return
+	}
+
+	allowed, err := h.crewOrOrganizer(r, eventID, groupID, user.ID, services.PermissionViewLineup)
+	if err != nil {
+		log.Printf("Error checking lineup permissions for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
+		return
+	}
+	if !allowed {
+		log.Printf("User %s is not authorized to view lineup health for event %s", user.ID, eventID)
+		RespondWithError(w, http.StatusForbidden, "Only the event MC, crew who can see the lineup and group organizers can view lineup health")
+		return
+	}
+
+	// An occurrence that hasn't been saved has no lineup to analyze yet
+	if unsavedOccurrence(r) != nil {
+		RespondWithJSON(w, http.StatusOK, ApiResponse{
+			Success: true,
+			Data: &services.LineupHealth{
+				EventID:      eventID,
+				OverallScore: lineup.OverallScore(nil),
+				Players:      []lineup.PlayerHealth{},
+				Suggestions:  []string{},
+			},
+		})
+		return
+	}
+
+	health, err := services.NewLineupService(h.db).Health(eventID)
+	if err != nil {
+		log.Printf("Error analyzing lineup health for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error analyzing lineup health")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    health,
+	})
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"improv-app/internal/lineup"
+)
+
+// showLineup returns show1's games with their places in the lineup, in order
+func showLineup(t *testing.T, testDB *sql.DB) string {
+	t.Helper()
+	var games string
+	err := testDB.QueryRow(`
+		SELECT COALESCE(GROUP_CONCAT(game_id || ':' || order_index), '')
+		FROM (SELECT * FROM event_games WHERE event_id = 'show1' ORDER BY order_index)
+	`).Scan(&games)
+	if err != nil {
+		t.Fatalf("Error fetching lineup: %v", err)
+	}
+	return games
+}
+
+func TestLineup_Games(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	h := NewEventHandler(testDB)
+
+	addGame := func(userID, gameID string) int {
+		recorder, _ := serve(t, testDB, userID, "/events/{id}/games", h.AddGameToEvent, http.MethodPost, "/events/show1/games",
+			map[string]string{"gameId": gameID})
+		return recorder.Code
+	}
+	if code := addGame("dana", "game3"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a member who isn't the MC, got %d", code)
+	}
+	if code := addGame("user123", "game3"); code != http.StatusOK {
+		t.Fatalf("Expected 200 adding a game, got %d", code)
+	}
+	if code := addGame("user123", "game3"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 adding a game twice, got %d", code)
+	}
+	if code := addGame("user123", "nope"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a game outside the library, got %d", code)
+	}
+	if got := showLineup(t, testDB); got != "game1:0,game2:1,game3:2" {
+		t.Errorf("Expected the game added last, got %s", got)
+	}
+
+	moveGame := func(gameID string, orderIndex int) int {
+		recorder, _ := serve(t, testDB, "user123", "/events/{id}/games/{gameId}/order", h.UpdateGameOrder, http.MethodPut, "/events/show1/games/"+gameID+"/order",
+			map[string]int{"orderIndex": orderIndex})
+		return recorder.Code
+	}
+	if code := moveGame("game3", 0); code != http.StatusOK {
+		t.Fatalf("Expected 200 moving a game, got %d", code)
+	}
+	if got := showLineup(t, testDB); got != "game3:0,game2:1,game1:2" {
+		t.Errorf("Expected the game swapped with the first, got %s", got)
+	}
+	if code := moveGame("game3", 3); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a place past the end, got %d", code)
+	}
+
+	recorder, _ := serve(t, testDB, "user123", "/events/{id}/games/{gameId}", h.RemoveGameFromEvent, http.MethodDelete, "/events/show1/games/game2", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 removing a game, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if got := showLineup(t, testDB); got != "game3:0,game1:1" {
+		t.Errorf("Expected the lineup closed up after the removal, got %s", got)
+	}
+
+	// Cancelled events keep the lineup they had
+	seed(t, testDB, `UPDATE events SET status = 'cancelled' WHERE id = 'show1'`)
+	if code := addGame("user123", "game2"); code != http.StatusConflict {
+		t.Errorf("Expected 409 changing a cancelled event's lineup, got %d", code)
+	}
+}
+
+func TestLineup_Players(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	h := NewEventHandler(testDB)
+
+	assign := func(gameID, userID string) int {
+		recorder, _ := serve(t, testDB, "user123", "/events/{id}/games/{gameId}/players", h.AssignPlayerToGame, http.MethodPost, "/events/show1/games/"+gameID+"/players",
+			map[string]string{"userId": userID})
+		return recorder.Code
+	}
+	if code := assign("game1", "dana"); code != http.StatusOK {
+		t.Fatalf("Expected 200 assigning, got %d", code)
+	}
+	tests := []struct {
+		name           string
+		gameID, userID string
+	}{
+		{"twice", "game1", "dana"},
+		{"to a game outside the lineup", "game3", "dana"},
+		{"someone outside the group", "game1", "outsider"},
+		{"nobody", "game1", "nope"},
+	}
+	for _, tt := range tests {
+		if code := assign(tt.gameID, tt.userID); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 assigning %s, got %d", tt.name, code)
+		}
+	}
+
+	// Only the MC, crew and organizers see the cast
+	recorder, _ := serve(t, testDB, "dana", "/events/{id}/players", h.GetEventPlayers, http.MethodGet, "/events/show1/players", nil)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a member, got %d", recorder.Code)
+	}
+	recorder, response := serve(t, testDB, "user123", "/events/{id}/players", h.GetEventPlayers, http.MethodGet, "/events/show1/players", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	want := []interface{}{map[string]interface{}{"userId": "dana", "gameId": "game1", "eventId": "show1", "name": "Dana Director", "isWalkIn": false}}
+	if !reflect.DeepEqual(response.Data, want) {
+		t.Errorf("Expected %v, got %v", want, response.Data)
+	}
+
+	remove := func() int {
+		recorder, _ := serve(t, testDB, "user123", "/events/{id}/games/{gameId}/players/{userId}", h.RemovePlayerFromGame, http.MethodDelete, "/events/show1/games/game1/players/dana", nil)
+		return recorder.Code
+	}
+	if code := remove(); code != http.StatusOK {
+		t.Fatalf("Expected 200 removing, got %d", code)
+	}
+	if code := remove(); code != http.StatusNotFound {
+		t.Errorf("Expected 404 removing again, got %d", code)
+	}
+}
+
+func TestLineup_Finalize(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	seed(t, testDB, `INSERT INTO events (id, group_id, title, start_time, end_time, created_by, mc_id)
+		VALUES ('show2', 'group123', 'Saturday Show', '2026-02-07 19:00:00', '2026-02-07 21:00:00', 'user123', 'user123')`)
+	h := NewEventHandler(testDB)
+
+	finalize := func(userID, eventID string) int {
+		recorder, _ := serve(t, testDB, userID, "/events/{id}/lineup/finalize", h.FinalizeLineup, http.MethodPost, "/events/"+eventID+"/lineup/finalize", nil)
+		return recorder.Code
+	}
+	if code := finalize("dana", "show1"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a member, got %d", code)
+	}
+	if code := finalize("user123", "show2"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 finalizing an empty lineup, got %d", code)
+	}
+	if code := finalize("user123", "show1"); code != http.StatusOK {
+		t.Fatalf("Expected 200 finalizing, got %d", code)
+	}
+
+	var finalized bool
+	if err := testDB.QueryRow(`SELECT lineup_finalized_at IS NOT NULL FROM events WHERE id = 'show1'`).Scan(&finalized); err != nil || !finalized {
+		t.Errorf("Expected the lineup marked finalized, got %v (%v)", finalized, err)
+	}
+	// The cast is told their lineup is set
+	if got := notificationsFor(t, testDB, "dana"); len(got) != 1 || !strings.Contains(got[0], "Friday Show") {
+		t.Errorf("Expected Dana told about the lineup, got %q", got)
+	}
+}
+
+func TestLineup_Preferences(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	seed(t, testDB, `INSERT INTO user_game_preferences (user_id, game_id, status) VALUES
+		('dana', 'game1', '`+lineup.StatusLove+`'), ('dana', 'game3', '`+lineup.StatusLove+`'), ('user123', 'game2', '`+lineup.StatusDislike+`')`)
+	h := NewEventHandler(testDB)
+
+	preferences := func(userID, query string) (int, interface{}) {
+		recorder, response := serve(t, testDB, userID, "/events/{id}/preferences", h.GetUserGamePreferences, http.MethodGet, "/events/show1/preferences"+query, nil)
+		return recorder.Code, response.Data
+	}
+	if code, _ := preferences("dana", ""); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a member, got %d", code)
+	}
+
+	// Only games in the lineup are included, and the games parameter narrows them down
+	code, data := preferences("user123", "")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	got := map[string]string{}
+	for _, preference := range data.([]interface{}) {
+		preference := preference.(map[string]interface{})
+		got[preference["userId"].(string)+":"+preference["gameId"].(string)] = preference["status"].(string)
+	}
+	want := map[string]string{"dana:game1": lineup.StatusLove, "user123:game2": lineup.StatusDislike}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	_, data = preferences("user123", "?games=game2")
+	if preferences := data.([]interface{}); len(preferences) != 1 || preferences[0].(map[string]interface{})["userId"] != "user123" {
+		t.Errorf("Expected only Ada's game2 preference, got %v", preferences)
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+)
+
+// NotificationPreferenceHandler handles reading and updating notification preferences
+type NotificationPreferenceHandler struct {
+	db *sql.DB
+}
+
+// NewNotificationPreferenceHandler creates a new NotificationPreferenceHandler
+func NewNotificationPreferenceHandler(db *sql.DB) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{
+		db: db,
+	}
+}
+
+// NotificationPreferenceGroup is a group the user can set preferences for
+type NotificationPreferenceGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Get returns the user's stored preferences along with the available types, channels and groups.
+// Anything without a stored preference falls back to the type's default.
+func (h *NotificationPreferenceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+
+	preferences, err := services.NewNotificationPreferenceService(h.db).List(user.ID)
+	if err != nil {
+		log.Printf("Error fetching notification preferences for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching notification preferences")
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT g.id, g.name
+		FROM improv_groups g
+		JOIN group_members m ON g.id = m.group_id
+		WHERE m.user_id = $1
+		ORDER BY g.name
+	`, user.ID)
+	if err != nil {
+		log.Printf("Error fetching groups for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching groups")
+		return
+	}
+	defer rows.Close()
+
+	groups := []NotificationPreferenceGroup{}
+	for rows.Next() {
+		var group NotificationPreferenceGroup
+		if err := rows.Scan(&group.ID, &group.Name); err != nil {
+			log.Printf("Error scanning group: %v", err)
+			RespondWithError(w, http.StatusInternalServerError, "Error fetching groups")
+			return
+		}
+		groups = append(groups, group)
+	}
+
+	defaults := make(map[string]bool)
+	for _, notificationType := range services.NotificationTypes {
+		defaults[notificationType] = services.IsEnabledByDefault(notificationType)
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"notificationTypes": services.NotificationTypes,
+			"defaults":          defaults,
+			"channels":          services.NotificationChannels,
+			"groups":            groups,
+			"preferences":       preferences,
+		},
+	})
+}
+
+// Update stores a batch of preferences. An empty groupId applies to all groups,
+// and a null enabled value clears the preference so it falls back to the default.
+func (h *NotificationPreferenceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+
+	var request struct {
+		Preferences []struct {
+			GroupID          string `json:"groupId"`
+			NotificationType string `json:"notificationType"`
+			Channel          string `json:"channel"`
+			Enabled          *bool  `json:"enabled"`
+		} `json:"preferences"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		log.Printf("Error decoding notification preferences request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	// Validate everything before writing anything
+	for _, preference := range request.Preferences {
+		if !services.IsValidNotificationType(preference.NotificationType) {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid notification type: %s", preference.NotificationType))
+			return
+		}
+		if !services.IsValidNotificationChannel(preference.Channel) {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid channel: %s", preference.Channel))
+			return
+		}
+		if preference.GroupID != "" {
+			var isMember bool
+			err := h.db.QueryRow(`
+				SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2)
+			`, preference.GroupID, user.ID).Scan(&isMember)
+			if err != nil {
+				log.Printf("Error checking group membership: %v", err)
+				RespondWithError(w, http.StatusInternalServerError, "Error checking group membership")
+				return
+			}
+			if !isMember {
+				RespondWithError(w, http.StatusForbidden, "Not a member of this group")
+				return
+			}
+		}
+	}
+
+	preferenceService := services.NewNotificationPreferenceService(h.db)
+	for _, preference := range request.Preferences {
+		var err error
+		if preference.Enabled == nil {
+			err = preferenceService.Clear(user.ID, preference.GroupID, preference.NotificationType, preference.Channel)
+		} else {
+			err = preferenceService.Set(user.ID, preference.GroupID, preference.NotificationType, preference.Channel, *preference.Enabled)
+		}
+		if err != nil {
+			log.Printf("Error updating notification preference for user %s: %v", user.ID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error updating notification preferences")
+			return
+		}
+	}
+
+	preferences, err := preferenceService.List(user.ID)
+	if err != nil {
+		log.Printf("Error fetching notification preferences for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching notification preferences")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Notification preferences updated",
+		Data:    preferences,
+	})
+}
+
+// UnsubscribePage is where a member clicking a signed unsubscribe link lands. It only asks them
+// to confirm: link scanners and mail prefetchers follow links too, so GET changes nothing.
+func (h *NotificationPreferenceHandler) UnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	if _, err := services.ParseUnsubscribeToken(r.URL.Query().Get("token")); err != nil {
+		log.Printf("Invalid unsubscribe token: %v", err)
+		writeUnsubscribePage(w, http.StatusBadRequest, "This unsubscribe link is invalid.", "")
+		return
+	}
+	writeUnsubscribePage(w, http.StatusOK, "Do you want to stop receiving these emails?", r.URL.RequestURI())
+}
+
+// Unsubscribe turns off the preference named in a signed unsubscribe link. It's the one-click
+// unsubscribe mail clients send, and the form on the unsubscribe page, which gets a page back.
+func (h *NotificationPreferenceHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	fromPage := r.FormValue("confirm") != ""
+
+	claims, err := services.ParseUnsubscribeToken(r.URL.Query().Get("token"))
+	if err != nil {
+		log.Printf("Invalid unsubscribe token: %v", err)
+		if fromPage {
+			writeUnsubscribePage(w, http.StatusBadRequest, "This unsubscribe link is invalid.", "")
+			return
+		}
+		RespondWithError(w, http.StatusBadRequest, "Invalid unsubscribe token")
+		return
+	}
+
+	err = services.NewNotificationPreferenceService(h.db).Set(claims.UserID, claims.GroupID, claims.NotificationType, claims.Channel, false)
+	if err != nil {
+		log.Printf("Error unsubscribing user %s: %v", claims.UserID, err)
+		if fromPage {
+			writeUnsubscribePage(w, http.StatusInternalServerError, "Something went wrong. Please try again later.", "")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Error updating notification preferences")
+		return
+	}
+	log.Printf("User %s unsubscribed from %s %s notifications (group %q)", claims.UserID, claims.NotificationType, claims.Channel, claims.GroupID)
+
+	if fromPage {
+		writeUnsubscribePage(w, http.StatusOK, "You've been unsubscribed. You can change this any time from your notification settings.", "")
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Unsubscribed",
+	})
+}
+
+// writeUnsubscribePage shows the message, with a button posting to confirmURL when there's something to confirm
+func writeUnsubscribePage(w http.ResponseWriter, statusCode int, message, confirmURL string) {
+	form := ""
+	if confirmURL != "" {
+		form = fmt.Sprintf(`<form method="post" action="%s"><input type="hidden" name="confirm" value="1"><button type="submit">Unsubscribe</button></form>`, html.EscapeString(confirmURL))
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "<!DOCTYPE html><html><head><title>Unsubscribe</title></head><body><p>%s</p>%s</body></html>", html.EscapeString(message), form)
+}
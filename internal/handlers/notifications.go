@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// NotificationHandler handles the in-app notification center
+type NotificationHandler struct {
+	db *sql.DB
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(db *sql.DB) *NotificationHandler {
+	return &NotificationHandler{
+		db: db,
+	}
+}
+
+// List returns the current user's notifications, newest first.
+// Pass unread=true to only return unread notifications.
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	queryParams := r.URL.Query()
+
+	page := 1
+	if parsedPage, err := strconv.Atoi(queryParams.Get("page")); err == nil && parsedPage > 0 {
+		page = parsedPage
+	}
+	pageSize := defaultNotificationPageSize
+	if parsedPageSize, err := strconv.Atoi(queryParams.Get("pageSize")); err == nil && parsedPageSize > 0 {
+		pageSize = parsedPageSize
+	}
+	if pageSize > maxNotificationPageSize {
+		pageSize = maxNotificationPageSize
+	}
+	unreadOnly := queryParams.Get("unread") == "true"
+
+	notifications, totalItems, err := services.NewNotificationService(h.db).List(user.ID, unreadOnly, page, pageSize)
+	if err != nil {
+		log.Printf("Error fetching notifications for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching notifications")
+		return
+	}
+
+	totalPages := (totalItems + pageSize - 1) / pageSize
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    notifications,
+		Pagination: &PaginationMetadata{
+			Page:       page,
+			PageSize:   pageSize,
+			TotalItems: totalItems,
+			TotalPages: totalPages,
+		},
+	})
+}
+
+// UnreadCount returns how many unread notifications the current user has
+func (h *NotificationHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+
+	count, err := services.NewNotificationService(h.db).UnreadCount(user.ID)
+	if err != nil {
+		log.Printf("Error counting unread notifications for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error counting notifications")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data: map[string]int{
+			"count": count,
+		},
+	})
+}
+
+// MarkRead marks a single notification as read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+
+	notificationID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	found, err := services.NewNotificationService(h.db).MarkRead(user.ID, notificationID)
+	if err != nil {
+		log.Printf("Error marking notification %d read for user %s: %v", notificationID, user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating notification")
+		return
+	}
+	if !found {
+		RespondWithError(w, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Notification marked as read",
+	})
+}
+
+// MarkAllRead marks all of the current user's notifications as read
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+
+	updated, err := services.NewNotificationService(h.db).MarkAllRead(user.ID)
+	if err != nil {
+		log.Printf("Error marking notifications read for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating notifications")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "All notifications marked as read",
+		Data: map[string]int64{
+			"updated": updated,
+		},
+	})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"improv-app/internal/services"
+)
+
+func TestNotifications_Invitation(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers)
+	h := NewInvitationHandler(testDB)
+
+	// With the email turned off nothing is sent, but the invitation still shows up in the app
+	if err := services.NewNotificationPreferenceService(testDB).Set("outsider", "", services.NotificationInvitation, services.ChannelEmail, false); err != nil {
+		t.Fatalf("Error setting preference: %v", err)
+	}
+	recorder, _ := serve(t, testDB, "user123", "/groups/{id}/members/invite", h.InviteMember, http.MethodPost, "/groups/group123/members/invite",
+		map[string]string{"email": "outsider@example.com", "role": "member"})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	want := []string{"Ada Admin invited you to join Test Group as member"}
+	if got := notificationsFor(t, testDB, "outsider"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestNotifications_RoleChanged(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers)
+	h := NewGroupHandler(testDB)
+
+	recorder, _ := serve(t, testDB, "user123", "/groups/{id}/members/{userId}", h.UpdateMemberRole, http.MethodPut, "/groups/group123/members/dana",
+		map[string]string{"role": "organizer"})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	want := []string{"Your role in Test Group is now organizer"}
+	if got := notificationsFor(t, testDB, "dana"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestNotifications_GameAssignment(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	h := NewEventHandler(testDB)
+
+	recorder, _ := serve(t, testDB, "user123", "/events/{id}/games/{gameId}/players", h.AssignPlayerToGame, http.MethodPost, "/events/show1/games/game1/players",
+		map[string]string{"userId": "dana"})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 assigning, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	recorder, _ = serve(t, testDB, "user123", "/events/{id}/games/{gameId}/players/{userId}", h.RemovePlayerFromGame, http.MethodDelete, "/events/show1/games/game1/players/dana", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 removing, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	// Nobody is told about assigning themself
+	recorder, _ = serve(t, testDB, "user123", "/events/{id}/games/{gameId}/players", h.AssignPlayerToGame, http.MethodPost, "/events/show1/games/game1/players",
+		map[string]string{"userId": "user123"})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 assigning, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	want := []string{"You've been assigned to Freeze Tag in Friday Show", "You've been removed from Freeze Tag in Friday Show"}
+	if got := notificationsFor(t, testDB, "dana"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := notificationsFor(t, testDB, "user123"); len(got) != 0 {
+		t.Errorf("Expected no notifications for Ada, got %q", got)
+	}
+}
+
+func TestNotifications_EventChanged(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedMembers, seedShow)
+	h := NewEventHandler(testDB)
+
+	update := map[string]string{"title": "Friday Show", "location": "Main Stage", "startTime": "2026-02-06T19:00:00Z", "endTime": "2026-02-06T21:00:00Z"}
+	// Only changes that affect people's plans are announced
+	update["description"] = "Now with snacks"
+	recorder, _ := serve(t, testDB, "user123", "/events/{id}", h.Update, http.MethodPut, "/events/show1", update)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	update["title"] = "Saturday Show"
+	update["startTime"], update["endTime"] = "2026-02-07T19:00:00Z", "2026-02-07T21:00:00Z"
+	recorder, _ = serve(t, testDB, "user123", "/events/{id}", h.Update, http.MethodPut, "/events/show1", update)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	want := []string{"Friday Show was renamed to Saturday Show, moved to Sat Feb 7 at 7:00 PM UTC"}
+	if got := notificationsFor(t, testDB, "dana"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := notificationsFor(t, testDB, "user123"); len(got) != 0 {
+		t.Errorf("Expected no notifications for Ada, who made the change, got %q", got)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// PublicEventHandler serves public events to people who aren't signed in
+type PublicEventHandler struct {
+	db *sql.DB
+}
+
+// NewPublicEventHandler creates a new PublicEventHandler
+func NewPublicEventHandler(db *sql.DB) *PublicEventHandler {
+	return &PublicEventHandler{
+		db: db,
+	}
+}
+
+// List returns upcoming public events, optionally for one group with the groupId query parameter.
+// The from and to query parameters change the window, which starts now by default.
+func (h *PublicEventHandler) List(w http.ResponseWriter, r *http.Request) {
+	from, to, err := occurrenceWindow(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Get("from") == "" {
+		from = time.Now()
+	}
+
+	events, err := services.NewPublicEventService(h.db).List(r.URL.Query().Get("groupId"), from, to)
+	if err != nil {
+		log.Printf("Error fetching public events: %v", err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching events")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    events,
+	})
+}
+
+// Get returns a public event
+func (h *PublicEventHandler) Get(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["id"]
+
+	event, err := services.NewPublicEventService(h.db).Get(eventID)
+	if err != nil {
+		log.Printf("Error fetching public event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return
+	}
+	if event == nil {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    event,
+	})
+}
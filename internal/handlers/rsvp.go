@@ -3,11 +3,14 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 
+	"improv-app/internal/auth"
 	"improv-app/internal/middleware"
 	"improv-app/internal/models"
+	"improv-app/internal/services"
 
 	"github.com/gorilla/mux"
 )
@@ -51,11 +54,7 @@ func (h *RSVPHandler) SubmitRSVP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify the event exists and user has access
-	var groupID string
-	err := h.db.QueryRow(`
-		SELECT group_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID)
+	groupID, err := eventGroupID(h.db, r, eventID)
 	if err != nil {
 		log.Printf("Error verifying event %s exists: %v", eventID, err)
 		RespondWithError(w, http.StatusNotFound, "Event not found")
@@ -81,50 +80,57 @@ func (h *RSVPHandler) SubmitRSVP(w http.ResponseWriter, r *http.Request) {
 		RespondWithError(w, http.StatusForbidden, "You must be a member of the group to RSVP")
 		return
 	}
+	// A past occurrence isn't saved just to turn the RSVP away
+	if occurrence := unsavedOccurrence(r); occurrence != nil && occurrence.Status != services.EventStatusScheduled {
+		RespondWithError(w, http.StatusConflict, rsvpClosedMessage(occurrence.Status))
+		return
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
 
-	// Check if RSVP already exists for this user and event
-	var exists bool
+	var eventStatus string
 	err = h.db.QueryRow(`
-		SELECT EXISTS(
-			SELECT 1 FROM event_rsvps
-			WHERE event_id = $1 AND user_id = $2
-		)
-	`, eventID, user.ID).Scan(&exists)
+		SELECT status FROM events
+		WHERE id = $1
+	`, eventID).Scan(&eventStatus)
 	if err != nil {
-		log.Printf("Error checking if RSVP exists: %v", err)
-		RespondWithError(w, http.StatusInternalServerError, "Error checking RSVP status")
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return
+	}
+	if eventStatus != services.EventStatusScheduled {
+		RespondWithError(w, http.StatusConflict, rsvpClosedMessage(eventStatus))
 		return
 	}
 
-	// Insert or update RSVP
-	if exists {
-		// Update existing RSVP
-		_, err = h.db.Exec(`
-			UPDATE event_rsvps
-			SET status = $1
-			WHERE event_id = $2 AND user_id = $3
-		`, request.Status, eventID, user.ID)
-		if err != nil {
-			log.Printf("Error updating RSVP for user %s, event %s: %v", user.ID, eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error updating RSVP")
-			return
-		}
-	} else {
-		// Insert new RSVP
-		_, err = h.db.Exec(`
-			INSERT INTO event_rsvps (event_id, user_id, status)
-			VALUES ($1, $2, $3)
-		`, eventID, user.ID, request.Status)
-		if err != nil {
-			log.Printf("Error creating RSVP for user %s, event %s: %v", user.ID, eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error creating RSVP")
-			return
-		}
+	change, err := services.NewRSVPService(h.db).Set(eventID, user.ID, request.Status, false)
+	if err != nil {
+		log.Printf("Error saving RSVP for user %s, event %s: %v", user.ID, eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error saving RSVP")
+		return
 	}
 
+	emitWebhook(h.db, groupID, services.WebhookRSVPChanged, map[string]string{
+		"eventId": eventID,
+		"userId":  user.ID,
+		"status":  change.Status,
+	})
+	publishEventUpdate(eventID, EventUpdateRSVPChanged, user.ID, map[string]string{
+		"userId": user.ID,
+		"status": change.Status,
+	})
+	announcePromotions(h.db, eventID, groupID, change.Promoted)
+
+	message := "RSVP submitted successfully"
+	if change.Status == services.RSVPWaitlisted {
+		message = "This event is full, so you've been added to the waitlist"
+	}
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
-		Message: "RSVP submitted successfully",
+		Message: message,
+		Data:    change,
 	})
 }
 
@@ -157,17 +163,29 @@ func (h *RSVPHandler) GetCurrentUserRSVP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	var waitlistPosition int
+	if status.String == services.RSVPWaitlisted {
+		waitlistPosition, err = services.NewRSVPService(h.db).WaitlistPosition(eventID, user.ID)
+		if err != nil {
+			log.Printf("Error fetching waitlist position for user %s, event %s: %v", user.ID, eventID, err)
+			RespondWithError(w, http.StatusInternalServerError, "Error retrieving RSVP status")
+			return
+		}
+	}
+
 	// RSVP found, return the status
 	rsvp := struct {
-		UserID    string `json:"userId"`
-		FirstName string `json:"firstName"`
-		LastName  string `json:"lastName"`
-		Status    string `json:"status"`
+		UserID           string `json:"userId"`
+		FirstName        string `json:"firstName"`
+		LastName         string `json:"lastName"`
+		Status           string `json:"status"`
+		WaitlistPosition int    `json:"waitlistPosition,omitempty"`
 	}{
-		UserID:    user.ID,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Status:    status.String,
+		UserID:           user.ID,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		Status:           status.String,
+		WaitlistPosition: waitlistPosition,
 	}
 
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
@@ -197,18 +215,14 @@ func (h *RSVPHandler) UpdateUserRSVP(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Validate status
-	if request.Status != "attending" && request.Status != "maybe" && request.Status != "declined" && request.Status != "awaiting-response" {
+	if request.Status != "attending" && request.Status != "maybe" && request.Status != "declined" && request.Status != "awaiting-response" && request.Status != "waitlisted" {
 		log.Printf("Invalid RSVP status: %s", request.Status)
-		RespondWithError(w, http.StatusBadRequest, "Invalid RSVP status. Must be 'attending', 'maybe', 'declined', or 'awaiting-response'")
+		RespondWithError(w, http.StatusBadRequest, "Invalid RSVP status. Must be 'attending', 'maybe', 'declined', 'awaiting-response', or 'waitlisted'")
 		return
 	}
 
 	// Get the event's group ID
-	var groupID string
-	err := h.db.QueryRow(`
-		SELECT group_id FROM events
-		WHERE id = $1
-	`, eventID).Scan(&groupID)
+	groupID, err := eventGroupID(h.db, r, eventID)
 	if err != nil {
 		log.Printf("Error fetching event %s group ID: %v", eventID, err)
 		RespondWithError(w, http.StatusNotFound, "Event not found")
@@ -228,11 +242,31 @@ func (h *RSVPHandler) UpdateUserRSVP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if user has admin/organizer permissions
-	if userRole != "admin" && userRole != "organizer" {
+	if userRole != auth.RoleAdmin && userRole != auth.RoleOrganizer {
 		log.Printf("User %s is not an admin/organizer of group %s", currentUser.ID, groupID)
 		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can update other users' RSVPs")
 		return
 	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return
+	}
+
+	var eventStatus string
+	err = h.db.QueryRow(`
+		SELECT status FROM events
+		WHERE id = $1
+	`, eventID).Scan(&eventStatus)
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return
+	}
+	// Organizers can still fix up RSVPs to drafts and past events, but not cancelled ones
+	if eventStatus == services.EventStatusCancelled {
+		RespondWithError(w, http.StatusConflict, rsvpClosedMessage(eventStatus))
+		return
+	}
 
 	// Verify target user is a member of the group
 	var isMember bool
@@ -254,46 +288,71 @@ func (h *RSVPHandler) UpdateUserRSVP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if RSVP already exists for the target user
-	var exists bool
-	err = h.db.QueryRow(`
-		SELECT EXISTS(
-			SELECT 1 FROM event_rsvps
-			WHERE event_id = $1 AND user_id = $2
-		)
-	`, eventID, targetUserID).Scan(&exists)
+	// Organizers can seat someone even when the event is full
+	change, err := services.NewRSVPService(h.db).Set(eventID, targetUserID, request.Status, true)
 	if err != nil {
-		log.Printf("Error checking if RSVP exists: %v", err)
-		RespondWithError(w, http.StatusInternalServerError, "Error checking RSVP status")
+		log.Printf("Error saving RSVP for user %s, event %s: %v", targetUserID, eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error saving RSVP")
 		return
 	}
 
-	// Insert or update RSVP
-	if exists {
-		_, err = h.db.Exec(`
-			UPDATE event_rsvps
-			SET status = $1
-			WHERE event_id = $2 AND user_id = $3
-		`, request.Status, eventID, targetUserID)
-		if err != nil {
-			log.Printf("Error updating RSVP for user %s, event %s: %v", targetUserID, eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error updating RSVP")
-			return
-		}
-	} else {
-		_, err = h.db.Exec(`
-			INSERT INTO event_rsvps (event_id, user_id, status)
-			VALUES ($1, $2, $3)
-		`, eventID, targetUserID, request.Status)
-		if err != nil {
-			log.Printf("Error creating RSVP for user %s, event %s: %v", targetUserID, eventID, err)
-			RespondWithError(w, http.StatusInternalServerError, "Error creating RSVP")
-			return
-		}
-	}
+	emitWebhook(h.db, groupID, services.WebhookRSVPChanged, map[string]string{
+		"eventId":   eventID,
+		"userId":    targetUserID,
+		"status":    change.Status,
+		"updatedBy": currentUser.ID,
+	})
+	publishEventUpdate(eventID, EventUpdateRSVPChanged, currentUser.ID, map[string]string{
+		"userId": targetUserID,
+		"status": change.Status,
+	})
+	announcePromotions(h.db, eventID, groupID, change.Promoted)
 
 	RespondWithJSON(w, http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "RSVP updated successfully",
+		Data:    change,
 	})
 }
+
+// announcePromotions tells members who got a spot off the waitlist, and lets webhooks and live viewers know
+func announcePromotions(db *sql.DB, eventID, groupID string, promoted []string) {
+	if len(promoted) == 0 {
+		return
+	}
+
+	var title string
+	if err := db.QueryRow(`SELECT title FROM events WHERE id = $1`, eventID).Scan(&title); err != nil {
+		log.Printf("Error fetching event %s for waitlist notifications: %v", eventID, err)
+		return
+	}
+
+	notificationService := services.NewNotificationService(db)
+	for _, userID := range promoted {
+		content := fmt.Sprintf("A spot opened up for %s, so you've been moved off the waitlist and are now attending", title)
+		if err := notificationService.Notify(userID, groupID, services.NotificationWaitlist, content, eventID); err != nil {
+			log.Printf("Error creating waitlist notification for user %s: %v", userID, err)
+		}
+		emitWebhook(db, groupID, services.WebhookRSVPChanged, map[string]string{
+			"eventId": eventID,
+			"userId":  userID,
+			"status":  services.RSVPAttending,
+		})
+		publishEventUpdate(eventID, EventUpdateRSVPChanged, userID, map[string]string{
+			"userId": userID,
+			"status": services.RSVPAttending,
+		})
+	}
+}
+
+// rsvpClosedMessage explains why an event in the given state doesn't take RSVPs
+func rsvpClosedMessage(eventStatus string) string {
+	switch eventStatus {
+	case services.EventStatusCancelled:
+		return "This event was cancelled"
+	case services.EventStatusCompleted:
+		return "This event has already happened"
+	default:
+		return "This event isn't open for RSVPs yet"
+	}
+}
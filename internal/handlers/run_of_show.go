@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type segmentRequest struct {
+	SegmentType    string `json:"segmentType"`
+	Title          string `json:"title"`
+	PlannedMinutes *int   `json:"plannedMinutes,omitempty"`
+	// BeforeGame is the place in the lineup the segment runs before, 0 for the start
+	BeforeGame int `json:"beforeGame"`
+}
+
+func (req segmentRequest) segment() services.Segment {
+	return services.Segment{
+		Type:           req.SegmentType,
+		Title:          req.Title,
+		PlannedMinutes: req.PlannedMinutes,
+		BeforeGame:     req.BeforeGame,
+	}
+}
+
+// requireRunOfShowAccess responds with an error and returns false unless the user has the permission
+// through the event's crew or organizes its group, and the event isn't cancelled. It returns the
+// event's ID, which changes when an occurrence is saved to plan its show.
+func (h *EventHandler) requireRunOfShowAccess(w http.ResponseWriter, r *http.Request, eventID, userID, permission string) (string, bool) {
+	groupID, err := eventGroupID(h.db, r, eventID)
+	if err == sql.ErrNoRows {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return "", false
+	}
+
+	allowed, err := h.crewOrOrganizer(r, eventID, groupID, userID, permission)
+	if err != nil {
+		log.Printf("Error checking run-of-show permissions for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error checking authorization")
+		return "", false
+	}
+	if !allowed {
+		log.Printf("User %s not authorized to %s for event %s", userID, permission, eventID)
+		if permission == services.PermissionRunShow {
+			RespondWithError(w, http.StatusForbidden, "Only the event MC, crew who run the show and group organizers can run the show")
+		} else {
+			RespondWithError(w, http.StatusForbidden, "Only the event MC, crew who can edit the lineup and group organizers can plan the run-of-show")
+		}
+		return "", false
+	}
+	eventID, ok := saveOccurrence(h.db, w, r, eventID)
+	if !ok {
+		return "", false
+	}
+
+	var eventStatus string
+	err = h.db.QueryRow(`SELECT status FROM events WHERE id = $1`, eventID).Scan(&eventStatus)
+	if err != nil {
+		log.Printf("Error fetching event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching event")
+		return "", false
+	}
+	if eventStatus == services.EventStatusCancelled {
+		RespondWithError(w, http.StatusConflict, "The run-of-show can't be changed once an event is cancelled")
+		return "", false
+	}
+	return eventID, true
+}
+
+// respondRunOfShow sends the event's running order after a change, and tells the event's streams
+func (h *EventHandler) respondRunOfShow(w http.ResponseWriter, eventID, userID, message string, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrEventNotFound):
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	case errors.Is(err, services.ErrSegmentNotFound):
+		RespondWithError(w, http.StatusNotFound, "Not in this event's run-of-show")
+		return
+	case errors.Is(err, services.ErrInvalidSegment):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, services.ErrShowStarted):
+		RespondWithError(w, http.StatusConflict, "The show has already started")
+		return
+	case errors.Is(err, services.ErrShowNotRunning):
+		RespondWithError(w, http.StatusConflict, "The show isn't running")
+		return
+	case errors.Is(err, services.ErrEmptyRunOfShow):
+		RespondWithError(w, http.StatusConflict, "Add games or segments before starting the show")
+		return
+	default:
+		log.Printf("Error updating run-of-show for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating run-of-show")
+		return
+	}
+
+	show, err := services.NewRunOfShowService(h.db).Get(eventID)
+	if err != nil {
+		log.Printf("Error fetching run-of-show for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching run-of-show")
+		return
+	}
+	publishEventUpdate(eventID, EventUpdateRunOfShowChanged, userID, map[string]interface{}{
+		"live":          show.Live,
+		"currentItemId": show.CurrentItemID,
+		"finished":      show.Finished,
+	})
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: message,
+		Data:    show,
+	})
+}
+
+// GetRunOfShow returns the event's running order with planned starts and any actual timings
+func (h *EventHandler) GetRunOfShow(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	// Nothing is planned for an occurrence that hasn't been saved
+	if occurrence := unsavedOccurrence(r); occurrence != nil {
+		RespondWithJSON(w, http.StatusOK, ApiResponse{
+			Success: true,
+			Data: &services.RunOfShow{
+				EventID:    eventID,
+				StartTime:  occurrence.StartTime.UTC(),
+				PlannedEnd: occurrence.StartTime.UTC(),
+				Items:      []services.RunOfShowItem{},
+			},
+		})
+		return
+	}
+
+	var isMember bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM events e
+			JOIN group_members m ON e.group_id = m.group_id
+			WHERE e.id = $1 AND m.user_id = $2
+		)
+	`, eventID, user.ID).Scan(&isMember)
+	if err != nil {
+		log.Printf("Error checking membership for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching run-of-show")
+		return
+	}
+	if !isMember {
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+
+	show, err := services.NewRunOfShowService(h.db).Get(eventID)
+	if err != nil {
+		log.Printf("Error fetching run-of-show for event %s: %v", eventID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching run-of-show")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    show,
+	})
+}
+
+// PlanRunOfShowGame sets a lineup game's segment type and planned length
+func (h *EventHandler) PlanRunOfShowGame(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+
+	var request struct {
+		SegmentType    string `json:"segmentType,omitempty"` // Defaults to game
+		PlannedMinutes *int   `json:"plannedMinutes,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding run-of-show game request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	eventID, ok := h.requireRunOfShowAccess(w, r, eventID, user.ID, services.PermissionEditLineup)
+	if !ok {
+		return
+	}
+
+	err := services.NewRunOfShowService(h.db).PlanGame(eventID, vars["gameId"], request.SegmentType, request.PlannedMinutes)
+	h.respondRunOfShow(w, eventID, user.ID, "Game planned successfully", err)
+}
+
+// AddRunOfShowSegment adds an intro, break or audience bit to the running order
+func (h *EventHandler) AddRunOfShowSegment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	var request segmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding segment request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	eventID, ok := h.requireRunOfShowAccess(w, r, eventID, user.ID, services.PermissionEditLineup)
+	if !ok {
+		return
+	}
+
+	_, err := services.NewRunOfShowService(h.db).AddSegment(eventID, request.segment())
+	h.respondRunOfShow(w, eventID, user.ID, "Segment added successfully", err)
+}
+
+// UpdateRunOfShowSegment replaces a segment's plan
+func (h *EventHandler) UpdateRunOfShowSegment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+
+	var request segmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding segment request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	eventID, ok := h.requireRunOfShowAccess(w, r, eventID, user.ID, services.PermissionEditLineup)
+	if !ok {
+		return
+	}
+
+	err := services.NewRunOfShowService(h.db).UpdateSegment(eventID, vars["segmentId"], request.segment())
+	h.respondRunOfShow(w, eventID, user.ID, "Segment updated successfully", err)
+}
+
+// DeleteRunOfShowSegment removes a segment from the running order
+func (h *EventHandler) DeleteRunOfShowSegment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	eventID := vars["id"]
+
+	eventID, ok := h.requireRunOfShowAccess(w, r, eventID, user.ID, services.PermissionEditLineup)
+	if !ok {
+		return
+	}
+
+	err := services.NewRunOfShowService(h.db).DeleteSegment(eventID, vars["segmentId"])
+	h.respondRunOfShow(w, eventID, user.ID, "Segment deleted successfully", err)
+}
+
+// StartShow starts the show clock on the first item of the running order
+func (h *EventHandler) StartShow(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	eventID, ok := h.requireRunOfShowAccess(w, r, eventID, user.ID, services.PermissionRunShow)
+	if !ok {
+		return
+	}
+
+	_, err := services.NewRunOfShowService(h.db).Start(eventID, time.Now())
+	h.respondRunOfShow(w, eventID, user.ID, "Show started", err)
+}
+
+// AdvanceShow ends the running item and starts the next. After the last item the show is over.
+func (h *EventHandler) AdvanceShow(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	eventID, ok := h.requireRunOfShowAccess(w, r, eventID, user.ID, services.PermissionRunShow)
+	if !ok {
+		return
+	}
+
+	_, err := services.NewRunOfShowService(h.db).Advance(eventID, time.Now())
+	h.respondRunOfShow(w, eventID, user.ID, "Moved on to the next segment", err)
+}
+
+// ResetShow clears the show's actual timings so it can be run again
+func (h *EventHandler) ResetShow(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	eventID := mux.Vars(r)["id"]
+
+	eventID, ok := h.requireRunOfShowAccess(w, r, eventID, user.ID, services.PermissionRunShow)
+	if !ok {
+		return
+	}
+
+	err := services.NewRunOfShowService(h.db).Reset(eventID)
+	h.respondRunOfShow(w, eventID, user.ID, "Show timings cleared", err)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/testutil"
+
+	"github.com/gorilla/mux"
+)
+
+// The test database helpers and group123 are shared with other packages' tests, in testutil.
+// Tests seed the fixtures they need and add their own rows with seed.
+var (
+	newTestDB = testutil.NewDB
+	seed      = testutil.Seed
+
+	// seedGroup is Ada Admin and group123, the group they run
+	seedGroup = testutil.SeedGroup
+	// seedMembers are Dana Director, a plain member of group123, and an outsider who isn't in it
+	seedMembers = []string{
+		`INSERT INTO users (id, email, first_name, last_name) VALUES ('dana', 'dana@example.com', 'Dana', 'Director')`,
+		`INSERT INTO group_members (group_id, user_id, role) VALUES ('group123', 'dana', 'member')`,
+		`INSERT INTO users (id, email, first_name, last_name) VALUES ('outsider', 'outsider@example.com', 'Otto', 'Outsider')`,
+	}
+	// seedShow is group123's show, show1, which Ada MCs, with two games in its lineup. Ada and Dana are going.
+	seedShow = []string{
+		`INSERT INTO events (id, group_id, title, location, start_time, end_time, created_by, mc_id)
+		 VALUES ('show1', 'group123', 'Friday Show', 'Main Stage', '2026-02-06 19:00:00', '2026-02-06 21:00:00', 'user123', 'user123')`,
+		`INSERT INTO event_rsvps (event_id, user_id, status) VALUES ('show1', 'user123', 'attending'), ('show1', 'dana', 'attending')`,
+		`INSERT INTO games (id, name, min_players, max_players, created_by, group_id)
+		 VALUES ('game1', 'Freeze Tag', 2, 6, 'user123', 'group123'), ('game2', 'Party Quirks', 2, 4, 'user123', 'group123'),
+			('game3', 'Questions Only', 2, 4, 'user123', 'group123')`,
+		`INSERT INTO event_games (event_id, game_id, order_index) VALUES ('show1', 'game1', 0), ('show1', 'game2', 1)`,
+	}
+)
+
+// serve routes a request through a router with the handler at the pattern, signed in as the
+// user, and decodes the response. Body is encoded as JSON unless it's nil.
+func serve(t *testing.T, testDB *sql.DB, userID, pattern string, handler http.HandlerFunc, method, path string, body interface{}) (*httptest.ResponseRecorder, ApiResponse) {
+	t.Helper()
+	var user models.User
+	err := testDB.QueryRow(`SELECT id, email, first_name, last_name FROM users WHERE id = $1`, userID).
+		Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName)
+	if err != nil {
+		t.Fatalf("Error fetching user %s: %v", userID, err)
+	}
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("Error encoding request: %v", err)
+		}
+	}
+	request := httptest.NewRequest(method, path, &payload)
+	request = request.WithContext(context.WithValue(request.Context(), middleware.UserContextKey, &user))
+
+	router := mux.NewRouter()
+	router.HandleFunc(pattern, handler).Methods(method)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var response ApiResponse
+	if recorder.Header().Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Error decoding response %s: %v", recorder.Body.String(), err)
+		}
+	}
+	return recorder, response
+}
+
+// notificationsFor returns the content of the user's in-app notifications, oldest first
+func notificationsFor(t *testing.T, testDB *sql.DB, userID string) []string {
+	t.Helper()
+	rows, err := testDB.Query(`SELECT content FROM notifications WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		t.Fatalf("Error fetching notifications: %v", err)
+	}
+	defer rows.Close()
+	contents := []string{}
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			t.Fatalf("Error scanning notification: %v", err)
+		}
+		contents = append(contents, content)
+	}
+	return contents
+}
@@ -34,4 +34,14 @@ type Event struct {
 	CreatedAt   time.Time
 	CreatedBy   string
 	MCID        *string
+	SeriesID    *string
+	Capacity    *int
+	Visibility  string
+	Status      string
+	// CancellationReason is set when the event was cancelled with a reason
+	CancellationReason *string
+	// VenueID is the venue from the group's catalogue, with Location as the free-text fallback
+	VenueID *string
+	// TimeZone is the IANA zone the event is in. StartTime and EndTime are UTC.
+	TimeZone string
 }
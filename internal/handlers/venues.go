@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// VenueHandler manages a group's catalogue of venues
+type VenueHandler struct {
+	db *sql.DB
+}
+
+// NewVenueHandler creates a new VenueHandler
+func NewVenueHandler(db *sql.DB) *VenueHandler {
+	return &VenueHandler{
+		db: db,
+	}
+}
+
+type venueRequest struct {
+	Name               string   `json:"name"`
+	Address            string   `json:"address"`
+	Latitude           *float64 `json:"latitude,omitempty"`
+	Longitude          *float64 `json:"longitude,omitempty"`
+	Capacity           *int     `json:"capacity,omitempty"`
+	AccessibilityNotes string   `json:"accessibilityNotes"`
+	LoadInInstructions string   `json:"loadInInstructions"`
+	// Locations are free-text event locations to point at the venue, usually from a suggestion
+	Locations []string `json:"locations,omitempty"`
+}
+
+// apply copies the request onto the venue
+func (req venueRequest) apply(venue *services.Venue) {
+	venue.Name = req.Name
+	venue.Address = req.Address
+	venue.Latitude = req.Latitude
+	venue.Longitude = req.Longitude
+	venue.Capacity = req.Capacity
+	venue.AccessibilityNotes = req.AccessibilityNotes
+	venue.LoadInInstructions = req.LoadInInstructions
+}
+
+// requireGroupRole responds with an error and returns false unless the user is a member of the group,
+// and an admin or organizer when organizersOnly is set
+func (h *VenueHandler) requireGroupRole(w http.ResponseWriter, groupID, userID string, organizersOnly bool) bool {
+	var role string
+	err := h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, userID).Scan(&role)
+	if err != nil {
+		log.Printf("User %s is not a member of group %s: %v", userID, groupID, err)
+		RespondWithError(w, http.StatusForbidden, "Not a member of this group")
+		return false
+	}
+	if organizersOnly && role != auth.RoleAdmin && role != auth.RoleOrganizer {
+		log.Printf("User %s is not an organizer of group %s (role=%s)", userID, groupID, role)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can manage venues")
+		return false
+	}
+	return true
+}
+
+// getVenue loads the venue named in the route, responding with 404 when it isn't the group's
+func (h *VenueHandler) getVenue(w http.ResponseWriter, groupID, venueID string) *services.Venue {
+	venue, err := services.NewVenueService(h.db).Get(groupID, venueID)
+	if err != nil {
+		log.Printf("Error fetching venue %s: %v", venueID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching venue")
+		return nil
+	}
+	if venue == nil {
+		RespondWithError(w, http.StatusNotFound, "Venue not found")
+		return nil
+	}
+	return venue
+}
+
+// save saves the venue and links the request's locations to it
+func (h *VenueHandler) save(w http.ResponseWriter, venue services.Venue, locations []string) (*services.Venue, bool) {
+	venueService := services.NewVenueService(h.db)
+	saved, err := venueService.Save(venue)
+	if errors.Is(err, services.ErrInvalidVenue) {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error saving venue for group %s: %v", venue.GroupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error saving venue")
+		return nil, false
+	}
+	if _, err := venueService.LinkLocations(saved.GroupID, saved.ID, locations); err != nil {
+		log.Printf("Error linking locations to venue %s: %v", saved.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error linking events to venue")
+		return nil, false
+	}
+	return saved, true
+}
+
+// List returns the group's venues
+func (h *VenueHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, false) {
+		return
+	}
+
+	venues, err := services.NewVenueService(h.db).List(groupID)
+	if err != nil {
+		log.Printf("Error fetching venues for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching venues")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    venues,
+	})
+}
+
+// Get returns one of the group's venues
+func (h *VenueHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, false) {
+		return
+	}
+
+	venue := h.getVenue(w, groupID, vars["venueId"])
+	if venue == nil {
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    venue,
+	})
+}
+
+// Create adds a venue to the group, linking any locations given in the request
+func (h *VenueHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	var request venueRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding venue request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	venue := services.Venue{
+		GroupID:   groupID,
+		CreatedBy: user.ID,
+	}
+	request.apply(&venue)
+
+	created, ok := h.save(w, venue, request.Locations)
+	if !ok {
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, ApiResponse{
+		Success: true,
+		Message: "Venue created successfully",
+		Data:    created,
+	})
+}
+
+// Update replaces a venue's details, linking any locations given in the request
+func (h *VenueHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	venue := h.getVenue(w, groupID, vars["venueId"])
+	if venue == nil {
+		return
+	}
+
+	var request venueRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding venue request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	request.apply(venue)
+
+	updated, ok := h.save(w, *venue, request.Locations)
+	if !ok {
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Venue updated successfully",
+		Data:    updated,
+	})
+}
+
+// Delete removes a venue. Its events keep their location text.
+func (h *VenueHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	venueID := vars["venueId"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	if h.getVenue(w, groupID, venueID) == nil {
+		return
+	}
+
+	if err := services.NewVenueService(h.db).Delete(groupID, venueID); err != nil {
+		log.Printf("Error deleting venue %s: %v", venueID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error deleting venue")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Venue deleted successfully",
+	})
+}
+
+// Suggestions groups the free-text locations of the group's events into likely venues,
+// so organizers can turn them into venues by creating one with the suggestion's locations
+func (h *VenueHandler) Suggestions(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !h.requireGroupRole(w, groupID, user.ID, true) {
+		return
+	}
+
+	suggestions, err := services.NewVenueService(h.db).Suggest(groupID)
+	if err != nil {
+		log.Printf("Error suggesting venues for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error suggesting venues")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    suggestions,
+	})
+}
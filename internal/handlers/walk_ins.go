@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"improv-app/internal/auth"
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// WalkInHandler links walk-ins to the accounts they later sign up with
+type WalkInHandler struct {
+	db *sql.DB
+}
+
+// NewWalkInHandler creates a new WalkInHandler
+func NewWalkInHandler(db *sql.DB) *WalkInHandler {
+	return &WalkInHandler{
+		db: db,
+	}
+}
+
+// requireOrganizer responds with an error and returns false unless the user is an admin or organizer of the group
+func (h *WalkInHandler) requireOrganizer(w http.ResponseWriter, groupID, userID string) bool {
+	var role string
+	err := h.db.QueryRow(`
+		SELECT role FROM group_members
+		WHERE group_id = $1 AND user_id = $2
+	`, groupID, userID).Scan(&role)
+	if err != nil || (role != auth.RoleAdmin && role != auth.RoleOrganizer) {
+		log.Printf("User %s not authorized to manage walk-in matches for group %s (role: %s, error: %v)", userID, groupID, role, err)
+		RespondWithError(w, http.StatusForbidden, "Only admins and organizers can confirm walk-in matches")
+		return false
+	}
+	return true
+}
+
+// ListMatches lists the group's walk-ins matched to accounts and waiting for confirmation
+func (h *WalkInHandler) ListMatches(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !h.requireOrganizer(w, groupID, user.ID) {
+		return
+	}
+
+	matches, err := services.NewWalkInLinkService(h.db).Pending(groupID)
+	if err != nil {
+		log.Printf("Error fetching walk-in matches for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching walk-in matches")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    matches,
+	})
+}
+
+// ConfirmMatch links a matched walk-in to the account, moving their place at the event onto it
+func (h *WalkInHandler) ConfirmMatch(w http.ResponseWriter, r *http.Request) {
+	h.resolveMatch(w, r, true)
+}
+
+// RejectMatch turns down a match, leaving the walk-in as it was
+func (h *WalkInHandler) RejectMatch(w http.ResponseWriter, r *http.Request) {
+	h.resolveMatch(w, r, false)
+}
+
+func (h *WalkInHandler) resolveMatch(w http.ResponseWriter, r *http.Request, confirm bool) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	attendeeID := vars["attendeeId"]
+
+	if !h.requireOrganizer(w, groupID, user.ID) {
+		return
+	}
+
+	walkInService := services.NewWalkInLinkService(h.db)
+	var err error
+	message := "Walk-in linked successfully"
+	if confirm {
+		err = walkInService.Confirm(groupID, attendeeID, user.ID)
+	} else {
+		err = walkInService.Reject(groupID, attendeeID, user.ID)
+		message = "Walk-in match rejected"
+	}
+	if errors.Is(err, services.ErrWalkInMatchNotFound) {
+		RespondWithError(w, http.StatusNotFound, "Walk-in match not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error resolving walk-in match %s: %v", attendeeID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating walk-in match")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: message,
+	})
+}
+
+// GroupsToJoin lists the groups the user came to as a walk-in but hasn't joined, to prompt them to join
+func (h *WalkInHandler) GroupsToJoin(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+
+	groups, err := services.NewWalkInLinkService(h.db).GroupsToJoin(user.ID)
+	if err != nil {
+		log.Printf("Error fetching walk-in groups for user %s: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching groups")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    groups,
+	})
+}
+
+// JoinGroup adds the user to a group they came to as a walk-in
+func (h *WalkInHandler) JoinGroup(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["groupId"]
+
+	err := services.NewWalkInLinkService(h.db).Join(user.ID, groupID)
+	if errors.Is(err, services.ErrNoWalkInHistory) {
+		RespondWithError(w, http.StatusForbidden, "You can only join groups you've been to as a walk-in")
+		return
+	}
+	if err != nil {
+		log.Printf("Error joining group %s from walk-in history for user %s: %v", groupID, user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error joining group")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Joined group successfully",
+	})
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"improv-app/internal/middleware"
+	"improv-app/internal/models"
+	"improv-app/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+const defaultWebhookDeliveryPageSize = 25
+
+// WebhookHandler lets group admins manage outbound webhooks
+type WebhookHandler struct {
+	db *sql.DB
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(db *sql.DB) *WebhookHandler {
+	return &WebhookHandler{
+		db: db,
+	}
+}
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// validate checks the URL is an absolute http(s) URL outside the server's network and every event type is known
+func (req webhookRequest) validate() error {
+	parsed, err := url.ParseRequestURI(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("URL must be an absolute http or https URL")
+	}
+	if err := services.CheckWebhookHost(parsed.Hostname()); err != nil {
+		return fmt.Errorf("URL must not point at a private or local address")
+	}
+	for _, eventType := range req.Events {
+		if !services.IsValidWebhookEvent(eventType) {
+			return fmt.Errorf("Unknown event type: %s", eventType)
+		}
+	}
+	return nil
+}
+
+// getWebhook loads the webhook named in the route, responding with 404 when it isn't the group's
+func (h *WebhookHandler) getWebhook(w http.ResponseWriter, groupID, webhookID string) *services.Webhook {
+	webhook, err := services.NewWebhookService(h.db).Get(groupID, webhookID)
+	if err != nil {
+		log.Printf("Error fetching webhook %s: %v", webhookID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching webhook")
+		return nil
+	}
+	if webhook == nil {
+		RespondWithError(w, http.StatusNotFound, "Webhook not found")
+		return nil
+	}
+	return webhook
+}
+
+// List returns the group's webhooks. Secrets are only shown when a webhook is created.
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "webhooks") {
+		return
+	}
+
+	webhooks, err := services.NewWebhookService(h.db).List(groupID)
+	if err != nil {
+		log.Printf("Error fetching webhooks for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching webhooks")
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"webhooks":        webhooks,
+			"availableEvents": services.WebhookEvents,
+		},
+	})
+}
+
+// Create registers a webhook and returns it along with its signing secret
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	groupID := mux.Vars(r)["id"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "webhooks") {
+		return
+	}
+
+	var request webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding webhook request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := request.validate(); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	webhook, err := services.NewWebhookService(h.db).Create(groupID, request.URL, request.Events, user.ID)
+	if err != nil {
+		log.Printf("Error creating webhook for group %s: %v", groupID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error creating webhook")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, ApiResponse{
+		Success: true,
+		Message: "Webhook created. Store the secret now, it won't be shown again.",
+		Data:    webhook,
+	})
+}
+
+// Update changes a webhook's URL, events or active flag
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	webhookID := vars["webhookId"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "webhooks") {
+		return
+	}
+
+	existing := h.getWebhook(w, groupID, webhookID)
+	if existing == nil {
+		return
+	}
+
+	var request webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Error decoding webhook request: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := request.validate(); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	active := existing.Active
+	if request.Active != nil {
+		active = *request.Active
+	}
+
+	webhook, err := services.NewWebhookService(h.db).Update(groupID, webhookID, request.URL, request.Events, active)
+	if err != nil {
+		log.Printf("Error updating webhook %s: %v", webhookID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error updating webhook")
+		return
+	}
+	webhook.Secret = ""
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Webhook updated successfully",
+		Data:    webhook,
+	})
+}
+
+// Delete removes a webhook and its delivery log
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	webhookID := vars["webhookId"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "webhooks") {
+		return
+	}
+
+	if h.getWebhook(w, groupID, webhookID) == nil {
+		return
+	}
+
+	if err := services.NewWebhookService(h.db).Delete(groupID, webhookID); err != nil {
+		log.Printf("Error deleting webhook %s: %v", webhookID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error deleting webhook")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: "Webhook deleted successfully",
+	})
+}
+
+// ListDeliveries returns a webhook's delivery log, newest first
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	webhookID := vars["webhookId"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "webhooks") {
+		return
+	}
+
+	if h.getWebhook(w, groupID, webhookID) == nil {
+		return
+	}
+
+	page := 1
+	if parsedPage, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && parsedPage > 0 {
+		page = parsedPage
+	}
+	pageSize := defaultWebhookDeliveryPageSize
+	if parsedPageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize")); err == nil && parsedPageSize > 0 && parsedPageSize <= 100 {
+		pageSize = parsedPageSize
+	}
+
+	deliveries, totalItems, err := services.NewWebhookService(h.db).ListDeliveries(webhookID, page, pageSize)
+	if err != nil {
+		log.Printf("Error fetching deliveries for webhook %s: %v", webhookID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error fetching webhook deliveries")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Data:    deliveries,
+		Pagination: &PaginationMetadata{
+			Page:       page,
+			PageSize:   pageSize,
+			TotalItems: totalItems,
+			TotalPages: (totalItems + pageSize - 1) / pageSize,
+		},
+	})
+}
+
+// SendTest delivers a test event to the webhook right away and returns the delivery result
+func (h *WebhookHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(*models.User)
+	vars := mux.Vars(r)
+	groupID := vars["id"]
+	webhookID := vars["webhookId"]
+
+	if !requireGroupAdmin(h.db, w, groupID, user.ID, "webhooks") {
+		return
+	}
+
+	webhook := h.getWebhook(w, groupID, webhookID)
+	if webhook == nil {
+		return
+	}
+
+	delivery, err := services.NewWebhookService(h.db).SendTest(*webhook)
+	if err != nil {
+		log.Printf("Error sending test to webhook %s: %v", webhookID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Error sending test event")
+		return
+	}
+
+	message := "Test event delivered"
+	if delivery.Status != "delivered" {
+		message = "Test event failed, it will be retried"
+	}
+
+	RespondWithJSON(w, http.StatusOK, ApiResponse{
+		Success: true,
+		Message: message,
+		Data:    delivery,
+	})
+}
+
+// emitWebhook queues a webhook event for the group, logging rather than failing the request on error
+func emitWebhook(db *sql.DB, groupID, eventType string, data interface{}) {
+	if err := services.NewWebhookService(db).Emit(groupID, eventType, data); err != nil {
+		log.Printf("Error emitting %s webhook for group %s: %v", eventType, groupID, err)
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWebhooks_RejectInternalURLs(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	h := NewWebhookHandler(testDB)
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"https://hooks.example.com/improv", http.StatusCreated},
+		{"http://localhost:8080/admin", http.StatusBadRequest},
+		{"http://127.0.0.1/hook", http.StatusBadRequest},
+		{"http://10.0.0.5/hook", http.StatusBadRequest},
+		{"http://169.254.169.254/latest/meta-data", http.StatusBadRequest},
+		{"http://[::1]:9000/hook", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		recorder, response := serve(t, testDB, "user123", "/groups/{id}/webhooks", h.Create, http.MethodPost, "/groups/group123/webhooks",
+			map[string]interface{}{"url": tt.url, "events": []string{"event.created"}})
+		if recorder.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", tt.url, tt.status, recorder.Code, response.Error)
+		}
+	}
+}
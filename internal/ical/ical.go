@@ -0,0 +1,176 @@
+// Package ical reads and writes iCalendar (RFC 5545) files, for calendar feeds and imports.
+package ical
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	prodID = "-//Improv App//Events//EN"
+	// maxLineOctets is the longest a content line can be before it's folded
+	maxLineOctets = 75
+	utcFormat     = "20060102T150405Z"
+)
+
+// Participation statuses for an attendee
+const (
+	PartStatAccepted    = "ACCEPTED"
+	PartStatTentative   = "TENTATIVE"
+	PartStatDeclined    = "DECLINED"
+	PartStatNeedsAction = "NEEDS-ACTION"
+)
+
+// Calendar is a named list of events
+type Calendar struct {
+	Name string
+	// RefreshInterval hints how often subscribers should fetch the feed again
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is one VEVENT. Events that share a UID are one recurring event: the one
+// with an RRule is the series, and ones with a RecurrenceID override an occurrence of it.
+type Event struct {
+	UID          string
+	Sequence     int
+	Stamp        time.Time
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Status       string
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID time.Time
+	Attendee     *Attendee
+}
+
+// Attendee is the subscriber's own participation in an event
+type Attendee struct {
+	Name     string
+	Email    string
+	PartStat string
+}
+
+// String renders the calendar with CRLF line endings and folded lines
+func (c Calendar) String() string {
+	var b strings.Builder
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+prodID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+EscapeText(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		interval := "PT" + strconv.Itoa(int(c.RefreshInterval.Minutes())) + "M"
+		writeLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:"+interval)
+		writeLine(&b, "X-PUBLISHED-TTL:"+interval)
+	}
+	for _, event := range c.Events {
+		event.write(&b)
+	}
+	writeLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+func (e Event) write(b *strings.Builder) {
+	writeLine(b, "BEGIN:VEVENT")
+	writeLine(b, "UID:"+EscapeText(e.UID))
+	writeLine(b, "DTSTAMP:"+FormatTime(e.Stamp))
+	writeLine(b, "SEQUENCE:"+strconv.Itoa(e.Sequence))
+	if !e.RecurrenceID.IsZero() {
+		writeLine(b, "RECURRENCE-ID:"+FormatTime(e.RecurrenceID))
+	}
+	writeLine(b, timeProperty("DTSTART", e.Start))
+	if e.End.After(e.Start) {
+		writeLine(b, timeProperty("DTEND", e.End))
+	}
+	if e.RRule != "" {
+		writeLine(b, "RRULE:"+e.RRule)
+	}
+	for _, exDate := range e.ExDates {
+		writeLine(b, timeProperty("EXDATE", exDate))
+	}
+	writeLine(b, "SUMMARY:"+EscapeText(e.Summary))
+	if e.Description != "" {
+		writeLine(b, "DESCRIPTION:"+EscapeText(e.Description))
+	}
+	if e.Location != "" {
+		writeLine(b, "LOCATION:"+EscapeText(e.Location))
+	}
+	if e.URL != "" {
+		writeLine(b, "URL:"+e.URL)
+	}
+	if e.Status != "" {
+		writeLine(b, "STATUS:"+e.Status)
+	}
+	if e.Attendee != nil {
+		attendee := "ATTENDEE;PARTSTAT=" + e.Attendee.PartStat
+		if e.Attendee.Name != "" {
+			attendee += ";CN=" + quoteParam(e.Attendee.Name)
+		}
+		writeLine(b, attendee+":mailto:"+e.Attendee.Email)
+	}
+	writeLine(b, "END:VEVENT")
+}
+
+// FormatTime formats a time as a UTC date-time
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(utcFormat)
+}
+
+// timeProperty formats a date-time property in the time's IANA zone, if it has one,
+// so a recurring event's rule is read in the zone it was written for
+func timeProperty(name string, t time.Time) string {
+	if zone := t.Location().String(); zone != "" && zone != "UTC" && zone != "Local" {
+		return name + ";TZID=" + zone + ":" + t.Format(floatingFormat)
+	}
+	return name + ":" + FormatTime(t)
+}
+
+// EscapeText escapes a TEXT value so commas, semicolons and newlines survive
+func EscapeText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(value)
+}
+
+// quoteParam quotes a parameter value, dropping the characters a parameter can't hold
+func quoteParam(value string) string {
+	value = strings.NewReplacer(`"`, "", "\r", " ", "\n", " ").Replace(value)
+	return `"` + value + `"`
+}
+
+// writeLine writes a content line, folding it onto continuation lines that
+// start with a space so no line is longer than 75 octets. Folds never split a UTF-8 character.
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the continuation line's length
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,111 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var start = time.Date(2026, 1, 6, 19, 0, 0, 0, time.UTC)
+
+func TestEscapeText(t *testing.T) {
+	got := EscapeText("Jam; bring snacks, friends\nand a \\ slash")
+	want := `Jam\; bring snacks\, friends\nand a \\ slash`
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestWriteLine_Folds(t *testing.T) {
+	var b strings.Builder
+	line := "DESCRIPTION:" + strings.Repeat("é", 100)
+	writeLine(&b, line)
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected the line to be folded, got %q", b.String())
+	}
+	unfolded := lines[0]
+	for i, l := range lines {
+		if len(l) > maxLineOctets {
+			t.Errorf("Line %d is %d octets", i, len(l))
+		}
+		if !utf8.ValidString(l) {
+			t.Errorf("Line %d splits a character: %q", i, l)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(l, " ") {
+				t.Errorf("Expected continuation line %d to start with a space", i)
+			}
+			unfolded += l[1:]
+		}
+	}
+	if unfolded != line {
+		t.Errorf("Expected unfolding to give back the line, got %q", unfolded)
+	}
+}
+
+func TestCalendar_String(t *testing.T) {
+	calendar := Calendar{
+		Name:            "Harold Night",
+		RefreshInterval: time.Hour,
+		Events: []Event{
+			{
+				UID:      "series1@improv",
+				Sequence: 2,
+				Stamp:    start,
+				Start:    start,
+				End:      start.Add(2 * time.Hour),
+				Summary:  "Weekly Jam",
+				RRule:    "FREQ=WEEKLY;BYDAY=TU",
+				ExDates:  []time.Time{start.AddDate(0, 0, 7)},
+			},
+			{
+				UID:          "series1@improv",
+				Stamp:        start,
+				RecurrenceID: start.AddDate(0, 0, 14),
+				Start:        start.AddDate(0, 0, 14).Add(time.Hour),
+				End:          start.AddDate(0, 0, 14).Add(3 * time.Hour),
+				Summary:      "Weekly Jam, late",
+				Location:     "Main Stage",
+				URL:          "https://improv.example.com/events/event1",
+				Attendee:     &Attendee{Name: `Sam "Ace" Lee`, Email: "sam@example.com", PartStat: PartStatAccepted},
+			},
+		},
+	}
+	got := calendar.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:Harold Night\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT60M\r\n",
+		"SEQUENCE:2\r\nDTSTART:20260106T190000Z\r\nDTEND:20260106T210000Z\r\nRRULE:FREQ=WEEKLY;BYDAY=TU\r\nEXDATE:20260113T190000Z\r\n",
+		"RECURRENCE-ID:20260120T190000Z\r\nDTSTART:20260120T200000Z\r\n",
+		"SUMMARY:Weekly Jam\\, late\r\n",
+		"ATTENDEE;PARTSTAT=ACCEPTED;CN=\"Sam Ace Lee\":mailto:sam@example.com\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected calendar to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "BEGIN:VEVENT") != 2 {
+		t.Errorf("Expected 2 events, got:\n%s", got)
+	}
+}
+
+func TestCalendar_String_KeepsTimeZones(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+	local := start.In(newYork)
+	got := Calendar{Events: []Event{{UID: "series1", Stamp: start, Start: local, End: local.Add(time.Hour), RRule: "FREQ=WEEKLY"}}}.String()
+	if !strings.Contains(got, "DTSTART;TZID=America/New_York:20260106T140000\r\n") {
+		t.Errorf("Expected the start in its zone, got:\n%s", got)
+	}
+	if !strings.Contains(got, "DTSTAMP:20260106T190000Z\r\n") {
+		t.Errorf("Expected the stamp in UTC, got:\n%s", got)
+	}
+}
@@ -0,0 +1,290 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormat          = "20060102"
+	floatingFormat      = "20060102T150405"
+	maxParsedEventCount = 10000
+)
+
+// Parse reads the events of an iCalendar file. Times with a TZID are read in that
+// time zone, and floating times in the calendar's X-WR-TIMEZONE, or UTC.
+// Components inside events, like alarms, are skipped.
+func Parse(r io.Reader) ([]Event, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	lines := unfold(string(data))
+
+	var events []Event
+	var stack []string
+	var current *Event
+	var hasEnd, allDay bool
+	var duration time.Duration
+	defaultLocation := time.UTC
+	foundCalendar := false
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, params, value, ok := splitContentLine(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid content line", i+1)
+		}
+
+		switch name {
+		case "BEGIN":
+			component := strings.ToUpper(value)
+			stack = append(stack, component)
+			if component == "VCALENDAR" {
+				foundCalendar = true
+			}
+			if component == "VEVENT" && len(stack) == 2 {
+				current = &Event{}
+				hasEnd = false
+				allDay = false
+				duration = 0
+			}
+			continue
+		case "END":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: END:%s without BEGIN", i+1, value)
+			}
+			if strings.ToUpper(value) == "VEVENT" && len(stack) == 2 && current != nil {
+				if !current.Start.IsZero() {
+					if !hasEnd {
+						current.End = current.Start.Add(duration)
+						if duration == 0 && allDay {
+							// All-day events without an end last the day
+							current.End = current.Start.AddDate(0, 0, 1)
+						}
+					}
+					events = append(events, *current)
+					if len(events) > maxParsedEventCount {
+						return nil, fmt.Errorf("calendar has more than %d events", maxParsedEventCount)
+					}
+				}
+				current = nil
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if len(stack) == 1 && stack[0] == "VCALENDAR" && name == "X-WR-TIMEZONE" {
+			if loc, err := time.LoadLocation(value); err == nil {
+				defaultLocation = loc
+			}
+			continue
+		}
+		if current == nil || len(stack) != 2 {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.UID = unescapeText(value)
+		case "SUMMARY":
+			current.Summary = unescapeText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case "LOCATION":
+			current.Location = unescapeText(value)
+		case "URL":
+			current.URL = value
+		case "STATUS":
+			current.Status = strings.ToUpper(value)
+		case "SEQUENCE":
+			current.Sequence, _ = strconv.Atoi(value)
+		case "RRULE":
+			current.RRule = value
+		case "DTSTART":
+			current.Start, err = parseTime(value, params, defaultLocation)
+			allDay = params.isDate || len(strings.TrimSpace(value)) == len(dateFormat)
+		case "DTEND":
+			current.End, err = parseTime(value, params, defaultLocation)
+			hasEnd = true
+		case "DURATION":
+			duration, err = parseDuration(value)
+		case "RECURRENCE-ID":
+			current.RecurrenceID, err = parseTime(value, params, defaultLocation)
+		case "EXDATE":
+			for _, part := range strings.Split(value, ",") {
+				exDate, exErr := parseTime(part, params, defaultLocation)
+				if exErr != nil {
+					err = exErr
+					break
+				}
+				current.ExDates = append(current.ExDates, exDate)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid %s: %v", i+1, name, err)
+		}
+	}
+
+	if !foundCalendar {
+		return nil, fmt.Errorf("not an iCalendar file")
+	}
+	return events, nil
+}
+
+// contentParams are the parameters of a content line that parsing cares about
+type contentParams struct {
+	tzid   string
+	isDate bool
+}
+
+// unfold joins continuation lines back onto the line they continue
+func unfold(data string) []string {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\r", "\n")
+	var lines []string
+	for _, line := range strings.Split(data, "\n") {
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitContentLine splits NAME;PARAM=VALUE:value, allowing quoted parameter values to contain colons
+func splitContentLine(line string) (string, contentParams, string, bool) {
+	var params contentParams
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return "", params, "", false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	name := strings.ToUpper(strings.TrimSpace(parts[0]))
+	for _, param := range parts[1:] {
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		switch strings.ToUpper(key) {
+		case "TZID":
+			params.tzid = value
+		case "VALUE":
+			params.isDate = strings.EqualFold(value, "DATE")
+		}
+	}
+	return name, params, line[colon+1:], true
+}
+
+// parseTime reads a DATE or DATE-TIME value
+func parseTime(value string, params contentParams, defaultLocation *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	loc := defaultLocation
+	if params.tzid != "" {
+		// Unknown zones, like Windows zone names, fall back to the calendar's zone
+		if tzLoc, err := time.LoadLocation(params.tzid); err == nil {
+			loc = tzLoc
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse(utcFormat, value)
+	case params.isDate || len(value) == len(dateFormat):
+		return time.ParseInLocation(dateFormat, value, loc)
+	default:
+		return time.ParseInLocation(floatingFormat, value, loc)
+	}
+}
+
+// parseDuration reads a DURATION value like PT1H30M, P1D or -PT15M
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	sign := time.Duration(1)
+	if strings.HasPrefix(value, "-") {
+		sign = -1
+		value = value[1:]
+	}
+	value = strings.TrimPrefix(value, "+")
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, c := range value[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number = ""
+		switch {
+		case c == 'W' && !inTime:
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			total += time.Duration(n) * 24 * time.Hour
+		case c == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * total, nil
+}
+
+// unescapeText reverses EscapeText
+func unescapeText(value string) string {
+	var b strings.Builder
+	escaped := false
+	for _, c := range value {
+		if escaped {
+			switch c {
+			case 'n', 'N':
+				b.WriteRune('\n')
+			default:
+				b.WriteRune(c)
+			}
+			escaped = false
+			continue
+		}
+		if c == '\\' {
+			escaped = true
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
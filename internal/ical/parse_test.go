@@ -0,0 +1,135 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const googleExport = "BEGIN:VCALENDAR\r\n" +
+	"PRODID:-//Google Inc//Google Calendar 70.9054//EN\r\n" +
+	"VERSION:2.0\r\n" +
+	"X-WR-TIMEZONE:America/New_York\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:America/New_York\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;TZID=America/New_York:20260106T190000\r\n" +
+	"DTEND;TZID=America/New_York:20260106T210000\r\n" +
+	"RRULE:FREQ=WEEKLY;WKST=SU;BYDAY=TU\r\n" +
+	"EXDATE;TZID=America/New_York:20260113T190000,20260120T190000\r\n" +
+	"UID:jam@google.com\r\n" +
+	"SUMMARY:Weekly Jam\r\n" +
+	"DESCRIPTION:Bring a friend\\, and snacks\\nDoors at 6:30\r\n" +
+	"LOCATION:Main Stage\\; upstairs\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20260127T010000Z\r\n" +
+	"DURATION:PT1H30M\r\n" +
+	"RECURRENCE-ID;TZID=America/New_York:20260127T190000\r\n" +
+	"UID:jam@google.com\r\n" +
+	"SUMMARY:Weekly Jam (late start with a very long title that is folded across\r\n" +
+	"  lines)\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20260214\r\n" +
+	"UID:retreat@google.com\r\n" +
+	"SUMMARY:Retreat\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse_GoogleExport(t *testing.T) {
+	events, err := Parse(strings.NewReader(googleExport))
+	if err != nil {
+		t.Fatalf("Error parsing calendar: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	jam := events[0]
+	if !jam.Start.Equal(time.Date(2026, 1, 6, 19, 0, 0, 0, newYork)) || jam.End.Sub(jam.Start) != 2*time.Hour {
+		t.Errorf("Unexpected times %v to %v", jam.Start, jam.End)
+	}
+	if jam.Start.Location().String() != "America/New_York" {
+		t.Errorf("Expected the start in its time zone, got %v", jam.Start.Location())
+	}
+	if jam.RRule != "FREQ=WEEKLY;WKST=SU;BYDAY=TU" || len(jam.ExDates) != 2 {
+		t.Errorf("Unexpected recurrence %s with exceptions %v", jam.RRule, jam.ExDates)
+	}
+	if jam.Description != "Bring a friend, and snacks\nDoors at 6:30" || jam.Location != "Main Stage; upstairs" {
+		t.Errorf("Unexpected text %q at %q", jam.Description, jam.Location)
+	}
+
+	late := events[1]
+	if late.Summary != "Weekly Jam (late start with a very long title that is folded across lines)" {
+		t.Errorf("Expected the folded title to be unfolded, got %q", late.Summary)
+	}
+	if !late.RecurrenceID.Equal(time.Date(2026, 1, 27, 19, 0, 0, 0, newYork)) || late.End.Sub(late.Start) != 90*time.Minute {
+		t.Errorf("Unexpected override %v from %v to %v", late.RecurrenceID, late.Start, late.End)
+	}
+
+	retreat := events[2]
+	if retreat.Status != "CANCELLED" || retreat.End.Sub(retreat.Start) != 24*time.Hour {
+		t.Errorf("Expected a cancelled all-day event, got %s from %v to %v", retreat.Status, retreat.Start, retreat.End)
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	calendar := Calendar{Events: []Event{{
+		UID:         "event1@improv-app",
+		Stamp:       start,
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Summary:     "Harold; Night, \\ Special",
+		Description: strings.Repeat("A long description. ", 10),
+	}}}
+	events, err := Parse(strings.NewReader(calendar.String()))
+	if err != nil {
+		t.Fatalf("Error parsing calendar: %v", err)
+	}
+	if len(events) != 1 || events[0].Summary != calendar.Events[0].Summary || events[0].Description != calendar.Events[0].Description {
+		t.Errorf("Expected the event back, got %+v", events)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := []string{
+		"not a calendar",
+		"BEGIN:VEVENT\r\nEND:VEVENT\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:tomorrow\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20260106T190000Z\r\nDURATION:1 hour\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+	}
+	for _, value := range cases {
+		if _, err := Parse(strings.NewReader(value)); err == nil {
+			t.Errorf("Expected an error parsing %q", value)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"PT1H30M":   90 * time.Minute,
+		"P1D":       24 * time.Hour,
+		"P1W":       7 * 24 * time.Hour,
+		"-PT15M":    -15 * time.Minute,
+		"P1DT2H":    26 * time.Hour,
+		"PT45S":     45 * time.Second,
+		"+PT1H0M0S": time.Hour,
+	}
+	for value, want := range cases {
+		got, err := parseDuration(value)
+		if err != nil || got != want {
+			t.Errorf("Expected %s to be %v, got %v (%v)", value, want, got, err)
+		}
+	}
+}
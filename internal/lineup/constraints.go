@@ -0,0 +1,228 @@
+package lineup
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of casting rule
+const (
+	// ConstraintMaxGames caps how many games each of its players, or everyone when it has none, is in
+	ConstraintMaxGames = "max-games"
+	// ConstraintMustPlay casts each of its players, in its game when it has one
+	ConstraintMustPlay = "must-play"
+	// ConstraintKeepApart keeps its two players out of the same game
+	ConstraintKeepApart = "keep-apart"
+	// ConstraintPair casts its two players together in every game either of them is in
+	ConstraintPair = "pair"
+	// ConstraintNewcomerSlots keeps Limit of its game's places for newcomers
+	ConstraintNewcomerSlots = "newcomer-slots"
+)
+
+// ErrInvalidConstraint means a constraint has an unknown kind or the wrong players, game or limit for its kind
+var ErrInvalidConstraint = errors.New("invalid constraint")
+
+// Constraint is a casting rule for an event's lineup
+type Constraint struct {
+	ID        string   `json:"id"`
+	Kind      string   `json:"kind"`
+	PlayerIDs []string `json:"playerIds"`
+	GameID    string   `json:"gameId,omitempty"`
+	// Limit is the most games for max-games, and the places kept for newcomer-slots
+	Limit int `json:"limit,omitempty"`
+}
+
+// Validate checks the constraint has what its kind needs
+func (c Constraint) Validate() error {
+	seen := map[string]bool{}
+	for _, playerID := range c.PlayerIDs {
+		if playerID == "" || seen[playerID] {
+			return fmt.Errorf("%w: players must be different", ErrInvalidConstraint)
+		}
+		seen[playerID] = true
+	}
+
+	switch c.Kind {
+	case ConstraintMaxGames:
+		if c.Limit < 1 {
+			return fmt.Errorf("%w: the most games must be at least 1", ErrInvalidConstraint)
+		}
+	case ConstraintMustPlay:
+		if len(c.PlayerIDs) == 0 {
+			return fmt.Errorf("%w: choose who must play", ErrInvalidConstraint)
+		}
+	case ConstraintKeepApart, ConstraintPair:
+		if len(c.PlayerIDs) != 2 {
+			return fmt.Errorf("%w: choose two players", ErrInvalidConstraint)
+		}
+	case ConstraintNewcomerSlots:
+		if c.GameID == "" || c.Limit < 1 {
+			return fmt.Errorf("%w: choose a game and at least 1 place", ErrInvalidConstraint)
+		}
+		if len(c.PlayerIDs) > 0 {
+			return fmt.Errorf("%w: newcomer places aren't for particular players", ErrInvalidConstraint)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be max-games, must-play, keep-apart, pair or newcomer-slots", ErrInvalidConstraint)
+	}
+	if c.Kind != ConstraintMustPlay && c.Kind != ConstraintNewcomerSlots && c.GameID != "" {
+		return fmt.Errorf("%w: %s rules aren't for a particular game", ErrInvalidConstraint, c.Kind)
+	}
+	return nil
+}
+
+// appliesTo reports whether a max-games constraint covers the player
+func (c Constraint) appliesTo(playerID string) bool {
+	return len(c.PlayerIDs) == 0 || c.includes(playerID)
+}
+
+func (c Constraint) includes(playerID string) bool {
+	for _, id := range c.PlayerIDs {
+		if id == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// partner returns the other player in a keep-apart or pair constraint
+func (c Constraint) partner(playerID string) (string, bool) {
+	switch playerID {
+	case c.PlayerIDs[0]:
+		return c.PlayerIDs[1], true
+	case c.PlayerIDs[1]:
+		return c.PlayerIDs[0], true
+	}
+	return "", false
+}
+
+// validConstraints drops constraints that can't be checked
+func validConstraints(constraints []Constraint) []Constraint {
+	valid := make([]Constraint, 0, len(constraints))
+	for _, c := range constraints {
+		if c.Validate() == nil {
+			valid = append(valid, c)
+		}
+	}
+	return valid
+}
+
+// Violation is a lineup breaking one of its constraints
+type Violation struct {
+	ConstraintID string   `json:"constraintId"`
+	Kind         string   `json:"kind"`
+	GameID       string   `json:"gameId,omitempty"`
+	PlayerIDs    []string `json:"playerIds"`
+	Message      string   `json:"message"`
+}
+
+// name returns the display name of a player or game, or its ID without one
+func (in Input) name(id string) string {
+	if name, ok := in.Names[id]; ok {
+		return name
+	}
+	return id
+}
+
+// Check returns every way the assignments break the input's constraints
+func Check(in Input, assignments []Assignment) []Violation {
+	cast := map[string]map[string]bool{}
+	loads := map[string]int{}
+	var players []string
+	for _, assignment := range assignments {
+		if cast[assignment.GameID] == nil {
+			cast[assignment.GameID] = map[string]bool{}
+		}
+		if cast[assignment.GameID][assignment.PlayerID] {
+			continue
+		}
+		cast[assignment.GameID][assignment.PlayerID] = true
+		if loads[assignment.PlayerID] == 0 {
+			players = append(players, assignment.PlayerID)
+		}
+		loads[assignment.PlayerID]++
+	}
+
+	violations := []Violation{}
+	add := func(c Constraint, gameID string, playerIDs []string, format string, args ...interface{}) {
+		violations = append(violations, Violation{
+			ConstraintID: c.ID,
+			Kind:         c.Kind,
+			GameID:       gameID,
+			PlayerIDs:    playerIDs,
+			Message:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, c := range validConstraints(in.Constraints) {
+		switch c.Kind {
+		case ConstraintMaxGames:
+			for _, playerID := range players {
+				if c.appliesTo(playerID) && loads[playerID] > c.Limit {
+					add(c, "", []string{playerID}, "%s is in %d games, more than %d", in.name(playerID), loads[playerID], c.Limit)
+				}
+			}
+		case ConstraintMustPlay:
+			for _, playerID := range c.PlayerIDs {
+				if c.GameID != "" && !cast[c.GameID][playerID] {
+					add(c, c.GameID, []string{playerID}, "%s must play %s", in.name(playerID), in.name(c.GameID))
+				} else if c.GameID == "" && loads[playerID] == 0 {
+					add(c, "", []string{playerID}, "%s must play", in.name(playerID))
+				}
+			}
+		case ConstraintKeepApart, ConstraintPair:
+			first, second := c.PlayerIDs[0], c.PlayerIDs[1]
+			for _, game := range in.Games {
+				together := cast[game.ID][first] && cast[game.ID][second]
+				if c.Kind == ConstraintKeepApart && together {
+					add(c, game.ID, c.PlayerIDs, "%s and %s are kept apart but are both in %s", in.name(first), in.name(second), in.name(game.ID))
+				}
+				if c.Kind == ConstraintPair && !together && (cast[game.ID][first] || cast[game.ID][second]) {
+					add(c, game.ID, c.PlayerIDs, "%s and %s play together but only one of them is in %s", in.name(first), in.name(second), in.name(game.ID))
+				}
+			}
+		case ConstraintNewcomerSlots:
+			for _, game := range in.Games {
+				if game.ID != c.GameID || game.MaxPlayers <= 0 {
+					continue
+				}
+				var regulars []string
+				for _, playerID := range players {
+					if cast[game.ID][playerID] && !in.Newcomers[playerID] {
+						regulars = append(regulars, playerID)
+					}
+				}
+				if len(regulars) > game.MaxPlayers-c.Limit {
+					add(c, game.ID, regulars, "%d places in %s are kept for newcomers", c.Limit, in.name(game.ID))
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// Breaks returns the constraints adding the assignment to the lineup would break: going over a
+// player's most games, casting a player with someone they're kept apart from, or taking a
+// place kept for newcomers. Must-play and pair rules can't be met one assignment at a time,
+// so they're left to Check.
+func Breaks(in Input, assignments []Assignment, add Assignment) []Violation {
+	after := append(append([]Assignment{}, assignments...), add)
+	broken := []Violation{}
+	for _, violation := range Check(in, after) {
+		involved := false
+		for _, playerID := range violation.PlayerIDs {
+			involved = involved || playerID == add.PlayerID
+		}
+		switch violation.Kind {
+		case ConstraintMaxGames:
+			if involved {
+				broken = append(broken, violation)
+			}
+		case ConstraintKeepApart, ConstraintNewcomerSlots:
+			if involved && violation.GameID == add.GameID {
+				broken = append(broken, violation)
+			}
+		}
+	}
+	return broken
+}
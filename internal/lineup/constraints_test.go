@@ -0,0 +1,146 @@
+package lineup
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func kinds(violations []Violation) []string {
+	result := []string{}
+	for _, violation := range violations {
+		result = append(result, violation.Kind)
+	}
+	return result
+}
+
+func TestConstraint_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint Constraint
+		valid      bool
+	}{
+		{"max games for everyone", Constraint{Kind: ConstraintMaxGames, Limit: 2}, true},
+		{"max games of 0", Constraint{Kind: ConstraintMaxGames}, false},
+		{"must play a game", Constraint{Kind: ConstraintMustPlay, PlayerIDs: []string{"ada"}, GameID: "freeze"}, true},
+		{"must play nobody", Constraint{Kind: ConstraintMustPlay}, false},
+		{"keep two apart", Constraint{Kind: ConstraintKeepApart, PlayerIDs: []string{"ada", "bo"}}, true},
+		{"keep one apart", Constraint{Kind: ConstraintKeepApart, PlayerIDs: []string{"ada"}}, false},
+		{"pair with themself", Constraint{Kind: ConstraintPair, PlayerIDs: []string{"ada", "ada"}}, false},
+		{"pair in a game", Constraint{Kind: ConstraintPair, PlayerIDs: []string{"ada", "bo"}, GameID: "freeze"}, false},
+		{"newcomer places", Constraint{Kind: ConstraintNewcomerSlots, GameID: "freeze", Limit: 1}, true},
+		{"newcomer places anywhere", Constraint{Kind: ConstraintNewcomerSlots, Limit: 1}, false},
+		{"unknown kind", Constraint{Kind: "understudy"}, false},
+	}
+	for _, tt := range tests {
+		err := tt.constraint.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected valid, got %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidConstraint) {
+			t.Errorf("%s: expected ErrInvalidConstraint, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	in := Input{
+		Games: []Game{{ID: "freeze", MinPlayers: 2, MaxPlayers: 3}, {ID: "quirks", MinPlayers: 2, MaxPlayers: 4}},
+		Constraints: []Constraint{
+			{ID: "c1", Kind: ConstraintMaxGames, Limit: 1},
+			{ID: "c2", Kind: ConstraintMustPlay, PlayerIDs: []string{"di"}},
+			{ID: "c3", Kind: ConstraintKeepApart, PlayerIDs: []string{"ada", "bo"}},
+			{ID: "c4", Kind: ConstraintPair, PlayerIDs: []string{"ada", "cy"}},
+			{ID: "c5", Kind: ConstraintNewcomerSlots, GameID: "freeze", Limit: 1},
+		},
+		Newcomers: map[string]bool{"cy": true},
+		Names:     map[string]string{"ada": "Ada", "bo": "Bo", "freeze": "Freeze Tag"},
+	}
+	assignments := []Assignment{
+		{GameID: "freeze", PlayerID: "ada"},
+		{GameID: "freeze", PlayerID: "bo"},
+		{GameID: "freeze", PlayerID: "ed"},
+		{GameID: "quirks", PlayerID: "ada"},
+		{GameID: "quirks", PlayerID: "cy"},
+	}
+
+	violations := Check(in, assignments)
+	want := []string{ConstraintMaxGames, ConstraintMustPlay, ConstraintKeepApart, ConstraintPair, ConstraintNewcomerSlots}
+	if got := kinds(violations); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	if violations[0].Message != "Ada is in 2 games, more than 1" {
+		t.Errorf("Unexpected max games message %q", violations[0].Message)
+	}
+	if violations[2].Message != "Ada and Bo are kept apart but are both in Freeze Tag" {
+		t.Errorf("Unexpected keep apart message %q", violations[2].Message)
+	}
+	if violations[4].Message != "1 places in Freeze Tag are kept for newcomers" || len(violations[4].PlayerIDs) != 3 {
+		t.Errorf("Unexpected newcomer violation %+v", violations[4])
+	}
+}
+
+func TestBreaks(t *testing.T) {
+	in := Input{
+		Games: []Game{{ID: "freeze", MinPlayers: 2, MaxPlayers: 3}, {ID: "quirks", MinPlayers: 2, MaxPlayers: 4}},
+		Constraints: []Constraint{
+			{ID: "c1", Kind: ConstraintMaxGames, PlayerIDs: []string{"ada"}, Limit: 1},
+			{ID: "c2", Kind: ConstraintKeepApart, PlayerIDs: []string{"bo", "cy"}},
+			{ID: "c3", Kind: ConstraintNewcomerSlots, GameID: "freeze", Limit: 1},
+			{ID: "c4", Kind: ConstraintPair, PlayerIDs: []string{"di", "ed"}},
+		},
+		Newcomers: map[string]bool{"nina": true},
+	}
+	assignments := []Assignment{{GameID: "freeze", PlayerID: "ada"}, {GameID: "freeze", PlayerID: "bo"}}
+
+	tests := []struct {
+		add  Assignment
+		want []string
+	}{
+		{Assignment{GameID: "quirks", PlayerID: "ada"}, []string{ConstraintMaxGames}},
+		{Assignment{GameID: "quirks", PlayerID: "bo"}, []string{}},
+		{Assignment{GameID: "freeze", PlayerID: "cy"}, []string{ConstraintKeepApart, ConstraintNewcomerSlots}},
+		{Assignment{GameID: "freeze", PlayerID: "nina"}, []string{}},
+		{Assignment{GameID: "quirks", PlayerID: "di"}, []string{}},
+	}
+	for _, tt := range tests {
+		if got := kinds(Breaks(in, assignments, tt.add)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Adding %s to %s: expected %v, got %v", tt.add.PlayerID, tt.add.GameID, tt.want, got)
+		}
+	}
+}
+
+func TestGenerate_FollowsConstraints(t *testing.T) {
+	in := Input{
+		Games: []Game{
+			{ID: "freeze", MinPlayers: 2, MaxPlayers: 3},
+			{ID: "quirks", MinPlayers: 2, MaxPlayers: 3},
+		},
+		Players: []string{"ada", "bo", "cy", "di", "nina"},
+		Preferences: map[string]map[string]string{
+			"ada":  {"freeze": StatusLove, "quirks": StatusLove},
+			"bo":   {"freeze": StatusLove},
+			"di":   {"quirks": StatusDislike},
+			"nina": {"freeze": StatusDislike},
+		},
+		Constraints: []Constraint{
+			{ID: "c1", Kind: ConstraintMaxGames, PlayerIDs: []string{"ada"}, Limit: 1},
+			{ID: "c2", Kind: ConstraintKeepApart, PlayerIDs: []string{"ada", "bo"}},
+			{ID: "c3", Kind: ConstraintMustPlay, PlayerIDs: []string{"di"}, GameID: "quirks"},
+			{ID: "c4", Kind: ConstraintPair, PlayerIDs: []string{"cy", "nina"}},
+			{ID: "c5", Kind: ConstraintNewcomerSlots, GameID: "quirks", Limit: 1},
+		},
+		Newcomers: map[string]bool{"nina": true},
+	}
+
+	result := Generate(in)
+	cast := castOf(result)
+	want := map[string][]string{"freeze": {"ada", "cy"}, "quirks": {"bo", "di", "nina"}}
+	if !reflect.DeepEqual(cast, want) {
+		t.Errorf("Expected %v, got %v", want, cast)
+	}
+	// Nina won't play Freeze Tag and Quirks is full, so the pair is split in both games
+	if got := kinds(result.Violations); !reflect.DeepEqual(got, []string{ConstraintPair, ConstraintPair}) {
+		t.Errorf("Expected only the pair broken, got %+v", result.Violations)
+	}
+}
@@ -0,0 +1,226 @@
+package lineup
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Problem types, named as the frontend's game health checks name them
+const (
+	ProblemDislikedGame          = "disliked-game"
+	ProblemTooFewGames           = "too-few-games"
+	ProblemTooManyGames          = "too-many-games"
+	ProblemUnbalancedAssignments = "unbalanced-assignments"
+)
+
+// Problem severities
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Problem is something wrong with a player's games. Score is how much it costs the lineup's health.
+type Problem struct {
+	Type        string `json:"type"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	GameID      string `json:"gameId,omitempty"`
+	Score       int    `json:"score"`
+}
+
+// PlayerHealth is how happy a player is with their games, and what's wrong with them
+type PlayerHealth struct {
+	UserID     string    `json:"userId"`
+	PlayerName string    `json:"playerName"`
+	Problems   []Problem `json:"problems"`
+	// HappinessScore sums the player's preference scores for their games
+	HappinessScore int `json:"happinessScore"`
+}
+
+// round rounds halves up, as JavaScript's Math.round does
+func round(x float64) int {
+	return int(math.Floor(x + 0.5))
+}
+
+// oneDecimal formats x with one decimal place, rounding halves up as JavaScript's toFixed does
+func oneDecimal(x float64) string {
+	return fmt.Sprintf("%.1f", math.Floor(x*10+0.5)/10)
+}
+
+// gapSeverity grades how far a player's number of games is from the average
+func gapSeverity(gap float64) string {
+	switch {
+	case gap > 1.5:
+		return SeverityHigh
+	case gap > 0.75:
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+// Analyze scores every player's assignments: games they dislike, loved games they're
+// not in, and more or fewer games than the average. Assignments of anyone who isn't
+// one of the input's players are left out.
+func Analyze(in Input, assignments []Assignment) []PlayerHealth {
+	counts := map[string]int{}
+	for _, playerID := range in.Players {
+		counts[playerID] = 0
+	}
+	total := 0
+	for _, assignment := range assignments {
+		if _, ok := counts[assignment.PlayerID]; ok {
+			counts[assignment.PlayerID]++
+			total++
+		}
+	}
+	players := len(counts)
+	if players == 0 {
+		players = 1
+	}
+	average := float64(total) / float64(players)
+
+	health := make([]PlayerHealth, 0, len(in.Players))
+	for _, playerID := range in.Players {
+		player := PlayerHealth{UserID: playerID, PlayerName: in.name(playerID), Problems: []Problem{}}
+		count := counts[playerID]
+
+		switch {
+		case count == 0:
+			player.Problems = append(player.Problems, Problem{
+				Type:        ProblemTooFewGames,
+				Severity:    SeverityHigh,
+				Description: "Not assigned to any games",
+				Score:       -20,
+			})
+		case len(in.Players) > 1 && float64(count) < average-0.5:
+			gap := average - float64(count)
+			player.Problems = append(player.Problems, Problem{
+				Type:        ProblemTooFewGames,
+				Severity:    gapSeverity(gap),
+				Description: fmt.Sprintf("Assigned to %d games, which is below the average of %s", count, oneDecimal(average)),
+				Score:       -round(gap * 10),
+			})
+		case len(in.Players) > 1 && float64(count) > average+0.5:
+			// Too many games costs less than too few
+			gap := float64(count) - average
+			player.Problems = append(player.Problems, Problem{
+				Type:        ProblemTooManyGames,
+				Severity:    gapSeverity(gap),
+				Description: fmt.Sprintf("Assigned to %d games, which is above the average of %s", count, oneDecimal(average)),
+				Score:       -round(gap * 5),
+			})
+		}
+
+		assigned := map[string]bool{}
+		for _, assignment := range assignments {
+			if assignment.PlayerID != playerID {
+				continue
+			}
+			assigned[assignment.GameID] = true
+			status := in.status(playerID, assignment.GameID)
+			player.HappinessScore += Score(status)
+			if status == StatusDislike {
+				player.Problems = append(player.Problems, Problem{
+					Type:        ProblemDislikedGame,
+					Severity:    SeverityHigh,
+					Description: "Assigned to a disliked game: " + in.name(assignment.GameID),
+					GameID:      assignment.GameID,
+					Score:       -20,
+				})
+			}
+		}
+
+		var missing []string
+		for _, game := range in.Games {
+			if in.status(playerID, game.ID) == StatusLove && !assigned[game.ID] {
+				missing = append(missing, in.name(game.ID))
+			}
+		}
+		if len(missing) > 0 {
+			severity := SeverityMedium
+			if len(missing) > 1 {
+				severity = SeverityHigh
+			}
+			player.Problems = append(player.Problems, Problem{
+				Type:        ProblemUnbalancedAssignments,
+				Severity:    severity,
+				Description: fmt.Sprintf("Not assigned to %d loved game(s): %s", len(missing), strings.Join(missing, ", ")),
+				Score:       -10 * len(missing),
+			})
+		}
+
+		health = append(health, player)
+	}
+	return health
+}
+
+// OverallScore rates the lineup from 0 to 100: 70, plus three for every point of
+// happiness, less the cost of every problem
+func OverallScore(health []PlayerHealth) int {
+	if len(health) == 0 {
+		return 100
+	}
+	score := 70
+	for _, player := range health {
+		score += player.HappinessScore * 3
+		for _, problem := range player.Problems {
+			score += problem.Score
+		}
+	}
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
+
+// hasProblem reports whether the player has a problem of the type
+func (p PlayerHealth) hasProblem(problemType string) bool {
+	for _, problem := range p.Problems {
+		if problem.Type == problemType {
+			return true
+		}
+	}
+	return false
+}
+
+// Suggestions turns the players' problems, and games outside their player limits,
+// into changes to try
+func Suggestions(in Input, assignments []Assignment, health []PlayerHealth) []string {
+	suggestions := []string{}
+	for _, player := range health {
+		if player.hasProblem(ProblemTooFewGames) {
+			suggestions = append(suggestions, fmt.Sprintf("Consider adding %s to more games to balance participation.", player.PlayerName))
+		}
+	}
+	for _, player := range health {
+		for _, problem := range player.Problems {
+			if problem.Type == ProblemDislikedGame {
+				suggestions = append(suggestions, fmt.Sprintf("Consider removing %s from \"%s\" which they dislike.", player.PlayerName, in.name(problem.GameID)))
+			}
+		}
+	}
+	for _, player := range health {
+		if player.hasProblem(ProblemUnbalancedAssignments) {
+			suggestions = append(suggestions, fmt.Sprintf("Consider adding %s to games they love but aren't assigned to.", player.PlayerName))
+		}
+	}
+
+	counts := map[string]int{}
+	for _, assignment := range assignments {
+		counts[assignment.GameID]++
+	}
+	for _, game := range in.Games {
+		count := counts[game.ID]
+		if count < game.MinPlayers {
+			suggestions = append(suggestions, fmt.Sprintf("\"%s\" needs at least %d more player(s) to meet minimum requirements.", in.name(game.ID), game.MinPlayers-count))
+		} else if game.MaxPlayers > 0 && count > game.MaxPlayers {
+			suggestions = append(suggestions, fmt.Sprintf("\"%s\" has %d too many player(s) exceeding maximum capacity.", in.name(game.ID), count-game.MaxPlayers))
+		}
+	}
+	return suggestions
+}
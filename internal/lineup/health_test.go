@@ -0,0 +1,289 @@
+package lineup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The cases below mirror frontend/src/utils/gameHealthUtils.test.ts, so the two stay in step
+
+// mockGameInput is the frontend tests' mockGameData without its assignments
+func mockGameInput() Input {
+	return Input{
+		Games: []Game{
+			{ID: "1", MinPlayers: 2, MaxPlayers: 4},
+			{ID: "2", MinPlayers: 3, MaxPlayers: 6},
+			{ID: "3", MinPlayers: 2, MaxPlayers: 5},
+		},
+		Players: []string{"user1", "user2", "user3"},
+		Preferences: map[string]map[string]string{
+			"user1": {"1": StatusLove, "3": StatusLove},
+			"user2": {"2": StatusPractice, "3": StatusDislike},
+			"user3": {"1": StatusLove},
+		},
+		Names: map[string]string{
+			"1":     "Game 1",
+			"2":     "Game 2",
+			"3":     "Game 3",
+			"user1": "John Doe",
+			"user2": "Jane Smith",
+			"user3": "Bob Johnson",
+		},
+	}
+}
+
+// cast builds assignments from player:game pairs
+func cast(pairs ...string) []Assignment {
+	assignments := make([]Assignment, len(pairs))
+	for i, pair := range pairs {
+		parts := strings.SplitN(pair, ":", 2)
+		assignments[i] = Assignment{PlayerID: parts[0], GameID: parts[1]}
+	}
+	return assignments
+}
+
+var mockAssignments = cast("user1:1", "user1:2", "user2:2", "user3:3")
+
+func healthOf(t *testing.T, health []PlayerHealth, userID string) PlayerHealth {
+	t.Helper()
+	for _, player := range health {
+		if player.UserID == userID {
+			return player
+		}
+	}
+	t.Fatalf("Expected health for %s, got %+v", userID, health)
+	return PlayerHealth{}
+}
+
+func hasSuggestion(suggestions []string, parts ...string) bool {
+	for _, suggestion := range suggestions {
+		matches := true
+		for _, part := range parts {
+			matches = matches && strings.Contains(suggestion, part)
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAnalyze_Golden(t *testing.T) {
+	health := Analyze(mockGameInput(), mockAssignments)
+	want := []PlayerHealth{
+		{
+			UserID:     "user1",
+			PlayerName: "John Doe",
+			Problems: []Problem{
+				{Type: ProblemTooManyGames, Severity: SeverityLow, Description: "Assigned to 2 games, which is above the average of 1.3", Score: -3},
+				{Type: ProblemUnbalancedAssignments, Severity: SeverityMedium, Description: "Not assigned to 1 loved game(s): Game 3", Score: -10},
+			},
+			HappinessScore: 2,
+		},
+		{UserID: "user2", PlayerName: "Jane Smith", Problems: []Problem{}, HappinessScore: 1},
+		{
+			UserID:     "user3",
+			PlayerName: "Bob Johnson",
+			Problems: []Problem{
+				{Type: ProblemUnbalancedAssignments, Severity: SeverityMedium, Description: "Not assigned to 1 loved game(s): Game 1", Score: -10},
+			},
+		},
+	}
+	if !reflect.DeepEqual(health, want) {
+		t.Errorf("Expected %+v, got %+v", want, health)
+	}
+	if score := OverallScore(health); score != 56 {
+		t.Errorf("Expected an overall score of 56, got %d", score)
+	}
+}
+
+func TestAnalyze_TooFewGames(t *testing.T) {
+	health := Analyze(mockGameInput(), cast("user1:1", "user1:2", "user1:3", "user2:2", "user2:1", "user3:3"))
+	user3 := healthOf(t, health, "user3")
+	if !user3.hasProblem(ProblemTooFewGames) {
+		t.Errorf("Expected user3 to have too few games, got %+v", user3.Problems)
+	}
+	if user3.Problems[0].Description != "Assigned to 1 games, which is below the average of 2.0" || user3.Problems[0].Score != -10 {
+		t.Errorf("Unexpected problem %+v", user3.Problems[0])
+	}
+
+	none := healthOf(t, Analyze(mockGameInput(), cast("user1:1")), "user2")
+	if none.Problems[0] != (Problem{Type: ProblemTooFewGames, Severity: SeverityHigh, Description: "Not assigned to any games", Score: -20}) {
+		t.Errorf("Expected no games to be a high severity problem, got %+v", none.Problems)
+	}
+}
+
+func TestAnalyze_TooManyGames(t *testing.T) {
+	health := Analyze(mockGameInput(), cast("user1:1", "user1:2", "user1:3", "user2:2", "user3:3"))
+	if user1 := healthOf(t, health, "user1"); !user1.hasProblem(ProblemTooManyGames) {
+		t.Errorf("Expected user1 to have too many games, got %+v", user1.Problems)
+	}
+}
+
+func TestAnalyze_DislikedGames(t *testing.T) {
+	if user2 := healthOf(t, Analyze(mockGameInput(), mockAssignments), "user2"); user2.hasProblem(ProblemDislikedGame) {
+		t.Errorf("Expected no disliked game for user2, got %+v", user2.Problems)
+	}
+
+	assignments := append(cast("user2:3"), mockAssignments...)
+	user2 := healthOf(t, Analyze(mockGameInput(), assignments), "user2")
+	if !user2.hasProblem(ProblemDislikedGame) {
+		t.Fatalf("Expected user2 in a disliked game, got %+v", user2.Problems)
+	}
+	if user2.HappinessScore != -1 {
+		t.Errorf("Expected happiness of -1, got %d", user2.HappinessScore)
+	}
+}
+
+func TestAnalyze_MissingLovedGames(t *testing.T) {
+	health := Analyze(mockGameInput(), mockAssignments)
+	for _, userID := range []string{"user1", "user3"} {
+		if player := healthOf(t, health, userID); !player.hasProblem(ProblemUnbalancedAssignments) {
+			t.Errorf("Expected %s to be missing a loved game, got %+v", userID, player.Problems)
+		}
+	}
+}
+
+func TestAnalyze_HappinessScores(t *testing.T) {
+	health := Analyze(mockGameInput(), mockAssignments)
+	for userID, want := range map[string]int{"user1": 2, "user2": 1, "user3": 0} {
+		if got := healthOf(t, health, userID).HappinessScore; got != want {
+			t.Errorf("Expected %s happiness of %d, got %d", userID, want, got)
+		}
+	}
+}
+
+func TestOverallScore(t *testing.T) {
+	if score := OverallScore(nil); score != 100 {
+		t.Errorf("Expected 100 with no players, got %d", score)
+	}
+
+	original := OverallScore(Analyze(mockGameInput(), mockAssignments))
+	if original >= 100 {
+		t.Errorf("Expected problems to lower the score, got %d", original)
+	}
+	optimal := OverallScore(Analyze(mockGameInput(), cast("user1:1", "user1:3", "user2:2", "user3:1")))
+	if optimal <= original {
+		t.Errorf("Expected better assignments to score higher than %d, got %d", original, optimal)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	in := mockGameInput()
+	suggest := func(assignments []Assignment) []string {
+		return Suggestions(in, assignments, Analyze(in, assignments))
+	}
+
+	tests := []struct {
+		name        string
+		assignments []Assignment
+		parts       []string
+	}{
+		{"more games", cast("user1:1", "user1:2", "user2:1", "user2:2", "user3:3"), []string{"Bob Johnson", "more games"}},
+		{"disliked game", append(cast("user2:3"), mockAssignments...), []string{"Jane Smith", "removing", "Game 3"}},
+		{"loved games", mockAssignments, []string{"games they love"}},
+		{"minimum players", cast("user1:2"), []string{"Game 2", "more player"}},
+		{"maximum players", cast("user1:1", "user2:1", "user3:1", "user4:1", "user5:1"), []string{"Game 1", "too many"}},
+	}
+	for _, tt := range tests {
+		if suggestions := suggest(tt.assignments); !hasSuggestion(suggestions, tt.parts...) {
+			t.Errorf("%s: expected a suggestion with %v, got %v", tt.name, tt.parts, suggestions)
+		}
+	}
+
+	want := []string{
+		"Consider adding Bob Johnson to more games to balance participation.",
+		`Consider removing Jane Smith from "Game 3" which they dislike.`,
+		"Consider adding John Doe to games they love but aren't assigned to.",
+		"Consider adding Bob Johnson to games they love but aren't assigned to.",
+		`"Game 1" needs at least 1 more player(s) to meet minimum requirements.`,
+		`"Game 2" needs at least 1 more player(s) to meet minimum requirements.`,
+	}
+	if got := suggest(append(cast("user2:3"), mockAssignments...)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// The frontend's autoAssignPlayers cases, run against Generate
+
+func TestGenerate_AssignsEveryone(t *testing.T) {
+	loads := loadsOf(Generate(mockGameInput()))
+	for _, player := range mockGameInput().Players {
+		if loads[player] == 0 {
+			t.Errorf("Expected %s in at least one game, got %v", player, loads)
+		}
+	}
+}
+
+func TestGenerate_RespectsPlayerLimits(t *testing.T) {
+	result := Generate(mockGameInput())
+	counts := map[string]int{}
+	for _, assignment := range result.Assignments {
+		counts[assignment.GameID]++
+	}
+	for _, game := range mockGameInput().Games {
+		if counts[game.ID] < game.MinPlayers || counts[game.ID] > game.MaxPlayers {
+			t.Errorf("Expected %s to have %d-%d players, got %d", game.ID, game.MinPlayers, game.MaxPlayers, counts[game.ID])
+		}
+	}
+}
+
+func TestGenerate_AssignsLovedGames(t *testing.T) {
+	in := mockGameInput()
+	games := castOf(Generate(in))
+	for player, preferences := range in.Preferences {
+		loved, assigned := false, false
+		for gameID, status := range preferences {
+			if status != StatusLove {
+				continue
+			}
+			loved = true
+			for _, cast := range games[gameID] {
+				assigned = assigned || cast == player
+			}
+		}
+		if loved && !assigned {
+			t.Errorf("Expected %s in a game they love, got %v", player, games)
+		}
+	}
+}
+
+func TestGenerate_AvoidsDislikedGames(t *testing.T) {
+	in := mockGameInput()
+	in.Players = append(in.Players, "user4")
+	in.Preferences["user4"] = map[string]string{"1": StatusLove, "2": StatusLove}
+
+	result := Generate(in)
+	for _, assignment := range result.Assignments {
+		if in.status(assignment.PlayerID, assignment.GameID) == StatusDislike {
+			t.Errorf("Expected %s kept out of disliked game %s", assignment.PlayerID, assignment.GameID)
+		}
+	}
+}
+
+func TestGenerate_BalancesLikeTheFrontend(t *testing.T) {
+	in := mockGameInput()
+	in.Players = append(in.Players, "user4", "user5")
+
+	loads := loadsOf(Generate(in))
+	total := 0
+	for _, player := range in.Players {
+		total += loads[player]
+	}
+	average := float64(total) / float64(len(in.Players))
+	for _, player := range in.Players {
+		if gap := float64(loads[player]) - average; gap > 1.5 || gap < -1.5 {
+			t.Errorf("Expected %s within 1.5 games of the average %.1f, got %d", player, average, loads[player])
+		}
+	}
+}
+
+func TestGenerate_ImprovesHealth(t *testing.T) {
+	in := mockGameInput()
+	original := OverallScore(Analyze(in, mockAssignments))
+	generated := OverallScore(Analyze(in, Generate(in).Assignments))
+	if generated < original {
+		t.Errorf("Expected the generated lineup to score at least %d, got %d", original, generated)
+	}
+}
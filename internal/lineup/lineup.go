@@ -0,0 +1,330 @@
+// Package lineup casts an event's players into its games. Players are scored on
+// their game preferences the same way the frontend's game health checks score
+// them, each player's games are kept close to everyone else's, and the event's
+// casting rules are followed where the players and games allow.
+package lineup
+
+import "sort"
+
+// Preference statuses, as saved in user_game_preferences
+const (
+	StatusLove      = "I Love playing this"
+	StatusPractice  = "I Need to practice this"
+	StatusDislike   = "I dont like this game"
+	StatusTry       = "I want to try this game"
+	StatusNoOpinion = "No opinion on this game"
+)
+
+// Weights the generator trades preference against balance with. A loved game is
+// worth one game more than a player's share, but not two; a disliked game is
+// only used to get a game up to its minimum.
+const (
+	preferenceWeight = 10
+	loadPenalty      = 15
+	dislikePenalty   = 100
+)
+
+// Score returns how much a player wants to play a game given their preference
+// status. Games without a preference, and games to try, score 0.
+func Score(status string) int {
+	switch status {
+	case StatusLove:
+		return 2
+	case StatusPractice:
+		return 1
+	case StatusDislike:
+		return -2
+	}
+	return 0
+}
+
+// Game is a game in the event's lineup
+type Game struct {
+	ID         string
+	MinPlayers int
+	MaxPlayers int
+	// TargetPlayers is how many players the MC would like in the game, when set
+	TargetPlayers *int
+}
+
+// want is how many players the generator tries to give the game before
+// balancing, kept between its minimum and maximum
+func (g Game) want() int {
+	want := g.MinPlayers
+	if g.TargetPlayers != nil {
+		want = *g.TargetPlayers
+	}
+	return g.clamp(want)
+}
+
+func (g Game) clamp(n int) int {
+	if n < g.MinPlayers {
+		n = g.MinPlayers
+	}
+	if g.MaxPlayers > 0 && n > g.MaxPlayers {
+		n = g.MaxPlayers
+	}
+	return n
+}
+
+// hasRoom reports whether the game can take another player past count
+func (g Game) hasRoom(count int) bool {
+	return g.MaxPlayers <= 0 || count < g.MaxPlayers
+}
+
+// Input is everything the generator casts from. Games and Players are in the
+// order ties are broken in.
+type Input struct {
+	Games   []Game
+	Players []string
+	// Preferences maps player ID, then game ID, to the player's preference status
+	Preferences map[string]map[string]string
+	Constraints []Constraint
+	// Newcomers are the players that places kept for newcomers can go to
+	Newcomers map[string]bool
+	// Names are the player and game names used in violation messages
+	Names map[string]string
+}
+
+func (in Input) status(playerID, gameID string) string {
+	return in.Preferences[playerID][gameID]
+}
+
+// Assignment puts a player in a game
+type Assignment struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"userId"`
+}
+
+// Shortfall is a game left under its minimum because too few players were available
+type Shortfall struct {
+	GameID  string `json:"gameId"`
+	Missing int    `json:"missing"`
+}
+
+// Result is a generated lineup
+type Result struct {
+	// Assignments are in lineup order, then player order
+	Assignments []Assignment
+	Shortfalls  []Shortfall
+	// Score sums the preference scores of every assignment
+	Score int
+	// Violations are the constraints the players and games didn't allow to be met
+	Violations []Violation
+}
+
+// caster holds the lineup as it's built up
+type caster struct {
+	in     Input
+	cast   []map[int]bool // by game, the players in it
+	counts []int          // by game
+	loads  []int          // by player, how many games they're in
+	index  map[string]int // players by ID
+}
+
+func (c *caster) add(game, player int) {
+	c.cast[game][player] = true
+	c.counts[game]++
+	c.loads[player]++
+}
+
+// utility is how good putting the player in the game would be, given the games they already have
+func (c *caster) utility(game, player int) int {
+	score := Score(c.in.status(c.in.Players[player], c.in.Games[game].ID))
+	value := score * preferenceWeight
+	if score < 0 {
+		value = -dislikePenalty
+	}
+	return value - c.loads[player]*loadPenalty
+}
+
+// allowed reports whether the constraints let the player into the game
+func (c *caster) allowed(game, player int) bool {
+	playerID := c.in.Players[player]
+	g := c.in.Games[game]
+	for _, constraint := range c.in.Constraints {
+		switch constraint.Kind {
+		case ConstraintMaxGames:
+			if constraint.appliesTo(playerID) && c.loads[player] >= constraint.Limit {
+				return false
+			}
+		case ConstraintKeepApart:
+			if partner, ok := constraint.partner(playerID); ok {
+				if p, cast := c.index[partner]; cast && c.cast[game][p] {
+					return false
+				}
+			}
+		case ConstraintNewcomerSlots:
+			if constraint.GameID != g.ID || g.MaxPlayers <= 0 || c.in.Newcomers[playerID] {
+				continue
+			}
+			regulars := 0
+			for p := range c.cast[game] {
+				if !c.in.Newcomers[c.in.Players[p]] {
+					regulars++
+				}
+			}
+			if regulars >= g.MaxPlayers-constraint.Limit {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// best returns the highest utility game and player allowed by ok and the constraints.
+// Ties go to the player with fewer games, then to the earlier game and player.
+func (c *caster) best(ok func(game, player int) bool) (int, int, bool) {
+	bestGame, bestPlayer, found := -1, -1, false
+	bestUtility := 0
+	for g := range c.in.Games {
+		for p := range c.in.Players {
+			if c.cast[g][p] || !ok(g, p) || !c.allowed(g, p) {
+				continue
+			}
+			utility := c.utility(g, p)
+			if !found || utility > bestUtility || (utility == bestUtility && c.loads[p] < c.loads[bestPlayer]) {
+				bestGame, bestPlayer, bestUtility, found = g, p, utility, true
+			}
+		}
+	}
+	return bestGame, bestPlayer, found
+}
+
+func (c *caster) dislikes(game, player int) bool {
+	return Score(c.in.status(c.in.Players[player], c.in.Games[game].ID)) < 0
+}
+
+// Generate casts the players into the games. Players who must play are cast
+// first. Then it fills every game to its target, or its minimum without one,
+// gives anyone left out a game they don't dislike, and adds loved games for
+// players below the average number of games. Players are only put in games they
+// dislike to reach a game's minimum, or because they must play. Last, paired
+// players join their partners' games where there's room.
+func Generate(in Input) Result {
+	in.Constraints = validConstraints(in.Constraints)
+	c := &caster{
+		in:     in,
+		cast:   make([]map[int]bool, len(in.Games)),
+		counts: make([]int, len(in.Games)),
+		loads:  make([]int, len(in.Players)),
+		index:  map[string]int{},
+	}
+	for g := range in.Games {
+		c.cast[g] = map[int]bool{}
+	}
+	for p, playerID := range in.Players {
+		c.index[playerID] = p
+	}
+
+	// Players who must play get their places first
+	for _, constraint := range in.Constraints {
+		if constraint.Kind != ConstraintMustPlay {
+			continue
+		}
+		for _, playerID := range constraint.PlayerIDs {
+			p, ok := c.index[playerID]
+			if !ok {
+				continue
+			}
+			g, _, found := c.best(func(g, candidate int) bool {
+				if candidate != p || !in.Games[g].hasRoom(c.counts[g]) {
+					return false
+				}
+				if constraint.GameID != "" {
+					return in.Games[g].ID == constraint.GameID
+				}
+				return c.loads[p] == 0
+			})
+			if found {
+				c.add(g, p)
+			}
+		}
+	}
+
+	// Fill each game to what it wants
+	for {
+		g, p, found := c.best(func(g, p int) bool {
+			game := in.Games[g]
+			if c.counts[g] >= game.want() {
+				return false
+			}
+			return !c.dislikes(g, p) || c.counts[g] < game.MinPlayers
+		})
+		if !found {
+			break
+		}
+		c.add(g, p)
+	}
+
+	// Nobody sits the whole show out if there's a game with room they'd play
+	for p := range in.Players {
+		if c.loads[p] > 0 {
+			continue
+		}
+		g, _, found := c.best(func(g, candidate int) bool {
+			return candidate == p && in.Games[g].hasRoom(c.counts[g]) && !c.dislikes(g, p)
+		})
+		if found {
+			c.add(g, p)
+		}
+	}
+
+	// Loved games for players still below the average, while there's room
+	if len(in.Players) > 0 {
+		total := 0
+		for _, count := range c.counts {
+			total += count
+		}
+		average := (total + len(in.Players) - 1) / len(in.Players)
+		for {
+			g, p, found := c.best(func(g, p int) bool {
+				return c.loads[p] < average && in.Games[g].hasRoom(c.counts[g]) &&
+					in.status(in.Players[p], in.Games[g].ID) == StatusLove
+			})
+			if !found {
+				break
+			}
+			c.add(g, p)
+		}
+	}
+
+	// Paired players join their partners in games they don't dislike, where there's room
+	for _, constraint := range in.Constraints {
+		if constraint.Kind != ConstraintPair {
+			continue
+		}
+		first, firstOK := c.index[constraint.PlayerIDs[0]]
+		second, secondOK := c.index[constraint.PlayerIDs[1]]
+		if !firstOK || !secondOK {
+			continue
+		}
+		for g := range in.Games {
+			for _, pair := range [][2]int{{first, second}, {second, first}} {
+				player, partner := pair[0], pair[1]
+				if c.cast[g][player] && !c.cast[g][partner] && in.Games[g].hasRoom(c.counts[g]) &&
+					c.allowed(g, partner) && !c.dislikes(g, partner) {
+					c.add(g, partner)
+				}
+			}
+		}
+	}
+
+	result := Result{Assignments: []Assignment{}, Shortfalls: []Shortfall{}}
+	for g, game := range in.Games {
+		players := make([]int, 0, len(c.cast[g]))
+		for p := range c.cast[g] {
+			players = append(players, p)
+		}
+		sort.Ints(players)
+		for _, p := range players {
+			result.Assignments = append(result.Assignments, Assignment{GameID: game.ID, PlayerID: in.Players[p]})
+			result.Score += Score(in.status(in.Players[p], game.ID))
+		}
+		if c.counts[g] < game.MinPlayers {
+			result.Shortfalls = append(result.Shortfalls, Shortfall{GameID: game.ID, Missing: game.MinPlayers - c.counts[g]})
+		}
+	}
+	result.Violations = Check(in, result.Assignments)
+	return result
+}
@@ -0,0 +1,144 @@
+package lineup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func target(n int) *int {
+	return &n
+}
+
+// castOf groups a result's players by game
+func castOf(result Result) map[string][]string {
+	cast := map[string][]string{}
+	for _, assignment := range result.Assignments {
+		cast[assignment.GameID] = append(cast[assignment.GameID], assignment.PlayerID)
+	}
+	return cast
+}
+
+func loadsOf(result Result) map[string]int {
+	loads := map[string]int{}
+	for _, assignment := range result.Assignments {
+		loads[assignment.PlayerID]++
+	}
+	return loads
+}
+
+func TestScore(t *testing.T) {
+	tests := map[string]int{
+		StatusLove:      2,
+		StatusPractice:  1,
+		StatusTry:       0,
+		StatusNoOpinion: 0,
+		"":              0,
+		StatusDislike:   -2,
+	}
+	for status, want := range tests {
+		if got := Score(status); got != want {
+			t.Errorf("Score(%q) = %d, want %d", status, got, want)
+		}
+	}
+}
+
+func TestGenerate_FollowsPreferences(t *testing.T) {
+	result := Generate(Input{
+		Games: []Game{
+			{ID: "freeze", MinPlayers: 2, MaxPlayers: 4},
+			{ID: "quirks", MinPlayers: 2, MaxPlayers: 4},
+		},
+		Players: []string{"ada", "bo", "cy", "di"},
+		Preferences: map[string]map[string]string{
+			"ada": {"freeze": StatusLove, "quirks": StatusDislike},
+			"bo":  {"freeze": StatusPractice},
+			"cy":  {"quirks": StatusLove},
+			"di":  {"quirks": StatusPractice, "freeze": StatusDislike},
+		},
+	})
+
+	want := map[string][]string{"freeze": {"ada", "bo"}, "quirks": {"cy", "di"}}
+	if cast := castOf(result); !reflect.DeepEqual(cast, want) {
+		t.Errorf("Expected %v, got %v", want, cast)
+	}
+	if result.Score != 6 || len(result.Shortfalls) != 0 {
+		t.Errorf("Expected a score of 6 and no shortfalls, got %d and %v", result.Score, result.Shortfalls)
+	}
+}
+
+func TestGenerate_BalancesGames(t *testing.T) {
+	// Everyone loves everything, so only balance decides
+	games := []Game{
+		{ID: "a", MinPlayers: 2, MaxPlayers: 2},
+		{ID: "b", MinPlayers: 2, MaxPlayers: 2},
+		{ID: "c", MinPlayers: 2, MaxPlayers: 2},
+	}
+	players := []string{"p1", "p2", "p3"}
+	preferences := map[string]map[string]string{}
+	for _, player := range players {
+		preferences[player] = map[string]string{"a": StatusLove, "b": StatusLove, "c": StatusLove}
+	}
+
+	result := Generate(Input{Games: games, Players: players, Preferences: preferences})
+	for _, player := range players {
+		if loads := loadsOf(result); loads[player] != 2 {
+			t.Errorf("Expected every player in 2 games, got %v", loads)
+			break
+		}
+	}
+}
+
+func TestGenerate_TargetsAndRoom(t *testing.T) {
+	result := Generate(Input{
+		Games: []Game{
+			{ID: "scene", MinPlayers: 2, MaxPlayers: 6, TargetPlayers: target(3)},
+			{ID: "solo", MinPlayers: 1, MaxPlayers: 1},
+		},
+		Players: []string{"ada", "bo", "cy", "di", "ed"},
+		Preferences: map[string]map[string]string{
+			"ed": {"solo": StatusLove},
+			"di": {"scene": StatusDislike, "solo": StatusDislike},
+		},
+	})
+
+	cast := castOf(result)
+	if len(cast["scene"]) != 3 || !reflect.DeepEqual(cast["solo"], []string{"ed"}) {
+		t.Errorf("Expected 3 in the scene and Ed alone in the solo, got %v", cast)
+	}
+	// Di dislikes both games, so sits out rather than be forced in
+	if loads := loadsOf(result); loads["di"] != 0 || loads["ada"]+loads["bo"]+loads["cy"] != 3 {
+		t.Errorf("Expected Di left out and everyone else cast, got %v", loads)
+	}
+}
+
+func TestGenerate_DislikesOnlyForMinimums(t *testing.T) {
+	result := Generate(Input{
+		Games:   []Game{{ID: "freeze", MinPlayers: 3, MaxPlayers: 6}},
+		Players: []string{"ada", "bo", "cy"},
+		Preferences: map[string]map[string]string{
+			"cy": {"freeze": StatusDislike},
+		},
+	})
+	if loads := loadsOf(result); loads["cy"] != 1 || result.Score != -2 {
+		t.Errorf("Expected Cy cast to make the minimum, got %v with score %d", loads, result.Score)
+	}
+}
+
+func TestGenerate_Shortfalls(t *testing.T) {
+	result := Generate(Input{
+		Games:   []Game{{ID: "quirks", MinPlayers: 4, MaxPlayers: 4}, {ID: "freeze", MinPlayers: 2, MaxPlayers: 4}},
+		Players: []string{"ada", "bo"},
+	})
+	want := []Shortfall{{GameID: "quirks", Missing: 2}}
+	if !reflect.DeepEqual(result.Shortfalls, want) {
+		t.Errorf("Expected %v, got %v", want, result.Shortfalls)
+	}
+	if cast := castOf(result); len(cast["freeze"]) != 2 {
+		t.Errorf("Expected the players cast into freeze as well, got %v", cast)
+	}
+
+	empty := Generate(Input{Games: []Game{{ID: "freeze", MinPlayers: 2, MaxPlayers: 4}}})
+	if len(empty.Assignments) != 0 || len(empty.Shortfalls) != 1 {
+		t.Errorf("Expected an empty lineup short by 2, got %+v", empty)
+	}
+}
@@ -0,0 +1,104 @@
+// Package pubsub is an in-process publish/subscribe broker used to push live updates to connected clients.
+package pubsub
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Message is a single update published to a topic
+type Message struct {
+	ID   uint64
+	Type string
+	Data interface{}
+}
+
+// Subscription receives the messages published to a topic until it is closed
+type Subscription struct {
+	C <-chan Message
+
+	broker *Broker
+	topic  string
+	ch     chan Message
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.remove(s.topic, s.ch)
+}
+
+// Broker fans published messages out to every subscriber of a topic.
+// Publishing never blocks: a subscriber that falls a full buffer behind is
+// dropped and its channel closed, so it can reconnect and refetch.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan Message]struct{}
+	bufferSize  int
+	lastID      uint64
+}
+
+// NewBroker creates a broker whose subscribers buffer up to bufferSize messages
+func NewBroker(bufferSize int) *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[chan Message]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe starts receiving messages published to the topic
+func (b *Broker) Subscribe(topic string) *Subscription {
+	ch := make(chan Message, b.bufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan Message]struct{})
+	}
+	b.subscribers[topic][ch] = struct{}{}
+
+	return &Subscription{C: ch, broker: b, topic: topic, ch: ch}
+}
+
+// Publish sends a message to every subscriber of the topic and returns the message as sent
+func (b *Broker) Publish(topic, messageType string, data interface{}) Message {
+	message := Message{
+		ID:   atomic.AddUint64(&b.lastID, 1),
+		Type: messageType,
+		Data: data,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[topic] {
+		select {
+		case ch <- message:
+		default:
+			b.removeLocked(topic, ch)
+		}
+	}
+	return message
+}
+
+// Subscribers returns how many subscribers the topic has
+func (b *Broker) Subscribers(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[topic])
+}
+
+func (b *Broker) remove(topic string, ch chan Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(topic, ch)
+}
+
+func (b *Broker) removeLocked(topic string, ch chan Message) {
+	if _, ok := b.subscribers[topic][ch]; !ok {
+		return
+	}
+	delete(b.subscribers[topic], ch)
+	close(ch)
+	if len(b.subscribers[topic]) == 0 {
+		delete(b.subscribers, topic)
+	}
+}
@@ -0,0 +1,99 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBroker_DeliversToTopicSubscribers(t *testing.T) {
+	broker := NewBroker(4)
+	first := broker.Subscribe("event123")
+	second := broker.Subscribe("event123")
+	other := broker.Subscribe("event456")
+	defer first.Close()
+	defer second.Close()
+	defer other.Close()
+
+	sent := broker.Publish("event123", "rsvp.changed", "attending")
+
+	for _, sub := range []*Subscription{first, second} {
+		message := <-sub.C
+		if message.ID != sent.ID || message.Type != "rsvp.changed" || message.Data != "attending" {
+			t.Errorf("Unexpected message: %+v", message)
+		}
+	}
+
+	select {
+	case message := <-other.C:
+		t.Errorf("Expected no message on another topic, got %+v", message)
+	default:
+	}
+}
+
+func TestBroker_IDsIncrease(t *testing.T) {
+	broker := NewBroker(1)
+	first := broker.Publish("a", "x", nil)
+	second := broker.Publish("b", "x", nil)
+	if second.ID <= first.ID {
+		t.Errorf("Expected increasing IDs, got %d then %d", first.ID, second.ID)
+	}
+}
+
+func TestBroker_CloseUnsubscribes(t *testing.T) {
+	broker := NewBroker(1)
+	sub := broker.Subscribe("event123")
+	if broker.Subscribers("event123") != 1 {
+		t.Fatalf("Expected 1 subscriber, got %d", broker.Subscribers("event123"))
+	}
+
+	sub.Close()
+	sub.Close()
+
+	if broker.Subscribers("event123") != 0 {
+		t.Errorf("Expected no subscribers after close, got %d", broker.Subscribers("event123"))
+	}
+	if _, ok := <-sub.C; ok {
+		t.Errorf("Expected the subscription channel to be closed")
+	}
+	broker.Publish("event123", "rsvp.changed", nil)
+}
+
+func TestBroker_DropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker(2)
+	slow := broker.Subscribe("event123")
+	defer slow.Close()
+
+	for i := 0; i < 3; i++ {
+		broker.Publish("event123", "game.added", i)
+	}
+
+	if broker.Subscribers("event123") != 0 {
+		t.Errorf("Expected the slow subscriber to be dropped")
+	}
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("Expected the buffered messages before the drop, got %d", received)
+	}
+}
+
+func TestBroker_ConcurrentPublishAndSubscribe(t *testing.T) {
+	broker := NewBroker(100)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sub := broker.Subscribe("event123")
+			sub.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			broker.Publish("event123", "rsvp.changed", nil)
+		}()
+	}
+	wg.Wait()
+}
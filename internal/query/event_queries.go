@@ -0,0 +1,44 @@
+package query
+
+// VisibleEventsFrom joins events to their group and to the membership and follower rows
+// of the user bound to $1. Pair it with VisibleEventsCondition.
+const VisibleEventsFrom = `
+	FROM events e
+	JOIN improv_groups g ON e.group_id = g.id
+	LEFT JOIN group_members m ON e.group_id = m.group_id AND m.user_id = $1
+	LEFT JOIN group_followers f ON e.group_id = f.group_id AND f.user_id = $1
+`
+
+// VisibleEventsCondition restricts VisibleEventsFrom to events the user can see:
+// public events, events of groups they belong to, and members-and-followers events
+// of groups they follow. Drafts are only seen by the group's admins and organizers.
+const VisibleEventsCondition = `
+	(e.status != 'draft' OR m.role IN ('admin', 'organizer'))
+	AND (e.visibility = 'public'
+	 OR m.user_id IS NOT NULL
+	 OR (e.visibility = 'members-and-followers' AND f.user_id IS NOT NULL))
+`
+
+// VisibleSeriesFrom is VisibleEventsFrom for recurring event series, aliased s.
+// Pair it with VisibleSeriesCondition.
+const VisibleSeriesFrom = `
+	FROM event_series s
+	JOIN improv_groups g ON s.group_id = g.id
+	LEFT JOIN group_members m ON s.group_id = m.group_id AND m.user_id = $1
+	LEFT JOIN group_followers f ON s.group_id = f.group_id AND f.user_id = $1
+`
+
+// VisibleSeriesCondition restricts VisibleSeriesFrom the same way VisibleEventsCondition does
+const VisibleSeriesCondition = `
+	(s.visibility = 'public'
+	 OR m.user_id IS NOT NULL
+	 OR (s.visibility = 'members-and-followers' AND f.user_id IS NOT NULL))
+`
+
+// NotCancelledOccurrenceCondition hides saved occurrences that were cancelled from their series
+const NotCancelledOccurrenceCondition = `
+	NOT EXISTS (
+		SELECT 1 FROM event_series_exceptions x
+		WHERE x.event_id = e.id AND x.cancelled = TRUE
+	)
+`
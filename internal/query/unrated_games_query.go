@@ -0,0 +1,66 @@
+package query
+
+// BuildUnratedGamesQuery builds the query for games in the user's group libraries
+// that the user hasn't set a status for yet, optionally narrowed by a search term.
+// Rows are scanned as id, name, description, min_players, max_players, created_at,
+// created_by, group_id, public, tags, [relevance_score,] event_count.
+func BuildUnratedGamesQuery(userID, searchTerm string, limit int) QueryResult {
+	var queryStr string
+	var params []interface{}
+
+	if searchTerm != "" {
+		likePattern := "%" + searchTerm + "%"
+		// Add wildcard for partial word matching
+		searchTermWithWildcard := searchTerm + "*"
+
+		queryStr = `
+			SELECT g.id, g.name, g.description, g.min_players, g.max_players, g.created_at, g.created_by, g.group_id, g.public,
+				GROUP_CONCAT(DISTINCT t.name) as tags,
+				(CASE
+				  WHEN g.name LIKE ? THEN 3
+				  WHEN g.description LIKE ? THEN 1
+				  ELSE 0
+				END) AS relevance_score,
+				COUNT(DISTINCT eg.event_id) AS event_count
+			FROM games g
+			JOIN games_fts ON games_fts.docid = g.rowid
+			JOIN group_game_libraries ggl ON g.id = ggl.game_id
+			JOIN group_members gm ON ggl.group_id = gm.group_id
+			LEFT JOIN game_tag_associations gta ON g.id = gta.game_id
+			LEFT JOIN game_tags t ON gta.tag_id = t.id
+			LEFT JOIN user_game_preferences ugp ON g.id = ugp.game_id AND ugp.user_id = ?
+			LEFT JOIN event_games eg ON g.id = eg.game_id
+			WHERE gm.user_id = ? AND ugp.status IS NULL
+			AND games_fts MATCH ?
+			GROUP BY g.id
+			ORDER BY relevance_score DESC, event_count DESC, g.created_at DESC
+			LIMIT ?
+		`
+		params = []interface{}{likePattern, likePattern, userID, userID, searchTermWithWildcard, limit}
+	} else {
+		queryStr = `
+			SELECT g.id, g.name, g.description, g.min_players, g.max_players, g.created_at, g.created_by, g.group_id, g.public,
+				GROUP_CONCAT(DISTINCT t.name) as tags,
+				COUNT(DISTINCT eg.event_id) AS event_count
+			FROM games g
+			JOIN group_game_libraries ggl ON g.id = ggl.game_id
+			JOIN group_members gm ON ggl.group_id = gm.group_id
+			LEFT JOIN game_tag_associations gta ON g.id = gta.game_id
+			LEFT JOIN game_tags t ON gta.tag_id = t.id
+			LEFT JOIN user_game_preferences ugp ON g.id = ugp.game_id AND ugp.user_id = ?
+			LEFT JOIN event_games eg ON g.id = eg.game_id
+			WHERE gm.user_id = ? AND ugp.status IS NULL
+			GROUP BY g.id
+			ORDER BY event_count DESC, g.created_at DESC
+			LIMIT ?
+		`
+		params = []interface{}{userID, userID, limit}
+	}
+
+	return QueryResult{
+		Query:         queryStr,
+		Params:        params,
+		PageSize:      limit,
+		IsSearchQuery: searchTerm != "",
+	}
+}
@@ -0,0 +1,63 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildUnratedGamesQuery_Basic(t *testing.T) {
+	result := BuildUnratedGamesQuery("user123", "", 10)
+
+	if !strings.Contains(result.Query, "WHERE gm.user_id = ? AND ugp.status IS NULL") {
+		t.Errorf("Expected unrated filter, got: %s", result.Query)
+	}
+
+	if strings.Contains(result.Query, "games_fts") {
+		t.Errorf("Expected no FTS join without a search term, got: %s", result.Query)
+	}
+
+	// user ID for the preference join, user ID for membership, and the limit
+	if len(result.Params) != 3 {
+		t.Fatalf("Expected 3 parameters, got %d", len(result.Params))
+	}
+
+	if result.Params[0] != "user123" || result.Params[1] != "user123" {
+		t.Errorf("Expected user params to be 'user123', got '%v' and '%v'", result.Params[0], result.Params[1])
+	}
+
+	if result.Params[2] != 10 {
+		t.Errorf("Expected limit param to be 10, got '%v'", result.Params[2])
+	}
+
+	if result.IsSearchQuery {
+		t.Errorf("Expected IsSearchQuery to be false")
+	}
+}
+
+func TestBuildUnratedGamesQuery_Search(t *testing.T) {
+	result := BuildUnratedGamesQuery("user123", "zip", 5)
+
+	if !strings.Contains(result.Query, "AND games_fts MATCH ?") {
+		t.Errorf("Expected MATCH clause, got: %s", result.Query)
+	}
+
+	if !strings.Contains(result.Query, "ORDER BY relevance_score DESC") {
+		t.Errorf("Expected ordering by relevance_score, got: %s", result.Query)
+	}
+
+	if len(result.Params) != 6 {
+		t.Fatalf("Expected 6 parameters, got %d", len(result.Params))
+	}
+
+	if result.Params[0] != "%zip%" {
+		t.Errorf("Expected first param to be '%%zip%%', got '%v'", result.Params[0])
+	}
+
+	if result.Params[4] != "zip*" {
+		t.Errorf("Expected fifth param to be 'zip*', got '%v'", result.Params[4])
+	}
+
+	if !result.IsSearchQuery {
+		t.Errorf("Expected IsSearchQuery to be true")
+	}
+}
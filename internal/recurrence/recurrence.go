@@ -0,0 +1,478 @@
+// Package recurrence parses and expands the subset of RFC 5545 recurrence rules
+// used for repeating events: daily, weekly and monthly rules with INTERVAL,
+// BYDAY (including "nth weekday of the month"), BYMONTHDAY, COUNT and UNTIL.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// untilFormat is the UTC date-time form of UNTIL; untilDateFormat is the date-only form
+const (
+	untilFormat     = "20060102T150405Z"
+	untilDateFormat = "20060102"
+)
+
+// maxPeriods stops expansion of rules that can never produce another occurrence
+const maxPeriods = 100000
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeekdayNum is a BYDAY entry. N is the nth occurrence of the weekday in the
+// month (negative counts from the end); 0 means every such weekday.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+func (d WeekdayNum) String() string {
+	if d.N == 0 {
+		return weekdayNames[d.Day]
+	}
+	return strconv.Itoa(d.N) + weekdayNames[d.Day]
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq       string
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int
+	// Until is the last moment an occurrence may start; zero means no limit
+	Until time.Time
+}
+
+// Parse reads an RRULE value such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH".
+// A leading "RRULE:" is allowed.
+func Parse(value string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "RRULE:"), "rrule:")
+	if value == "" {
+		return rule, fmt.Errorf("empty recurrence rule")
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		val = strings.ToUpper(strings.TrimSpace(val))
+
+		switch key {
+		case "FREQ":
+			rule.Freq = val
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return rule, fmt.Errorf("INTERVAL must be a positive number")
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return rule, fmt.Errorf("COUNT must be a positive number")
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return rule, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekdayNum, err := parseWeekdayNum(day)
+				if err != nil {
+					return rule, err
+				}
+				rule.ByDay = append(rule.ByDay, weekdayNum)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return rule, fmt.Errorf("invalid BYMONTHDAY %q", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		case "WKST":
+			if val != "MO" {
+				return rule, fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			return rule, fmt.Errorf("unsupported recurrence rule part %s", key)
+		}
+	}
+
+	return rule, rule.Validate()
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse(untilFormat, value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse(untilDateFormat, value); err == nil {
+		// A date means the whole day is included
+		return until.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL must be a UTC date-time like 20260131T235959Z")
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	day, ok := weekdayCodes[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	weekdayNum := WeekdayNum{Day: day}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+		}
+		weekdayNum.N = n
+	}
+	return weekdayNum, nil
+}
+
+// Validate checks the rule only combines parts that make sense together
+func (r Rule) Validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly:
+	case "":
+		return fmt.Errorf("FREQ is required")
+	default:
+		return fmt.Errorf("unsupported FREQ %s", r.Freq)
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("INTERVAL must be a positive number")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("COUNT and UNTIL can't both be set")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly {
+		return fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	if len(r.ByMonthDay) > 0 && len(r.ByDay) > 0 {
+		return fmt.Errorf("BYDAY and BYMONTHDAY can't both be set")
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != Monthly {
+			return fmt.Errorf("numbered BYDAY like %s is only supported with FREQ=MONTHLY", day)
+		}
+	}
+	return nil
+}
+
+// String formats the rule as an RRULE value
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilFormat))
+	}
+	return strings.Join(parts, ";")
+}
+
+// IsFinite reports whether the rule ends on its own
+func (r Rule) IsFinite() bool {
+	return r.Count > 0 || !r.Until.IsZero()
+}
+
+// Between returns the occurrences of a series starting at dtstart that start in [from, to).
+// Occurrences keep dtstart's wall-clock time in dtstart's location, so a 7pm
+// practice stays at 7pm across daylight saving changes.
+func (r Rule) Between(dtstart, from, to time.Time) []time.Time {
+	var occurrences []time.Time
+	r.each(dtstart, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// Occurs reports whether t is one of the series' occurrences
+func (r Rule) Occurs(dtstart, t time.Time) bool {
+	found := false
+	r.each(dtstart, func(occurrence time.Time) bool {
+		if occurrence.Equal(t) {
+			found = true
+		}
+		return occurrence.Before(t)
+	})
+	return found
+}
+
+// CountBefore returns how many occurrences start before t
+func (r Rule) CountBefore(dtstart, t time.Time) int {
+	return len(r.Between(dtstart, dtstart, t))
+}
+
+// First returns the series' first occurrence, which is dtstart unless the rule skips that day
+func (r Rule) First(dtstart time.Time) (time.Time, bool) {
+	var first time.Time
+	found := false
+	r.each(dtstart, func(t time.Time) bool {
+		first = t
+		found = true
+		return false
+	})
+	return first, found
+}
+
+// Last returns the final occurrence of a finite rule, or false if there is none or the rule never ends
+func (r Rule) Last(dtstart time.Time) (time.Time, bool) {
+	if !r.IsFinite() {
+		return time.Time{}, false
+	}
+	var last time.Time
+	found := false
+	r.each(dtstart, func(t time.Time) bool {
+		last = t
+		found = true
+		return true
+	})
+	return last, found
+}
+
+// EndingBefore returns a copy of the rule that stops before t
+func (r Rule) EndingBefore(t time.Time) Rule {
+	r.Count = 0
+	r.Until = t.Add(-time.Second).UTC()
+	return r
+}
+
+// ShiftDays moves the rule's weekdays and month days along with a series
+// whose start moved by the given number of days
+func (r Rule) ShiftDays(days int) (Rule, error) {
+	if days == 0 {
+		return r, nil
+	}
+	shifted := r
+	shifted.ByDay = make([]WeekdayNum, len(r.ByDay))
+	for i, day := range r.ByDay {
+		// The 2nd Tuesday plus a day isn't always the 2nd Wednesday
+		if day.N != 0 {
+			return r, fmt.Errorf("can't move a BYDAY=%s rule to another day, send a new rule instead", day)
+		}
+		shifted.ByDay[i] = WeekdayNum{N: day.N, Day: time.Weekday(((int(day.Day)+days)%7 + 7) % 7)}
+	}
+	shifted.ByMonthDay = make([]int, len(r.ByMonthDay))
+	for i, day := range r.ByMonthDay {
+		moved := day + days
+		if day > 0 && (moved < 1 || moved > 28) || day < 0 && (moved > -1 || moved < -28) {
+			return r, fmt.Errorf("can't move a BYMONTHDAY=%d rule by %d days, send a new rule instead", day, days)
+		}
+		shifted.ByMonthDay[i] = moved
+	}
+	if len(r.ByDay) == 0 {
+		shifted.ByDay = nil
+	}
+	if len(r.ByMonthDay) == 0 {
+		shifted.ByMonthDay = nil
+	}
+	return shifted, nil
+}
+
+// each calls fn with every occurrence in order until fn returns false or the rule ends
+func (r Rule) each(dtstart time.Time, fn func(time.Time) bool) {
+	loc := dtstart.Location()
+	hour, minute, second := dtstart.Clock()
+	year, month, day := dtstart.Date()
+	first := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	// Weeks start on Monday
+	weekStart := first.AddDate(0, 0, -((int(first.Weekday()) + 6) % 7))
+
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		var dates []time.Time
+		switch r.Freq {
+		case Daily:
+			date := first.AddDate(0, 0, period*r.Interval)
+			if r.matchesWeekday(date.Weekday()) {
+				dates = []time.Time{date}
+			}
+		case Weekly:
+			dates = r.weekDates(weekStart.AddDate(0, 0, 7*period*r.Interval), dtstart.Weekday())
+		case Monthly:
+			dates = r.monthDates(time.Date(year, month+time.Month(period*r.Interval), 1, 0, 0, 0, 0, loc), day)
+		default:
+			return
+		}
+
+		for _, date := range dates {
+			occurrence := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, second, 0, loc)
+			if occurrence.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && occurrence.After(r.Until) {
+				return
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return
+			}
+			if !fn(occurrence) {
+				return
+			}
+		}
+	}
+}
+
+func (r Rule) matchesWeekday(weekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// weekDates returns the rule's days in the week starting on monday, in order
+func (r Rule) weekDates(monday time.Time, defaultDay time.Weekday) []time.Time {
+	days := []time.Weekday{defaultDay}
+	if len(r.ByDay) > 0 {
+		days = days[:0]
+		for _, day := range r.ByDay {
+			days = append(days, day.Day)
+		}
+	}
+
+	var dates []time.Time
+	seen := map[int]bool{}
+	for _, day := range days {
+		offset := (int(day) + 6) % 7
+		if !seen[offset] {
+			seen[offset] = true
+			dates = append(dates, monday.AddDate(0, 0, offset))
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// monthDates returns the rule's days in the month starting on firstOfMonth, in order.
+// Days that don't exist in the month, like the 31st of April, are skipped.
+func (r Rule) monthDates(firstOfMonth time.Time, defaultDay int) []time.Time {
+	daysInMonth := firstOfMonth.AddDate(0, 1, -1).Day()
+	seen := map[int]bool{}
+	var days []int
+	add := func(day int) {
+		if day >= 1 && day <= daysInMonth && !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	switch {
+	case len(r.ByDay) > 0:
+		for _, weekdayNum := range r.ByDay {
+			firstMatch := 1 + (int(weekdayNum.Day)-int(firstOfMonth.Weekday())+7)%7
+			switch {
+			case weekdayNum.N > 0:
+				add(firstMatch + 7*(weekdayNum.N-1))
+			case weekdayNum.N < 0:
+				lastMatch := firstMatch + 7*((daysInMonth-firstMatch)/7)
+				add(lastMatch + 7*(weekdayNum.N+1))
+			default:
+				for d := firstMatch; d <= daysInMonth; d += 7 {
+					add(d)
+				}
+			}
+		}
+	case len(r.ByMonthDay) > 0:
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay < 0 {
+				monthDay = daysInMonth + monthDay + 1
+			}
+			add(monthDay)
+		}
+	default:
+		add(defaultDay)
+	}
+
+	sort.Ints(days)
+	dates := make([]time.Time, len(days))
+	for i, d := range days {
+		dates[i] = firstOfMonth.AddDate(0, 0, d-1)
+	}
+	return dates
+}
+
+// occurrenceIDFormat is the time part of an occurrence ID
+const occurrenceIDFormat = "20060102T150405Z"
+
+// OccurrenceID identifies an occurrence of a series that hasn't been saved as an event yet
+func OccurrenceID(seriesID string, start time.Time) string {
+	return seriesID + "_" + start.UTC().Format(occurrenceIDFormat)
+}
+
+// ParseOccurrenceID splits an occurrence ID into its series ID and original start time
+func ParseOccurrenceID(id string) (string, time.Time, bool) {
+	i := strings.LastIndex(id, "_")
+	if i <= 0 {
+		return "", time.Time{}, false
+	}
+	start, err := time.Parse(occurrenceIDFormat, id[i+1:])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return id[:i], start, true
+}
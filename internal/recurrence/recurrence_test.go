@@ -0,0 +1,224 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, value string) Rule {
+	t.Helper()
+	rule, err := Parse(value)
+	if err != nil {
+		t.Fatalf("Error parsing %q: %v", value, err)
+	}
+	return rule
+}
+
+func dates(times []time.Time) []string {
+	formatted := make([]string, len(times))
+	for i, t := range times {
+		formatted[i] = t.Format("2006-01-02 15:04")
+	}
+	return formatted
+}
+
+func assertDates(t *testing.T, got []time.Time, want ...string) {
+	t.Helper()
+	formatted := dates(got)
+	if len(formatted) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, formatted)
+	}
+	for i := range want {
+		if formatted[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, formatted)
+			return
+		}
+	}
+}
+
+// Tuesday 2026-01-06 at 19:00 UTC
+var tuesday = time.Date(2026, 1, 6, 19, 0, 0, 0, time.UTC)
+
+func TestParse_RoundTrip(t *testing.T) {
+	cases := []string{
+		"FREQ=WEEKLY",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+		"FREQ=MONTHLY;BYDAY=2TU",
+		"FREQ=MONTHLY;BYDAY=-1FR;COUNT=6",
+		"FREQ=MONTHLY;BYMONTHDAY=1,-1",
+		"FREQ=DAILY;UNTIL=20260131T235959Z",
+	}
+	for _, value := range cases {
+		if got := mustParse(t, "RRULE:"+value).String(); got != value {
+			t.Errorf("Expected %s, got %s", value, got)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20260131T000000Z",
+		"FREQ=WEEKLY;BYDAY=2TU",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=6TU",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYSETPOS=1",
+		"FREQ=WEEKLY;UNTIL=tomorrow",
+	}
+	for _, value := range cases {
+		if _, err := Parse(value); err == nil {
+			t.Errorf("Expected an error parsing %q", value)
+		}
+	}
+}
+
+func TestParse_UntilDateIncludesTheDay(t *testing.T) {
+	rule := mustParse(t, "FREQ=WEEKLY;UNTIL=20260120")
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(1, 0, 0)),
+		"2026-01-06 19:00", "2026-01-13 19:00", "2026-01-20 19:00")
+}
+
+func TestBetween_Weekly(t *testing.T) {
+	rule := mustParse(t, "FREQ=WEEKLY")
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(0, 0, 21)),
+		"2026-01-06 19:00", "2026-01-13 19:00", "2026-01-20 19:00")
+}
+
+func TestBetween_BiweeklyOnSeveralDays(t *testing.T) {
+	rule := mustParse(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TH,TU")
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(0, 0, 28)),
+		"2026-01-06 19:00", "2026-01-08 19:00", "2026-01-20 19:00", "2026-01-22 19:00")
+}
+
+func TestBetween_WeeklySkipsDaysBeforeStart(t *testing.T) {
+	// Starting on a Tuesday, the Monday of that week isn't an occurrence
+	rule := mustParse(t, "FREQ=WEEKLY;BYDAY=MO,WE")
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(0, 0, 9)),
+		"2026-01-07 19:00", "2026-01-12 19:00", "2026-01-14 19:00")
+}
+
+func TestBetween_MonthlyNthWeekday(t *testing.T) {
+	rule := mustParse(t, "FREQ=MONTHLY;BYDAY=2TU")
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(0, 4, 0)),
+		"2026-01-13 19:00", "2026-02-10 19:00", "2026-03-10 19:00", "2026-04-14 19:00")
+}
+
+func TestBetween_MonthlyLastWeekday(t *testing.T) {
+	rule := mustParse(t, "FREQ=MONTHLY;BYDAY=-1FR")
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(0, 3, 0)),
+		"2026-01-30 19:00", "2026-02-27 19:00", "2026-03-27 19:00")
+}
+
+func TestBetween_MonthlyFifthWeekdaySkipsShortMonths(t *testing.T) {
+	rule := mustParse(t, "FREQ=MONTHLY;BYDAY=5TH")
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(0, 6, 0)),
+		"2026-01-29 19:00", "2026-04-30 19:00")
+}
+
+func TestBetween_MonthlyDaySkipsMissingDays(t *testing.T) {
+	start := time.Date(2026, 1, 31, 20, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=MONTHLY")
+	assertDates(t, rule.Between(start, start, start.AddDate(0, 4, 0)),
+		"2026-01-31 20:00", "2026-03-31 20:00")
+
+	lastDay := mustParse(t, "FREQ=MONTHLY;BYMONTHDAY=-1")
+	assertDates(t, lastDay.Between(start, start, start.AddDate(0, 3, 0)),
+		"2026-01-31 20:00", "2026-02-28 20:00", "2026-03-31 20:00", "2026-04-30 20:00")
+}
+
+func TestBetween_CountIsFromStart(t *testing.T) {
+	rule := mustParse(t, "FREQ=WEEKLY;COUNT=3")
+	// The window starts after the first occurrence, but it still counts
+	assertDates(t, rule.Between(tuesday, tuesday.AddDate(0, 0, 1), tuesday.AddDate(1, 0, 0)),
+		"2026-01-13 19:00", "2026-01-20 19:00")
+}
+
+func TestBetween_Daily(t *testing.T) {
+	rule := mustParse(t, "FREQ=DAILY;INTERVAL=3;COUNT=3")
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(1, 0, 0)),
+		"2026-01-06 19:00", "2026-01-09 19:00", "2026-01-12 19:00")
+}
+
+func TestBetween_KeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+	// US daylight saving starts on 2026-03-08
+	start := time.Date(2026, 3, 3, 19, 0, 0, 0, loc)
+	occurrences := mustParse(t, "FREQ=WEEKLY").Between(start, start, start.AddDate(0, 0, 14))
+	assertDates(t, occurrences, "2026-03-03 19:00", "2026-03-10 19:00")
+	if occurrences[1].Sub(occurrences[0]) != 7*24*time.Hour-time.Hour {
+		t.Errorf("Expected the week across the change to be an hour shorter, got %v", occurrences[1].Sub(occurrences[0]))
+	}
+}
+
+func TestOccurs(t *testing.T) {
+	rule := mustParse(t, "FREQ=WEEKLY;COUNT=2")
+	if !rule.Occurs(tuesday, tuesday.AddDate(0, 0, 7)) {
+		t.Errorf("Expected the second week to be an occurrence")
+	}
+	if rule.Occurs(tuesday, tuesday.AddDate(0, 0, 14)) {
+		t.Errorf("Expected the third week to be past COUNT")
+	}
+	if rule.Occurs(tuesday, tuesday.Add(time.Hour)) {
+		t.Errorf("Expected a different time of day not to be an occurrence")
+	}
+}
+
+func TestEndingBefore(t *testing.T) {
+	rule := mustParse(t, "FREQ=WEEKLY;COUNT=10").EndingBefore(tuesday.AddDate(0, 0, 14))
+	assertDates(t, rule.Between(tuesday, tuesday, tuesday.AddDate(1, 0, 0)),
+		"2026-01-06 19:00", "2026-01-13 19:00")
+	if rule.CountBefore(tuesday, tuesday.AddDate(0, 0, 8)) != 2 {
+		t.Errorf("Expected 2 occurrences before the second Wednesday")
+	}
+	if first, ok := mustParse(t, "FREQ=WEEKLY;BYDAY=TH").First(tuesday); !ok || !first.Equal(tuesday.AddDate(0, 0, 2)) {
+		t.Errorf("Expected the first occurrence on Thursday, got %v", first)
+	}
+	if last, ok := rule.Last(tuesday); !ok || !last.Equal(tuesday.AddDate(0, 0, 7)) {
+		t.Errorf("Expected the last occurrence a week after the start, got %v", last)
+	}
+	if _, ok := mustParse(t, "FREQ=WEEKLY").Last(tuesday); ok {
+		t.Errorf("Expected an endless rule to have no last occurrence")
+	}
+}
+
+func TestShiftDays(t *testing.T) {
+	shifted, err := mustParse(t, "FREQ=WEEKLY;BYDAY=TU,SA").ShiftDays(1)
+	if err != nil {
+		t.Fatalf("Error shifting rule: %v", err)
+	}
+	if shifted.String() != "FREQ=WEEKLY;BYDAY=WE,SU" {
+		t.Errorf("Expected weekdays to move forward a day, got %s", shifted)
+	}
+
+	if _, err := mustParse(t, "FREQ=MONTHLY;BYDAY=2TU").ShiftDays(1); err == nil {
+		t.Errorf("Expected an error moving an nth weekday rule")
+	}
+	if _, err := mustParse(t, "FREQ=MONTHLY;BYMONTHDAY=28").ShiftDays(2); err == nil {
+		t.Errorf("Expected an error moving a month day past the shortest month")
+	}
+}
+
+func TestOccurrenceID(t *testing.T) {
+	seriesID := "2b0f4c1e-8c7e-4a57-9f0a-3f6d1f0f4b1a"
+	id := OccurrenceID(seriesID, tuesday)
+	if id != seriesID+"_20260106T190000Z" {
+		t.Errorf("Unexpected occurrence ID %s", id)
+	}
+
+	parsedSeriesID, start, ok := ParseOccurrenceID(id)
+	if !ok || parsedSeriesID != seriesID || !start.Equal(tuesday) {
+		t.Errorf("Expected %s at %v, got %s at %v (ok=%v)", seriesID, tuesday, parsedSeriesID, start, ok)
+	}
+
+	if _, _, ok := ParseOccurrenceID(seriesID); ok {
+		t.Errorf("Expected a plain event ID not to parse as an occurrence")
+	}
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Attendance statuses, recorded separately from the RSVP that said who meant to come
+const (
+	AttendanceCheckedIn = "checked-in"
+	// AttendanceLate is a check-in more than LateAfter past the start
+	AttendanceLate   = "late"
+	AttendanceNoShow = "no-show"
+)
+
+// Attendee types
+const (
+	AttendeeMember = "member"
+	AttendeeWalkIn = "walk-in"
+)
+
+// How attendance was recorded
+const (
+	// AttendanceMethodOrganizer is attendance taken by the MC or an organizer
+	AttendanceMethodOrganizer = "organizer"
+	// AttendanceMethodSelf is a member checking in with the code shown at the venue
+	AttendanceMethodSelf = "self"
+)
+
+const (
+	// LateAfter is how long after the start a check-in still counts as on time
+	LateAfter = 10 * time.Minute
+	// CheckInOpensBefore is how long before the start members can check themselves in
+	CheckInOpensBefore = time.Hour
+	// CheckInCodePeriod is how long each self check-in code is shown for. The previous
+	// code is still accepted, so one read just before it changes still works.
+	CheckInCodePeriod = 2 * time.Minute
+)
+
+// checkInCodeAlphabet leaves out letters and digits that are easily mixed up
+const checkInCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const checkInCodeLength = 6
+
+var (
+	// ErrNotAnAttendee means the attendee isn't a member of the event's group or a walk-in at the event
+	ErrNotAnAttendee = errors.New("not a member or walk-in of this event")
+	// ErrAttendanceClosed means attendance can't be taken for the event in its current state
+	ErrAttendanceClosed = errors.New("attendance is closed for this event")
+	// ErrInvalidCheckInCode means a self check-in code is wrong or has expired
+	ErrInvalidCheckInCode = errors.New("invalid check-in code")
+)
+
+// IsValidAttendanceStatus reports whether status is one of the attendance statuses
+func IsValidAttendanceStatus(status string) bool {
+	return status == AttendanceCheckedIn || status == AttendanceLate || status == AttendanceNoShow
+}
+
+// Attendance is whether a member or walk-in came to an event
+type Attendance struct {
+	EventID      string     `json:"eventId"`
+	AttendeeID   string     `json:"attendeeId"`
+	AttendeeType string     `json:"attendeeType"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	CheckedInAt  *time.Time `json:"checkedInAt,omitempty"`
+	Method       string     `json:"method"`
+	RecordedBy   string     `json:"recordedBy"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// AttendanceSummary compares who said they'd come with who did
+type AttendanceSummary struct {
+	// Attending is the number of members who RSVP'd attending
+	Attending int `json:"attending"`
+	CheckedIn int `json:"checkedIn"`
+	Late      int `json:"late"`
+	NoShow    int `json:"noShow"`
+	// WalkIns is the number of walk-ins who were checked in or late
+	WalkIns int `json:"walkIns"`
+	// Unrecorded is the number of attending members with no attendance recorded yet
+	Unrecorded int `json:"unrecorded"`
+}
+
+// MemberAttendance totals a member's attendance across a group's events
+type MemberAttendance struct {
+	UserID          string     `json:"userId"`
+	Name            string     `json:"name"`
+	CheckedIn       int        `json:"checkedIn"`
+	Late            int        `json:"late"`
+	NoShow          int        `json:"noShow"`
+	LastCheckedInAt *time.Time `json:"lastCheckedInAt,omitempty"`
+}
+
+// CheckInCode is the self check-in code shown at the venue. QRPayload is the same code for a QR
+// code, as "improv-check-in:{eventID}:{code}". It's opaque rather than a link: whatever scans it
+// posts the code to /api/events/{eventID}/check-in.
+type CheckInCode struct {
+	Code      string    `json:"code"`
+	QRPayload string    `json:"qrPayload"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AttendanceService records who checked in to events
+type AttendanceService struct {
+	db *sql.DB
+}
+
+func NewAttendanceService(db *sql.DB) *AttendanceService {
+	return &AttendanceService{db: db}
+}
+
+// checkInSecret signs check-in codes, falling back to the session secret
+func checkInSecret() []byte {
+	if secret := os.Getenv("CHECK_IN_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	return []byte(os.Getenv("SESSION_SECRET"))
+}
+
+// checkInCodeFor returns the event's code for one period
+func checkInCodeFor(eventID string, period int64) string {
+	mac := hmac.New(sha256.New, checkInSecret())
+	fmt.Fprintf(mac, "%s|%d", eventID, period)
+	sum := mac.Sum(nil)
+
+	code := make([]byte, checkInCodeLength)
+	for i := range code {
+		code[i] = checkInCodeAlphabet[int(sum[i])%len(checkInCodeAlphabet)]
+	}
+	return string(code)
+}
+
+func checkInPeriod(at time.Time) int64 {
+	return at.Unix() / int64(CheckInCodePeriod/time.Second)
+}
+
+// NewCheckInCode returns the code to show at the venue at the given time
+func NewCheckInCode(eventID string, at time.Time) CheckInCode {
+	period := checkInPeriod(at)
+	code := checkInCodeFor(eventID, period)
+	return CheckInCode{
+		Code:      code,
+		QRPayload: fmt.Sprintf("improv-check-in:%s:%s", eventID, code),
+		ExpiresAt: time.Unix((period+1)*int64(CheckInCodePeriod/time.Second), 0).UTC(),
+	}
+}
+
+// ValidCheckInCode reports whether code is the event's current or previous check-in code
+func ValidCheckInCode(eventID, code string, at time.Time) bool {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	period := checkInPeriod(at)
+	for _, p := range []int64{period, period - 1} {
+		if hmac.Equal([]byte(code), []byte(checkInCodeFor(eventID, p))) {
+			return true
+		}
+	}
+	return false
+}
+
+// attendanceEvent is what recording attendance needs to know about an event
+type attendanceEvent struct {
+	groupID   string
+	status    string
+	startTime time.Time
+	endTime   time.Time
+}
+
+func (s *AttendanceService) event(eventID string) (*attendanceEvent, error) {
+	var event attendanceEvent
+	err := s.db.QueryRow(`
+		SELECT group_id, status, start_time, end_time FROM events WHERE id = $1
+	`, eventID).Scan(&event.groupID, &event.status, &event.startTime, &event.endTime)
+	if err == sql.ErrNoRows {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event: %v", err)
+	}
+	return &event, nil
+}
+
+// attendeeType returns whether the attendee is a member of the event's group or one of its walk-ins
+func (s *AttendanceService) attendeeType(eventID, groupID, attendeeID string) (string, error) {
+	var isMember, isWalkIn bool
+	err := s.db.QueryRow(`
+		SELECT
+			EXISTS(SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2),
+			EXISTS(SELECT 1 FROM non_registered_attendees WHERE id = $2 AND event_id = $3)
+	`, groupID, attendeeID, eventID).Scan(&isMember, &isWalkIn)
+	if err != nil {
+		return "", fmt.Errorf("error checking attendee: %v", err)
+	}
+	switch {
+	case isMember:
+		return AttendeeMember, nil
+	case isWalkIn:
+		return AttendeeWalkIn, nil
+	}
+	return "", ErrNotAnAttendee
+}
+
+// Record sets a member's or walk-in's attendance. An empty status checks them in, as late
+// if it's more than LateAfter past the start. Attendance can be taken for scheduled events
+// and corrected once they're completed.
+func (s *AttendanceService) Record(eventID, attendeeID, status, method, recordedBy string, now time.Time) (*Attendance, error) {
+	event, err := s.event(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.status != EventStatusScheduled && event.status != EventStatusCompleted {
+		return nil, fmt.Errorf("%w: the event is %s", ErrAttendanceClosed, event.status)
+	}
+	attendeeType, err := s.attendeeType(eventID, event.groupID, attendeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "" {
+		status = AttendanceCheckedIn
+		if now.After(event.startTime.Add(LateAfter)) {
+			status = AttendanceLate
+		}
+	}
+	var checkedInAt interface{}
+	if status != AttendanceNoShow {
+		checkedInAt = now.UTC()
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO event_attendance (event_id, attendee_id, attendee_type, status, checked_in_at, method, recorded_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (event_id, attendee_id) DO UPDATE SET
+			status = excluded.status, checked_in_at = excluded.checked_in_at, method = excluded.method,
+			recorded_by = excluded.recorded_by, updated_at = excluded.updated_at
+	`, eventID, attendeeID, attendeeType, status, checkedInAt, method, recordedBy, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error recording attendance: %v", err)
+	}
+	return s.Get(eventID, attendeeID)
+}
+
+// SelfCheckIn checks a member in with the code shown at the venue. Self check-in opens
+// CheckInOpensBefore the start and closes when the event ends. Checking in again keeps
+// the first check-in.
+func (s *AttendanceService) SelfCheckIn(eventID, userID, code string, now time.Time) (*Attendance, error) {
+	if !ValidCheckInCode(eventID, code, now) {
+		return nil, ErrInvalidCheckInCode
+	}
+	event, err := s.event(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.status != EventStatusScheduled || now.Before(event.startTime.Add(-CheckInOpensBefore)) || now.After(event.endTime) {
+		return nil, fmt.Errorf("%w: check-in is only open around the event", ErrAttendanceClosed)
+	}
+
+	existing, err := s.Get(eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Status != AttendanceNoShow {
+		return existing, nil
+	}
+	return s.Record(eventID, userID, "", AttendanceMethodSelf, userID, now)
+}
+
+// Clear removes an attendee's attendance, so it's unrecorded again
+func (s *AttendanceService) Clear(eventID, attendeeID string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM event_attendance WHERE event_id = $1 AND attendee_id = $2
+	`, eventID, attendeeID)
+	if err != nil {
+		return fmt.Errorf("error clearing attendance: %v", err)
+	}
+	return nil
+}
+
+// MarkNoShows records every member who RSVP'd attending but has no attendance as a no-show
+func (s *AttendanceService) MarkNoShows(eventID, recordedBy string, now time.Time) (int64, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO event_attendance (event_id, attendee_id, attendee_type, status, method, recorded_by, updated_at)
+		SELECT $1, r.user_id, $2, $3, $4, $5, $6
+		FROM event_rsvps r
+		WHERE r.event_id = $1 AND r.status = 'attending'
+		  AND NOT EXISTS (SELECT 1 FROM event_attendance a WHERE a.event_id = r.event_id AND a.attendee_id = r.user_id)
+	`, eventID, AttendeeMember, AttendanceNoShow, AttendanceMethodOrganizer, recordedBy, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error marking no-shows: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+const attendanceColumns = `
+	a.event_id, a.attendee_id, a.attendee_type,
+	CASE WHEN a.attendee_type = 'walk-in'
+		THEN TRIM(COALESCE(n.first_name, '') || ' ' || COALESCE(n.last_name, ''))
+		ELSE TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, ''))
+	END,
+	a.status, a.checked_in_at, a.method, a.recorded_by, a.updated_at
+	FROM event_attendance a
+	LEFT JOIN users u ON a.attendee_type = 'member' AND a.attendee_id = u.id
+	LEFT JOIN non_registered_attendees n ON a.attendee_type = 'walk-in' AND a.attendee_id = n.id
+`
+
+func scanAttendance(scanner interface{ Scan(...interface{}) error }) (*Attendance, error) {
+	var attendance Attendance
+	var checkedInAt sql.NullTime
+	err := scanner.Scan(&attendance.EventID, &attendance.AttendeeID, &attendance.AttendeeType, &attendance.Name,
+		&attendance.Status, &checkedInAt, &attendance.Method, &attendance.RecordedBy, &attendance.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if checkedInAt.Valid {
+		checkedIn := checkedInAt.Time.UTC()
+		attendance.CheckedInAt = &checkedIn
+	}
+	attendance.UpdatedAt = attendance.UpdatedAt.UTC()
+	return &attendance, nil
+}
+
+// Get returns an attendee's attendance, or nil if none is recorded
+func (s *AttendanceService) Get(eventID, attendeeID string) (*Attendance, error) {
+	attendance, err := scanAttendance(s.db.QueryRow(`
+		SELECT `+attendanceColumns+`
+		WHERE a.event_id = $1 AND a.attendee_id = $2
+	`, eventID, attendeeID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching attendance: %v", err)
+	}
+	return attendance, nil
+}
+
+// List returns the attendance recorded for an event, in check-in order with no-shows last
+func (s *AttendanceService) List(eventID string) ([]Attendance, error) {
+	rows, err := s.db.Query(`
+		SELECT `+attendanceColumns+`
+		WHERE a.event_id = $1
+		ORDER BY a.checked_in_at IS NULL, a.checked_in_at, a.updated_at
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching attendance: %v", err)
+	}
+	defer rows.Close()
+
+	attendance := []Attendance{}
+	for rows.Next() {
+		record, err := scanAttendance(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning attendance: %v", err)
+		}
+		attendance = append(attendance, *record)
+	}
+	return attendance, nil
+}
+
+// Summary counts an event's attendance against its RSVPs
+func (s *AttendanceService) Summary(eventID string) (*AttendanceSummary, error) {
+	var summary AttendanceSummary
+	err := s.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM event_rsvps WHERE event_id = $1 AND status = 'attending'),
+			COUNT(CASE WHEN status = 'checked-in' THEN 1 END),
+			COUNT(CASE WHEN status = 'late' THEN 1 END),
+			COUNT(CASE WHEN status = 'no-show' THEN 1 END),
+			COUNT(CASE WHEN attendee_type = 'walk-in' AND status != 'no-show' THEN 1 END),
+			(SELECT COUNT(*) FROM event_rsvps r WHERE r.event_id = $1 AND r.status = 'attending'
+			   AND NOT EXISTS (SELECT 1 FROM event_attendance a WHERE a.event_id = r.event_id AND a.attendee_id = r.user_id))
+		FROM event_attendance
+		WHERE event_id = $1
+	`, eventID).Scan(&summary.Attending, &summary.CheckedIn, &summary.Late, &summary.NoShow, &summary.WalkIns, &summary.Unrecorded)
+	if err != nil {
+		return nil, fmt.Errorf("error summarizing attendance: %v", err)
+	}
+	return &summary, nil
+}
+
+// GroupReport totals each member's attendance across the group's events starting from from to to
+func (s *AttendanceService) GroupReport(groupID string, from, to time.Time) ([]MemberAttendance, error) {
+	rows, err := s.db.Query(`
+		SELECT m.user_id, TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')),
+			COUNT(CASE WHEN a.status = 'checked-in' THEN 1 END),
+			COUNT(CASE WHEN a.status = 'late' THEN 1 END),
+			COUNT(CASE WHEN a.status = 'no-show' THEN 1 END),
+			MAX(a.checked_in_at)
+		FROM group_members m
+		JOIN users u ON m.user_id = u.id AND m.group_id = $1
+		LEFT JOIN events e ON e.group_id = m.group_id
+			AND julianday(e.start_time) >= julianday($2) AND julianday(e.start_time) < julianday($3)
+		LEFT JOIN event_attendance a ON a.event_id = e.id AND a.attendee_id = m.user_id
+		GROUP BY m.user_id, u.first_name, u.last_name
+		ORDER BY u.first_name, u.last_name
+	`, groupID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching attendance report: %v", err)
+	}
+	defer rows.Close()
+
+	report := []MemberAttendance{}
+	for rows.Next() {
+		var member MemberAttendance
+		var lastCheckedInAt sql.NullString
+		if err := rows.Scan(&member.UserID, &member.Name, &member.CheckedIn, &member.Late, &member.NoShow, &lastCheckedInAt); err != nil {
+			return nil, fmt.Errorf("error scanning attendance report: %v", err)
+		}
+		if lastCheckedInAt.Valid {
+			// MAX loses the column's type, so the time comes back as text
+			last, err := parseSQLiteTime(lastCheckedInAt.String)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing last check-in: %v", err)
+			}
+			member.LastCheckedInAt = &last
+		}
+		report = append(report, member)
+	}
+	return report, nil
+}
+
+// sqliteTimeLayouts are the formats the SQLite driver writes times in
+var sqliteTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+func parseSQLiteTime(value string) (time.Time, error) {
+	value = strings.TrimSuffix(value, "Z")
+	for _, layout := range sqliteTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
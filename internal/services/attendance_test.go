@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckInCode_Rotates(t *testing.T) {
+	t.Setenv("CHECK_IN_SECRET", "check-in-secret")
+	at := time.Date(2026, 2, 1, 19, 0, 30, 0, time.UTC)
+	code := NewCheckInCode("public1", at)
+	if len(code.Code) != 6 || code.QRPayload != "improv-check-in:public1:"+code.Code || !code.ExpiresAt.Equal(time.Date(2026, 2, 1, 19, 2, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected check-in code %+v", code)
+	}
+
+	// The code still works in the next period, in any case, but not after that or for another event
+	if !ValidCheckInCode("public1", code.Code, at.Add(CheckInCodePeriod)) {
+		t.Error("Expected the previous code to be accepted")
+	}
+	if !ValidCheckInCode("public1", " "+strings.ToLower(code.Code)+" ", at) {
+		t.Error("Expected the code to be accepted in lower case")
+	}
+	if ValidCheckInCode("public1", code.Code, at.Add(2*CheckInCodePeriod)) {
+		t.Error("Expected the code to expire")
+	}
+	if ValidCheckInCode("private1", code.Code, at) {
+		t.Error("Expected the code to only work for its event")
+	}
+}
+
+func TestAttendance_RecordAndSummarize(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedOutsider, seedPublicShow)
+	seed(t, testDB,
+		`INSERT INTO users (id, email, first_name, last_name) VALUES ('member2', 'member2@example.com', 'Bea', 'Bit')`,
+		`INSERT INTO group_members (group_id, user_id, role) VALUES ('group123', 'member2', 'member')`,
+		`INSERT INTO event_rsvps (event_id, user_id, status) VALUES ('public1', 'member2', 'attending')`,
+		`INSERT INTO non_registered_attendees (id, event_id, first_name, last_name) VALUES ('walkin1', 'public1', 'Walt', 'Walker')`,
+	)
+	service := NewAttendanceService(testDB)
+	start := time.Date(2026, 2, 1, 19, 0, 0, 0, time.UTC)
+
+	checkedIn, err := service.Record("public1", "user123", "", AttendanceMethodOrganizer, "user123", start.Add(LateAfter))
+	if err != nil {
+		t.Fatalf("Error checking in: %v", err)
+	}
+	if checkedIn.Status != AttendanceCheckedIn || checkedIn.AttendeeType != AttendeeMember || checkedIn.Name != "Ada Admin" {
+		t.Errorf("Expected Ada on time, got %+v", checkedIn)
+	}
+	late, err := service.Record("public1", "walkin1", "", AttendanceMethodOrganizer, "user123", start.Add(LateAfter+time.Minute))
+	if err != nil {
+		t.Fatalf("Error checking in walk-in: %v", err)
+	}
+	if late.Status != AttendanceLate || late.AttendeeType != AttendeeWalkIn || late.Name != "Walt Walker" {
+		t.Errorf("Expected Walt late, got %+v", late)
+	}
+	if _, err := service.Record("public1", "outsider", "", AttendanceMethodOrganizer, "user123", start); !errors.Is(err, ErrNotAnAttendee) {
+		t.Errorf("Expected ErrNotAnAttendee for an outsider, got %v", err)
+	}
+
+	summary, err := service.Summary("public1")
+	if err != nil {
+		t.Fatalf("Error summarizing attendance: %v", err)
+	}
+	if *summary != (AttendanceSummary{Attending: 2, CheckedIn: 1, Late: 1, WalkIns: 1, Unrecorded: 1}) {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	marked, err := service.MarkNoShows("public1", "user123", start.Add(3*time.Hour))
+	if err != nil || marked != 1 {
+		t.Fatalf("Expected member2 marked a no-show, got %d (%v)", marked, err)
+	}
+	attendance, err := service.List("public1")
+	if err != nil {
+		t.Fatalf("Error listing attendance: %v", err)
+	}
+	if len(attendance) != 3 || attendance[2].AttendeeID != "member2" || attendance[2].Status != AttendanceNoShow || attendance[2].CheckedInAt != nil {
+		t.Errorf("Expected the no-show listed last, got %+v", attendance)
+	}
+
+	report, err := service.GroupReport("group123", time.Time{}, start.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("Error fetching report: %v", err)
+	}
+	if len(report) != 2 || report[0].UserID != "user123" || report[0].CheckedIn != 1 || report[1].NoShow != 1 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	if report[0].LastCheckedInAt == nil || !report[0].LastCheckedInAt.Equal(start.Add(LateAfter)) {
+		t.Errorf("Expected Ada's last check-in at %v, got %v", start.Add(LateAfter), report[0].LastCheckedInAt)
+	}
+}
+
+func TestAttendance_SelfCheckIn(t *testing.T) {
+	t.Setenv("CHECK_IN_SECRET", "check-in-secret")
+	service := NewAttendanceService(newTestDB(t, seedGroup, seedOutsider, seedPublicShow))
+	start := time.Date(2026, 2, 1, 19, 0, 0, 0, time.UTC)
+	now := start.Add(-5 * time.Minute)
+	code := NewCheckInCode("public1", now).Code
+
+	if _, err := service.SelfCheckIn("public1", "user123", "WRONG1", now); !errors.Is(err, ErrInvalidCheckInCode) {
+		t.Errorf("Expected ErrInvalidCheckInCode, got %v", err)
+	}
+	early := start.Add(-CheckInOpensBefore - time.Minute)
+	if _, err := service.SelfCheckIn("public1", "user123", NewCheckInCode("public1", early).Code, early); !errors.Is(err, ErrAttendanceClosed) {
+		t.Errorf("Expected ErrAttendanceClosed before check-in opens, got %v", err)
+	}
+
+	first, err := service.SelfCheckIn("public1", "user123", code, now)
+	if err != nil {
+		t.Fatalf("Error checking in: %v", err)
+	}
+	if first.Status != AttendanceCheckedIn || first.Method != AttendanceMethodSelf {
+		t.Errorf("Expected a self check-in, got %+v", first)
+	}
+
+	// Checking in again keeps the first time
+	later := start.Add(30 * time.Minute)
+	again, err := service.SelfCheckIn("public1", "user123", NewCheckInCode("public1", later).Code, later)
+	if err != nil {
+		t.Fatalf("Error checking in again: %v", err)
+	}
+	if again.Status != AttendanceCheckedIn || !again.CheckedInAt.Equal(now) {
+		t.Errorf("Expected the first check-in kept, got %+v", again)
+	}
+
+	if _, err := service.SelfCheckIn("public1", "outsider", code, now); !errors.Is(err, ErrNotAnAttendee) {
+		t.Errorf("Expected ErrNotAnAttendee for an outsider, got %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"improv-app/internal/ical"
+)
+
+// calendarFeedRefresh is how often calendar apps are asked to fetch feeds again
+const calendarFeedRefresh = time.Hour
+
+// ErrFeedNotFound means the feed token doesn't exist, or was regenerated
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+// CalendarFeedService manages the secret feed URLs calendar apps subscribe to,
+// and renders the feeds themselves
+type CalendarFeedService struct {
+	db *sql.DB
+}
+
+func NewCalendarFeedService(db *sql.DB) *CalendarFeedService {
+	return &CalendarFeedService{db: db}
+}
+
+// CalendarFeedURL is the URL calendar apps subscribe to for a feed token
+func CalendarFeedURL(token string) string {
+	return fmt.Sprintf("%s/api/calendar/%s.ics", os.Getenv("BASE_URL"), token)
+}
+
+// FeedToken returns the user's feed token, creating it the first time. An empty
+// group ID is the feed of all the user's events, otherwise it's the group's feed.
+func (s *CalendarFeedService) FeedToken(userID, groupID string) (string, error) {
+	var token string
+	err := s.db.QueryRow(`
+		SELECT token FROM calendar_feeds
+		WHERE user_id = $1 AND group_id = $2
+	`, userID, groupID).Scan(&token)
+	if err == nil {
+		return token, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("error fetching calendar feed: %v", err)
+	}
+	return s.RegenerateFeedToken(userID, groupID)
+}
+
+// RegenerateFeedToken replaces the feed token, so the old URL stops working
+func (s *CalendarFeedService) RegenerateFeedToken(userID, groupID string) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("error generating calendar feed token: %v", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO calendar_feeds (token, user_id, group_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, group_id) DO UPDATE SET token = excluded.token, created_at = CURRENT_TIMESTAMP
+	`, token, userID, groupID)
+	if err != nil {
+		return "", fmt.Errorf("error saving calendar feed token: %v", err)
+	}
+	return token, nil
+}
+
+// Feed renders the calendar for a feed token
+func (s *CalendarFeedService) Feed(token string) (*ical.Calendar, error) {
+	var userID, groupID string
+	err := s.db.QueryRow(`
+		SELECT user_id, group_id FROM calendar_feeds WHERE token = $1
+	`, token).Scan(&userID, &groupID)
+	if err == sql.ErrNoRows {
+		return nil, ErrFeedNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching calendar feed: %v", err)
+	}
+	if groupID == "" {
+		return s.UserFeed(userID)
+	}
+	return s.GroupFeed(userID, groupID)
+}
+
+// UserFeed is every event in the user's groups, with their RSVP status
+func (s *CalendarFeedService) UserFeed(userID string) (*ical.Calendar, error) {
+	calendar := &ical.Calendar{Name: "Improv events", RefreshInterval: calendarFeedRefresh}
+
+	events, err := s.feedEvents(userID, `
+		JOIN group_members m ON e.group_id = m.group_id AND m.user_id = $1
+	`, "TRUE")
+	if err != nil {
+		return nil, err
+	}
+	seriesList, err := NewEventSeriesService(s.db).list(`
+		SELECT `+eventSeriesColumns+`, g.name
+		FROM event_series s
+		JOIN improv_groups g ON s.group_id = g.id
+		JOIN group_members m ON s.group_id = m.group_id AND m.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addSeries(calendar, seriesList); err != nil {
+		return nil, err
+	}
+	calendar.Events = append(calendar.Events, events...)
+	return calendar, nil
+}
+
+// GroupFeed is the group's events. Members see all of them, followers the ones shared with
+// followers, and everyone else only public ones.
+func (s *CalendarFeedService) GroupFeed(userID, groupID string) (*ical.Calendar, error) {
+	var groupName string
+	var isMember, isFollower bool
+	err := s.db.QueryRow(`
+		SELECT g.name,
+		       EXISTS(SELECT 1 FROM group_members m WHERE m.group_id = g.id AND m.user_id = $1),
+		       EXISTS(SELECT 1 FROM group_followers f WHERE f.group_id = g.id AND f.user_id = $1)
+		FROM improv_groups g
+		WHERE g.id = $2
+	`, userID, groupID).Scan(&groupName, &isMember, &isFollower)
+	if err == sql.ErrNoRows {
+		return nil, ErrFeedNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching group for calendar feed: %v", err)
+	}
+	calendar := &ical.Calendar{Name: groupName, RefreshInterval: calendarFeedRefresh}
+
+	// Followers also see members-and-followers events, and everyone else only public ones
+	condition := "e.group_id = $2"
+	if !isMember && isFollower {
+		condition += " AND e.visibility IN ('public', 'members-and-followers')"
+	} else if !isMember {
+		condition += " AND e.visibility = 'public'"
+	}
+	events, err := s.feedEvents(userID, "", condition, groupID)
+	if err != nil {
+		return nil, err
+	}
+	seriesList, err := NewEventSeriesService(s.db).ListForGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		var visible []EventSeries
+		for _, series := range seriesList {
+			if series.Visibility == VisibilityPublic || (isFollower && series.Visibility == VisibilityMembersAndFollowers) {
+				visible = append(visible, series)
+			}
+		}
+		seriesList = visible
+	}
+	if err := s.addSeries(calendar, seriesList); err != nil {
+		return nil, err
+	}
+	calendar.Events = append(calendar.Events, events...)
+	return calendar, nil
+}
+
+// feedEvents loads saved events for a feed. Saved occurrences of a series share the
+// series' UID and override the occurrence they were saved from. Drafts are left out and
+// cancelled events stay in the feed marked cancelled. $1 is the subscriber.
+func (s *CalendarFeedService) feedEvents(userID, join, condition string, args ...interface{}) ([]ical.Event, error) {
+	var email, firstName, lastName string
+	err := s.db.QueryRow(`
+		SELECT email, COALESCE(first_name, ''), COALESCE(last_name, '') FROM users WHERE id = $1
+	`, userID).Scan(&email, &firstName, &lastName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching calendar feed subscriber: %v", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT e.id, e.title, COALESCE(e.description, ''), COALESCE(e.location, ''), e.start_time, e.end_time,
+		       e.sequence, e.created_at, e.status, x.series_id, x.original_start,
+		       TRIM(COALESCE(mc.first_name, '') || ' ' || COALESCE(mc.last_name, '')), COALESCE(r.status, '')
+		FROM events e
+		`+join+`
+		LEFT JOIN event_series_exceptions x ON x.event_id = e.id
+		LEFT JOIN users mc ON e.mc_id = mc.id
+		LEFT JOIN event_rsvps r ON r.event_id = e.id AND r.user_id = $1
+		WHERE `+condition+` AND e.status != 'draft' AND (x.cancelled IS NULL OR x.cancelled = FALSE)
+		ORDER BY e.start_time
+	`, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching calendar feed events: %v", err)
+	}
+	defer rows.Close()
+
+	var events []ical.Event
+	for rows.Next() {
+		var id, title, description, location, eventStatus, mcName, status string
+		var startTime, endTime, createdAt time.Time
+		var sequence int
+		var seriesID sql.NullString
+		var originalStart sql.NullTime
+		err := rows.Scan(&id, &title, &description, &location, &startTime, &endTime,
+			&sequence, &createdAt, &eventStatus, &seriesID, &originalStart, &mcName, &status)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning calendar feed event: %v", err)
+		}
+
+		eventURL := fmt.Sprintf("%s/events/%s", os.Getenv("FRONTEND_URL"), id)
+		event := ical.Event{
+			UID:         feedUID(id),
+			Sequence:    sequence,
+			Stamp:       createdAt,
+			Start:       startTime,
+			End:         endTime,
+			Summary:     title,
+			Description: feedDescription(description, mcName, status, eventURL),
+			Location:    location,
+			URL:         eventURL,
+		}
+		if eventStatus == EventStatusCancelled {
+			event.Status = "CANCELLED"
+		}
+		if seriesID.Valid {
+			event.UID = feedUID(seriesID.String)
+			event.RecurrenceID = originalStart.Time
+		}
+		if status != "" {
+			event.Attendee = &ical.Attendee{
+				Name:     strings.TrimSpace(firstName + " " + lastName),
+				Email:    email,
+				PartStat: partStat(status),
+			}
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// addSeries adds each series as a recurring event, leaving out cancelled occurrences
+func (s *CalendarFeedService) addSeries(calendar *ical.Calendar, seriesList []EventSeries) error {
+	mcNames := map[string]string{}
+	for _, series := range seriesList {
+		rule, err := series.Rule()
+		if err != nil {
+			return fmt.Errorf("invalid rule for series %s: %v", series.ID, err)
+		}
+		// Calendar apps treat DTSTART as the first occurrence
+		first, ok := rule.First(series.StartTime)
+		if !ok {
+			continue
+		}
+
+		cancelled, err := s.cancelledOccurrences(series.ID)
+		if err != nil {
+			return err
+		}
+		for i := range cancelled {
+			cancelled[i] = cancelled[i].In(series.TimeLocation())
+		}
+
+		mcName := ""
+		if series.MCID != nil {
+			if name, ok := mcNames[*series.MCID]; ok {
+				mcName = name
+			} else {
+				err := s.db.QueryRow(`
+					SELECT TRIM(COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) FROM users WHERE id = $1
+				`, *series.MCID).Scan(&mcName)
+				if err != nil && err != sql.ErrNoRows {
+					return fmt.Errorf("error fetching series MC: %v", err)
+				}
+				mcNames[*series.MCID] = mcName
+			}
+		}
+
+		seriesURL := fmt.Sprintf("%s/groups/%s", os.Getenv("FRONTEND_URL"), series.GroupID)
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:         feedUID(series.ID),
+			Sequence:    series.Sequence,
+			Stamp:       series.CreatedAt,
+			Start:       first,
+			End:         first.Add(series.EndTime.Sub(series.StartTime)),
+			Summary:     series.Title,
+			Description: feedDescription(series.Description, mcName, "", seriesURL),
+			Location:    series.Location,
+			URL:         seriesURL,
+			RRule:       rule.String(),
+			ExDates:     cancelled,
+		})
+	}
+	return nil
+}
+
+// cancelledOccurrences returns the original starts of the series' cancelled occurrences
+func (s *CalendarFeedService) cancelledOccurrences(seriesID string) ([]time.Time, error) {
+	rows, err := s.db.Query(`
+		SELECT original_start FROM event_series_exceptions
+		WHERE series_id = $1 AND cancelled = TRUE
+		ORDER BY original_start
+	`, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cancelled occurrences: %v", err)
+	}
+	defer rows.Close()
+
+	var starts []time.Time
+	for rows.Next() {
+		var start time.Time
+		if err := rows.Scan(&start); err != nil {
+			return nil, fmt.Errorf("error scanning cancelled occurrence: %v", err)
+		}
+		starts = append(starts, start)
+	}
+	return starts, nil
+}
+
+// feedUID is a globally unique ID for an event or series that stays the same across fetches
+func feedUID(id string) string {
+	return id + "@improv-app"
+}
+
+// feedDescription adds the MC, the subscriber's RSVP and a link back to the event's description
+func feedDescription(description, mcName, status, link string) string {
+	var lines []string
+	if description != "" {
+		lines = append(lines, description, "")
+	}
+	if mcName != "" {
+		lines = append(lines, "MC: "+mcName)
+	}
+	if status != "" {
+		lines = append(lines, "Your RSVP: "+status)
+	}
+	lines = append(lines, link)
+	return strings.Join(lines, "\n")
+}
+
+// partStat maps an RSVP status to an iCalendar participation status
+func partStat(status string) string {
+	switch status {
+	case "attending":
+		return ical.PartStatAccepted
+	case "maybe":
+		return ical.PartStatTentative
+	case "declined":
+		return ical.PartStatDeclined
+	default:
+		return ical.PartStatNeedsAction
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func renderFeed(t *testing.T, service *CalendarFeedService, token string) string {
+	t.Helper()
+	calendar, err := service.Feed(token)
+	if err != nil {
+		t.Fatalf("Error rendering feed: %v", err)
+	}
+	return calendar.String()
+}
+
+func TestCalendarFeed_UserFeed(t *testing.T) {
+	service := NewCalendarFeedService(newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedRehearsal))
+	token, err := service.FeedToken("user123", "")
+	if err != nil {
+		t.Fatalf("Error getting feed token: %v", err)
+	}
+	feed := renderFeed(t, service, token)
+
+	for _, want := range []string{
+		"UID:public1@improv-app\r\n",
+		"UID:private1@improv-app\r\n",
+		"DTSTART:20260201T190000Z\r\n",
+		"LOCATION:Main Stage\r\n",
+		"MC: Ada Admin",
+		"ATTENDEE;PARTSTAT=ACCEPTED;CN=\"Ada Admin\":mailto:admin@example.com\r\n",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("Expected feed to contain %q, got:\n%s", want, feed)
+		}
+	}
+}
+
+func TestCalendarFeed_GroupFeedHidesPrivateEventsFromNonMembers(t *testing.T) {
+	service := NewCalendarFeedService(newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedRehearsal))
+
+	memberToken, err := service.FeedToken("user123", "group123")
+	if err != nil {
+		t.Fatalf("Error getting feed token: %v", err)
+	}
+	if feed := renderFeed(t, service, memberToken); !strings.Contains(feed, "UID:private1@improv-app") {
+		t.Errorf("Expected members to see private events, got:\n%s", feed)
+	}
+
+	outsiderToken, err := service.FeedToken("outsider", "group123")
+	if err != nil {
+		t.Fatalf("Error getting feed token: %v", err)
+	}
+	feed := renderFeed(t, service, outsiderToken)
+	if strings.Contains(feed, "private1") {
+		t.Errorf("Expected non-members not to see private events, got:\n%s", feed)
+	}
+	if !strings.Contains(feed, "UID:public1@improv-app") || !strings.Contains(feed, "X-WR-CALNAME:Test Group") {
+		t.Errorf("Expected non-members to see public events, got:\n%s", feed)
+	}
+}
+
+func TestCalendarFeed_RegenerateRevokesOldToken(t *testing.T) {
+	service := NewCalendarFeedService(newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedRehearsal))
+	oldToken, err := service.FeedToken("user123", "")
+	if err != nil {
+		t.Fatalf("Error getting feed token: %v", err)
+	}
+	if again, _ := service.FeedToken("user123", ""); again != oldToken {
+		t.Errorf("Expected the same token until it's regenerated")
+	}
+
+	newToken, err := service.RegenerateFeedToken("user123", "")
+	if err != nil {
+		t.Fatalf("Error regenerating feed token: %v", err)
+	}
+	if newToken == oldToken {
+		t.Fatalf("Expected a new token")
+	}
+	if _, err := service.Feed(oldToken); err != ErrFeedNotFound {
+		t.Errorf("Expected ErrFeedNotFound for the old token, got %v", err)
+	}
+	renderFeed(t, service, newToken)
+}
+
+func TestCalendarFeed_SeriesAndOverrides(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedRehearsal)
+	seriesService := NewEventSeriesService(testDB)
+	series := createTestSeries(t, seriesService, "FREQ=WEEKLY;BYDAY=TU")
+
+	second, err := seriesService.Materialize(series.ID, seriesStart.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("Error saving occurrence: %v", err)
+	}
+	if err := seriesService.Skip(series.ID, seriesStart.AddDate(0, 0, 14)); err != nil {
+		t.Fatalf("Error skipping occurrence: %v", err)
+	}
+	if _, err := testDB.Exec(`UPDATE events SET title = 'Special Jam', sequence = sequence + 1 WHERE id = $1`, second); err != nil {
+		t.Fatalf("Error editing occurrence: %v", err)
+	}
+
+	service := NewCalendarFeedService(testDB)
+	token, err := service.FeedToken("user123", "group123")
+	if err != nil {
+		t.Fatalf("Error getting feed token: %v", err)
+	}
+	feed := renderFeed(t, service, token)
+
+	uid := "UID:" + series.ID + "@improv-app\r\n"
+	if strings.Count(feed, uid) != 2 {
+		t.Errorf("Expected the series and its override to share a UID, got:\n%s", feed)
+	}
+	for _, want := range []string{
+		"SEQUENCE:1\r\nDTSTART:20260106T190000Z\r\nDTEND:20260106T210000Z\r\nRRULE:FREQ=WEEKLY;BYDAY=TU\r\nEXDATE:20260120T190000Z\r\n",
+		"SEQUENCE:1\r\nRECURRENCE-ID:20260113T190000Z\r\nDTSTART:20260113T190000Z\r\n",
+		"SUMMARY:Special Jam\r\n",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("Expected feed to contain %q, got:\n%s", want, feed)
+		}
+	}
+	if strings.Count(feed, "20260120T190000Z") != 1 {
+		t.Errorf("Expected the skipped occurrence only as an exception, got:\n%s", feed)
+	}
+
+	// Editing the whole series bumps its sequence so calendar apps pick it up
+	err = seriesService.ApplyEdit(second, ScopeAll, SeriesEdit{Title: "Weekly Jam", Duration: 2 * time.Hour})
+	if err != nil {
+		t.Fatalf("Error editing series: %v", err)
+	}
+	if feed := renderFeed(t, service, token); !strings.Contains(feed, "SEQUENCE:2\r\nDTSTART:20260106T190000Z\r\n") {
+		t.Errorf("Expected the series sequence to go up, got:\n%s", feed)
+	}
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"improv-app/internal/chat"
+	"improv-app/internal/query"
+
+	"github.com/google/uuid"
+)
+
+// tonightPostHour is the local hour after which the daily "tonight's show" post goes out
+const tonightPostHour = 12
+
+// ChatIntegration posts a group's announcements to a Slack or Discord incoming webhook
+type ChatIntegration struct {
+	ID              string    `json:"id"`
+	GroupID         string    `json:"groupId"`
+	Platform        string    `json:"platform"`
+	WebhookURL      string    `json:"webhookUrl"`
+	AnnounceEvents  bool      `json:"announceEvents"`
+	AnnounceLineups bool      `json:"announceLineups"`
+	DailyPost       bool      `json:"dailyPost"`
+	CreatedBy       string    `json:"createdBy"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// Wants reports whether the integration posts announcements of the given kind
+func (i ChatIntegration) Wants(kind string) bool {
+	switch kind {
+	case chat.KindEventCreated:
+		return i.AnnounceEvents
+	case chat.KindLineupFinalized:
+		return i.AnnounceLineups
+	case chat.KindTonight:
+		return i.DailyPost
+	default:
+		return true
+	}
+}
+
+// ErrChatPostRejected means the chat platform answered a post with an error status
+var ErrChatPostRejected = errors.New("chat post rejected")
+
+// ChatService posts event announcements to groups' chat integrations
+type ChatService struct {
+	db     *sql.DB
+	client *http.Client
+}
+
+func NewChatService(db *sql.DB) *ChatService {
+	return &ChatService{
+		db:     db,
+		client: newWebhookClient(),
+	}
+}
+
+const chatIntegrationColumns = `
+	id, group_id, platform, webhook_url, announce_events, announce_lineups, daily_post, created_by, created_at
+`
+
+func scanChatIntegration(scanner interface{ Scan(...interface{}) error }) (ChatIntegration, error) {
+	var integration ChatIntegration
+	err := scanner.Scan(&integration.ID, &integration.GroupID, &integration.Platform, &integration.WebhookURL,
+		&integration.AnnounceEvents, &integration.AnnounceLineups, &integration.DailyPost, &integration.CreatedBy, &integration.CreatedAt)
+	return integration, err
+}
+
+// List returns every chat integration for the group
+func (s *ChatService) List(groupID string) ([]ChatIntegration, error) {
+	rows, err := s.db.Query(`
+		SELECT `+chatIntegrationColumns+`
+		FROM group_chat_integrations
+		WHERE group_id = $1
+		ORDER BY created_at
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching chat integrations: %v", err)
+	}
+	defer rows.Close()
+
+	integrations := []ChatIntegration{}
+	for rows.Next() {
+		integration, err := scanChatIntegration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning chat integration: %v", err)
+		}
+		integrations = append(integrations, integration)
+	}
+	return integrations, nil
+}
+
+// Get returns one of the group's chat integrations, or nil if it doesn't exist
+func (s *ChatService) Get(groupID, integrationID string) (*ChatIntegration, error) {
+	integration, err := scanChatIntegration(s.db.QueryRow(`
+		SELECT `+chatIntegrationColumns+`
+		FROM group_chat_integrations
+		WHERE id = $1 AND group_id = $2
+	`, integrationID, groupID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching chat integration: %v", err)
+	}
+	return &integration, nil
+}
+
+// Save creates the integration when it has no ID, otherwise updates it
+func (s *ChatService) Save(integration ChatIntegration) (*ChatIntegration, error) {
+	var err error
+	if integration.ID == "" {
+		integration.ID = uuid.New().String()
+		_, err = s.db.Exec(`
+			INSERT INTO group_chat_integrations (id, group_id, platform, webhook_url, announce_events, announce_lineups, daily_post, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, integration.ID, integration.GroupID, integration.Platform, integration.WebhookURL,
+			integration.AnnounceEvents, integration.AnnounceLineups, integration.DailyPost, integration.CreatedBy)
+	} else {
+		_, err = s.db.Exec(`
+			UPDATE group_chat_integrations
+			SET platform = $1, webhook_url = $2, announce_events = $3, announce_lineups = $4, daily_post = $5
+			WHERE id = $6 AND group_id = $7
+		`, integration.Platform, integration.WebhookURL, integration.AnnounceEvents, integration.AnnounceLineups,
+			integration.DailyPost, integration.ID, integration.GroupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error saving chat integration: %v", err)
+	}
+	return s.Get(integration.GroupID, integration.ID)
+}
+
+// Delete removes a chat integration and its post history
+func (s *ChatService) Delete(groupID, integrationID string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM chat_posts
+		WHERE integration_id IN (SELECT id FROM group_chat_integrations WHERE id = $1 AND group_id = $2)
+	`, integrationID, groupID)
+	if err != nil {
+		return fmt.Errorf("error deleting chat posts: %v", err)
+	}
+	_, err = s.db.Exec(`
+		DELETE FROM group_chat_integrations WHERE id = $1 AND group_id = $2
+	`, integrationID, groupID)
+	if err != nil {
+		return fmt.Errorf("error deleting chat integration: %v", err)
+	}
+	return nil
+}
+
+// Announce posts an announcement about the event to every integration in its group that wants this kind
+func (s *ChatService) Announce(eventID, kind string) error {
+	announcement, groupID, err := s.loadAnnouncement(eventID, kind)
+	if err != nil {
+		return err
+	}
+
+	integrations, err := s.List(groupID)
+	if err != nil {
+		return err
+	}
+
+	for _, integration := range integrations {
+		if !integration.Wants(kind) {
+			continue
+		}
+		if err := s.post(integration, announcement); err != nil {
+			log.Printf("Error posting %s announcement for event %s to chat integration %s: %v", kind, eventID, integration.ID, err)
+		}
+	}
+	return nil
+}
+
+// SendTest posts a test announcement, using the group's next event when there is one
+func (s *ChatService) SendTest(integration ChatIntegration) error {
+	var eventID string
+	err := s.db.QueryRow(`
+		SELECT id FROM events
+		WHERE group_id = $1 AND status = 'scheduled' AND julianday(start_time) > julianday($2)
+		ORDER BY start_time
+		LIMIT 1
+	`, integration.GroupID, time.Now().UTC()).Scan(&eventID)
+
+	var announcement chat.Announcement
+	switch {
+	case err == sql.ErrNoRows:
+		var groupName string
+		if err := s.db.QueryRow(`SELECT name FROM improv_groups WHERE id = $1`, integration.GroupID).Scan(&groupName); err != nil {
+			return fmt.Errorf("error fetching group: %v", err)
+		}
+		announcement = chat.Announcement{
+			GroupName: groupName,
+			Title:     "Sample show",
+			StartTime: time.Now().Add(24 * time.Hour),
+		}
+	case err != nil:
+		return fmt.Errorf("error fetching next event: %v", err)
+	default:
+		announcement, _, err = s.loadAnnouncement(eventID, chat.KindTest)
+		if err != nil {
+			return err
+		}
+	}
+	announcement.Kind = chat.KindTest
+
+	return s.post(integration, announcement)
+}
+
+// post sends a formatted announcement to the integration's incoming webhook
+func (s *ChatService) post(integration ChatIntegration, announcement chat.Announcement) error {
+	body, err := chat.Format(integration.Platform, announcement)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(integration.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The body isn't read back, since whatever answered might not be a chat platform
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s responded %d", ErrChatPostRejected, integration.Platform, resp.StatusCode)
+	}
+	return nil
+}
+
+// isTonight reports whether an event starting at startTime in timeZone is due its "tonight's show" post at now:
+// it's past the posting hour where the event is and the event starts before the end of that day
+func isTonight(startTime time.Time, timeZone string, now time.Time) bool {
+	local := now.In(EventLocation(timeZone))
+	endOfDay := time.Date(local.Year(), local.Month(), local.Day(), 23, 59, 59, 0, local.Location())
+	return local.Hour() >= tonightPostHour && !startTime.After(endOfDay)
+}
+
+// Start posts the daily "tonight's show" announcements in the background every interval
+func (s *ChatService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.PostTonightsShows(time.Now()); err != nil {
+				log.Printf("Error posting tonight's shows: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// PostTonightsShows announces events later today to integrations with the daily post turned on.
+// "Today" and the posting hour are in each event's zone. Each event is posted once per integration.
+func (s *ChatService) PostTonightsShows(now time.Time) error {
+	// Tonight's occurrences are saved first, so their posts are claimed by event like any other
+	seriesService := NewEventSeriesService(s.db)
+	seriesList, err := seriesService.list(`
+		SELECT DISTINCT ` + eventSeriesColumns + `, g.name
+		FROM event_series s
+		JOIN improv_groups g ON s.group_id = g.id
+		JOIN group_chat_integrations ci ON ci.group_id = s.group_id AND ci.daily_post = TRUE
+	`)
+	if err != nil {
+		return err
+	}
+	err = seriesService.materializeDue(seriesList, now, now.Add(24*time.Hour), func(occurrence Occurrence) bool {
+		return isTonight(occurrence.StartTime, occurrence.TimeZone, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The end of the day is under a day away in any zone, so later checks narrow this down
+	rows, err := s.db.Query(`
+		SELECT e.id, e.start_time, e.time_zone, ci.id, ci.group_id, ci.platform, ci.webhook_url, ci.announce_events,
+			ci.announce_lineups, ci.daily_post, ci.created_by, ci.created_at
+		FROM events e
+		JOIN group_chat_integrations ci ON ci.group_id = e.group_id AND ci.daily_post = TRUE
+		LEFT JOIN chat_posts cp ON cp.integration_id = ci.id AND cp.event_id = e.id AND cp.kind = $1
+		WHERE cp.event_id IS NULL AND e.status = 'scheduled' AND `+query.NotCancelledOccurrenceCondition+`
+		  AND julianday(e.start_time) > julianday($2)
+		  AND julianday(e.start_time) <= julianday($3)
+		ORDER BY e.start_time
+	`, chat.KindTonight, now.UTC(), now.Add(24*time.Hour).UTC())
+	if err != nil {
+		return fmt.Errorf("error fetching tonight's shows: %v", err)
+	}
+
+	type tonightPost struct {
+		eventID     string
+		integration ChatIntegration
+	}
+	var posts []tonightPost
+	for rows.Next() {
+		var post tonightPost
+		var integration ChatIntegration
+		var startTime time.Time
+		var timeZone string
+		err := rows.Scan(&post.eventID, &startTime, &timeZone, &integration.ID, &integration.GroupID, &integration.Platform, &integration.WebhookURL,
+			&integration.AnnounceEvents, &integration.AnnounceLineups, &integration.DailyPost, &integration.CreatedBy, &integration.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning tonight's show: %v", err)
+		}
+		if !isTonight(startTime, timeZone, now) {
+			continue
+		}
+		post.integration = integration
+		posts = append(posts, post)
+	}
+	rows.Close()
+
+	for _, post := range posts {
+		// Claim the post before sending so a restart never posts it twice
+		result, err := s.db.Exec(`
+			INSERT OR IGNORE INTO chat_posts (integration_id, event_id, kind)
+			VALUES ($1, $2, $3)
+		`, post.integration.ID, post.eventID, chat.KindTonight)
+		if err != nil {
+			return fmt.Errorf("error recording chat post: %v", err)
+		}
+		if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+			continue
+		}
+
+		announcement, _, err := s.loadAnnouncement(post.eventID, chat.KindTonight)
+		if err == nil {
+			err = s.post(post.integration, announcement)
+		}
+		if err != nil {
+			log.Printf("Error posting tonight's show %s to chat integration %s: %v", post.eventID, post.integration.ID, err)
+			// Release the claim so the next run retries
+			s.db.Exec(`
+				DELETE FROM chat_posts
+				WHERE integration_id = $1 AND event_id = $2 AND kind = $3
+			`, post.integration.ID, post.eventID, chat.KindTonight)
+		}
+	}
+	return nil
+}
+
+// loadAnnouncement gathers the event details, RSVP counts and lineup for a chat post
+func (s *ChatService) loadAnnouncement(eventID, kind string) (chat.Announcement, string, error) {
+	announcement := chat.Announcement{Kind: kind}
+	var groupID string
+	err := s.db.QueryRow(`
+		SELECT e.group_id, g.name, e.title, COALESCE(e.location, ''), e.start_time,
+			COALESCE(TRIM(u.first_name || ' ' || u.last_name), '')
+		FROM events e
+		JOIN improv_groups g ON e.group_id = g.id
+		LEFT JOIN users u ON e.mc_id = u.id
+		WHERE e.id = $1
+	`, eventID).Scan(&groupID, &announcement.GroupName, &announcement.Title, &announcement.Location, &announcement.StartTime, &announcement.MCName)
+	if err != nil {
+		return announcement, "", fmt.Errorf("error fetching event for announcement: %v", err)
+	}
+	announcement.URL = fmt.Sprintf("%s/events/%s", os.Getenv("FRONTEND_URL"), eventID)
+
+	err = s.db.QueryRow(`
+		SELECT
+			COUNT(CASE WHEN status = 'attending' THEN 1 END),
+			COUNT(CASE WHEN status = 'maybe' THEN 1 END)
+		FROM event_rsvps
+		WHERE event_id = $1
+	`, eventID).Scan(&announcement.Attending, &announcement.Maybe)
+	if err != nil {
+		return announcement, "", fmt.Errorf("error counting RSVPs for announcement: %v", err)
+	}
+
+	// Players may be members or walk-in attendees
+	rows, err := s.db.Query(`
+		SELECT g.id, g.name,
+			COALESCE(NULLIF(TRIM(u.first_name || ' ' || u.last_name), ''), TRIM(nra.first_name || ' ' || nra.last_name), '') AS player_name
+		FROM event_games eg
+		JOIN games g ON eg.game_id = g.id
+		LEFT JOIN event_player_assignments epa ON epa.event_id = eg.event_id AND epa.game_id = eg.game_id
+		LEFT JOIN users u ON epa.user_id = u.id
+		LEFT JOIN non_registered_attendees nra ON epa.user_id = nra.id
+		WHERE eg.event_id = $1
+		ORDER BY eg.order_index, eg.game_id, player_name
+	`, eventID)
+	if err != nil {
+		return announcement, "", fmt.Errorf("error fetching lineup for announcement: %v", err)
+	}
+	defer rows.Close()
+
+	var lastGameID string
+	for rows.Next() {
+		var gameID, gameName, playerName string
+		if err := rows.Scan(&gameID, &gameName, &playerName); err != nil {
+			return announcement, "", fmt.Errorf("error scanning lineup for announcement: %v", err)
+		}
+		if gameID != lastGameID {
+			announcement.Lineup = append(announcement.Lineup, chat.LineupGame{Name: gameName})
+			lastGameID = gameID
+		}
+		if playerName != "" {
+			game := &announcement.Lineup[len(announcement.Lineup)-1]
+			game.Players = append(game.Players, playerName)
+		}
+	}
+	return announcement, groupID, nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"improv-app/internal/chat"
+)
+
+// seedChatEvent adds an event with an MC, RSVPs and a lineup with a member and a walk-in
+func seedChatEvent(t *testing.T, testDB *sql.DB, eventID string, start time.Time) {
+	_, err := testDB.Exec(`
+		INSERT OR IGNORE INTO users (id, email, first_name, last_name) VALUES ('mc123', 'mc@example.com', 'Jane', 'Doe');
+		INSERT OR IGNORE INTO games (id, name, min_players, max_players, created_by, group_id) VALUES ('game123', 'Freeze Tag', 2, 6, 'user123', 'group123');
+	`)
+	if err != nil {
+		t.Fatalf("Error seeding users and games: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		INSERT INTO events (id, group_id, title, location, start_time, end_time, created_by, mc_id)
+		VALUES ($1, 'group123', 'Friday Show', 'The Basement', $2, $3, 'user123', 'mc123')
+	`, eventID, start.UTC(), start.Add(2*time.Hour).UTC())
+	if err != nil {
+		t.Fatalf("Error seeding event: %v", err)
+	}
+
+	for _, statement := range []string{
+		`INSERT INTO event_rsvps (event_id, user_id, status) VALUES ($1, 'mc123', 'attending'), ($1, 'user123', 'maybe')`,
+		`INSERT INTO non_registered_attendees (id, event_id, first_name, last_name) VALUES ($1 || '-walkin', $1, 'Walk', 'In')`,
+		`INSERT INTO event_games (event_id, game_id, order_index) VALUES ($1, 'game123', 0)`,
+		`INSERT INTO event_player_assignments (event_id, game_id, user_id) VALUES ($1, 'game123', 'mc123'), ($1, 'game123', $1 || '-walkin')`,
+	} {
+		if _, err := testDB.Exec(statement, eventID); err != nil {
+			t.Fatalf("Error seeding event details: %v", err)
+		}
+	}
+}
+
+// newLocalChatService posts to the test receivers on loopback, which real integrations can't reach
+func newLocalChatService(testDB *sql.DB) *ChatService {
+	service := NewChatService(testDB)
+	service.client = &http.Client{Timeout: 10 * time.Second}
+	return service
+}
+
+func TestChatService_AnnouncesToMatchingIntegrations(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	slack := &webhookReceiver{}
+	slackServer := httptest.NewServer(slack)
+	defer slackServer.Close()
+	discord := &webhookReceiver{}
+	discordServer := httptest.NewServer(discord)
+	defer discordServer.Close()
+
+	seedChatEvent(t, testDB, "event123", time.Now().Add(48*time.Hour))
+
+	service := newLocalChatService(testDB)
+	_, err := service.Save(ChatIntegration{GroupID: "group123", Platform: chat.PlatformSlack, WebhookURL: slackServer.URL, AnnounceLineups: true, CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error creating Slack integration: %v", err)
+	}
+	_, err = service.Save(ChatIntegration{GroupID: "group123", Platform: chat.PlatformDiscord, WebhookURL: discordServer.URL, AnnounceEvents: true, AnnounceLineups: true, CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error creating Discord integration: %v", err)
+	}
+
+	// Only Discord wants new events
+	if err := service.Announce("event123", chat.KindEventCreated); err != nil {
+		t.Fatalf("Error announcing event: %v", err)
+	}
+	if len(slack.received()) != 0 || len(discord.received()) != 1 {
+		t.Fatalf("Expected only Discord to get the new event, got slack=%d discord=%d", len(slack.received()), len(discord.received()))
+	}
+
+	if err := service.Announce("event123", chat.KindLineupFinalized); err != nil {
+		t.Fatalf("Error announcing lineup: %v", err)
+	}
+	if len(slack.received()) != 1 || len(discord.received()) != 2 {
+		t.Fatalf("Expected both to get the lineup, got slack=%d discord=%d", len(slack.received()), len(discord.received()))
+	}
+
+	var message struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Text struct {
+				Text string `json:"text"`
+			} `json:"text"`
+			Fields []struct {
+				Text string `json:"text"`
+			} `json:"fields"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(slack.received()[0].body, &message); err != nil {
+		t.Fatalf("Error decoding Slack message: %v", err)
+	}
+	if message.Text != "The lineup for Friday Show is set!" {
+		t.Errorf("Unexpected Slack text: %s", message.Text)
+	}
+
+	body := string(slack.received()[0].body)
+	for _, want := range []string{"Jane Doe", "The Basement", "1 attending, 1 maybe", "Freeze Tag — Jane Doe, Walk In"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected Slack message to contain %q, got %s", want, body)
+		}
+	}
+}
+
+func TestChatService_PostsTonightsShowsOnce(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	receiver := &webhookReceiver{responses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	now := time.Date(2026, 3, 6, 15, 0, 0, 0, time.UTC)
+	seedChatEvent(t, testDB, "tonight", now.Add(5*time.Hour))
+	seedChatEvent(t, testDB, "tomorrow", now.Add(24*time.Hour))
+
+	service := newLocalChatService(testDB)
+	_, err := service.Save(ChatIntegration{GroupID: "group123", Platform: chat.PlatformDiscord, WebhookURL: server.URL, DailyPost: true, CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error creating integration: %v", err)
+	}
+
+	// Nothing goes out in the morning
+	if err := service.PostTonightsShows(now.Add(-6 * time.Hour)); err != nil {
+		t.Fatalf("Error posting tonight's shows: %v", err)
+	}
+	if len(receiver.received()) != 0 {
+		t.Fatalf("Expected no posts before %d:00, got %d", tonightPostHour, len(receiver.received()))
+	}
+
+	// The first attempt fails, so the next run retries it
+	for i := 0; i < 3; i++ {
+		if err := service.PostTonightsShows(now); err != nil {
+			t.Fatalf("Error posting tonight's shows: %v", err)
+		}
+	}
+
+	requests := receiver.received()
+	if len(requests) != 2 {
+		t.Fatalf("Expected a failed post and one retry, got %d requests", len(requests))
+	}
+	if !strings.Contains(string(requests[1].body), "Tonight: Friday Show") {
+		t.Errorf("Expected a tonight post, got %s", requests[1].body)
+	}
+}
+
+func TestChatService_SendTestWithoutEvents(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	service := newLocalChatService(testDB)
+	integration, err := service.Save(ChatIntegration{GroupID: "group123", Platform: chat.PlatformSlack, WebhookURL: server.URL, CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error creating integration: %v", err)
+	}
+
+	if err := service.SendTest(*integration); err != nil {
+		t.Fatalf("Error sending test: %v", err)
+	}
+	requests := receiver.received()
+	if len(requests) != 1 || !strings.Contains(string(requests[0].body), "Test announcement from Test Group") {
+		t.Errorf("Expected a test announcement, got %d requests", len(requests))
+	}
+
+	receiver.responses = []int{http.StatusNotFound}
+	if err := service.SendTest(*integration); !errors.Is(err, ErrChatPostRejected) {
+		t.Errorf("Expected ErrChatPostRejected when the chat platform rejects the post, got %v", err)
+	}
+
+	// Outside tests, posts never reach the server's own network
+	if err := NewChatService(testDB).SendTest(*integration); !errors.Is(err, ErrWebhookAddressNotAllowed) {
+		t.Errorf("Expected ErrWebhookAddressNotAllowed posting to loopback, got %v", err)
+	}
+	if len(receiver.received()) != 2 {
+		t.Errorf("Expected the loopback post refused, got %d requests", len(receiver.received()))
+	}
+}
+
+func TestChatService_PostsTonightInTheEventsZone(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	// 6 PM in New York, 11 PM in UTC
+	seedChatEvent(t, testDB, "nyc", time.Date(2026, 3, 6, 23, 0, 0, 0, time.UTC))
+	seed(t, testDB, `UPDATE events SET time_zone = 'America/New_York' WHERE id = 'nyc'`)
+
+	service := newLocalChatService(testDB)
+	_, err := service.Save(ChatIntegration{GroupID: "group123", Platform: chat.PlatformDiscord, WebhookURL: server.URL, DailyPost: true, CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error creating integration: %v", err)
+	}
+
+	// Past noon in UTC but still 10 AM in New York
+	if err := service.PostTonightsShows(time.Date(2026, 3, 6, 15, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Error posting tonight's shows: %v", err)
+	}
+	if len(receiver.received()) != 0 {
+		t.Fatalf("Expected no post before noon in New York, got %d", len(receiver.received()))
+	}
+
+	if err := service.PostTonightsShows(time.Date(2026, 3, 6, 17, 30, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Error posting tonight's shows: %v", err)
+	}
+	if len(receiver.received()) != 1 {
+		t.Errorf("Expected the show posted after noon in New York, got %d posts", len(receiver.received()))
+	}
+}
+
+func TestChatService_PostsTonightsSeriesOccurrence(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	start := time.Date(2026, 3, 3, 19, 0, 0, 0, time.UTC)
+	_, err := NewEventSeriesService(testDB).Create(EventSeries{
+		GroupID:   "group123",
+		Title:     "Tuesday Jam",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=4",
+		CreatedBy: "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error creating series: %v", err)
+	}
+
+	service := newLocalChatService(testDB)
+	_, err = service.Save(ChatIntegration{GroupID: "group123", Platform: chat.PlatformDiscord, WebhookURL: server.URL, DailyPost: true, CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error creating integration: %v", err)
+	}
+
+	// The second jam hasn't been saved as an event, but it's still posted once
+	for i := 0; i < 2; i++ {
+		if err := service.PostTonightsShows(start.AddDate(0, 0, 7).Add(-4 * time.Hour)); err != nil {
+			t.Fatalf("Error posting tonight's shows: %v", err)
+		}
+	}
+	requests := receiver.received()
+	if len(requests) != 1 || !strings.Contains(string(requests[0].body), "Tonight: Tuesday Jam") {
+		t.Fatalf("Expected the jam posted once, got %d posts", len(requests))
+	}
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"improv-app/internal/query"
+)
+
+// Conflict kinds
+const (
+	// ConflictGroup means the group has another event at the same time
+	ConflictGroup = "group"
+	// ConflictVenue means the venue is booked for another event at the same time
+	ConflictVenue = "venue"
+	// ConflictPerformer means a performer is in another show at the same time, in any group
+	ConflictPerformer = "performer"
+)
+
+// Conflict policies decide what happens when an event or assignment conflicts
+const (
+	// ConflictPolicyWarn saves the change and returns the conflicts as warnings
+	ConflictPolicyWarn = "warn"
+	// ConflictPolicyBlock refuses the change
+	ConflictPolicyBlock = "block"
+)
+
+// IsValidConflictPolicy reports whether policy is one of the conflict policies
+func IsValidConflictPolicy(policy string) bool {
+	return policy == ConflictPolicyWarn || policy == ConflictPolicyBlock
+}
+
+// GroupConflictPolicy returns what the group does about scheduling conflicts
+func GroupConflictPolicy(db *sql.DB, groupID string) (string, error) {
+	var policy string
+	err := db.QueryRow(`SELECT conflict_policy FROM improv_groups WHERE id = $1`, groupID).Scan(&policy)
+	if err != nil {
+		return "", fmt.Errorf("error fetching group conflict policy: %v", err)
+	}
+	return policy, nil
+}
+
+// ScheduledEvent is an event or unsaved occurrence taking up a slot in someone's calendar
+type ScheduledEvent struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	GroupID   string    `json:"groupId"`
+	GroupName string    `json:"groupName"`
+	VenueID   *string   `json:"venueId,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+// Conflict is another event that clashes with the one being checked
+type Conflict struct {
+	Kind string `json:"kind"`
+	// Event is left out when it's another group's event the user checking can't see
+	Event *ScheduledEvent `json:"event,omitempty"`
+	// UserID is the double-booked performer, for performer conflicts
+	UserID  string `json:"userId,omitempty"`
+	Message string `json:"message"`
+}
+
+// ConflictPair is two of a user's upcoming events that clash, and why
+type ConflictPair struct {
+	Kinds  []string         `json:"kinds"`
+	Events []ScheduledEvent `json:"events"`
+}
+
+// EventSlot is the time and place an event is being booked for
+type EventSlot struct {
+	// EventID is left out of the check. It's empty for a new event.
+	EventID   string
+	GroupID   string
+	VenueID   *string
+	StartTime time.Time
+	EndTime   time.Time
+	// Performers are the members performing in the event, such as its MC and assigned players
+	Performers []string
+	// ViewerID is the user checking the slot, who is only shown the other groups' events they can see
+	ViewerID string
+}
+
+// ConflictService finds events that overlap in time and share a group, venue or performer
+type ConflictService struct {
+	db *sql.DB
+}
+
+func NewConflictService(db *sql.DB) *ConflictService {
+	return &ConflictService{db: db}
+}
+
+// overlaps reports whether two events overlap. Events starting at the same moment
+// always do, so events without a length still clash with each other.
+func overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Equal(bStart) || (aStart.Before(bEnd) && bStart.Before(aEnd))
+}
+
+// overlapCondition matches events overlapping the slot from $2 to $3, the same way overlaps does
+const overlapCondition = `
+	(julianday(e.start_time) = julianday($2)
+	 OR (julianday(e.end_time) > julianday($2) AND julianday(e.start_time) < julianday($3)))
+`
+
+// Check returns the events clashing with the slot: the group's own events, events at the
+// same venue, and other shows any of the performers are in
+func (s *ConflictService) Check(slot EventSlot) ([]Conflict, error) {
+	conflicts := []Conflict{}
+	start, end := slot.StartTime.UTC(), slot.EndTime.UTC()
+
+	groupEvents, err := s.savedEvents(`e.group_id = $4`, slot.EventID, start, end, slot.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	var venueEvents []ScheduledEvent
+	if slot.VenueID != nil {
+		if venueEvents, err = s.savedEvents(`e.venue_id = $4`, slot.EventID, start, end, *slot.VenueID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Recurring events clash through their unsaved occurrences too
+	occurrences, err := s.occurrences(slot.GroupID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, occurrence := range occurrences {
+		if occurrence.ID == slot.EventID {
+			continue
+		}
+		event := scheduledOccurrence(occurrence)
+		groupEvents = append(groupEvents, event)
+		if slot.VenueID != nil && event.VenueID != nil && *event.VenueID == *slot.VenueID {
+			venueEvents = append(venueEvents, event)
+		}
+	}
+
+	venueBooked := map[string]bool{}
+	for _, event := range venueEvents {
+		venueBooked[event.ID] = true
+		visible, err := s.visible(slot, event)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			conflicts = append(conflicts, Conflict{
+				Kind:    ConflictVenue,
+				Message: "The venue is booked by another group at this time",
+			})
+			continue
+		}
+		conflicts = append(conflicts, Conflict{
+			Kind:    ConflictVenue,
+			Event:   &event,
+			Message: fmt.Sprintf("The venue is booked for %s at this time", event.Title),
+		})
+	}
+	for _, event := range groupEvents {
+		// An event at the same venue is already reported
+		if venueBooked[event.ID] {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{
+			Kind:    ConflictGroup,
+			Event:   &event,
+			Message: fmt.Sprintf("%s already has %s at this time", event.GroupName, event.Title),
+		})
+	}
+
+	performerConflicts, err := s.CheckPerformers(slot)
+	if err != nil {
+		return nil, err
+	}
+	return append(conflicts, performerConflicts...), nil
+}
+
+// CheckPerformers returns the other shows the slot's performers are in at the same time, in any group
+func (s *ConflictService) CheckPerformers(slot EventSlot) ([]Conflict, error) {
+	conflicts := []Conflict{}
+	seen := map[string]bool{}
+	for _, userID := range slot.Performers {
+		if userID == "" || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		performerConflicts, err := s.performerConflicts(slot, userID)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, performerConflicts...)
+	}
+	return conflicts, nil
+}
+
+// Performers returns the members working an event: its crew, MC included, and the players assigned to its games
+func (s *ConflictService) Performers(eventID string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id FROM event_crew WHERE event_id = $1
+		UNION
+		SELECT user_id FROM event_player_assignments WHERE event_id = $1
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event performers: %v", err)
+	}
+	defer rows.Close()
+
+	var performers []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error scanning event performer: %v", err)
+		}
+		performers = append(performers, userID)
+	}
+	return performers, nil
+}
+
+// performerConflicts returns the other shows the user is on the crew of or assigned to in the slot
+func (s *ConflictService) performerConflicts(slot EventSlot, userID string) ([]Conflict, error) {
+	var name string
+	err := s.db.QueryRow(`
+		SELECT TRIM(COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) FROM users WHERE id = $1
+	`, userID).Scan(&name)
+	if err == sql.ErrNoRows {
+		// Walk-ins only ever play in one event
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching performer: %v", err)
+	}
+	if name == "" {
+		name = "A performer"
+	}
+
+	events, err := s.savedEvents(`
+		(EXISTS (
+			SELECT 1 FROM event_crew c WHERE c.event_id = e.id AND c.user_id = $4
+		) OR EXISTS (
+			SELECT 1 FROM event_player_assignments a WHERE a.event_id = e.id AND a.user_id = $4
+		))
+	`, slot.EventID, slot.StartTime.UTC(), slot.EndTime.UTC(), userID)
+	if err != nil {
+		return nil, err
+	}
+	conflicts := make([]Conflict, len(events))
+	for i, event := range events {
+		visible, err := s.visible(slot, event)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			conflicts[i] = Conflict{
+				Kind:    ConflictPerformer,
+				UserID:  userID,
+				Message: fmt.Sprintf("%s is booked elsewhere at this time", name),
+			}
+			continue
+		}
+		conflicts[i] = Conflict{
+			Kind:    ConflictPerformer,
+			Event:   &event,
+			UserID:  userID,
+			Message: fmt.Sprintf("%s is in %s (%s) at this time", name, event.Title, event.GroupName),
+		}
+	}
+	return conflicts, nil
+}
+
+// visible reports whether the slot's viewer may see a clashing event. Events of the slot's
+// own group always are; other groups' events follow the same rules as the events list.
+func (s *ConflictService) visible(slot EventSlot, event ScheduledEvent) (bool, error) {
+	if event.GroupID == slot.GroupID {
+		return true, nil
+	}
+	var visible bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+	`+query.VisibleEventsFrom+`
+			WHERE e.id = $2 AND `+query.VisibleEventsCondition+`
+		)
+	`, slot.ViewerID, event.ID).Scan(&visible)
+	if err != nil {
+		return false, fmt.Errorf("error checking event visibility: %v", err)
+	}
+	return visible, nil
+}
+
+// savedEvents returns the scheduled and draft events overlapping start to end that match
+// the condition, which is given the argument as $4
+func (s *ConflictService) savedEvents(condition, excludeEventID string, start, end time.Time, arg string) ([]ScheduledEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT e.id, e.title, e.group_id, g.name, e.venue_id, e.start_time, e.end_time
+		FROM events e
+		JOIN improv_groups g ON e.group_id = g.id
+		WHERE e.id != $1 AND `+overlapCondition+` AND `+condition+`
+		  AND e.status IN ('draft', 'scheduled') AND `+query.NotCancelledOccurrenceCondition+`
+		ORDER BY e.start_time
+	`, excludeEventID, start, end, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching overlapping events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []ScheduledEvent{}
+	for rows.Next() {
+		var event ScheduledEvent
+		if err := rows.Scan(&event.ID, &event.Title, &event.GroupID, &event.GroupName, &event.VenueID, &event.StartTime, &event.EndTime); err != nil {
+			return nil, fmt.Errorf("error scanning overlapping event: %v", err)
+		}
+		event.StartTime, event.EndTime = event.StartTime.UTC(), event.EndTime.UTC()
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// occurrences returns the unsaved occurrences of the group's series overlapping start to end
+func (s *ConflictService) occurrences(groupID string, start, end time.Time) ([]Occurrence, error) {
+	seriesService := NewEventSeriesService(s.db)
+	seriesList, err := seriesService.ListForGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.expand(seriesList, start, end)
+}
+
+// expand returns the series' unsaved occurrences overlapping start to end
+func (s *ConflictService) expand(seriesList []EventSeries, start, end time.Time) ([]Occurrence, error) {
+	seriesService := NewEventSeriesService(s.db)
+	var overlapping []Occurrence
+	for _, series := range seriesList {
+		// Start early enough to catch an occurrence already running at start
+		length := series.EndTime.Sub(series.StartTime)
+		occurrences, err := seriesService.Occurrences(series, start.Add(-length), end.Add(time.Second))
+		if err != nil {
+			return nil, err
+		}
+		for _, occurrence := range occurrences {
+			if overlaps(occurrence.StartTime, occurrence.EndTime, start, end) {
+				overlapping = append(overlapping, occurrence)
+			}
+		}
+	}
+	return overlapping, nil
+}
+
+// scheduledOccurrence returns the slot an unsaved occurrence takes up
+func scheduledOccurrence(occurrence Occurrence) ScheduledEvent {
+	return ScheduledEvent{
+		ID:        occurrence.ID,
+		Title:     occurrence.Title,
+		GroupID:   occurrence.GroupID,
+		GroupName: occurrence.GroupName,
+		VenueID:   occurrence.VenueID,
+		StartTime: occurrence.StartTime.UTC(),
+		EndTime:   occurrence.EndTime.UTC(),
+	}
+}
+
+// ForUser returns the clashes between the scheduled events of the user's groups from from
+// to to: events of the same group, events at the same venue, and events the user is performing
+// in or attending.
+func (s *ConflictService) ForUser(userID string, from, to time.Time) ([]ConflictPair, error) {
+	rows, err := s.db.Query(`
+		SELECT e.id, e.title, e.group_id, g.name, e.venue_id, e.start_time, e.end_time,
+		       EXISTS (SELECT 1 FROM event_crew c WHERE c.event_id = e.id AND c.user_id = $1)
+		       OR EXISTS (SELECT 1 FROM event_player_assignments a WHERE a.event_id = e.id AND a.user_id = $1)
+		       OR EXISTS (SELECT 1 FROM event_rsvps r WHERE r.event_id = e.id AND r.user_id = $1 AND r.status = 'attending')
+		FROM events e
+		JOIN improv_groups g ON e.group_id = g.id
+		JOIN group_members m ON e.group_id = m.group_id AND m.user_id = $1
+		WHERE e.status = 'scheduled'
+		  AND julianday(e.end_time) >= julianday($2) AND julianday(e.start_time) < julianday($3)
+		  AND `+query.NotCancelledOccurrenceCondition+`
+	`, userID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching upcoming events: %v", err)
+	}
+	defer rows.Close()
+
+	type userEvent struct {
+		ScheduledEvent
+		involved bool
+	}
+	var events []userEvent
+	for rows.Next() {
+		var event userEvent
+		var involved sql.NullBool
+		if err := rows.Scan(&event.ID, &event.Title, &event.GroupID, &event.GroupName, &event.VenueID,
+			&event.StartTime, &event.EndTime, &involved); err != nil {
+			return nil, fmt.Errorf("error scanning upcoming event: %v", err)
+		}
+		event.StartTime, event.EndTime = event.StartTime.UTC(), event.EndTime.UTC()
+		event.involved = involved.Bool
+		events = append(events, event)
+	}
+	rows.Close()
+
+	seriesList, err := NewEventSeriesService(s.db).list(`
+		SELECT `+eventSeriesColumns+`, g.name
+		FROM event_series s
+		JOIN improv_groups g ON s.group_id = g.id
+		JOIN group_members m ON s.group_id = m.group_id AND m.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	mcOf := map[string]bool{}
+	for _, series := range seriesList {
+		mcOf[series.ID] = series.MCID != nil && *series.MCID == userID
+	}
+	occurrences, err := s.expand(seriesList, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, occurrence := range occurrences {
+		events = append(events, userEvent{ScheduledEvent: scheduledOccurrence(occurrence), involved: mcOf[occurrence.SeriesID]})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartTime.Before(events[j].StartTime) })
+	pairs := []ConflictPair{}
+	for i, a := range events {
+		for _, b := range events[i+1:] {
+			if !b.StartTime.Before(a.EndTime) && !b.StartTime.Equal(a.StartTime) {
+				break
+			}
+			var kinds []string
+			if a.GroupID == b.GroupID {
+				kinds = append(kinds, ConflictGroup)
+			}
+			if a.VenueID != nil && b.VenueID != nil && *a.VenueID == *b.VenueID {
+				kinds = append(kinds, ConflictVenue)
+			}
+			if a.involved && b.involved {
+				kinds = append(kinds, ConflictPerformer)
+			}
+			if len(kinds) > 0 {
+				pairs = append(pairs, ConflictPair{Kinds: kinds, Events: []ScheduledEvent{a.ScheduledEvent, b.ScheduledEvent}})
+			}
+		}
+	}
+	return pairs, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// newConflictTestDB adds a second group, Other Troupe, whose 8 PM show at the Old Theater
+// user123 plays in, to the public show, and a group123 event starting as public1 ends
+func newConflictTestDB(t *testing.T) *sql.DB {
+	testDB := newTestDB(t, seedGroup, seedOutsider, seedPublicShow)
+	seed(t, testDB,
+		`INSERT INTO improv_groups (id, name, description, created_by) VALUES ('group456', 'Other Troupe', '', 'user123')`,
+		`INSERT INTO group_members (group_id, user_id, role) VALUES ('group456', 'user123', 'member')`,
+		`INSERT INTO venues (id, group_id, name, created_by) VALUES ('venue1', 'group456', 'Old Theater', 'user123')`,
+		`INSERT INTO events (id, group_id, title, start_time, end_time, created_by, venue_id)
+		 VALUES ('other1', 'group456', 'Late Show', '2026-02-01 20:00:00', '2026-02-01 22:00:00', 'user123', 'venue1')`,
+		`INSERT INTO event_player_assignments (event_id, game_id, user_id) VALUES ('other1', 'game1', 'user123')`,
+		`INSERT INTO events (id, group_id, title, start_time, end_time, created_by)
+		 VALUES ('late1', 'group123', 'Afterparty', '2026-02-01 21:00:00', '2026-02-01 23:00:00', 'user123')`,
+	)
+	return testDB
+}
+
+func describeConflicts(conflicts []Conflict) []string {
+	var described []string
+	for _, conflict := range conflicts {
+		if conflict.Event == nil {
+			described = append(described, conflict.Kind+" hidden")
+			continue
+		}
+		described = append(described, conflict.Kind+" "+conflict.Event.ID)
+	}
+	return described
+}
+
+func assertConflicts(t *testing.T, got []Conflict, want ...string) {
+	t.Helper()
+	described := describeConflicts(got)
+	if len(described) != len(want) {
+		t.Fatalf("Expected conflicts %v, got %v", want, described)
+	}
+	for i := range want {
+		if described[i] != want[i] {
+			t.Fatalf("Expected conflicts %v, got %v", want, described)
+		}
+	}
+}
+
+func TestConflicts_Check(t *testing.T) {
+	testDB := newConflictTestDB(t)
+	service := NewConflictService(testDB)
+	venueID := "venue1"
+
+	conflicts, err := service.Check(EventSlot{
+		GroupID:    "group123",
+		StartTime:  time.Date(2026, 2, 1, 20, 0, 0, 0, time.UTC),
+		EndTime:    time.Date(2026, 2, 1, 21, 0, 0, 0, time.UTC),
+		Performers: []string{"user123"},
+		ViewerID:   "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error checking conflicts: %v", err)
+	}
+	// late1 starts as the slot ends, so it doesn't clash
+	assertConflicts(t, conflicts, "group public1", "performer public1", "performer other1")
+	if conflicts[2].Message != "Ada Admin is in Late Show (Other Troupe) at this time" {
+		t.Errorf("Unexpected performer message: %s", conflicts[2].Message)
+	}
+
+	// Someone outside Other Troupe only learns Ada is busy, not where
+	conflicts, err = service.CheckPerformers(EventSlot{
+		GroupID:    "group123",
+		StartTime:  time.Date(2026, 2, 1, 20, 0, 0, 0, time.UTC),
+		EndTime:    time.Date(2026, 2, 1, 21, 0, 0, 0, time.UTC),
+		Performers: []string{"user123"},
+		ViewerID:   "outsider",
+	})
+	if err != nil {
+		t.Fatalf("Error checking performer conflicts: %v", err)
+	}
+	assertConflicts(t, conflicts, "performer public1", "performer hidden")
+	if conflicts[1].Message != "Ada Admin is booked elsewhere at this time" {
+		t.Errorf("Unexpected performer message: %s", conflicts[1].Message)
+	}
+
+	// A booked venue is reported instead of the group's own event there
+	conflicts, err = service.Check(EventSlot{
+		GroupID:   "group456",
+		VenueID:   &venueID,
+		StartTime: time.Date(2026, 2, 1, 21, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2026, 2, 1, 23, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Error checking conflicts: %v", err)
+	}
+	assertConflicts(t, conflicts, "venue other1")
+
+	// An event doesn't clash with itself, and cancelled events don't clash
+	if _, err := testDB.Exec(`UPDATE events SET status = 'cancelled' WHERE id = 'late1'`); err != nil {
+		t.Fatalf("Error cancelling event: %v", err)
+	}
+	conflicts, err = service.Check(EventSlot{
+		EventID:   "public1",
+		GroupID:   "group123",
+		StartTime: time.Date(2026, 2, 1, 19, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2026, 2, 1, 23, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Error checking conflicts: %v", err)
+	}
+	assertConflicts(t, conflicts)
+
+	// A slot with no length clashes with events running at that moment
+	conflicts, err = service.CheckPerformers(EventSlot{
+		GroupID:    "group123",
+		StartTime:  time.Date(2026, 2, 1, 21, 0, 0, 0, time.UTC),
+		EndTime:    time.Date(2026, 2, 1, 21, 0, 0, 0, time.UTC),
+		Performers: []string{"user123", "walkin1"},
+		ViewerID:   "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error checking performer conflicts: %v", err)
+	}
+	assertConflicts(t, conflicts, "performer other1")
+}
+
+func TestConflicts_CheckSeriesOccurrences(t *testing.T) {
+	testDB := newConflictTestDB(t)
+	start := time.Date(2026, 3, 3, 19, 0, 0, 0, time.UTC)
+	venueID := "venue1"
+	series, err := NewEventSeriesService(testDB).Create(EventSeries{
+		GroupID:   "group456",
+		Title:     "Tuesday Jam",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=4",
+		VenueID:   &venueID,
+		CreatedBy: "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error creating series: %v", err)
+	}
+
+	// The third Tuesday is still running at 8:30
+	conflicts, err := NewConflictService(testDB).Check(EventSlot{
+		GroupID:   "group456",
+		VenueID:   &venueID,
+		StartTime: time.Date(2026, 3, 17, 20, 30, 0, 0, time.UTC),
+		EndTime:   time.Date(2026, 3, 17, 22, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Error checking conflicts: %v", err)
+	}
+	assertConflicts(t, conflicts, "venue "+series.ID+"_20260317T190000Z")
+}
+
+func TestConflicts_ForUser(t *testing.T) {
+	testDB := newConflictTestDB(t)
+	service := NewConflictService(testDB)
+
+	pairs, err := service.ForUser("user123", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error listing conflicts: %v", err)
+	}
+	if len(pairs) != 1 {
+		t.Fatalf("Expected 1 conflict, got %+v", pairs)
+	}
+	// user123 is the MC of public1 and plays in other1. late1 shares other1's time but not its
+	// group, venue or performers.
+	if pairs[0].Events[0].ID != "public1" || pairs[0].Events[1].ID != "other1" || len(pairs[0].Kinds) != 1 || pairs[0].Kinds[0] != ConflictPerformer {
+		t.Errorf("Expected a performer conflict between public1 and other1, got %+v", pairs[0])
+	}
+
+	// Outsiders see none of it
+	pairs, err = service.ForUser("outsider", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error listing conflicts: %v", err)
+	}
+	if len(pairs) != 0 {
+		t.Errorf("Expected no conflicts for an outsider, got %+v", pairs)
+	}
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"os"
+	"sort"
+	texttemplate "text/template"
+	"time"
+
+	"improv-app/internal/models"
+	"improv-app/internal/query"
+)
+
+// Digests go out on Monday mornings and cover the following week
+const (
+	digestWeekday = time.Monday
+	digestHour    = 9
+	digestWindow  = 7 * 24 * time.Hour
+	digestGames   = 5
+)
+
+// DigestService emails opted-in members a weekly summary of what's coming up across their groups
+type DigestService struct {
+	db           *sql.DB
+	emailService *EmailService
+}
+
+func NewDigestService(db *sql.DB, emailService *EmailService) *DigestService {
+	return &DigestService{
+		db:           db,
+		emailService: emailService,
+	}
+}
+
+type digestEvent struct {
+	ID        string
+	GroupName string
+	Title     string
+	Location  string
+	StartTime time.Time
+	URL       string
+}
+
+type digestGame struct {
+	Name      string
+	GroupName string
+	URL       string
+}
+
+// weeklyDigest is the data both digest templates render
+type weeklyDigest struct {
+	FirstName      string
+	WeekOf         time.Time
+	Upcoming       []digestEvent
+	AwaitingRSVP   []digestEvent
+	NewGames       []digestGame
+	UnratedGames   []digestGame
+	SettingsURL    string
+	UnsubscribeURL string
+}
+
+func (d weeklyDigest) isEmpty() bool {
+	return len(d.Upcoming) == 0 && len(d.NewGames) == 0 && len(d.UnratedGames) == 0
+}
+
+// Start checks whether digests are due in the background every interval
+func (s *DigestService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.SendDueDigests(time.Now()); err != nil {
+				log.Printf("Error sending weekly digests: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// digestWeekStart returns the date identifying this week's digest, or false before it's due.
+// Now is in the recipient's zone, so the digest arrives on their Monday morning.
+func digestWeekStart(now time.Time) (string, bool) {
+	if now.Weekday() != digestWeekday || now.Hour() < digestHour {
+		return "", false
+	}
+	return now.Format("2006-01-02"), true
+}
+
+// digestRecipients is every user who wants the digest, in the zone of the first group they
+// joined. Users who aren't in a group get it on UTC's Monday.
+const digestRecipients = `
+	WITH recipients AS (
+		SELECT u.id, u.email, COALESCE(u.first_name, '') AS first_name, COALESCE((
+			SELECT g.time_zone FROM group_members m
+			JOIN improv_groups g ON m.group_id = g.id
+			WHERE m.user_id = u.id
+			ORDER BY m.created_at, g.id
+			LIMIT 1
+		), 'UTC') AS time_zone
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.id AND np.group_id = ''
+			AND np.notification_type = $1 AND np.channel = $2
+		WHERE COALESCE(np.enabled, $3)
+	)`
+
+// SendDueDigests sends this week's digest to every opted-in user whose Monday morning has come
+// and who hasn't had it yet
+func (s *DigestService) SendDueDigests(now time.Time) error {
+	rows, err := s.db.Query(digestRecipients+`
+		SELECT DISTINCT time_zone FROM recipients
+	`, NotificationWeeklyDigest, ChannelEmail, IsEnabledByDefault(NotificationWeeklyDigest))
+	if err != nil {
+		return fmt.Errorf("error fetching digest time zones: %v", err)
+	}
+	var zones []string
+	for rows.Next() {
+		var zone string
+		if err := rows.Scan(&zone); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning digest time zone: %v", err)
+		}
+		zones = append(zones, zone)
+	}
+	rows.Close()
+
+	for _, zone := range zones {
+		local := now.In(EventLocation(zone))
+		weekStart, due := digestWeekStart(local)
+		if !due {
+			continue
+		}
+		if err := s.sendDigests(zone, weekStart, local); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDigests sends the week's digest to the recipients in the zone who haven't had it yet
+func (s *DigestService) sendDigests(zone, weekStart string, now time.Time) error {
+	rows, err := s.db.Query(digestRecipients+`
+		SELECT r.id, r.email, r.first_name
+		FROM recipients r
+		LEFT JOIN digest_log dl ON dl.user_id = r.id AND dl.week_start = $4
+		WHERE r.time_zone = $5 AND dl.user_id IS NULL
+	`, NotificationWeeklyDigest, ChannelEmail, IsEnabledByDefault(NotificationWeeklyDigest), weekStart, zone)
+	if err != nil {
+		return fmt.Errorf("error fetching digest recipients: %v", err)
+	}
+
+	type digestRecipient struct {
+		ID        string
+		Email     string
+		FirstName string
+	}
+	var recipients []digestRecipient
+	for rows.Next() {
+		var recipient digestRecipient
+		if err := rows.Scan(&recipient.ID, &recipient.Email, &recipient.FirstName); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning digest recipient: %v", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	rows.Close()
+
+	for _, recipient := range recipients {
+		// Claim the digest before sending so a restart never sends it twice
+		result, err := s.db.Exec(`
+			INSERT OR IGNORE INTO digest_log (user_id, week_start)
+			VALUES ($1, $2)
+		`, recipient.ID, weekStart)
+		if err != nil {
+			return fmt.Errorf("error recording digest: %v", err)
+		}
+		claimed, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error recording digest: %v", err)
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		if err := s.sendDigest(recipient.ID, recipient.Email, recipient.FirstName, now); err != nil {
+			log.Printf("Error sending weekly digest to user %s: %v", recipient.ID, err)
+			// Release the claim so the next run retries
+			s.db.Exec(`
+				DELETE FROM digest_log
+				WHERE user_id = $1 AND week_start = $2
+			`, recipient.ID, weekStart)
+		}
+	}
+	return nil
+}
+
+// sendDigest builds and sends one user's digest, skipping users with nothing to report
+func (s *DigestService) sendDigest(userID, email, firstName string, now time.Time) error {
+	digest, err := s.buildDigest(userID, now)
+	if err != nil {
+		return err
+	}
+	if digest.isEmpty() {
+		log.Printf("Skipping empty weekly digest for user %s", userID)
+		return nil
+	}
+	digest.FirstName = firstName
+	digest.UnsubscribeURL = UnsubscribeURL(UnsubscribeClaims{
+		UserID:           userID,
+		NotificationType: NotificationWeeklyDigest,
+		Channel:          ChannelEmail,
+	})
+
+	subject, textBody, htmlBody, err := renderDigest(digest)
+	if err != nil {
+		return err
+	}
+	if err := s.emailService.sendHTMLEmail(email, subject, textBody, htmlBody, digest.UnsubscribeURL); err != nil {
+		return err
+	}
+	log.Printf("Sent weekly digest to user %s", userID)
+	return nil
+}
+
+// buildDigest gathers everything in a user's digest for the week starting at now
+func (s *DigestService) buildDigest(userID string, now time.Time) (weeklyDigest, error) {
+	frontendURL := os.Getenv("FRONTEND_URL")
+	digest := weeklyDigest{
+		WeekOf:      now,
+		SettingsURL: frontendURL + "/profile",
+	}
+
+	// Upcoming events use the same visibility rules as the events list,
+	// limited to groups the user belongs to or follows
+	rows, err := s.db.Query(`
+		SELECT DISTINCT e.id, g.name, e.title, COALESCE(e.location, ''), e.start_time, e.time_zone,
+		       m.user_id IS NOT NULL AS is_member, COALESCE(r.status, '') AS rsvp_status
+	`+query.VisibleEventsFrom+`
+		LEFT JOIN event_rsvps r ON r.event_id = e.id AND r.user_id = $1
+		WHERE `+query.VisibleEventsCondition+`
+		  AND e.status = 'scheduled' AND `+query.NotCancelledOccurrenceCondition+`
+		  AND (m.user_id IS NOT NULL OR f.user_id IS NOT NULL)
+		  AND julianday(e.start_time) > julianday($2)
+		  AND julianday(e.start_time) <= julianday($3)
+		ORDER BY e.start_time
+	`, userID, now.UTC(), now.Add(digestWindow).UTC())
+	if err != nil {
+		return digest, fmt.Errorf("error fetching digest events: %v", err)
+	}
+	for rows.Next() {
+		var event digestEvent
+		var isMember bool
+		var rsvpStatus, timeZone string
+		if err := rows.Scan(&event.ID, &event.GroupName, &event.Title, &event.Location, &event.StartTime, &timeZone, &isMember, &rsvpStatus); err != nil {
+			rows.Close()
+			return digest, fmt.Errorf("error scanning digest event: %v", err)
+		}
+		// The digest shows each event's time where it is
+		event.StartTime = event.StartTime.In(EventLocation(timeZone))
+		event.URL = fmt.Sprintf("%s/events/%s", frontendURL, event.ID)
+		digest.Upcoming = append(digest.Upcoming, event)
+		if isMember && (rsvpStatus == "" || rsvpStatus == "awaiting-response") {
+			digest.AwaitingRSVP = append(digest.AwaitingRSVP, event)
+		}
+	}
+	rows.Close()
+
+	// Add the occurrences of the same groups' series that haven't been saved as events
+	seriesService := NewEventSeriesService(s.db)
+	seriesList, err := seriesService.list(`
+		SELECT DISTINCT `+eventSeriesColumns+`, g.name
+	`+query.VisibleSeriesFrom+`
+		WHERE `+query.VisibleSeriesCondition+`
+		  AND (m.user_id IS NOT NULL OR f.user_id IS NOT NULL)
+	`, userID)
+	if err != nil {
+		return digest, err
+	}
+	memberOf := map[string]bool{}
+	rows, err = s.db.Query(`SELECT group_id FROM group_members WHERE user_id = $1`, userID)
+	if err != nil {
+		return digest, fmt.Errorf("error fetching digest groups: %v", err)
+	}
+	for rows.Next() {
+		var groupID string
+		if err := rows.Scan(&groupID); err != nil {
+			rows.Close()
+			return digest, fmt.Errorf("error scanning digest group: %v", err)
+		}
+		memberOf[groupID] = true
+	}
+	rows.Close()
+	for _, series := range seriesList {
+		occurrences, err := seriesService.Occurrences(series, now, now.Add(digestWindow))
+		if err != nil {
+			return digest, err
+		}
+		for _, occurrence := range occurrences {
+			event := digestEvent{
+				ID:        occurrence.ID,
+				GroupName: occurrence.GroupName,
+				Title:     occurrence.Title,
+				Location:  occurrence.Location,
+				StartTime: occurrence.StartTime,
+				URL:       fmt.Sprintf("%s/events/%s", frontendURL, occurrence.ID),
+			}
+			digest.Upcoming = append(digest.Upcoming, event)
+			// Nobody has answered an occurrence that hasn't been saved
+			if memberOf[occurrence.GroupID] {
+				digest.AwaitingRSVP = append(digest.AwaitingRSVP, event)
+			}
+		}
+	}
+	sort.SliceStable(digest.Upcoming, func(i, j int) bool { return digest.Upcoming[i].StartTime.Before(digest.Upcoming[j].StartTime) })
+	sort.SliceStable(digest.AwaitingRSVP, func(i, j int) bool { return digest.AwaitingRSVP[i].StartTime.Before(digest.AwaitingRSVP[j].StartTime) })
+
+	// Games added to the user's group libraries in the last week
+	rows, err = s.db.Query(`
+		SELECT g.id, g.name, grp.name
+		FROM group_game_libraries ggl
+		JOIN games g ON ggl.game_id = g.id
+		JOIN improv_groups grp ON ggl.group_id = grp.id
+		JOIN group_members gm ON ggl.group_id = gm.group_id AND gm.user_id = $1
+		WHERE julianday(ggl.added_at) > julianday($2)
+		ORDER BY ggl.added_at DESC
+		LIMIT $3
+	`, userID, now.Add(-digestWindow).UTC(), digestGames)
+	if err != nil {
+		return digest, fmt.Errorf("error fetching new digest games: %v", err)
+	}
+	for rows.Next() {
+		var gameID string
+		var game digestGame
+		if err := rows.Scan(&gameID, &game.Name, &game.GroupName); err != nil {
+			rows.Close()
+			return digest, fmt.Errorf("error scanning new digest game: %v", err)
+		}
+		game.URL = fmt.Sprintf("%s/games/%s", frontendURL, gameID)
+		digest.NewGames = append(digest.NewGames, game)
+	}
+	rows.Close()
+
+	// Games the user hasn't rated yet, the same list the games page prompts for
+	unrated := query.BuildUnratedGamesQuery(userID, "", digestGames)
+	rows, err = s.db.Query(unrated.Query, unrated.Params...)
+	if err != nil {
+		return digest, fmt.Errorf("error fetching unrated digest games: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var game models.Game
+		var tags sql.NullString
+		var eventCount int
+		err := rows.Scan(&game.ID, &game.Name, &game.Description, &game.MinPlayers, &game.MaxPlayers, &game.CreatedAt, &game.CreatedBy, &game.GroupID, &game.Public, &tags, &eventCount)
+		if err != nil {
+			return digest, fmt.Errorf("error scanning unrated digest game: %v", err)
+		}
+		digest.UnratedGames = append(digest.UnratedGames, digestGame{
+			Name: game.Name,
+			URL:  fmt.Sprintf("%s/games/%s", frontendURL, game.ID),
+		})
+	}
+
+	return digest, nil
+}
+
+var digestTextTemplate = texttemplate.Must(texttemplate.New("digest.txt").Funcs(texttemplate.FuncMap{
+	"when": formatDigestTime,
+}).Parse(`Hi{{if .FirstName}} {{.FirstName}}{{end}},
+
+Here's your week in improv.
+{{if .Upcoming}}
+Coming up this week:
+{{range .Upcoming}}  - {{.Title}} ({{.GroupName}}), {{when .StartTime}}{{if .Location}} at {{.Location}}{{end}}
+    {{.URL}}
+{{end}}{{end}}{{if .AwaitingRSVP}}
+Waiting on your RSVP:
+{{range .AwaitingRSVP}}  - {{.Title}}, {{when .StartTime}}
+    {{.URL}}
+{{end}}{{end}}{{if .NewGames}}
+New in your groups' libraries:
+{{range .NewGames}}  - {{.Name}} ({{.GroupName}})
+    {{.URL}}
+{{end}}{{end}}{{if .UnratedGames}}
+Games you haven't rated yet:
+{{range .UnratedGames}}  - {{.Name}}
+    {{.URL}}
+{{end}}{{end}}
+You're getting this because you turned on the weekly digest. Manage your emails at {{.SettingsURL}}
+`))
+
+var digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(htmltemplate.FuncMap{
+	"when": formatDigestTime,
+}).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222;">
+<p>Hi{{if .FirstName}} {{.FirstName}}{{end}},</p>
+<p>Here's your week in improv.</p>
+{{if .Upcoming}}
+<h3>Coming up this week</h3>
+<ul>
+{{range .Upcoming}}<li><a href="{{.URL}}">{{.Title}}</a> ({{.GroupName}}), {{when .StartTime}}{{if .Location}} at {{.Location}}{{end}}</li>
+{{end}}</ul>
+{{end}}{{if .AwaitingRSVP}}
+<h3>Waiting on your RSVP</h3>
+<ul>
+{{range .AwaitingRSVP}}<li><a href="{{.URL}}">{{.Title}}</a>, {{when .StartTime}}</li>
+{{end}}</ul>
+{{end}}{{if .NewGames}}
+<h3>New in your groups' libraries</h3>
+<ul>
+{{range .NewGames}}<li><a href="{{.URL}}">{{.Name}}</a> ({{.GroupName}})</li>
+{{end}}</ul>
+{{end}}{{if .UnratedGames}}
+<h3>Games you haven't rated yet</h3>
+<ul>
+{{range .UnratedGames}}<li><a href="{{.URL}}">{{.Name}}</a></li>
+{{end}}</ul>
+{{end}}
+<p style="font-size: 12px; color: #777;">
+You're getting this because you turned on the weekly digest.
+<a href="{{.SettingsURL}}">Manage your emails</a> or <a href="{{.UnsubscribeURL}}">unsubscribe</a>.
+</p>
+</body>
+</html>
+`))
+
+func formatDigestTime(t time.Time) string {
+	return t.Format("Mon Jan 2, 3:04 PM")
+}
+
+// renderDigest renders the digest subject and its text and HTML bodies
+func renderDigest(digest weeklyDigest) (string, string, string, error) {
+	subject := fmt.Sprintf("Your improv week of %s", digest.WeekOf.Format("January 2"))
+
+	var text, html bytes.Buffer
+	if err := digestTextTemplate.Execute(&text, digest); err != nil {
+		return "", "", "", fmt.Errorf("error rendering digest text: %v", err)
+	}
+	if err := digestHTMLTemplate.Execute(&html, digest); err != nil {
+		return "", "", "", fmt.Errorf("error rendering digest html: %v", err)
+	}
+	return subject, text.String(), html.String(), nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestWeekStart(t *testing.T) {
+	monday := time.Date(2025, time.June, 2, 9, 30, 0, 0, time.UTC)
+
+	weekStart, due := digestWeekStart(monday)
+	if !due || weekStart != "2025-06-02" {
+		t.Errorf("Expected digest due for 2025-06-02, got %q (due=%v)", weekStart, due)
+	}
+
+	if _, due := digestWeekStart(monday.Add(-time.Hour)); due {
+		t.Errorf("Expected digest not due before %d:00", digestHour)
+	}
+
+	if _, due := digestWeekStart(monday.Add(24 * time.Hour)); due {
+		t.Errorf("Expected digest not due on Tuesday")
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	start := time.Date(2025, time.June, 6, 19, 0, 0, 0, time.UTC)
+	event := digestEvent{
+		ID:        "event123",
+		GroupName: "Harold Night",
+		Title:     "Friday <Jam>",
+		Location:  "The Annex",
+		StartTime: start,
+		URL:       "http://localhost/events/event123",
+	}
+	digest := weeklyDigest{
+		FirstName:      "Sam",
+		WeekOf:         time.Date(2025, time.June, 2, 9, 0, 0, 0, time.UTC),
+		Upcoming:       []digestEvent{event},
+		AwaitingRSVP:   []digestEvent{event},
+		UnratedGames:   []digestGame{{Name: "Zip Zap Zop", URL: "http://localhost/games/game123"}},
+		SettingsURL:    "http://localhost/profile",
+		UnsubscribeURL: "http://localhost/api/unsubscribe?token=abc",
+	}
+
+	subject, text, html, err := renderDigest(digest)
+	if err != nil {
+		t.Fatalf("Expected digest to render, got error: %v", err)
+	}
+
+	if subject != "Your improv week of June 2" {
+		t.Errorf("Unexpected subject: %s", subject)
+	}
+
+	for _, expected := range []string{"Hi Sam,", "Friday <Jam> (Harold Night), Fri Jun 6, 7:00 PM at The Annex", "Waiting on your RSVP:", "Zip Zap Zop"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected text body to contain %q, got: %s", expected, text)
+		}
+	}
+
+	if strings.Contains(text, "New in your groups' libraries") {
+		t.Errorf("Expected empty sections to be left out, got: %s", text)
+	}
+
+	// HTML output must escape event titles
+	if !strings.Contains(html, "Friday &lt;Jam&gt;") {
+		t.Errorf("Expected escaped title in HTML body, got: %s", html)
+	}
+
+	if !strings.Contains(html, `href="http://localhost/api/unsubscribe?token=abc"`) {
+		t.Errorf("Expected unsubscribe link in HTML body, got: %s", html)
+	}
+}
+
+func TestDigest_DueOnEachMembersMonday(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedOutsider)
+	seed(t, testDB,
+		`UPDATE improv_groups SET time_zone = 'America/Los_Angeles' WHERE id = 'group123'`,
+		`INSERT INTO users (id, email, first_name, last_name) VALUES ('kiri', 'kiri@example.com', 'Kiri', 'Kiwi')`,
+		`INSERT INTO improv_groups (id, name, description, created_by, time_zone) VALUES ('group456', 'Auckland Improv', '', 'kiri', 'Pacific/Auckland')`,
+		`INSERT INTO group_members (group_id, user_id, role) VALUES ('group456', 'kiri', 'admin')`,
+	)
+	preferences := NewNotificationPreferenceService(testDB)
+	for _, userID := range []string{"user123", "kiri"} {
+		if err := preferences.Set(userID, "", NotificationWeeklyDigest, ChannelEmail, true); err != nil {
+			t.Fatalf("Error opting in: %v", err)
+		}
+	}
+	service := NewDigestService(testDB, NewEmailService(testDB))
+
+	sent := func() []string {
+		t.Helper()
+		rows, err := testDB.Query(`SELECT user_id || ' ' || week_start FROM digest_log ORDER BY user_id`)
+		if err != nil {
+			t.Fatalf("Error fetching digest log: %v", err)
+		}
+		defer rows.Close()
+		logged := []string{}
+		for rows.Next() {
+			var entry string
+			rows.Scan(&entry)
+			logged = append(logged, entry)
+		}
+		return logged
+	}
+
+	// Nobody has anything coming up, so the digests are logged without being emailed
+	steps := []struct {
+		now  time.Time
+		want []string
+	}{
+		// 10 AM Monday in Auckland, still Sunday in Los Angeles
+		{time.Date(2025, time.June, 1, 22, 0, 0, 0, time.UTC), []string{"kiri 2025-06-02"}},
+		// 10 AM Monday in Los Angeles, already Tuesday in Auckland
+		{time.Date(2025, time.June, 2, 17, 0, 0, 0, time.UTC), []string{"kiri 2025-06-02", "user123 2025-06-02"}},
+		// Later ticks that Monday send nothing more
+		{time.Date(2025, time.June, 2, 20, 0, 0, 0, time.UTC), []string{"kiri 2025-06-02", "user123 2025-06-02"}},
+	}
+	for _, step := range steps {
+		if err := service.SendDueDigests(step.now); err != nil {
+			t.Fatalf("Error sending digests at %s: %v", step.now, err)
+		}
+		if got := sent(); strings.Join(got, ", ") != strings.Join(step.want, ", ") {
+			t.Errorf("At %s: expected %v, got %v", step.now, step.want, got)
+		}
+	}
+}
+
+func TestBuildDigest_SeriesOccurrences(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	start := time.Date(2026, 3, 3, 19, 0, 0, 0, time.UTC)
+	seriesService := NewEventSeriesService(testDB)
+	series, err := seriesService.Create(EventSeries{
+		GroupID:   "group123",
+		Title:     "Tuesday Jam",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=4",
+		CreatedBy: "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error creating series: %v", err)
+	}
+	if err := seriesService.Skip(series.ID, start.AddDate(0, 0, 14)); err != nil {
+		t.Fatalf("Error skipping occurrence: %v", err)
+	}
+
+	// The week after the first jam has the second one; the third was skipped
+	service := NewDigestService(testDB, NewEmailService(testDB))
+	digest, err := service.buildDigest("user123", start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Error building digest: %v", err)
+	}
+	if len(digest.Upcoming) != 1 || !digest.Upcoming[0].StartTime.Equal(start.AddDate(0, 0, 7)) {
+		t.Fatalf("Expected the second jam coming up, got %v", digest.Upcoming)
+	}
+	if len(digest.AwaitingRSVP) != 1 {
+		t.Errorf("Expected the member asked to RSVP to the jam, got %v", digest.AwaitingRSVP)
+	}
+
+	digest, err = service.buildDigest("user123", start.AddDate(0, 0, 8))
+	if err != nil {
+		t.Fatalf("Error building digest: %v", err)
+	}
+	if len(digest.Upcoming) != 0 {
+		t.Errorf("Expected the skipped jam left out, got %v", digest.Upcoming)
+	}
+}
@@ -1,12 +1,13 @@
 package services
 
 import (
-	"crypto/rand"
+	"bytes"
 	"database/sql"
-	"encoding/base64"
 	"fmt"
 	"log"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"os"
 	"time"
 
@@ -23,16 +24,89 @@ func NewEmailService(db *sql.DB) *EmailService {
 	return &EmailService{db: db}
 }
 
-func (s *EmailService) generateToken() (string, error) {
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+// sendEmail delivers a plain text email through the configured SMTP server.
+// Notification emails pass an unsubscribe URL, which is added to the body and
+// advertised through the List-Unsubscribe headers for one-click unsubscribing.
+func (s *EmailService) sendEmail(email, subject, body, unsubscribeURL string) error {
+	return s.sendMessage(email, subject, body, "", unsubscribeURL)
+}
+
+// sendHTMLEmail delivers a multipart email with plain text and HTML versions of the body.
+// The HTML body is expected to render its own unsubscribe link.
+func (s *EmailService) sendHTMLEmail(email, subject, textBody, htmlBody, unsubscribeURL string) error {
+	return s.sendMessage(email, subject, textBody, htmlBody, unsubscribeURL)
+}
+
+// sendMessage builds the email and hands it to the SMTP server. Every email goes through it.
+func (s *EmailService) sendMessage(email, subject, textBody, htmlBody, unsubscribeURL string) error {
+	from := os.Getenv("SMTP_FROM")
+	fromName := os.Getenv("SMTP_FROM_NAME")
+	if fromName == "" {
+		fromName = "Improv App"
+	}
+
+	to := os.Getenv("SMTP_TO")
+	if to == "" {
+		to = email
+	}
+
+	headers := ""
+	if unsubscribeURL != "" {
+		headers = fmt.Sprintf("List-Unsubscribe: <%s>\r\n"+
+			"List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n", unsubscribeURL)
+		textBody += fmt.Sprintf("\n--\nUnsubscribe from these emails: %s\n", unsubscribeURL)
+	}
+
+	contentType := "text/plain; charset=UTF-8"
+	body := textBody
+	if htmlBody != "" {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		for _, part := range []struct{ contentType, content string }{
+			{"text/plain; charset=UTF-8", textBody},
+			{"text/html; charset=UTF-8", htmlBody},
+		} {
+			w, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+			if err != nil {
+				return fmt.Errorf("failed to build email: %v", err)
+			}
+			w.Write([]byte(part.content))
+		}
+		writer.Close()
+		contentType = "multipart/alternative; boundary=" + writer.Boundary()
+		body = buf.String()
+	}
+
+	msg := []byte(fmt.Sprintf("From: %s <%s>\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"%s"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: %s\r\n"+
+		"\r\n"+
+		"%s", fromName, from, to, subject, headers, contentType, body))
+
+	host := os.Getenv("SMTP_HOST")
+	port := os.Getenv("SMTP_PORT")
+	username := os.Getenv("SMTP_USERNAME")
+	password := os.Getenv("SMTP_PASSWORD")
+
+	addr := fmt.Sprintf("%s:%s", host, port)
+	var auth smtp.Auth
+	if username != "" && password != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	if err := smtp.SendMail(addr, auth, from, []string{to}, msg); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
+	return nil
 }
 
+// SendMagicLink emails a sign-in link. Sign-in emails are transactional, so they
+// skip notification preferences and carry no unsubscribe link.
 func (s *EmailService) SendMagicLink(email string) error {
-	token, err := s.generateToken()
+	token, err := generateToken()
 	if err != nil {
 		return err
 	}
@@ -61,17 +135,6 @@ func (s *EmailService) SendMagicLink(email string) error {
 	}
 
 	// Send email with magic link
-	from := os.Getenv("SMTP_FROM")
-	fromName := os.Getenv("SMTP_FROM_NAME")
-	if fromName == "" {
-		fromName = "Improv App"
-	}
-
-	to := os.Getenv("SMTP_TO")
-	if to == "" {
-		to = email
-	}
-
 	subject := "Your Magic Link for Improv App"
 
 	baseURL := os.Getenv("BASE_URL")
@@ -92,32 +155,10 @@ MAGIC_LINK: %s
 This link will expire in 24 hours.
 	`, magicLink)
 
-	// Set up email message
-	msg := []byte(fmt.Sprintf("From: %s <%s>\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"MIME-Version: 1.0\r\n"+
-		"Content-Type: text/plain; charset=UTF-8\r\n"+
-		"\r\n"+
-		"%s", fromName, from, to, subject, body))
-
-	// Connect to SMTP server
-	host := os.Getenv("SMTP_HOST")
-	port := os.Getenv("SMTP_PORT")
-	username := os.Getenv("SMTP_USERNAME")
-	password := os.Getenv("SMTP_PASSWORD")
-	fmt.Println("Sending email to:", to, "from:", from, "host:", host, "port:", port, "username:", username, "password:", password)
-
-	addr := fmt.Sprintf("%s:%s", host, port)
-	var auth smtp.Auth
-	if username != "" && password != "" {
-		auth = smtp.PlainAuth("", username, password, host)
-	}
-
-	err = smtp.SendMail(addr, auth, from, []string{to}, msg)
+	err = s.sendEmail(email, subject, body, "")
 	if err != nil {
 		log.Printf("Error sending email: %v", err)
-		return fmt.Errorf("failed to send email: %v", err)
+		return err
 	}
 
 	log.Printf("Magic link email sent to %s", email)
@@ -180,18 +221,35 @@ func (s *EmailService) SendGroupInvitation(email, groupID, groupName, inviterNam
 		return "", err
 	}
 
-	// Send email with invitation link
-	from := os.Getenv("SMTP_FROM")
+	// Respect the invitee's preferences when they already have an account
+	var unsubscribeURL string
+	var inviteeID string
+	err = s.db.QueryRow(`SELECT id FROM users WHERE email = $1`, email).Scan(&inviteeID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if inviteeID != "" {
+		enabled, err := NewNotificationPreferenceService(s.db).IsEnabled(inviteeID, groupID, NotificationInvitation, ChannelEmail)
+		if err != nil {
+			return "", err
+		}
+		if !enabled {
+			log.Printf("User %s has invitation emails turned off, skipping email for invitation %s", inviteeID, invitationID)
+			return invitationID, nil
+		}
+		unsubscribeURL = UnsubscribeURL(UnsubscribeClaims{
+			UserID:           inviteeID,
+			GroupID:          groupID,
+			NotificationType: NotificationInvitation,
+			Channel:          ChannelEmail,
+		})
+	}
+
 	fromName := os.Getenv("SMTP_FROM_NAME")
 	if fromName == "" {
 		fromName = "Improv App"
 	}
 
-	to := os.Getenv("SMTP_TO")
-	if to == "" {
-		to = email
-	}
-
 	subject := fmt.Sprintf("Invitation to join %s on Improv App", groupName)
 
 	baseURL := os.Getenv("FRONTEND_URL")
@@ -212,29 +270,8 @@ func (s *EmailService) SendGroupInvitation(email, groupID, groupName, inviterNam
 		%s
 	`, inviterName, groupName, role, baseURL, fromName)
 
-	// Set up email message
-	msg := []byte(fmt.Sprintf("From: %s <%s>\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"MIME-Version: 1.0\r\n"+
-		"Content-Type: text/plain; charset=UTF-8\r\n"+
-		"\r\n"+
-		"%s", fromName, from, to, subject, body))
-
-	// Connect to SMTP server
-	host := os.Getenv("SMTP_HOST")
-	port := os.Getenv("SMTP_PORT")
-	username := os.Getenv("SMTP_USERNAME")
-	password := os.Getenv("SMTP_PASSWORD")
-	log.Printf("Sending group invitation email to: %s for group: %s", to, groupName)
-
-	addr := fmt.Sprintf("%s:%s", host, port)
-	var auth smtp.Auth
-	if username != "" && password != "" {
-		auth = smtp.PlainAuth("", username, password, host)
-	}
-
-	err = smtp.SendMail(addr, auth, from, []string{to}, msg)
+	log.Printf("Sending group invitation email to: %s for group: %s", email, groupName)
+	err = s.sendEmail(email, subject, body, unsubscribeURL)
 	if err != nil {
 		log.Printf("Error sending invitation email: %v", err)
 		return "", fmt.Errorf("failed to send invitation email: %v", err)
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CloneOptions says when the copy takes place and what it brings along
+type CloneOptions struct {
+	StartTime time.Time
+	// EndTime defaults to keeping the original event's length
+	EndTime time.Time
+	// IncludeMC keeps the original MC, if they're still a member
+	IncludeMC bool
+	// IncludeCrew keeps the rest of the crew in their roles, for members who are still in the group
+	IncludeCrew bool
+	// IncludeAssignments keeps the cast of each game, for players who are still members
+	IncludeAssignments bool
+	CreatedBy          string
+}
+
+// EventCloneService copies an event's format to a new date
+type EventCloneService struct {
+	db *sql.DB
+}
+
+func NewEventCloneService(db *sql.DB) *EventCloneService {
+	return &EventCloneService{db: db}
+}
+
+// Clone copies an event, its game lineup and its run-of-show plan to a new time and returns the new event's ID.
+// The copy is scheduled, stands on its own outside any series and starts without RSVPs or walk-ins.
+func (s *EventCloneService) Clone(eventID string, options CloneOptions) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var groupID string
+	var startTime, endTime time.Time
+	err = tx.QueryRow(`
+		SELECT group_id, start_time, end_time FROM events WHERE id = $1
+	`, eventID).Scan(&groupID, &startTime, &endTime)
+	if err == sql.ErrNoRows {
+		return "", ErrEventNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching event to clone: %v", err)
+	}
+	if options.EndTime.IsZero() {
+		options.EndTime = options.StartTime.Add(endTime.Sub(startTime))
+	}
+
+	cloneID := uuid.New().String()
+	_, err = tx.Exec(`
+		INSERT INTO events (id, group_id, title, description, location, start_time, end_time, created_by, mc_id, capacity, visibility, venue_id, time_zone)
+		SELECT $1, e.group_id, e.title, e.description, e.location, $2, $3, $4, CASE WHEN $5 THEN m.user_id END, e.capacity, e.visibility, e.venue_id, e.time_zone
+		FROM events e
+		LEFT JOIN group_members m ON m.group_id = e.group_id AND m.user_id = e.mc_id
+		WHERE e.id = $6
+	`, cloneID, options.StartTime.UTC(), options.EndTime.UTC(), options.CreatedBy, options.IncludeMC, eventID)
+	if err != nil {
+		return "", fmt.Errorf("error copying event: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO event_games (event_id, game_id, order_index, target_players, planned_minutes, segment_type)
+		SELECT $1, game_id, order_index, target_players, planned_minutes, segment_type FROM event_games WHERE event_id = $2
+	`, cloneID, eventID)
+	if err != nil {
+		return "", fmt.Errorf("error copying event games: %v", err)
+	}
+
+	// The run-of-show plan comes along, without the original's timings
+	rows, err := tx.Query(`SELECT id FROM event_segments WHERE event_id = $1`, eventID)
+	if err != nil {
+		return "", fmt.Errorf("error fetching run-of-show segments: %v", err)
+	}
+	var segmentIDs []string
+	for rows.Next() {
+		var segmentID string
+		if err := rows.Scan(&segmentID); err != nil {
+			rows.Close()
+			return "", fmt.Errorf("error scanning run-of-show segment: %v", err)
+		}
+		segmentIDs = append(segmentIDs, segmentID)
+	}
+	rows.Close()
+	for _, segmentID := range segmentIDs {
+		_, err = tx.Exec(`
+			INSERT INTO event_segments (id, event_id, segment_type, title, planned_minutes, before_game, position)
+			SELECT $1, $2, segment_type, title, planned_minutes, before_game, position
+			FROM event_segments WHERE id = $3
+		`, uuid.New().String(), cloneID, segmentID)
+		if err != nil {
+			return "", fmt.Errorf("error copying run-of-show segment: %v", err)
+		}
+	}
+
+	if options.IncludeCrew {
+		_, err = tx.Exec(`
+			INSERT OR IGNORE INTO event_crew (event_id, user_id, role_id, assigned_by)
+			SELECT $1, c.user_id, c.role_id, $2
+			FROM event_crew c
+			JOIN event_roles r ON c.role_id = r.id
+			JOIN group_members m ON m.group_id = $3 AND m.user_id = c.user_id
+			WHERE c.event_id = $4 AND r.role_key IS NOT 'mc'
+		`, cloneID, options.CreatedBy, groupID, eventID)
+		if err != nil {
+			return "", fmt.Errorf("error copying event crew: %v", err)
+		}
+	}
+
+	if options.IncludeAssignments {
+		_, err = tx.Exec(`
+			INSERT INTO event_player_assignments (event_id, game_id, user_id)
+			SELECT $1, a.game_id, a.user_id
+			FROM event_player_assignments a
+			JOIN group_members m ON m.group_id = $2 AND m.user_id = a.user_id
+			WHERE a.event_id = $3
+		`, cloneID, groupID, eventID)
+		if err != nil {
+			return "", fmt.Errorf("error copying player assignments: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("error cloning event: %v", err)
+	}
+	return cloneID, nil
+}
+
+// CloneOccurrence copies an occurrence that hasn't been saved as an event to a new time, the way
+// Clone copies an event. The occurrence has no lineup, plan or crew of its own, so only its details come along.
+func (s *EventCloneService) CloneOccurrence(occurrence Occurrence, options CloneOptions) (string, error) {
+	if options.EndTime.IsZero() {
+		options.EndTime = options.StartTime.Add(occurrence.EndTime.Sub(occurrence.StartTime))
+	}
+
+	var mcID *string
+	if options.IncludeMC && occurrence.MCID != nil {
+		var isMember bool
+		err := s.db.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2)
+		`, occurrence.GroupID, *occurrence.MCID).Scan(&isMember)
+		if err != nil {
+			return "", fmt.Errorf("error checking MC membership: %v", err)
+		}
+		if isMember {
+			mcID = occurrence.MCID
+		}
+	}
+
+	cloneID := uuid.New().String()
+	_, err := s.db.Exec(`
+		INSERT INTO events (id, group_id, title, description, location, start_time, end_time, created_by, mc_id, capacity, visibility, venue_id, time_zone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, cloneID, occurrence.GroupID, occurrence.Title, occurrence.Description, occurrence.Location, options.StartTime.UTC(), options.EndTime.UTC(),
+		options.CreatedBy, mcID, occurrence.Capacity, occurrence.Visibility, occurrence.VenueID, occurrence.TimeZone)
+	if err != nil {
+		return "", fmt.Errorf("error copying occurrence: %v", err)
+	}
+	return cloneID, nil
+}
+
+// Performers returns the members who would work the clone: the MC, crew and cast the options keep
+func (s *EventCloneService) Performers(eventID string, options CloneOptions) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT c.user_id FROM event_crew c
+		JOIN event_roles r ON c.role_id = r.id
+		WHERE c.event_id = $1 AND CASE WHEN r.role_key = 'mc' THEN $2 ELSE $3 END
+		UNION
+		SELECT user_id FROM event_player_assignments WHERE event_id = $1 AND $4
+	`, eventID, options.IncludeMC, options.IncludeCrew, options.IncludeAssignments)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event performers: %v", err)
+	}
+	defer rows.Close()
+
+	var performers []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error scanning event performer: %v", err)
+		}
+		performers = append(performers, userID)
+	}
+	return performers, nil
+}
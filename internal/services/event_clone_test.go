@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func newCloneTestService(t *testing.T) *EventCloneService {
+	t.Helper()
+	testDB := newTestDB(t, seedGroup, seedPublicShow)
+	_, err := testDB.Exec(`
+		INSERT INTO users (id, email) VALUES ('former', 'former@example.com');
+		INSERT INTO games (id, name, min_players, max_players, created_by, group_id)
+		VALUES ('game1', 'Freeze Tag', 2, 6, 'user123', 'group123'), ('game2', 'Party Quirks', 4, 4, 'user123', 'group123');
+		INSERT INTO event_games (event_id, game_id, order_index) VALUES ('public1', 'game1', 1), ('public1', 'game2', 0);
+		INSERT INTO event_player_assignments (event_id, game_id, user_id)
+		VALUES ('public1', 'game1', 'user123'), ('public1', 'game2', 'former');
+		UPDATE events SET capacity = 20, status = 'completed', lineup_finalized_at = CURRENT_TIMESTAMP WHERE id = 'public1';
+	`)
+	if err != nil {
+		t.Fatalf("Error seeding lineup: %v", err)
+	}
+	return NewEventCloneService(testDB)
+}
+
+func TestEventClone_CopiesLineup(t *testing.T) {
+	service := newCloneTestService(t)
+	start := time.Date(2026, 3, 1, 19, 0, 0, 0, time.UTC)
+
+	cloneID, err := service.Clone("public1", CloneOptions{StartTime: start, IncludeMC: true, IncludeAssignments: true, CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error cloning event: %v", err)
+	}
+
+	var title, status, visibility string
+	var mcID *string
+	var capacity *int
+	var startTime, endTime time.Time
+	var finalized bool
+	err = service.db.QueryRow(`
+		SELECT title, status, visibility, mc_id, capacity, start_time, end_time, lineup_finalized_at IS NOT NULL
+		FROM events WHERE id = $1
+	`, cloneID).Scan(&title, &status, &visibility, &mcID, &capacity, &startTime, &endTime, &finalized)
+	if err != nil {
+		t.Fatalf("Error fetching clone: %v", err)
+	}
+	if title != "Public Show" || status != EventStatusScheduled || visibility != VisibilityPublic || mcID == nil || *mcID != "user123" || capacity == nil || *capacity != 20 || finalized {
+		t.Errorf("Expected a scheduled copy with the same details and MC, got %s %s %s %v %v %v", title, status, visibility, mcID, capacity, finalized)
+	}
+	if !startTime.Equal(start) || endTime.Sub(startTime) != 2*time.Hour {
+		t.Errorf("Expected the copy to keep the original length, got %v to %v", startTime, endTime)
+	}
+
+	var games string
+	err = service.db.QueryRow(`
+		SELECT GROUP_CONCAT(game_id) FROM (SELECT game_id FROM event_games WHERE event_id = $1 ORDER BY order_index)
+	`, cloneID).Scan(&games)
+	if err != nil || games != "game2,game1" {
+		t.Errorf("Expected the games in their original order, got %s (error: %v)", games, err)
+	}
+
+	var assignments, rsvps int
+	service.db.QueryRow(`SELECT COUNT(*) FROM event_player_assignments WHERE event_id = $1`, cloneID).Scan(&assignments)
+	service.db.QueryRow(`SELECT COUNT(*) FROM event_rsvps WHERE event_id = $1`, cloneID).Scan(&rsvps)
+	if assignments != 1 || rsvps != 0 {
+		t.Errorf("Expected only current members' assignments and no RSVPs, got %d assignments and %d RSVPs", assignments, rsvps)
+	}
+}
+
+func TestEventClone_WithoutMCOrCast(t *testing.T) {
+	service := newCloneTestService(t)
+	start := time.Date(2026, 3, 1, 19, 0, 0, 0, time.UTC)
+
+	cloneID, err := service.Clone("public1", CloneOptions{StartTime: start, EndTime: start.Add(time.Hour), CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error cloning event: %v", err)
+	}
+
+	var mcID *string
+	var endTime time.Time
+	var assignments int
+	if err := service.db.QueryRow(`SELECT mc_id, end_time FROM events WHERE id = $1`, cloneID).Scan(&mcID, &endTime); err != nil {
+		t.Fatalf("Error fetching clone: %v", err)
+	}
+	service.db.QueryRow(`SELECT COUNT(*) FROM event_player_assignments WHERE event_id = $1`, cloneID).Scan(&assignments)
+	if mcID != nil || assignments != 0 || !endTime.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected no MC or cast and the given end time, got %v, %d assignments, ending %v", mcID, assignments, endTime)
+	}
+
+	if _, err := service.Clone("missing", CloneOptions{StartTime: start}); err != ErrEventNotFound {
+		t.Errorf("Expected ErrEventNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permissions an event role can grant its crew at the events they're assigned to
+const (
+	// PermissionEditLineup lets the crew member add and order games, cast players and finalize the lineup
+	PermissionEditLineup = "edit_lineup"
+	// PermissionViewLineup lets the crew member see the cast and players' game preferences
+	PermissionViewLineup = "view_lineup"
+	// PermissionTakeAttendance lets the crew member check people in
+	PermissionTakeAttendance = "take_attendance"
+	// PermissionRunShow lets the crew member start and advance the run-of-show live
+	PermissionRunShow = "run_show"
+)
+
+// EventRoleMC is the key of the MC role, which events.mc_id is kept in step with
+const EventRoleMC = "mc"
+
+var (
+	// ErrInvalidEventRole means a role is missing its name, grants an unknown permission or can't be changed that way
+	ErrInvalidEventRole = errors.New("invalid event role")
+	// ErrEventRoleNotFound means the role isn't one of the event's group's roles
+	ErrEventRoleNotFound = errors.New("event role not found")
+	// ErrNotGroupMember means the user can't be crew because they aren't in the event's group
+	ErrNotGroupMember = errors.New("not a member of the group")
+)
+
+// IsValidPermission reports whether permission is one an event role can grant
+func IsValidPermission(permission string) bool {
+	switch permission {
+	case PermissionEditLineup, PermissionViewLineup, PermissionTakeAttendance, PermissionRunShow:
+		return true
+	}
+	return false
+}
+
+// EventRole is a job on an event's crew
+type EventRole struct {
+	ID      string `json:"id"`
+	GroupID string `json:"groupId"`
+	// Key names the built-in roles, and is empty for the group's own
+	Key         string    `json:"key,omitempty"`
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	Position    int       `json:"position"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Validate checks the role has a name and only grants known permissions
+func (r EventRole) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidEventRole)
+	}
+	for _, permission := range r.Permissions {
+		if !IsValidPermission(permission) {
+			return fmt.Errorf("%w: unknown permission %q", ErrInvalidEventRole, permission)
+		}
+	}
+	return nil
+}
+
+// DefaultEventRoles are the roles every group starts with. The database triggers keeping the
+// MC in step with events.mc_id create the MC role with the same name and permissions.
+var DefaultEventRoles = []EventRole{
+	{Key: EventRoleMC, Name: "MC", Permissions: []string{PermissionEditLineup, PermissionViewLineup, PermissionTakeAttendance, PermissionRunShow}},
+	{Key: "director", Name: "Director", Permissions: []string{PermissionEditLineup, PermissionViewLineup}},
+	{Key: "tech", Name: "Tech", Permissions: []string{PermissionViewLineup}},
+	{Key: "musician", Name: "Musician", Permissions: []string{PermissionViewLineup}},
+	{Key: "front-of-house", Name: "Front of house", Permissions: []string{PermissionTakeAttendance}},
+}
+
+// CrewMember is a member's role on an event's crew. Members with several roles appear once for each.
+type CrewMember struct {
+	UserID    string `json:"userId"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	RoleID    string `json:"roleId"`
+	RoleKey   string `json:"roleKey,omitempty"`
+	RoleName  string `json:"roleName"`
+}
+
+// CrewService keeps groups' event roles and who is on each event's crew
+type CrewService struct {
+	db *sql.DB
+}
+
+func NewCrewService(db *sql.DB) *CrewService {
+	return &CrewService{db: db}
+}
+
+const eventRoleColumns = `
+	id, group_id, COALESCE(role_key, ''), name, permissions, position, created_at
+`
+
+func scanEventRole(scanner interface{ Scan(...interface{}) error }) (EventRole, error) {
+	var role EventRole
+	var permissions string
+	err := scanner.Scan(&role.ID, &role.GroupID, &role.Key, &role.Name, &permissions, &role.Position, &role.CreatedAt)
+	role.Permissions = splitPermissions(permissions)
+	return role, err
+}
+
+func splitPermissions(permissions string) []string {
+	split := []string{}
+	for _, permission := range strings.Split(permissions, ",") {
+		if permission != "" {
+			split = append(split, permission)
+		}
+	}
+	return split
+}
+
+// joinPermissions stores permissions without duplicates. Editing the lineup includes seeing it.
+func joinPermissions(permissions []string) string {
+	seen := map[string]bool{}
+	var kept []string
+	for _, permission := range permissions {
+		if !seen[permission] {
+			seen[permission] = true
+			kept = append(kept, permission)
+		}
+		if permission == PermissionEditLineup && !seen[PermissionViewLineup] {
+			seen[PermissionViewLineup] = true
+			kept = append(kept, PermissionViewLineup)
+		}
+	}
+	return strings.Join(kept, ",")
+}
+
+// ensureRoles gives the group the default roles the first time its roles are looked at. Built-in
+// roles a group has since removed stay removed; only the MC is always there.
+func (s *CrewService) ensureRoles(groupID string) error {
+	var seeded bool
+	err := s.db.QueryRow(`SELECT event_roles_seeded FROM improv_groups WHERE id = $1`, groupID).Scan(&seeded)
+	if err != nil {
+		return fmt.Errorf("error fetching group: %v", err)
+	}
+	roles := DefaultEventRoles
+	if seeded {
+		roles = DefaultEventRoles[:1]
+	}
+	for i, role := range roles {
+		_, err := s.db.Exec(`
+			INSERT OR IGNORE INTO event_roles (id, group_id, role_key, name, permissions, position)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, groupID+":"+role.Key, groupID, role.Key, role.Name, joinPermissions(role.Permissions), i)
+		if err != nil {
+			return fmt.Errorf("error adding default event role: %v", err)
+		}
+	}
+	if !seeded {
+		if _, err := s.db.Exec(`UPDATE improv_groups SET event_roles_seeded = 1 WHERE id = $1`, groupID); err != nil {
+			return fmt.Errorf("error updating group: %v", err)
+		}
+	}
+	return nil
+}
+
+// Roles returns the group's event roles in order
+func (s *CrewService) Roles(groupID string) ([]EventRole, error) {
+	if err := s.ensureRoles(groupID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`
+		SELECT `+eventRoleColumns+`
+		FROM event_roles
+		WHERE group_id = $1
+		ORDER BY position, name
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event roles: %v", err)
+	}
+	defer rows.Close()
+
+	roles := []EventRole{}
+	for rows.Next() {
+		role, err := scanEventRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning event role: %v", err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// Role returns one of the group's event roles, or nil if it doesn't exist
+func (s *CrewService) Role(groupID, roleID string) (*EventRole, error) {
+	role, err := scanEventRole(s.db.QueryRow(`
+		SELECT `+eventRoleColumns+`
+		FROM event_roles
+		WHERE id = $1 AND group_id = $2
+	`, roleID, groupID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event role: %v", err)
+	}
+	return &role, nil
+}
+
+// SaveRole creates the role when it has no ID, otherwise renames it and replaces its permissions.
+// New roles go after the group's others.
+func (s *CrewService) SaveRole(role EventRole) (*EventRole, error) {
+	if err := role.Validate(); err != nil {
+		return nil, err
+	}
+	var err error
+	if role.ID == "" {
+		role.ID = uuid.New().String()
+		_, err = s.db.Exec(`
+			INSERT INTO event_roles (id, group_id, name, permissions, position)
+			SELECT $1, $2, $3, $4, COALESCE(MAX(position) + 1, 0) FROM event_roles WHERE group_id = $2
+		`, role.ID, role.GroupID, strings.TrimSpace(role.Name), joinPermissions(role.Permissions))
+	} else {
+		_, err = s.db.Exec(`
+			UPDATE event_roles SET name = $1, permissions = $2
+			WHERE id = $3 AND group_id = $4
+		`, strings.TrimSpace(role.Name), joinPermissions(role.Permissions), role.ID, role.GroupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error saving event role: %v", err)
+	}
+	return s.Role(role.GroupID, role.ID)
+}
+
+// DeleteRole removes a role and takes everyone off it. The MC role can't be removed.
+func (s *CrewService) DeleteRole(groupID, roleID string) error {
+	role, err := s.Role(groupID, roleID)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return ErrEventRoleNotFound
+	}
+	if role.Key == EventRoleMC {
+		return fmt.Errorf("%w: the MC role can't be removed", ErrInvalidEventRole)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM event_crew WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("error removing crew from event role: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM event_roles WHERE id = $1 AND group_id = $2`, roleID, groupID); err != nil {
+		return fmt.Errorf("error deleting event role: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error deleting event role: %v", err)
+	}
+	return nil
+}
+
+// Crew returns the event's crew by role, then name
+func (s *CrewService) Crew(eventID string) ([]CrewMember, error) {
+	rows, err := s.db.Query(`
+		SELECT c.user_id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), r.id, COALESCE(r.role_key, ''), r.name
+		FROM event_crew c
+		JOIN event_roles r ON c.role_id = r.id
+		JOIN users u ON c.user_id = u.id
+		WHERE c.event_id = $1
+		ORDER BY r.position, r.name, u.first_name, u.last_name
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event crew: %v", err)
+	}
+	defer rows.Close()
+
+	crew := []CrewMember{}
+	for rows.Next() {
+		var member CrewMember
+		err := rows.Scan(&member.UserID, &member.FirstName, &member.LastName, &member.RoleID, &member.RoleKey, &member.RoleName)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning crew member: %v", err)
+		}
+		crew = append(crew, member)
+	}
+	return crew, nil
+}
+
+// Assign puts a member of the event's group on its crew in the role. Someone given the MC role
+// when the event has no MC becomes its MC.
+func (s *CrewService) Assign(eventID, userID, roleID, assignedBy string) error {
+	groupID, role, err := s.eventRole(eventID, roleID)
+	if err != nil {
+		return err
+	}
+
+	var isMember bool
+	err = s.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2)
+	`, groupID, userID).Scan(&isMember)
+	if err != nil {
+		return fmt.Errorf("error checking group membership: %v", err)
+	}
+	if !isMember {
+		return ErrNotGroupMember
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO event_crew (event_id, user_id, role_id, assigned_by)
+		VALUES ($1, $2, $3, $4)
+	`, eventID, userID, roleID, assignedBy)
+	if err != nil {
+		return fmt.Errorf("error assigning crew: %v", err)
+	}
+	if role.Key == EventRoleMC {
+		if _, err := tx.Exec(`UPDATE events SET mc_id = $1 WHERE id = $2 AND mc_id IS NULL`, userID, eventID); err != nil {
+			return fmt.Errorf("error updating event MC: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error assigning crew: %v", err)
+	}
+	return nil
+}
+
+// Unassign takes a member off the role at the event. When that was the event's MC, the next
+// member with the MC role, if any, becomes its MC.
+func (s *CrewService) Unassign(eventID, userID, roleID string) error {
+	_, role, err := s.eventRole(eventID, roleID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM event_crew WHERE event_id = $1 AND user_id = $2 AND role_id = $3
+	`, eventID, userID, roleID)
+	if err != nil {
+		return fmt.Errorf("error removing crew: %v", err)
+	}
+	if role.Key == EventRoleMC {
+		_, err = tx.Exec(`
+			UPDATE events
+			SET mc_id = (
+				SELECT user_id FROM event_crew WHERE event_id = $1 AND role_id = $2
+				ORDER BY assigned_at, user_id LIMIT 1
+			)
+			WHERE id = $1 AND mc_id = $3
+		`, eventID, roleID, userID)
+		if err != nil {
+			return fmt.Errorf("error updating event MC: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error removing crew: %v", err)
+	}
+	return nil
+}
+
+// eventRole returns the event's group and the group's role
+func (s *CrewService) eventRole(eventID, roleID string) (string, *EventRole, error) {
+	var groupID string
+	err := s.db.QueryRow(`SELECT group_id FROM events WHERE id = $1`, eventID).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		return "", nil, ErrEventNotFound
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("error fetching event: %v", err)
+	}
+	role, err := s.Role(groupID, roleID)
+	if err != nil {
+		return "", nil, err
+	}
+	if role == nil {
+		return "", nil, ErrEventRoleNotFound
+	}
+	return groupID, role, nil
+}
+
+// HasPermission reports whether one of the user's roles on the event's crew grants the permission
+func (s *CrewService) HasPermission(eventID, userID, permission string) (bool, error) {
+	var allowed bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM event_crew c
+			JOIN event_roles r ON c.role_id = r.id
+			WHERE c.event_id = $1 AND c.user_id = $2
+			  AND ',' || r.permissions || ',' LIKE '%,' || $3 || ',%'
+		)
+	`, eventID, userID, permission).Scan(&allowed)
+	if err != nil {
+		return false, fmt.Errorf("error checking crew permissions: %v", err)
+	}
+	return allowed, nil
+}
+
+// MCHasPermission reports whether the group's MC role grants the permission. An occurrence of a
+// recurring series that hasn't been saved has no crew yet, only the MC it will be saved with.
+func (s *CrewService) MCHasPermission(groupID, permission string) (bool, error) {
+	permissions := DefaultEventRoles[0].Permissions
+	var saved string
+	err := s.db.QueryRow(`
+		SELECT permissions FROM event_roles
+		WHERE group_id = $1 AND role_key = $2
+	`, groupID, EventRoleMC).Scan(&saved)
+	switch {
+	case err == nil:
+		permissions = splitPermissions(saved)
+	case err != sql.ErrNoRows:
+		return false, fmt.Errorf("error fetching MC role: %v", err)
+	}
+	for _, granted := range permissions {
+		if granted == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"improv-app/internal/db"
+)
+
+// seedDana is Dana, a plain member of group123
+var seedDana = []string{
+	`INSERT INTO users (id, email, first_name, last_name) VALUES ('dana', 'dana@example.com', 'Dana', 'Director')`,
+	`INSERT INTO group_members (group_id, user_id, role) VALUES ('group123', 'dana', 'member')`,
+}
+
+// newCrewTestDB adds Dana to the public show, where Ada is the MC, and the rehearsal
+func newCrewTestDB(t *testing.T) *sql.DB {
+	return newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedRehearsal, seedDana)
+}
+
+func eventMC(t *testing.T, testDB *sql.DB, eventID string) string {
+	t.Helper()
+	var mcID sql.NullString
+	if err := testDB.QueryRow(`SELECT mc_id FROM events WHERE id = $1`, eventID).Scan(&mcID); err != nil {
+		t.Fatalf("Error fetching MC: %v", err)
+	}
+	return mcID.String
+}
+
+func TestCrew_RolesAndPermissions(t *testing.T) {
+	testDB := newCrewTestDB(t)
+	service := NewCrewService(testDB)
+
+	roles, err := service.Roles("group123")
+	if err != nil {
+		t.Fatalf("Error fetching roles: %v", err)
+	}
+	if len(roles) != len(DefaultEventRoles) || roles[0].Key != EventRoleMC || roles[1].Name != "Director" {
+		t.Fatalf("Expected the default roles, got %+v", roles)
+	}
+
+	// A removed default role stays removed, but the MC role can't go
+	if err := service.DeleteRole("group123", "group123:tech"); err != nil {
+		t.Fatalf("Error deleting role: %v", err)
+	}
+	if err := service.DeleteRole("group123", "group123:mc"); !errors.Is(err, ErrInvalidEventRole) {
+		t.Errorf("Expected ErrInvalidEventRole deleting the MC role, got %v", err)
+	}
+	if roles, _ := service.Roles("group123"); len(roles) != len(DefaultEventRoles)-1 {
+		t.Errorf("Expected %d roles after deleting one, got %+v", len(DefaultEventRoles)-1, roles)
+	}
+
+	// Editing the lineup includes seeing it
+	stageManager, err := service.SaveRole(EventRole{GroupID: "group123", Name: "Stage manager", Permissions: []string{PermissionEditLineup}})
+	if err != nil {
+		t.Fatalf("Error creating role: %v", err)
+	}
+	if len(stageManager.Permissions) != 2 || stageManager.Permissions[1] != PermissionViewLineup {
+		t.Errorf("Expected edit and view permissions, got %v", stageManager.Permissions)
+	}
+	if _, err := service.SaveRole(EventRole{GroupID: "group123", Name: "Juggler", Permissions: []string{"juggle"}}); !errors.Is(err, ErrInvalidEventRole) {
+		t.Errorf("Expected ErrInvalidEventRole for an unknown permission, got %v", err)
+	}
+
+	if err := service.Assign("public1", "outsider", "group123:director", "user123"); !errors.Is(err, ErrNotGroupMember) {
+		t.Errorf("Expected ErrNotGroupMember, got %v", err)
+	}
+	if err := service.Assign("public1", "dana", "group123:director", "user123"); err != nil {
+		t.Fatalf("Error assigning crew: %v", err)
+	}
+	tests := []struct {
+		userID, permission string
+		want               bool
+	}{
+		{"user123", PermissionEditLineup, true},
+		{"user123", PermissionTakeAttendance, true},
+		{"dana", PermissionEditLineup, true},
+		{"dana", PermissionTakeAttendance, false},
+		{"outsider", PermissionViewLineup, false},
+	}
+	for _, tt := range tests {
+		allowed, err := service.HasPermission("public1", tt.userID, tt.permission)
+		if err != nil {
+			t.Fatalf("Error checking permission: %v", err)
+		}
+		if allowed != tt.want {
+			t.Errorf("HasPermission(%s, %s) = %v, want %v", tt.userID, tt.permission, allowed, tt.want)
+		}
+	}
+
+	// Deleting a role takes its crew off it
+	if err := service.DeleteRole("group123", "group123:director"); err != nil {
+		t.Fatalf("Error deleting role: %v", err)
+	}
+	if allowed, _ := service.HasPermission("public1", "dana", PermissionEditLineup); allowed {
+		t.Error("Expected the director's permissions gone with the role")
+	}
+}
+
+func TestCrew_MCStaysInStep(t *testing.T) {
+	testDB := newCrewTestDB(t)
+	service := NewCrewService(testDB)
+
+	crew, err := service.Crew("public1")
+	if err != nil {
+		t.Fatalf("Error fetching crew: %v", err)
+	}
+	if len(crew) != 1 || crew[0].UserID != "user123" || crew[0].RoleKey != EventRoleMC {
+		t.Fatalf("Expected the MC on the crew, got %+v", crew)
+	}
+
+	// Changing mc_id the old way moves the MC role
+	if _, err := testDB.Exec(`UPDATE events SET mc_id = 'dana' WHERE id = 'public1'`); err != nil {
+		t.Fatalf("Error updating MC: %v", err)
+	}
+	if crew, _ := service.Crew("public1"); len(crew) != 1 || crew[0].UserID != "dana" {
+		t.Errorf("Expected Dana as the only MC, got %+v", crew)
+	}
+
+	// A second MC doesn't replace the first, and takes over when the first steps down
+	if err := service.Assign("public1", "user123", "group123:mc", "user123"); err != nil {
+		t.Fatalf("Error assigning MC: %v", err)
+	}
+	if mc := eventMC(t, testDB, "public1"); mc != "dana" {
+		t.Errorf("Expected Dana to stay MC, got %q", mc)
+	}
+	if err := service.Unassign("public1", "dana", "group123:mc"); err != nil {
+		t.Fatalf("Error removing MC: %v", err)
+	}
+	if mc := eventMC(t, testDB, "public1"); mc != "user123" {
+		t.Errorf("Expected Ada to take over as MC, got %q", mc)
+	}
+	if err := service.Unassign("public1", "user123", "group123:mc"); err != nil {
+		t.Fatalf("Error removing MC: %v", err)
+	}
+	if mc := eventMC(t, testDB, "public1"); mc != "" {
+		t.Errorf("Expected no MC left, got %q", mc)
+	}
+
+	// Giving the MC role to someone on an event without one makes them its MC
+	if err := service.Assign("private1", "dana", "group123:mc", "user123"); err != nil {
+		t.Fatalf("Error assigning MC: %v", err)
+	}
+	if mc := eventMC(t, testDB, "private1"); mc != "dana" {
+		t.Errorf("Expected Dana as MC, got %q", mc)
+	}
+}
+
+func TestCrew_MigratesExistingMCs(t *testing.T) {
+	testDB := newCrewTestDB(t)
+	// An MC chosen before crews existed
+	seed(t, testDB,
+		`DROP TRIGGER events_mc_ai`,
+		`INSERT INTO events (id, group_id, title, start_time, end_time, created_by, mc_id)
+		 VALUES ('old1', 'group123', 'Old Show', '2025-01-01 19:00:00', '2025-01-01 21:00:00', 'user123', 'dana')`,
+	)
+
+	migrated := db.InitDB()
+	defer migrated.Close()
+
+	crew, err := NewCrewService(migrated).Crew("old1")
+	if err != nil {
+		t.Fatalf("Error fetching crew: %v", err)
+	}
+	if len(crew) != 1 || crew[0].UserID != "dana" || crew[0].RoleName != "MC" {
+		t.Errorf("Expected Dana migrated in as MC, got %+v", crew)
+	}
+}
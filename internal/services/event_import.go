@@ -0,0 +1,328 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"improv-app/internal/ical"
+	"improv-app/internal/recurrence"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidImport means the calendar couldn't be read, or the commit doesn't match it
+var ErrInvalidImport = errors.New("invalid import")
+
+// ImportCandidate is an event found in an imported calendar. Recurring events are
+// one candidate, with their modified occurrences and exceptions carried along.
+type ImportCandidate struct {
+	// Key identifies the candidate between the preview and the commit
+	Key         string    `json:"key"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	RRule       string    `json:"rrule,omitempty"`
+	// ModifiedOccurrences counts occurrences of a recurring event that were moved or edited
+	ModifiedOccurrences int `json:"modifiedOccurrences,omitempty"`
+	// DuplicateOf is the existing event or series this looks like it was already imported as
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+
+	event     ical.Event
+	overrides []ical.Event
+	exDates   []time.Time
+}
+
+// ImportResult lists what committing an import created
+type ImportResult struct {
+	EventIDs  []string `json:"eventIds"`
+	SeriesIDs []string `json:"seriesIds"`
+}
+
+// EventImportService turns iCalendar files into a group's events
+type EventImportService struct {
+	db *sql.DB
+}
+
+func NewEventImportService(db *sql.DB) *EventImportService {
+	return &EventImportService{db: db}
+}
+
+// Preview parses a calendar into import candidates and flags the ones the group already has
+func (s *EventImportService) Preview(groupID string, calendar io.Reader) ([]ImportCandidate, error) {
+	events, err := ical.Parse(calendar)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	candidates := importCandidates(events)
+	for i := range candidates {
+		duplicateOf, err := s.findDuplicate(groupID, candidates[i])
+		if err != nil {
+			return nil, err
+		}
+		candidates[i].DuplicateOf = duplicateOf
+	}
+	return candidates, nil
+}
+
+// Commit imports the selected candidates in one transaction, so a failed import saves nothing.
+// Selecting a duplicate imports it again.
+func (s *EventImportService) Commit(groupID, userID string, calendar io.Reader, keys []string) (*ImportResult, error) {
+	candidates, err := s.Preview(groupID, calendar)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]ImportCandidate, len(candidates))
+	for _, candidate := range candidates {
+		byKey[candidate.Key] = candidate
+	}
+	for _, key := range keys {
+		if _, ok := byKey[key]; !ok {
+			return nil, fmt.Errorf("%w: no event %q in the calendar", ErrInvalidImport, key)
+		}
+	}
+
+	groupZone, err := GroupTimeZone(s.db, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result := &ImportResult{EventIDs: []string{}, SeriesIDs: []string{}}
+	for _, key := range keys {
+		candidate := byKey[key]
+		if candidate.RRule != "" {
+			seriesID, eventIDs, err := s.importSeries(tx, groupID, userID, groupZone, candidate)
+			if err != nil {
+				return nil, err
+			}
+			result.SeriesIDs = append(result.SeriesIDs, seriesID)
+			result.EventIDs = append(result.EventIDs, eventIDs...)
+			continue
+		}
+		eventID, err := s.insertEvent(tx, groupID, userID, groupZone, candidate.Key, candidate.event)
+		if err != nil {
+			return nil, err
+		}
+		result.EventIDs = append(result.EventIDs, eventID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error saving import: %v", err)
+	}
+	return result, nil
+}
+
+// importCandidates groups a calendar's events by UID into candidates, sorted by start time.
+// Cancelled events are left out, and cancelled occurrences become exceptions.
+func importCandidates(events []ical.Event) []ImportCandidate {
+	masters := map[string]int{}
+	var candidates []ImportCandidate
+	for i, event := range events {
+		if !event.RecurrenceID.IsZero() || event.Status == "CANCELLED" {
+			continue
+		}
+		key := event.UID
+		if key == "" {
+			key = fmt.Sprintf("event-%d", i+1)
+		}
+		if _, seen := masters[key]; seen {
+			// Some exports repeat a UID for unrelated events
+			key = fmt.Sprintf("%s-%d", key, i+1)
+		}
+		candidate := ImportCandidate{
+			Key:         key,
+			Title:       event.Summary,
+			Description: event.Description,
+			Location:    event.Location,
+			StartTime:   event.Start,
+			EndTime:     event.End,
+			event:       event,
+		}
+		if event.RRule != "" {
+			candidate.RRule, candidate.Warning = mapRRule(event.RRule)
+			candidate.exDates = event.ExDates
+		}
+		masters[key] = len(candidates)
+		candidates = append(candidates, candidate)
+	}
+
+	for _, event := range events {
+		if event.RecurrenceID.IsZero() {
+			continue
+		}
+		if index, ok := masters[event.UID]; ok && candidates[index].RRule != "" {
+			master := &candidates[index]
+			if event.Status == "CANCELLED" {
+				master.exDates = append(master.exDates, event.RecurrenceID)
+			} else {
+				master.overrides = append(master.overrides, event)
+				master.ModifiedOccurrences++
+			}
+			continue
+		}
+		if event.Status == "CANCELLED" {
+			continue
+		}
+		// A modified occurrence without its series is imported on its own
+		candidates = append(candidates, ImportCandidate{
+			Key:         event.UID + "@" + ical.FormatTime(event.RecurrenceID),
+			Title:       event.Summary,
+			Description: event.Description,
+			Location:    event.Location,
+			StartTime:   event.Start,
+			EndTime:     event.End,
+			event:       event,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].StartTime.Before(candidates[j].StartTime) })
+	return candidates
+}
+
+// mapRRule maps an imported rule onto the rules series support. Rules that can't be
+// mapped are dropped with a warning, and only the first occurrence is imported.
+func mapRRule(rrule string) (string, string) {
+	rrule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rrule)), "RRULE:")
+	var parts []string
+	for _, part := range strings.Split(rrule, ";") {
+		// Weeks always start on Monday here, which only matters for multi-week rules
+		if strings.HasPrefix(part, "WKST=") {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	rule, err := recurrence.Parse(strings.Join(parts, ";"))
+	if err != nil {
+		return "", fmt.Sprintf("Repeats in a way that isn't supported (%v), so only the first occurrence will be imported", err)
+	}
+	return rule.String(), ""
+}
+
+// findDuplicate returns the ID of an event or series that was imported with the same
+// key, or that has the same title and start time
+func (s *EventImportService) findDuplicate(groupID string, candidate ImportCandidate) (string, error) {
+	var id string
+	err := s.db.QueryRow(`
+		SELECT id FROM events
+		WHERE group_id = $1 AND (
+			import_uid = $2
+			OR (LOWER(TRIM(title)) = LOWER(TRIM($3)) AND ABS(julianday(start_time) - julianday($4)) < 1.0 / 1440)
+		)
+		UNION ALL
+		SELECT id FROM event_series
+		WHERE group_id = $1 AND (
+			import_uid = $2
+			OR (LOWER(TRIM(title)) = LOWER(TRIM($3)) AND ABS(julianday(start_time) - julianday($4)) < 1.0 / 1440)
+		)
+		LIMIT 1
+	`, groupID, candidate.Key, candidate.Title, candidate.StartTime.UTC()).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error checking for duplicate events: %v", err)
+	}
+	return id, nil
+}
+
+// importTimeZone returns the zone an imported event was given in. Times in UTC, or given
+// without a zone, are taken to be in the group's zone.
+func importTimeZone(start time.Time, groupZone string) string {
+	timeZone := start.Location().String()
+	if timeZone == "UTC" {
+		return groupZone
+	}
+	if _, err := LoadTimeZone(timeZone); err != nil {
+		return groupZone
+	}
+	return timeZone
+}
+
+func (s *EventImportService) insertEvent(tx *sql.Tx, groupID, userID, groupZone, importUID string, event ical.Event) (string, error) {
+	eventID := uuid.New().String()
+	_, err := tx.Exec(`
+		INSERT INTO events (id, group_id, title, description, location, start_time, end_time, created_by, import_uid, visibility, time_zone)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, default_event_visibility, $10 FROM improv_groups WHERE id = $2
+	`, eventID, groupID, importTitle(event.Summary), event.Description, event.Location,
+		event.Start.UTC(), event.End.UTC(), userID, importUID, importTimeZone(event.Start, groupZone))
+	if err != nil {
+		return "", fmt.Errorf("error importing event: %v", err)
+	}
+	return eventID, nil
+}
+
+// importSeries creates a series for a recurring event, skips its exceptions and saves
+// its modified occurrences. Modified occurrences that don't fit the rule are imported on their own.
+func (s *EventImportService) importSeries(tx *sql.Tx, groupID, userID, groupZone string, candidate ImportCandidate) (string, []string, error) {
+	seriesService := NewEventSeriesService(s.db)
+	series, err := seriesService.create(tx, EventSeries{
+		GroupID:     groupID,
+		Title:       importTitle(candidate.Title),
+		Description: candidate.Description,
+		Location:    candidate.Location,
+		StartTime:   candidate.StartTime,
+		EndTime:     candidate.EndTime,
+		RRule:       candidate.RRule,
+		TimeZone:    importTimeZone(candidate.StartTime, groupZone),
+		CreatedBy:   userID,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("error importing series: %v", err)
+	}
+	if _, err := tx.Exec(`UPDATE event_series SET import_uid = $1 WHERE id = $2`, candidate.Key, series.ID); err != nil {
+		return "", nil, fmt.Errorf("error importing series: %v", err)
+	}
+
+	for _, exDate := range candidate.exDates {
+		if err := skipOccurrence(tx, series.ID, exDate); err != nil {
+			return series.ID, nil, err
+		}
+	}
+
+	var eventIDs []string
+	for _, override := range candidate.overrides {
+		eventID, err := seriesService.materializeTx(tx, series.ID, override.RecurrenceID)
+		if err == ErrOccurrenceNotFound || err == ErrOccurrenceCancelled {
+			eventID, err = s.insertEvent(tx, groupID, userID, groupZone, candidate.Key+"@"+ical.FormatTime(override.RecurrenceID), override)
+			if err != nil {
+				return series.ID, eventIDs, err
+			}
+			eventIDs = append(eventIDs, eventID)
+			continue
+		}
+		if err != nil {
+			return series.ID, eventIDs, err
+		}
+		_, err = tx.Exec(`
+			UPDATE events
+			SET title = $1, description = $2, location = $3, start_time = $4, end_time = $5
+			WHERE id = $6
+		`, importTitle(override.Summary), override.Description, override.Location, override.Start.UTC(), override.End.UTC(), eventID)
+		if err != nil {
+			return series.ID, eventIDs, fmt.Errorf("error importing modified occurrence: %v", err)
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+	return series.ID, eventIDs, nil
+}
+
+// importTitle gives events without a summary a title, since events need one
+func importTitle(summary string) string {
+	if strings.TrimSpace(summary) == "" {
+		return "Untitled event"
+	}
+	return summary
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const importCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:jam@example.com\r\n" +
+	"DTSTART;TZID=America/New_York:20260106T190000\r\n" +
+	"DTEND;TZID=America/New_York:20260106T210000\r\n" +
+	"RRULE:FREQ=WEEKLY;WKST=SU;BYDAY=TU;COUNT=6\r\n" +
+	"EXDATE;TZID=America/New_York:20260113T190000\r\n" +
+	"SUMMARY:Weekly Jam\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:jam@example.com\r\n" +
+	"RECURRENCE-ID;TZID=America/New_York:20260120T190000\r\n" +
+	"DTSTART;TZID=America/New_York:20260120T200000\r\n" +
+	"DTEND;TZID=America/New_York:20260120T220000\r\n" +
+	"SUMMARY:Weekly Jam (late)\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:show@example.com\r\n" +
+	"DTSTART:20260201T190000Z\r\n" +
+	"DTEND:20260201T210000Z\r\n" +
+	"SUMMARY:Public Show\r\n" +
+	"LOCATION:Main Stage\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:yearly@example.com\r\n" +
+	"DTSTART:20260301T190000Z\r\n" +
+	"RRULE:FREQ=YEARLY\r\n" +
+	"SUMMARY:Anniversary Show\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled@example.com\r\n" +
+	"DTSTART:20260401T190000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"SUMMARY:Cancelled Show\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestEventImport_Preview(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedPublicShow)
+	service := NewEventImportService(testDB)
+
+	candidates, err := service.Preview("group123", strings.NewReader(importCalendar))
+	if err != nil {
+		t.Fatalf("Error previewing import: %v", err)
+	}
+	if len(candidates) != 3 {
+		t.Fatalf("Expected 3 candidates, got %+v", candidates)
+	}
+
+	jam, show, yearly := candidates[0], candidates[1], candidates[2]
+	if jam.Key != "jam@example.com" || jam.RRule != "FREQ=WEEKLY;BYDAY=TU;COUNT=6" || jam.ModifiedOccurrences != 1 {
+		t.Errorf("Unexpected recurring candidate %+v", jam)
+	}
+	// The seeded public1 event has the same title and start
+	if show.DuplicateOf != "public1" {
+		t.Errorf("Expected the show to be flagged as a duplicate of public1, got %q", show.DuplicateOf)
+	}
+	if yearly.RRule != "" || yearly.Warning == "" {
+		t.Errorf("Expected an unsupported rule to be dropped with a warning, got %+v", yearly)
+	}
+}
+
+func TestEventImport_Commit(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedPublicShow, []string{
+		`UPDATE improv_groups SET time_zone = 'America/Chicago' WHERE id = 'group123'`,
+	})
+	service := NewEventImportService(testDB)
+
+	result, err := service.Commit("group123", "user123", strings.NewReader(importCalendar), []string{"jam@example.com", "yearly@example.com"})
+	if err != nil {
+		t.Fatalf("Error committing import: %v", err)
+	}
+	if len(result.SeriesIDs) != 1 || len(result.EventIDs) != 2 {
+		t.Fatalf("Expected a series, its modified occurrence and the yearly event, got %+v", result)
+	}
+
+	seriesService := NewEventSeriesService(testDB)
+	series, err := seriesService.Get(result.SeriesIDs[0])
+	if err != nil || series == nil {
+		t.Fatalf("Error fetching imported series: %v", err)
+	}
+	if series.TimeZone != "America/New_York" {
+		t.Errorf("Expected the series to keep its time zone, got %s", series.TimeZone)
+	}
+
+	// The skipped and modified occurrences are left out, and the rest stay on Tuesday evenings
+	occurrences, err := seriesService.Occurrences(*series, time.Time{}, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error expanding series: %v", err)
+	}
+	var starts []string
+	for _, occurrence := range occurrences {
+		starts = append(starts, occurrence.StartTime.Format("Mon 2006-01-02 15:04"))
+	}
+	want := []string{"Tue 2026-01-06 19:00", "Tue 2026-01-27 19:00", "Tue 2026-02-03 19:00", "Tue 2026-02-10 19:00"}
+	if strings.Join(starts, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Expected %v, got %v", want, starts)
+	}
+
+	var title string
+	var start time.Time
+	err = testDB.QueryRow(`SELECT title, start_time FROM events WHERE id = $1`, result.EventIDs[0]).Scan(&title, &start)
+	if err != nil {
+		t.Fatalf("Error fetching modified occurrence: %v", err)
+	}
+	if title != "Weekly Jam (late)" || !start.Equal(time.Date(2026, 1, 21, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected modified occurrence %q at %v", title, start)
+	}
+
+	// The yearly show's times are in UTC, so it's taken to be in the group's zone
+	var timeZone string
+	if err := testDB.QueryRow(`SELECT time_zone FROM events WHERE id = $1`, result.EventIDs[1]).Scan(&timeZone); err != nil || timeZone != "America/Chicago" {
+		t.Errorf("Expected the group's time zone, got %q (%v)", timeZone, err)
+	}
+
+	// Importing the same calendar again flags everything that was imported
+	candidates, err := service.Preview("group123", strings.NewReader(importCalendar))
+	if err != nil {
+		t.Fatalf("Error previewing import: %v", err)
+	}
+	for _, candidate := range candidates {
+		if candidate.DuplicateOf == "" {
+			t.Errorf("Expected %s to be flagged as a duplicate", candidate.Key)
+		}
+	}
+
+	if _, err := service.Commit("group123", "user123", strings.NewReader(importCalendar), []string{"missing"}); err == nil {
+		t.Errorf("Expected an error committing a key that isn't in the calendar")
+	}
+}
+
+func TestEventImport_CommitSavesNothingOnError(t *testing.T) {
+	// Saving the yearly show fails after the series was imported
+	testDB := newTestDB(t, seedGroup, []string{
+		`CREATE TRIGGER fail_import BEFORE INSERT ON events WHEN NEW.title = 'Anniversary Show'
+		 BEGIN SELECT RAISE(ABORT, 'import failed'); END`,
+	})
+
+	result, err := NewEventImportService(testDB).Commit("group123", "user123", strings.NewReader(importCalendar), []string{"jam@example.com", "yearly@example.com"})
+	if err == nil {
+		t.Fatal("Expected the import to fail")
+	}
+	if result != nil {
+		t.Errorf("Expected no result from a failed import, got %+v", result)
+	}
+
+	var series, events, exceptions int
+	testDB.QueryRow(`SELECT COUNT(*) FROM event_series`).Scan(&series)
+	testDB.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&events)
+	testDB.QueryRow(`SELECT COUNT(*) FROM event_series_exceptions`).Scan(&exceptions)
+	if series != 0 || events != 0 || exceptions != 0 {
+		t.Errorf("Expected nothing saved, got %d series, %d events and %d exceptions", series, events, exceptions)
+	}
+}
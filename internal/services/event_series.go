@@ -0,0 +1,776 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"improv-app/internal/query"
+	"improv-app/internal/recurrence"
+
+	"github.com/google/uuid"
+)
+
+// Edit and cancel scopes for an occurrence of a recurring series
+const (
+	ScopeThis      = "this"
+	ScopeFollowing = "following"
+	ScopeAll       = "all"
+)
+
+// IsValidScope checks if the scope is one of the supported edit scopes
+func IsValidScope(scope string) bool {
+	return scope == ScopeThis || scope == ScopeFollowing || scope == ScopeAll
+}
+
+var (
+	// ErrOccurrenceNotFound means the time isn't an occurrence of the series, or the series doesn't exist
+	ErrOccurrenceNotFound = errors.New("occurrence not found")
+	// ErrOccurrenceCancelled means the occurrence was cancelled
+	ErrOccurrenceCancelled = errors.New("occurrence was cancelled")
+	// ErrNotInSeries means the event isn't an occurrence of a recurring series
+	ErrNotInSeries = errors.New("event is not part of a recurring series")
+	// ErrInvalidEdit means the edit can't be applied to the series, such as a bad rule
+	ErrInvalidEdit = errors.New("invalid series edit")
+)
+
+// EventSeries is the template a recurring event's occurrences are expanded from
+type EventSeries struct {
+	ID          string    `json:"id"`
+	GroupID     string    `json:"groupId"`
+	GroupName   string    `json:"groupName,omitempty"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	RRule       string    `json:"rrule"`
+	MCID        *string   `json:"mcId,omitempty"`
+	Visibility  string    `json:"visibility"`
+	// Capacity is copied to each occurrence when it's saved
+	Capacity *int `json:"capacity,omitempty"`
+	// VenueID is the venue occurrences are at, with Location as the free-text fallback
+	VenueID *string `json:"venueId,omitempty"`
+	// TimeZone is the IANA zone the rule's days and times are in
+	TimeZone  string    `json:"timeZone"`
+	Sequence  int       `json:"sequence"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Rule parses the series' recurrence rule
+func (s EventSeries) Rule() (recurrence.Rule, error) {
+	return recurrence.Parse(s.RRule)
+}
+
+// TimeLocation returns the series' time zone, or UTC if it's unknown
+func (s EventSeries) TimeLocation() *time.Location {
+	return EventLocation(s.TimeZone)
+}
+
+// Occurrence is an occurrence of a series that hasn't been saved as an event
+type Occurrence struct {
+	ID          string
+	SeriesID    string
+	GroupID     string
+	GroupName   string
+	Title       string
+	Description string
+	Location    string
+	StartTime   time.Time
+	EndTime     time.Time
+	CreatedBy   string
+	CreatedAt   time.Time
+	MCID        *string
+	Visibility  string
+	Status      string
+	Capacity    *int
+	VenueID     *string
+	TimeZone    string
+}
+
+// SeriesEdit is a change made to one occurrence that should carry over to others in the series
+type SeriesEdit struct {
+	Title       string
+	Description string
+	Location    string
+	MCID        *string
+	// Shift is how far the edited occurrence's start moved
+	Shift time.Duration
+	// Duration is the new length of each occurrence
+	Duration time.Duration
+	// RRule replaces the recurrence rule when set
+	RRule string
+	// Capacity applies to occurrences saved from now on. Saved occurrences keep their own.
+	Capacity   *int
+	Visibility string
+	VenueID    *string
+}
+
+// EventSeriesService manages recurring event series and their occurrences
+type EventSeriesService struct {
+	db *sql.DB
+}
+
+func NewEventSeriesService(db *sql.DB) *EventSeriesService {
+	return &EventSeriesService{db: db}
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+const eventSeriesColumns = `
+	s.id, s.group_id, s.title, COALESCE(s.description, ''), COALESCE(s.location, ''), s.start_time, s.end_time,
+	s.rrule, s.mc_id, s.visibility, s.capacity, s.venue_id, s.time_zone, s.sequence, s.created_by, s.created_at
+`
+
+func scanEventSeries(scanner interface{ Scan(...interface{}) error }, extra ...interface{}) (EventSeries, error) {
+	var series EventSeries
+	dest := []interface{}{&series.ID, &series.GroupID, &series.Title, &series.Description, &series.Location,
+		&series.StartTime, &series.EndTime, &series.RRule, &series.MCID, &series.Visibility, &series.Capacity, &series.VenueID, &series.TimeZone,
+		&series.Sequence, &series.CreatedBy, &series.CreatedAt}
+	err := scanner.Scan(append(dest, extra...)...)
+	// Expand the rule in the series' zone so occurrences keep their wall-clock time and weekday
+	series.StartTime = series.StartTime.In(series.TimeLocation())
+	series.EndTime = series.EndTime.In(series.TimeLocation())
+	return series, err
+}
+
+// occurrenceKey normalizes an occurrence's original start for storage and lookups
+func occurrenceKey(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// Create saves a new series after checking its rule
+func (s *EventSeriesService) Create(series EventSeries) (*EventSeries, error) {
+	return s.create(s.db, series)
+}
+
+func (s *EventSeriesService) create(q queryer, series EventSeries) (*EventSeries, error) {
+	if _, err := series.Rule(); err != nil {
+		return nil, err
+	}
+	if series.Visibility == "" {
+		visibility, err := GroupDefaultVisibility(s.db, series.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		series.Visibility = visibility
+	}
+	if series.TimeZone == "" {
+		series.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(series.TimeZone); err != nil {
+		return nil, fmt.Errorf("unknown time zone %s", series.TimeZone)
+	}
+	series.ID = uuid.New().String()
+
+	_, err := q.Exec(`
+		INSERT INTO event_series (id, group_id, title, description, location, start_time, end_time, rrule, mc_id, visibility, time_zone, created_by, capacity, venue_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, series.ID, series.GroupID, series.Title, series.Description, series.Location,
+		occurrenceKey(series.StartTime), occurrenceKey(series.EndTime), series.RRule, series.MCID, series.Visibility, series.TimeZone, series.CreatedBy, series.Capacity, series.VenueID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating event series: %v", err)
+	}
+	return s.get(q, series.ID)
+}
+
+// Get returns a series, or nil if it doesn't exist
+func (s *EventSeriesService) Get(seriesID string) (*EventSeries, error) {
+	return s.get(s.db, seriesID)
+}
+
+func (s *EventSeriesService) get(q queryer, seriesID string) (*EventSeries, error) {
+	series, err := scanEventSeries(q.QueryRow(`
+		SELECT `+eventSeriesColumns+`
+		FROM event_series s
+		WHERE s.id = $1
+	`, seriesID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event series: %v", err)
+	}
+	return &series, nil
+}
+
+// ListForGroup returns the group's series
+func (s *EventSeriesService) ListForGroup(groupID string) ([]EventSeries, error) {
+	return s.list(`
+		SELECT `+eventSeriesColumns+`, g.name
+		FROM event_series s
+		JOIN improv_groups g ON s.group_id = g.id
+		WHERE s.group_id = $1
+	`, groupID)
+}
+
+// ListVisible returns the series the user can see, by the same rules as their events
+func (s *EventSeriesService) ListVisible(userID string) ([]EventSeries, error) {
+	return s.list(`
+		SELECT DISTINCT `+eventSeriesColumns+`, g.name
+	`+query.VisibleSeriesFrom+`
+		WHERE `+query.VisibleSeriesCondition, userID)
+}
+
+func (s *EventSeriesService) list(statement string, args ...interface{}) ([]EventSeries, error) {
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event series: %v", err)
+	}
+	defer rows.Close()
+
+	var seriesList []EventSeries
+	for rows.Next() {
+		var groupName string
+		series, err := scanEventSeries(rows, &groupName)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning event series: %v", err)
+		}
+		series.GroupName = groupName
+		seriesList = append(seriesList, series)
+	}
+	return seriesList, nil
+}
+
+// Occurrences expands the series between from and to, leaving out occurrences
+// that were saved as events or cancelled
+func (s *EventSeriesService) Occurrences(series EventSeries, from, to time.Time) ([]Occurrence, error) {
+	rule, err := series.Rule()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule for series %s: %v", series.ID, err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT original_start FROM event_series_exceptions
+		WHERE series_id = $1
+	`, series.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching series exceptions: %v", err)
+	}
+	defer rows.Close()
+
+	exceptions := map[int64]bool{}
+	for rows.Next() {
+		var originalStart time.Time
+		if err := rows.Scan(&originalStart); err != nil {
+			return nil, fmt.Errorf("error scanning series exception: %v", err)
+		}
+		exceptions[originalStart.Unix()] = true
+	}
+
+	duration := series.EndTime.Sub(series.StartTime)
+	var occurrences []Occurrence
+	for _, start := range rule.Between(series.StartTime, from, to) {
+		if exceptions[start.Unix()] {
+			continue
+		}
+		occurrences = append(occurrences, Occurrence{
+			ID:          recurrence.OccurrenceID(series.ID, start),
+			SeriesID:    series.ID,
+			GroupID:     series.GroupID,
+			GroupName:   series.GroupName,
+			Title:       series.Title,
+			Description: series.Description,
+			Location:    series.Location,
+			StartTime:   start,
+			EndTime:     start.Add(duration),
+			CreatedBy:   series.CreatedBy,
+			CreatedAt:   series.CreatedAt,
+			MCID:        series.MCID,
+			Visibility:  series.Visibility,
+			Status:      OccurrenceStatus(start.Add(duration), time.Now()),
+			Capacity:    series.Capacity,
+			VenueID:     series.VenueID,
+			TimeZone:    series.TimeZone,
+		})
+	}
+	return occurrences, nil
+}
+
+// MaterializeFirst saves the series' first occurrence as an event. setUp, if it isn't nil, fills
+// in the new event in the same transaction, so it's never saved half set up.
+func (s *EventSeriesService) MaterializeFirst(series EventSeries, setUp func(tx *sql.Tx, eventID string) error) (string, error) {
+	rule, err := series.Rule()
+	if err != nil {
+		return "", err
+	}
+	first, ok := rule.First(series.StartTime)
+	if !ok {
+		return "", ErrOccurrenceNotFound
+	}
+	return s.materialize(series.ID, first, setUp)
+}
+
+// FindOccurrence returns the event an occurrence was saved as or, if it hasn't been saved,
+// the occurrence itself. Unlike Materialize it never saves anything.
+func (s *EventSeriesService) FindOccurrence(seriesID string, originalStart time.Time) (string, *Occurrence, error) {
+	originalStart = occurrenceKey(originalStart)
+
+	if eventID, err := s.existingOccurrence(s.db, seriesID, originalStart); err != ErrOccurrenceNotFound {
+		return eventID, nil, err
+	}
+
+	series, err := s.Get(seriesID)
+	if err != nil {
+		return "", nil, err
+	}
+	if series == nil {
+		return "", nil, ErrOccurrenceNotFound
+	}
+	occurrences, err := s.Occurrences(*series, originalStart, originalStart.Add(time.Second))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(occurrences) != 1 {
+		return "", nil, ErrOccurrenceNotFound
+	}
+	return "", &occurrences[0], nil
+}
+
+// Materialize saves an occurrence as an event so it can have RSVPs, a lineup and
+// its own edits, returning the event ID. Saving an occurrence twice returns the same event.
+func (s *EventSeriesService) Materialize(seriesID string, originalStart time.Time) (string, error) {
+	return s.materialize(seriesID, originalStart, nil)
+}
+
+func (s *EventSeriesService) materialize(seriesID string, originalStart time.Time, setUp func(tx *sql.Tx, eventID string) error) (string, error) {
+	originalStart = occurrenceKey(originalStart)
+
+	if eventID, err := s.existingOccurrence(s.db, seriesID, originalStart); err != ErrOccurrenceNotFound {
+		return eventID, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	eventID, claimed, err := s.saveOccurrence(tx, seriesID, originalStart, setUp)
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		tx.Rollback()
+		return s.existingOccurrence(s.db, seriesID, originalStart)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("error saving occurrence: %v", err)
+	}
+	return eventID, nil
+}
+
+// materializeDue saves the series' occurrences between from and to that due picks, so
+// background jobs that track what they've sent by event ID can treat them like any event
+func (s *EventSeriesService) materializeDue(seriesList []EventSeries, from, to time.Time, due func(Occurrence) bool) error {
+	for _, series := range seriesList {
+		occurrences, err := s.Occurrences(series, from, to)
+		if err != nil {
+			return err
+		}
+		for _, occurrence := range occurrences {
+			if !due(occurrence) {
+				continue
+			}
+			if _, err := s.Materialize(series.ID, occurrence.StartTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// materializeTx saves an occurrence as Materialize does, within the caller's transaction
+func (s *EventSeriesService) materializeTx(tx *sql.Tx, seriesID string, originalStart time.Time) (string, error) {
+	originalStart = occurrenceKey(originalStart)
+
+	if eventID, err := s.existingOccurrence(tx, seriesID, originalStart); err != ErrOccurrenceNotFound {
+		return eventID, err
+	}
+	eventID, claimed, err := s.saveOccurrence(tx, seriesID, originalStart, nil)
+	if err == nil && !claimed {
+		return s.existingOccurrence(tx, seriesID, originalStart)
+	}
+	return eventID, err
+}
+
+// saveOccurrence saves an occurrence as an event in tx. It returns false if another request
+// claimed the occurrence first, in which case nothing is saved.
+func (s *EventSeriesService) saveOccurrence(tx *sql.Tx, seriesID string, originalStart time.Time, setUp func(tx *sql.Tx, eventID string) error) (string, bool, error) {
+	series, err := s.get(tx, seriesID)
+	if err != nil {
+		return "", false, err
+	}
+	if series == nil {
+		return "", false, ErrOccurrenceNotFound
+	}
+	rule, err := series.Rule()
+	if err != nil {
+		return "", false, fmt.Errorf("invalid rule for series %s: %v", seriesID, err)
+	}
+	if !rule.Occurs(series.StartTime, originalStart) {
+		return "", false, ErrOccurrenceNotFound
+	}
+
+	eventID := uuid.New().String()
+	// Claim the occurrence first so two requests can't both save it
+	result, err := tx.Exec(`
+		INSERT OR IGNORE INTO event_series_exceptions (series_id, original_start, event_id)
+		VALUES ($1, $2, $3)
+	`, seriesID, originalStart, eventID)
+	if err != nil {
+		return "", false, fmt.Errorf("error recording occurrence: %v", err)
+	}
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+		return "", false, nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO events (id, group_id, title, description, location, start_time, end_time, created_by, mc_id, visibility, series_id, sequence, capacity, venue_id, time_zone)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, sequence, capacity, venue_id, time_zone FROM event_series WHERE id = $11
+	`, eventID, series.GroupID, series.Title, series.Description, series.Location,
+		originalStart, originalStart.Add(series.EndTime.Sub(series.StartTime)), series.CreatedBy, series.MCID, series.Visibility, series.ID)
+	if err != nil {
+		return "", false, fmt.Errorf("error saving occurrence: %v", err)
+	}
+	if setUp != nil {
+		if err := setUp(tx, eventID); err != nil {
+			return "", false, err
+		}
+	}
+	return eventID, true, nil
+}
+
+// Skip cancels an occurrence that was never saved as an event. Saved occurrences are
+// cancelled like any other event, so ErrOccurrenceNotFound is returned for them.
+func (s *EventSeriesService) Skip(seriesID string, originalStart time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := skipOccurrence(tx, seriesID, originalStart); err != nil {
+		return err
+	}
+	// Calendar apps only pick up the new exception when the series' sequence goes up
+	if _, err := tx.Exec(`UPDATE event_series SET sequence = sequence + 1 WHERE id = $1`, seriesID); err != nil {
+		return fmt.Errorf("error skipping occurrence: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error skipping occurrence: %v", err)
+	}
+	return nil
+}
+
+func skipOccurrence(q queryer, seriesID string, originalStart time.Time) error {
+	result, err := q.Exec(`
+		INSERT INTO event_series_exceptions (series_id, original_start, cancelled)
+		VALUES ($1, $2, TRUE)
+		ON CONFLICT (series_id, original_start) DO UPDATE SET cancelled = TRUE
+		WHERE event_series_exceptions.event_id IS NULL
+	`, seriesID, occurrenceKey(originalStart))
+	if err != nil {
+		return fmt.Errorf("error skipping occurrence: %v", err)
+	}
+	if skipped, err := result.RowsAffected(); err != nil || skipped == 0 {
+		return ErrOccurrenceNotFound
+	}
+	return nil
+}
+
+// existingOccurrence returns the event an occurrence was saved as, or ErrOccurrenceNotFound if it hasn't been
+func (s *EventSeriesService) existingOccurrence(q queryer, seriesID string, originalStart time.Time) (string, error) {
+	var eventID sql.NullString
+	var cancelled bool
+	err := q.QueryRow(`
+		SELECT event_id, cancelled FROM event_series_exceptions
+		WHERE series_id = $1 AND original_start = $2
+	`, seriesID, originalStart).Scan(&eventID, &cancelled)
+	switch {
+	case err == sql.ErrNoRows:
+		return "", ErrOccurrenceNotFound
+	case err != nil:
+		return "", fmt.Errorf("error fetching occurrence: %v", err)
+	case cancelled:
+		return "", ErrOccurrenceCancelled
+	case !eventID.Valid:
+		return "", ErrOccurrenceNotFound
+	}
+	return eventID.String, nil
+}
+
+// SeriesOf returns the series a saved event belongs to and the occurrence it was saved from
+func (s *EventSeriesService) SeriesOf(eventID string) (*EventSeries, time.Time, error) {
+	return s.seriesOf(s.db, eventID)
+}
+
+func (s *EventSeriesService) seriesOf(q queryer, eventID string) (*EventSeries, time.Time, error) {
+	var seriesID string
+	var originalStart time.Time
+	err := q.QueryRow(`
+		SELECT series_id, original_start FROM event_series_exceptions
+		WHERE event_id = $1
+	`, eventID).Scan(&seriesID, &originalStart)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, ErrNotInSeries
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error fetching event series: %v", err)
+	}
+
+	series, err := s.get(q, seriesID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if series == nil {
+		return nil, time.Time{}, ErrNotInSeries
+	}
+	return series, originalStart.In(series.TimeLocation()), nil
+}
+
+// ApplyEdit carries an edit of one saved occurrence over to the following
+// occurrences or the whole series. The edited event itself is left to the caller.
+// Editing "following" splits the series in two at the edited occurrence.
+func (s *EventSeriesService) ApplyEdit(eventID, scope string, edit SeriesEdit) error {
+	if scope == ScopeThis {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	series, originalStart, err := s.seriesOf(tx, eventID)
+	if err != nil {
+		return err
+	}
+	if edit.Visibility == "" {
+		edit.Visibility = series.Visibility
+	}
+	rule, err := series.Rule()
+	if err != nil {
+		return fmt.Errorf("invalid rule for series %s: %v", series.ID, err)
+	}
+
+	// The new rule either replaces the old one or follows the occurrence to its new day
+	var newRule recurrence.Rule
+	if edit.RRule != "" {
+		newRule, err = recurrence.Parse(edit.RRule)
+	} else {
+		newRule, err = rule.ShiftDays(daysBetween(originalStart, originalStart.Add(edit.Shift)))
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEdit, err)
+	}
+
+	targetID := series.ID
+	newStart := occurrenceKey(series.StartTime.Add(edit.Shift))
+	splitAt := time.Time{}
+	if scope == ScopeFollowing && originalStart.After(series.StartTime) {
+		// End the original series before this occurrence and start a new one from it
+		splitAt = originalStart
+		newStart = occurrenceKey(splitAt.Add(edit.Shift))
+		if edit.RRule == "" && rule.Count > 0 {
+			newRule.Count = rule.Count - rule.CountBefore(series.StartTime, splitAt)
+		}
+		_, err = tx.Exec(`
+			UPDATE event_series SET rrule = $1, sequence = sequence + 1 WHERE id = $2
+		`, rule.EndingBefore(splitAt).String(), series.ID)
+		if err != nil {
+			return fmt.Errorf("error ending event series: %v", err)
+		}
+
+		targetID = uuid.New().String()
+		_, err = tx.Exec(`
+			INSERT INTO event_series (id, group_id, title, description, location, start_time, end_time, rrule, mc_id, visibility, time_zone, created_by, capacity, venue_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`, targetID, series.GroupID, edit.Title, edit.Description, edit.Location, newStart, newStart.Add(edit.Duration),
+			newRule.String(), edit.MCID, edit.Visibility, series.TimeZone, series.CreatedBy, edit.Capacity, edit.VenueID)
+		if err != nil {
+			return fmt.Errorf("error splitting event series: %v", err)
+		}
+	} else {
+		_, err = tx.Exec(`
+			UPDATE event_series
+			SET title = $1, description = $2, location = $3, mc_id = $4, start_time = $5, end_time = $6, rrule = $7,
+				capacity = $8, visibility = $9, venue_id = $10, sequence = sequence + 1
+			WHERE id = $11
+		`, edit.Title, edit.Description, edit.Location, edit.MCID, newStart, newStart.Add(edit.Duration), newRule.String(), edit.Capacity, edit.Visibility, edit.VenueID, series.ID)
+		if err != nil {
+			return fmt.Errorf("error updating event series: %v", err)
+		}
+	}
+
+	if err := s.moveOccurrences(tx, series.ID, targetID, splitAt, eventID, newStart.In(series.TimeLocation()), newRule, edit); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error updating event series: %v", err)
+	}
+	return nil
+}
+
+// moveOccurrences moves the saved and cancelled occurrences from splitAt onwards
+// to the target series, shifted along with it, and applies the edit to the saved events.
+// Occurrences that no longer fit a replaced rule become standalone events.
+func (s *EventSeriesService) moveOccurrences(tx *sql.Tx, fromSeriesID, toSeriesID string, splitAt time.Time, editedEventID string, newStart time.Time, newRule recurrence.Rule, edit SeriesEdit) error {
+	rows, err := tx.Query(`
+		SELECT x.original_start, x.event_id, e.start_time
+		FROM event_series_exceptions x
+		LEFT JOIN events e ON x.event_id = e.id
+		WHERE x.series_id = $1
+	`, fromSeriesID)
+	if err != nil {
+		return fmt.Errorf("error fetching series exceptions: %v", err)
+	}
+
+	type exception struct {
+		originalStart time.Time
+		eventID       sql.NullString
+		startTime     sql.NullTime
+	}
+	var exceptions []exception
+	for rows.Next() {
+		var ex exception
+		if err := rows.Scan(&ex.originalStart, &ex.eventID, &ex.startTime); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning series exception: %v", err)
+		}
+		if !ex.originalStart.Before(splitAt) {
+			exceptions = append(exceptions, ex)
+		}
+	}
+	rows.Close()
+
+	// Move keys in the direction of the shift so none lands on one that hasn't moved yet
+	sort.Slice(exceptions, func(i, j int) bool {
+		if edit.Shift > 0 {
+			return exceptions[i].originalStart.After(exceptions[j].originalStart)
+		}
+		return exceptions[i].originalStart.Before(exceptions[j].originalStart)
+	})
+
+	for _, ex := range exceptions {
+		movedStart := occurrenceKey(ex.originalStart.Add(edit.Shift))
+
+		if edit.RRule != "" && !newRule.Occurs(newStart, movedStart) && ex.eventID.String != editedEventID {
+			// The occurrence isn't part of the new pattern, so keep any saved event on its own
+			if _, err := tx.Exec(`
+				DELETE FROM event_series_exceptions WHERE series_id = $1 AND original_start = $2
+			`, fromSeriesID, ex.originalStart); err != nil {
+				return fmt.Errorf("error detaching occurrence: %v", err)
+			}
+			if ex.eventID.Valid {
+				if _, err := tx.Exec(`UPDATE events SET series_id = NULL WHERE id = $1`, ex.eventID.String); err != nil {
+					return fmt.Errorf("error detaching occurrence: %v", err)
+				}
+			}
+			continue
+		}
+
+		_, err := tx.Exec(`
+			UPDATE event_series_exceptions SET series_id = $1, original_start = $2
+			WHERE series_id = $3 AND original_start = $4
+		`, toSeriesID, movedStart, fromSeriesID, ex.originalStart)
+		if err != nil {
+			return fmt.Errorf("error moving occurrence: %v", err)
+		}
+
+		if !ex.eventID.Valid {
+			continue
+		}
+		if ex.eventID.String == editedEventID {
+			_, err = tx.Exec(`UPDATE events SET series_id = $1 WHERE id = $2`, toSeriesID, editedEventID)
+		} else {
+			start := occurrenceKey(ex.startTime.Time.Add(edit.Shift))
+			_, err = tx.Exec(`
+				UPDATE events
+				SET title = $1, description = $2, location = $3, mc_id = $4, start_time = $5, end_time = $6, series_id = $7,
+					visibility = $8, venue_id = $9, sequence = sequence + 1
+				WHERE id = $10
+			`, edit.Title, edit.Description, edit.Location, edit.MCID, start, start.Add(edit.Duration), toSeriesID, edit.Visibility, edit.VenueID, ex.eventID.String)
+		}
+		if err != nil {
+			return fmt.Errorf("error updating occurrence: %v", err)
+		}
+	}
+	return nil
+}
+
+// End ends the series before from, so it has no occurrences from then on. It returns the
+// occurrences from then on that were saved as events and haven't finished or been cancelled.
+// Those have RSVPs, so the caller cancels them like any other event.
+func (s *EventSeriesService) End(seriesID string, from time.Time) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	series, err := s.get(tx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series == nil {
+		return nil, ErrNotInSeries
+	}
+	rule, err := series.Rule()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule for series %s: %v", series.ID, err)
+	}
+	_, err = tx.Exec(`
+		UPDATE event_series SET rrule = $1, sequence = sequence + 1 WHERE id = $2
+	`, rule.EndingBefore(from).String(), series.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error ending event series: %v", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT e.id FROM event_series_exceptions x
+		JOIN events e ON e.id = x.event_id
+		WHERE x.series_id = $1 AND julianday(x.original_start) >= julianday($2) AND NOT x.cancelled
+		  AND e.status IN ('draft', 'scheduled')
+		ORDER BY x.original_start
+	`, series.ID, occurrenceKey(from))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching saved occurrences: %v", err)
+	}
+	var saved []string
+	for rows.Next() {
+		var eventID string
+		if err := rows.Scan(&eventID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning saved occurrence: %v", err)
+		}
+		saved = append(saved, eventID)
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error ending event series: %v", err)
+	}
+	return saved, nil
+}
+
+// daysBetween counts the calendar days from a to b
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	dateA := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// Tuesday 2026-01-06 at 19:00 UTC
+var seriesStart = time.Date(2026, 1, 6, 19, 0, 0, 0, time.UTC)
+
+func createTestSeries(t *testing.T, service *EventSeriesService, rrule string) *EventSeries {
+	t.Helper()
+	series, err := service.Create(EventSeries{
+		GroupID:   "group123",
+		Title:     "Weekly Jam",
+		Location:  "Main Stage",
+		StartTime: seriesStart,
+		EndTime:   seriesStart.Add(2 * time.Hour),
+		RRule:     rrule,
+		CreatedBy: "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error creating series: %v", err)
+	}
+	return series
+}
+
+func occurrenceStarts(t *testing.T, service *EventSeriesService, seriesID string, to time.Time) []string {
+	t.Helper()
+	series, err := service.Get(seriesID)
+	if err != nil || series == nil {
+		t.Fatalf("Error fetching series %s: %v", seriesID, err)
+	}
+	occurrences, err := service.Occurrences(*series, time.Time{}, to)
+	if err != nil {
+		t.Fatalf("Error expanding series: %v", err)
+	}
+	starts := make([]string, len(occurrences))
+	for i, occurrence := range occurrences {
+		starts[i] = occurrence.StartTime.Format("2006-01-02 15:04")
+	}
+	return starts
+}
+
+func assertStarts(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+			return
+		}
+	}
+}
+
+func TestEventSeries_MaterializeIsIdempotent(t *testing.T) {
+	service := NewEventSeriesService(newTestDB(t, seedGroup))
+	series := createTestSeries(t, service, "FREQ=WEEKLY;COUNT=3")
+
+	first, err := service.MaterializeFirst(*series, nil)
+	if err != nil {
+		t.Fatalf("Error saving first occurrence: %v", err)
+	}
+	again, err := service.Materialize(series.ID, seriesStart)
+	if err != nil {
+		t.Fatalf("Error saving occurrence again: %v", err)
+	}
+	if first != again {
+		t.Errorf("Expected the same event, got %s and %s", first, again)
+	}
+
+	if _, err := service.Materialize(series.ID, seriesStart.Add(time.Hour)); err != ErrOccurrenceNotFound {
+		t.Errorf("Expected ErrOccurrenceNotFound for a time off the rule, got %v", err)
+	}
+	if _, err := service.Materialize(series.ID, seriesStart.AddDate(0, 0, 21)); err != ErrOccurrenceNotFound {
+		t.Errorf("Expected ErrOccurrenceNotFound past COUNT, got %v", err)
+	}
+
+	// The saved occurrence is no longer listed as unsaved
+	assertStarts(t, occurrenceStarts(t, service, series.ID, seriesStart.AddDate(1, 0, 0)),
+		"2026-01-13 19:00", "2026-01-20 19:00")
+}
+
+func TestEventSeries_FindOccurrenceDoesNotSave(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	service := NewEventSeriesService(testDB)
+	series := createTestSeries(t, service, "FREQ=WEEKLY;COUNT=3")
+
+	eventID, occurrence, err := service.FindOccurrence(series.ID, seriesStart.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("Error finding occurrence: %v", err)
+	}
+	if eventID != "" || occurrence == nil || !occurrence.StartTime.Equal(seriesStart.AddDate(0, 0, 7)) {
+		t.Fatalf("Expected the unsaved occurrence, got %q and %+v", eventID, occurrence)
+	}
+	var saved int
+	if err := testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE series_id = $1`, series.ID).Scan(&saved); err != nil || saved != 0 {
+		t.Errorf("Expected nothing saved, got %d events (%v)", saved, err)
+	}
+
+	// Once it's saved, finding it returns the event
+	materialized, err := service.Materialize(series.ID, seriesStart.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("Error saving occurrence: %v", err)
+	}
+	if eventID, occurrence, err := service.FindOccurrence(series.ID, seriesStart.AddDate(0, 0, 7)); err != nil || eventID != materialized || occurrence != nil {
+		t.Errorf("Expected event %s, got %q, %+v (%v)", materialized, eventID, occurrence, err)
+	}
+
+	if _, _, err := service.FindOccurrence(series.ID, seriesStart.Add(time.Hour)); err != ErrOccurrenceNotFound {
+		t.Errorf("Expected ErrOccurrenceNotFound for a time off the rule, got %v", err)
+	}
+}
+
+func TestEventSeries_EditAllShiftsSavedOccurrences(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	service := NewEventSeriesService(testDB)
+	series := createTestSeries(t, service, "FREQ=WEEKLY;BYDAY=TU;COUNT=4")
+
+	second, err := service.Materialize(series.ID, seriesStart.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("Error saving occurrence: %v", err)
+	}
+	third, err := service.Materialize(series.ID, seriesStart.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("Error saving occurrence: %v", err)
+	}
+
+	// Move everything a day later and an hour earlier
+	err = service.ApplyEdit(second, ScopeAll, SeriesEdit{
+		Title:    "Wednesday Jam",
+		Location: "Main Stage",
+		Shift:    23 * time.Hour,
+		Duration: 2 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Error editing series: %v", err)
+	}
+
+	updated, err := service.Get(series.ID)
+	if err != nil {
+		t.Fatalf("Error fetching series: %v", err)
+	}
+	if updated.RRule != "FREQ=WEEKLY;BYDAY=WE;COUNT=4" {
+		t.Errorf("Expected the rule to move to Wednesday, got %s", updated.RRule)
+	}
+	assertStarts(t, occurrenceStarts(t, service, series.ID, seriesStart.AddDate(1, 0, 0)),
+		"2026-01-07 18:00", "2026-01-28 18:00")
+
+	// The caller updates the edited event, but the other saved occurrence moves with the series
+	var title string
+	var start time.Time
+	if err := testDB.QueryRow(`SELECT title, start_time FROM events WHERE id = $1`, third).Scan(&title, &start); err != nil {
+		t.Fatalf("Error fetching event: %v", err)
+	}
+	if title != "Wednesday Jam" || !start.Equal(time.Date(2026, 1, 21, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the saved occurrence to move with the series, got %q at %v", title, start)
+	}
+
+	// Saving it again finds it under its new time
+	if again, err := service.Materialize(series.ID, time.Date(2026, 1, 21, 18, 0, 0, 0, time.UTC)); err != nil || again != third {
+		t.Errorf("Expected the moved occurrence to be %s, got %s (%v)", third, again, err)
+	}
+}
+
+func TestEventSeries_EditFollowingSplitsSeries(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	service := NewEventSeriesService(testDB)
+	series := createTestSeries(t, service, "FREQ=WEEKLY;COUNT=5")
+
+	third, err := service.Materialize(series.ID, seriesStart.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("Error saving occurrence: %v", err)
+	}
+	err = service.ApplyEdit(third, ScopeFollowing, SeriesEdit{
+		Title:    "Late Jam",
+		Shift:    time.Hour,
+		Duration: 2 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Error editing series: %v", err)
+	}
+
+	// The original series stops before the edited occurrence
+	assertStarts(t, occurrenceStarts(t, service, series.ID, seriesStart.AddDate(1, 0, 0)),
+		"2026-01-06 19:00", "2026-01-13 19:00")
+
+	// The new series starts at the edited occurrence and keeps the remaining count
+	newSeries, _, err := service.SeriesOf(third)
+	if err != nil {
+		t.Fatalf("Error fetching new series: %v", err)
+	}
+	if newSeries.ID == series.ID || newSeries.Title != "Late Jam" {
+		t.Fatalf("Expected the edited occurrence to move to a new series, got %+v", newSeries)
+	}
+	if newSeries.RRule != "FREQ=WEEKLY;COUNT=3" {
+		t.Errorf("Expected the remaining 3 occurrences, got %s", newSeries.RRule)
+	}
+	assertStarts(t, occurrenceStarts(t, service, newSeries.ID, seriesStart.AddDate(1, 0, 0)),
+		"2026-01-27 20:00", "2026-02-03 20:00")
+}
+
+func TestEventSeries_SkipAndEnd(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	service := NewEventSeriesService(testDB)
+	series := createTestSeries(t, service, "FREQ=WEEKLY")
+
+	if err := service.Skip(series.ID, seriesStart.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("Error skipping occurrence: %v", err)
+	}
+	if _, err := service.Materialize(series.ID, seriesStart.AddDate(0, 0, 7)); err != ErrOccurrenceCancelled {
+		t.Errorf("Expected ErrOccurrenceCancelled, got %v", err)
+	}
+	assertStarts(t, occurrenceStarts(t, service, series.ID, seriesStart.AddDate(0, 0, 21)),
+		"2026-01-06 19:00", "2026-01-20 19:00")
+
+	// Saved occurrences are cancelled as events, so they can't be skipped
+	third, err := service.Materialize(series.ID, seriesStart.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("Error saving occurrence: %v", err)
+	}
+	if err := service.Skip(series.ID, seriesStart.AddDate(0, 0, 14)); err != ErrOccurrenceNotFound {
+		t.Errorf("Expected ErrOccurrenceNotFound skipping a saved occurrence, got %v", err)
+	}
+
+	// Ending the series hands back the saved occurrences from then on
+	saved, err := service.End(series.ID, seriesStart.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("Error ending series: %v", err)
+	}
+	if len(saved) != 1 || saved[0] != third {
+		t.Errorf("Expected the saved third occurrence to cancel, got %v", saved)
+	}
+	assertStarts(t, occurrenceStarts(t, service, series.ID, seriesStart.AddDate(1, 0, 0)),
+		"2026-01-06 19:00")
+
+	if _, err := service.End("not-a-series", seriesStart); err != ErrNotInSeries {
+		t.Errorf("Expected ErrNotInSeries, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Event lifecycle states
+const (
+	// EventStatusDraft events are only seen by the group's admins and organizers
+	EventStatusDraft     = "draft"
+	EventStatusScheduled = "scheduled"
+	// EventStatusCancelled events stay listed, marked cancelled, and take no RSVPs
+	EventStatusCancelled = "cancelled"
+	// EventStatusCompleted events have ended. Scheduled events become completed after their end time.
+	EventStatusCompleted = "completed"
+)
+
+// eventTransitions lists the states each state can move to
+var eventTransitions = map[string][]string{
+	EventStatusDraft:     {EventStatusScheduled, EventStatusCancelled},
+	EventStatusScheduled: {EventStatusDraft, EventStatusCancelled, EventStatusCompleted},
+	EventStatusCancelled: {EventStatusScheduled},
+	EventStatusCompleted: {},
+}
+
+var (
+	// ErrInvalidTransition means the event can't move from its current state to the requested one
+	ErrInvalidTransition = errors.New("invalid event status transition")
+	// ErrEventNotFound means there's no event with the given ID
+	ErrEventNotFound = errors.New("event not found")
+)
+
+// IsValidEventStatus reports whether status is one of the lifecycle states
+func IsValidEventStatus(status string) bool {
+	_, ok := eventTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether an event can move from one state to another
+func CanTransition(from, to string) bool {
+	for _, allowed := range eventTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OccurrenceStatus is the state of a series occurrence that hasn't been saved as an event
+func OccurrenceStatus(endTime, now time.Time) string {
+	if endTime.Before(now) {
+		return EventStatusCompleted
+	}
+	return EventStatusScheduled
+}
+
+// EventStatusService moves events through their lifecycle
+type EventStatusService struct {
+	db *sql.DB
+}
+
+func NewEventStatusService(db *sql.DB) *EventStatusService {
+	return &EventStatusService{db: db}
+}
+
+// Transition moves an event to a new state and returns the state it was in.
+// The reason is kept for cancellations and cleared otherwise.
+func (s *EventStatusService) Transition(eventID, to, reason string) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var from string
+	err = tx.QueryRow(`SELECT status FROM events WHERE id = $1`, eventID).Scan(&from)
+	if err == sql.ErrNoRows {
+		return "", ErrEventNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching event status: %v", err)
+	}
+	if !CanTransition(from, to) {
+		return from, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+
+	var cancellationReason interface{}
+	if to == EventStatusCancelled && reason != "" {
+		cancellationReason = reason
+	}
+	_, err = tx.Exec(`
+		UPDATE events SET status = $1, cancellation_reason = $2, sequence = sequence + 1
+		WHERE id = $3
+	`, to, cancellationReason, eventID)
+	if err != nil {
+		return from, fmt.Errorf("error updating event status: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return from, fmt.Errorf("error updating event status: %v", err)
+	}
+	return from, nil
+}
+
+// CompleteEnded marks scheduled events that ended before now as completed
+func (s *EventStatusService) CompleteEnded(now time.Time) (int64, error) {
+	result, err := s.db.Exec(`
+		UPDATE events SET status = 'completed'
+		WHERE status = 'scheduled' AND julianday(end_time) < julianday($1)
+	`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error completing ended events: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// Start completes ended events in the background every interval
+func (s *EventStatusService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.CompleteEnded(time.Now()); err != nil {
+				log.Printf("Error completing ended events: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"improv-app/internal/query"
+)
+
+func TestEventStatus_Transition(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedPublicShow, seedRehearsal)
+	service := NewEventStatusService(testDB)
+
+	from, err := service.Transition("public1", EventStatusCancelled, "Venue flooded")
+	if err != nil || from != EventStatusScheduled {
+		t.Fatalf("Expected to cancel a scheduled event, got %s (error: %v)", from, err)
+	}
+	var status string
+	var reason *string
+	var sequence int
+	err = testDB.QueryRow(`SELECT status, cancellation_reason, sequence FROM events WHERE id = 'public1'`).Scan(&status, &reason, &sequence)
+	if err != nil {
+		t.Fatalf("Error fetching event: %v", err)
+	}
+	if status != EventStatusCancelled || reason == nil || *reason != "Venue flooded" || sequence != 1 {
+		t.Errorf("Expected a cancelled event with its reason and a new sequence, got %s %v %d", status, reason, sequence)
+	}
+
+	if _, err := service.Transition("public1", EventStatusCompleted, ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected a cancelled event not to be completed, got %v", err)
+	}
+
+	if _, err := service.Transition("public1", EventStatusScheduled, ""); err != nil {
+		t.Fatalf("Error reinstating event: %v", err)
+	}
+	err = testDB.QueryRow(`SELECT status, cancellation_reason FROM events WHERE id = 'public1'`).Scan(&status, &reason)
+	if err != nil || status != EventStatusScheduled || reason != nil {
+		t.Errorf("Expected a reinstated event without a reason, got %s %v (error: %v)", status, reason, err)
+	}
+
+	if _, err := service.Transition("missing", EventStatusCancelled, ""); err != ErrEventNotFound {
+		t.Errorf("Expected ErrEventNotFound, got %v", err)
+	}
+}
+
+func TestEventStatus_CompleteEnded(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedPublicShow, seedRehearsal)
+	_, err := testDB.Exec(`
+		INSERT INTO events (id, group_id, title, start_time, end_time, created_by, status)
+		VALUES ('draft1', 'group123', 'Idea', '2026-02-03 19:00:00', '2026-02-03 21:00:00', 'user123', 'draft'),
+		       ('later1', 'group123', 'Later Show', '2026-03-01 19:00:00', '2026-03-01 21:00:00', 'user123', 'scheduled')
+	`)
+	if err != nil {
+		t.Fatalf("Error seeding events: %v", err)
+	}
+
+	completed, err := NewEventStatusService(testDB).CompleteEnded(time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error completing ended events: %v", err)
+	}
+	if completed != 2 {
+		t.Errorf("Expected the two ended scheduled events to be completed, got %d", completed)
+	}
+
+	statuses := map[string]string{}
+	rows, err := testDB.Query(`SELECT id, status FROM events`)
+	if err != nil {
+		t.Fatalf("Error fetching events: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, status string
+		if err := rows.Scan(&id, &status); err != nil {
+			t.Fatalf("Error scanning event: %v", err)
+		}
+		statuses[id] = status
+	}
+	if statuses["public1"] != EventStatusCompleted || statuses["draft1"] != EventStatusDraft || statuses["later1"] != EventStatusScheduled {
+		t.Errorf("Expected only ended scheduled events to be completed, got %v", statuses)
+	}
+}
+
+func TestVisibleEventsCondition_Drafts(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedPublicShow, seedRehearsal)
+	_, err := testDB.Exec(`
+		INSERT INTO users (id, email) VALUES ('member', 'member@example.com');
+		INSERT INTO group_members (group_id, user_id, role) VALUES ('group123', 'member', 'member');
+		UPDATE events SET status = 'draft' WHERE id = 'private1';
+	`)
+	if err != nil {
+		t.Fatalf("Error seeding draft: %v", err)
+	}
+
+	sees := func(userID string) bool {
+		var visible bool
+		err := testDB.QueryRow(`SELECT EXISTS(SELECT 1 `+query.VisibleEventsFrom+` WHERE e.id = 'private1' AND `+query.VisibleEventsCondition+`)`, userID).Scan(&visible)
+		if err != nil {
+			t.Fatalf("Error checking visibility: %v", err)
+		}
+		return visible
+	}
+	if !sees("user123") {
+		t.Error("Expected admins to see drafts")
+	}
+	if sees("member") {
+		t.Error("Expected members not to see drafts")
+	}
+}
+
+func TestCalendarFeed_EventStatus(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedPublicShow, seedRehearsal)
+	_, err := testDB.Exec(`
+		UPDATE events SET status = 'cancelled' WHERE id = 'public1';
+		UPDATE events SET status = 'draft' WHERE id = 'private1';
+	`)
+	if err != nil {
+		t.Fatalf("Error updating statuses: %v", err)
+	}
+	service := NewCalendarFeedService(testDB)
+	token, err := service.FeedToken("user123", "")
+	if err != nil {
+		t.Fatalf("Error getting feed token: %v", err)
+	}
+	feed := renderFeed(t, service, token)
+
+	if !strings.Contains(feed, "STATUS:CANCELLED\r\n") {
+		t.Errorf("Expected the cancelled event to be marked cancelled, got:\n%s", feed)
+	}
+	if strings.Contains(feed, "UID:private1@improv-app") {
+		t.Errorf("Expected drafts to be left out of feeds, got:\n%s", feed)
+	}
+}
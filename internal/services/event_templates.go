@@ -0,0 +1,274 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidTemplate means a template is missing something it needs or refers to a game the group can't use
+var ErrInvalidTemplate = errors.New("invalid event template")
+
+// TemplateGame is a game in a template's lineup
+type TemplateGame struct {
+	GameID   string `json:"gameId"`
+	GameName string `json:"gameName,omitempty"`
+	// TargetPlayers is how many players the game should have, when the format calls for a number
+	TargetPlayers *int `json:"targetPlayers,omitempty"`
+}
+
+// EventTemplate is a group's named show format, used to prefill new events
+type EventTemplate struct {
+	ID      string `json:"id"`
+	GroupID string `json:"groupId"`
+	Name    string `json:"name"`
+	// TitlePattern is the new event's title. {weekday}, {date} and {month} are replaced with the event's start.
+	TitlePattern    string `json:"titlePattern"`
+	DurationMinutes int    `json:"durationMinutes"`
+	Location        string `json:"location"`
+	// Visibility is left out to use the group's default
+	Visibility *string `json:"visibility,omitempty"`
+	Capacity   *int    `json:"capacity,omitempty"`
+	// Games are in lineup order
+	Games     []TemplateGame `json:"games"`
+	CreatedBy string         `json:"createdBy"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// Validate checks the template has a name, title and length, and sensible limits
+func (t EventTemplate) Validate() error {
+	switch {
+	case strings.TrimSpace(t.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+	case strings.TrimSpace(t.TitlePattern) == "":
+		return fmt.Errorf("%w: title pattern is required", ErrInvalidTemplate)
+	case t.DurationMinutes < 1:
+		return fmt.Errorf("%w: duration must be at least 1 minute", ErrInvalidTemplate)
+	case t.Visibility != nil && !IsValidVisibility(*t.Visibility):
+		return fmt.Errorf("%w: visibility must be private, members-and-followers or public", ErrInvalidTemplate)
+	case t.Capacity != nil && *t.Capacity < 1:
+		return fmt.Errorf("%w: capacity must be at least 1", ErrInvalidTemplate)
+	}
+	seen := map[string]bool{}
+	for _, game := range t.Games {
+		if seen[game.GameID] {
+			return fmt.Errorf("%w: a game can only be in the lineup once", ErrInvalidTemplate)
+		}
+		seen[game.GameID] = true
+		if game.TargetPlayers != nil && *game.TargetPlayers < 1 {
+			return fmt.Errorf("%w: target players must be at least 1", ErrInvalidTemplate)
+		}
+	}
+	return nil
+}
+
+// Title fills in the title pattern for an event starting at start
+func (t EventTemplate) Title(start time.Time) string {
+	return strings.NewReplacer(
+		"{weekday}", start.Format("Monday"),
+		"{date}", start.Format("Jan 2"),
+		"{month}", start.Format("January"),
+	).Replace(t.TitlePattern)
+}
+
+// EventTemplateService stores groups' event templates
+type EventTemplateService struct {
+	db *sql.DB
+}
+
+func NewEventTemplateService(db *sql.DB) *EventTemplateService {
+	return &EventTemplateService{db: db}
+}
+
+const eventTemplateColumns = `
+	id, group_id, name, title_pattern, duration_minutes, location, visibility, capacity, created_by, created_at
+`
+
+func scanEventTemplate(scanner interface{ Scan(...interface{}) error }) (EventTemplate, error) {
+	var template EventTemplate
+	err := scanner.Scan(&template.ID, &template.GroupID, &template.Name, &template.TitlePattern, &template.DurationMinutes,
+		&template.Location, &template.Visibility, &template.Capacity, &template.CreatedBy, &template.CreatedAt)
+	return template, err
+}
+
+// List returns the group's templates by name
+func (s *EventTemplateService) List(groupID string) ([]EventTemplate, error) {
+	rows, err := s.db.Query(`
+		SELECT `+eventTemplateColumns+`
+		FROM event_templates
+		WHERE group_id = $1
+		ORDER BY name
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event templates: %v", err)
+	}
+	defer rows.Close()
+
+	templates := []EventTemplate{}
+	for rows.Next() {
+		template, err := scanEventTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning event template: %v", err)
+		}
+		templates = append(templates, template)
+	}
+	rows.Close()
+
+	for i := range templates {
+		if templates[i].Games, err = s.games(templates[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+// Get returns one of the group's templates, or nil if it doesn't exist
+func (s *EventTemplateService) Get(groupID, templateID string) (*EventTemplate, error) {
+	template, err := scanEventTemplate(s.db.QueryRow(`
+		SELECT `+eventTemplateColumns+`
+		FROM event_templates
+		WHERE id = $1 AND group_id = $2
+	`, templateID, groupID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event template: %v", err)
+	}
+	if template.Games, err = s.games(template.ID); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Save creates the template when it has no ID, otherwise updates it, replacing its lineup
+func (s *EventTemplateService) Save(template EventTemplate) (*EventTemplate, error) {
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if template.ID == "" {
+		template.ID = uuid.New().String()
+		_, err = tx.Exec(`
+			INSERT INTO event_templates (id, group_id, name, title_pattern, duration_minutes, location, visibility, capacity, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, template.ID, template.GroupID, template.Name, template.TitlePattern, template.DurationMinutes,
+			template.Location, template.Visibility, template.Capacity, template.CreatedBy)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE event_templates
+			SET name = $1, title_pattern = $2, duration_minutes = $3, location = $4, visibility = $5, capacity = $6
+			WHERE id = $7 AND group_id = $8
+		`, template.Name, template.TitlePattern, template.DurationMinutes, template.Location,
+			template.Visibility, template.Capacity, template.ID, template.GroupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error saving event template: %v", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM event_template_games WHERE template_id = $1`, template.ID); err != nil {
+		return nil, fmt.Errorf("error clearing event template games: %v", err)
+	}
+	for i, game := range template.Games {
+		// Same rule as adding a game to an event: it must be the group's own or in its library
+		var usable bool
+		err := tx.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM games g
+				LEFT JOIN group_game_libraries l ON g.id = l.game_id
+				WHERE g.id = $1 AND (g.group_id = $2 OR l.group_id = $2)
+			)
+		`, game.GameID, template.GroupID).Scan(&usable)
+		if err != nil {
+			return nil, fmt.Errorf("error checking event template game: %v", err)
+		}
+		if !usable {
+			return nil, fmt.Errorf("%w: game %s is not in the group's library", ErrInvalidTemplate, game.GameID)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO event_template_games (template_id, game_id, order_index, target_players)
+			VALUES ($1, $2, $3, $4)
+		`, template.ID, game.GameID, i, game.TargetPlayers)
+		if err != nil {
+			return nil, fmt.Errorf("error saving event template game: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error saving event template: %v", err)
+	}
+	return s.Get(template.GroupID, template.ID)
+}
+
+// Delete removes a template. Events created from it are left as they are.
+func (s *EventTemplateService) Delete(groupID, templateID string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM event_template_games
+		WHERE template_id IN (SELECT id FROM event_templates WHERE id = $1 AND group_id = $2)
+	`, templateID, groupID)
+	if err != nil {
+		return fmt.Errorf("error deleting event template games: %v", err)
+	}
+	_, err = s.db.Exec(`DELETE FROM event_templates WHERE id = $1 AND group_id = $2`, templateID, groupID)
+	if err != nil {
+		return fmt.Errorf("error deleting event template: %v", err)
+	}
+	return nil
+}
+
+// AddGames copies the template's lineup onto an event, after any games it already has. It runs
+// in the transaction that saves the event, so the event isn't saved without its lineup.
+func (s *EventTemplateService) AddGames(tx *sql.Tx, templateID, eventID string) error {
+	var next int
+	err := tx.QueryRow(`
+		SELECT COALESCE(MAX(order_index), -1) + 1 FROM event_games WHERE event_id = $1
+	`, eventID).Scan(&next)
+	if err != nil {
+		return fmt.Errorf("error fetching event game order: %v", err)
+	}
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO event_games (event_id, game_id, order_index, target_players)
+		SELECT $1, game_id, order_index + $2, target_players
+		FROM event_template_games
+		WHERE template_id = $3
+	`, eventID, next, templateID)
+	if err != nil {
+		return fmt.Errorf("error adding template games to event: %v", err)
+	}
+	return nil
+}
+
+// games returns a template's lineup in order
+func (s *EventTemplateService) games(templateID string) ([]TemplateGame, error) {
+	rows, err := s.db.Query(`
+		SELECT tg.game_id, g.name, tg.target_players
+		FROM event_template_games tg
+		JOIN games g ON tg.game_id = g.id
+		WHERE tg.template_id = $1
+		ORDER BY tg.order_index
+	`, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event template games: %v", err)
+	}
+	defer rows.Close()
+
+	games := []TemplateGame{}
+	for rows.Next() {
+		var game TemplateGame
+		if err := rows.Scan(&game.GameID, &game.GameName, &game.TargetPlayers); err != nil {
+			return nil, fmt.Errorf("error scanning event template game: %v", err)
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventTemplate_Title(t *testing.T) {
+	template := EventTemplate{TitlePattern: "{month} Cage Match ({weekday} {date})"}
+	title := template.Title(time.Date(2026, 3, 6, 20, 0, 0, 0, time.UTC))
+	if title != "March Cage Match (Friday Mar 6)" {
+		t.Errorf("Unexpected title %q", title)
+	}
+}
+
+func TestEventTemplates_SaveAndAddGames(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedPublicShow)
+	_, err := testDB.Exec(`
+		INSERT INTO improv_groups (id, name, created_by) VALUES ('other-group', 'Other Group', 'user123');
+		INSERT INTO games (id, name, min_players, max_players, created_by, group_id)
+		VALUES ('game1', 'Freeze Tag', 2, 6, 'user123', 'group123'),
+		       ('game2', 'Party Quirks', 4, 4, 'user123', 'group123'),
+		       ('game3', 'Other Game', 2, 4, 'user123', 'other-group');
+		INSERT INTO event_games (event_id, game_id, order_index) VALUES ('public1', 'game1', 0);
+	`)
+	if err != nil {
+		t.Fatalf("Error seeding games: %v", err)
+	}
+	service := NewEventTemplateService(testDB)
+
+	four := 4
+	template := EventTemplate{
+		GroupID:         "group123",
+		Name:            "Friday Cage Match",
+		TitlePattern:    "Cage Match {date}",
+		DurationMinutes: 90,
+		Games:           []TemplateGame{{GameID: "game2", TargetPlayers: &four}, {GameID: "game1"}},
+		CreatedBy:       "user123",
+	}
+	if _, err := service.Save(EventTemplate{GroupID: "group123", TitlePattern: "Untitled", DurationMinutes: 60}); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("Expected a template without a name to be rejected, got %v", err)
+	}
+	invalid := template
+	invalid.Games = []TemplateGame{{GameID: "game3"}}
+	if _, err := service.Save(invalid); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("Expected a game outside the group's library to be rejected, got %v", err)
+	}
+
+	saved, err := service.Save(template)
+	if err != nil {
+		t.Fatalf("Error saving template: %v", err)
+	}
+	if len(saved.Games) != 2 || saved.Games[0].GameID != "game2" || saved.Games[0].GameName != "Party Quirks" || *saved.Games[0].TargetPlayers != 4 {
+		t.Errorf("Expected the lineup in order with target players, got %+v", saved.Games)
+	}
+
+	saved.Games = saved.Games[:1]
+	if saved, err = service.Save(*saved); err != nil || len(saved.Games) != 1 {
+		t.Fatalf("Expected the lineup to be replaced, got %+v (error: %v)", saved, err)
+	}
+
+	// The template's games go after the event's own, skipping any it already has
+	saved.Games = append(saved.Games, TemplateGame{GameID: "game1"})
+	if _, err := service.Save(*saved); err != nil {
+		t.Fatalf("Error saving template: %v", err)
+	}
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatalf("Error starting transaction: %v", err)
+	}
+	if err := service.AddGames(tx, saved.ID, "public1"); err != nil {
+		t.Fatalf("Error adding template games: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Error committing template games: %v", err)
+	}
+	var lineup string
+	err = testDB.QueryRow(`
+		SELECT GROUP_CONCAT(game_id || ':' || order_index || ':' || COALESCE(target_players, '-'))
+		FROM (SELECT * FROM event_games WHERE event_id = 'public1' ORDER BY order_index)
+	`).Scan(&lineup)
+	if err != nil || lineup != "game1:0:-,game2:1:4" {
+		t.Errorf("Unexpected lineup %s (error: %v)", lineup, err)
+	}
+
+	if err := service.Delete("group123", saved.ID); err != nil {
+		t.Fatalf("Error deleting template: %v", err)
+	}
+	if deleted, err := service.Get("group123", saved.ID); err != nil || deleted != nil {
+		t.Errorf("Expected the template to be gone, got %+v (error: %v)", deleted, err)
+	}
+}
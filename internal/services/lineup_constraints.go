@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"improv-app/internal/lineup"
+
+	"github.com/google/uuid"
+)
+
+// ErrConstraintNotFound means the casting rule isn't one of the event's
+var ErrConstraintNotFound = errors.New("lineup constraint not found")
+
+// constraints returns the event's casting rules, oldest first
+func (s *LineupService) constraints(q queryer, eventID string) ([]lineup.Constraint, error) {
+	rows, err := q.Query(`
+		SELECT id, kind, player_ids, COALESCE(game_id, ''), limit_value
+		FROM event_lineup_constraints
+		WHERE event_id = $1
+		ORDER BY created_at, id
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lineup constraints: %v", err)
+	}
+	defer rows.Close()
+
+	constraints := []lineup.Constraint{}
+	for rows.Next() {
+		var constraint lineup.Constraint
+		var playerIDs string
+		if err := rows.Scan(&constraint.ID, &constraint.Kind, &playerIDs, &constraint.GameID, &constraint.Limit); err != nil {
+			return nil, fmt.Errorf("error scanning lineup constraint: %v", err)
+		}
+		constraint.PlayerIDs = []string{}
+		for _, playerID := range strings.Split(playerIDs, ",") {
+			if playerID != "" {
+				constraint.PlayerIDs = append(constraint.PlayerIDs, playerID)
+			}
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints, rows.Err()
+}
+
+// Constraints returns the event's casting rules, oldest first
+func (s *LineupService) Constraints(eventID string) ([]lineup.Constraint, error) {
+	return s.constraints(s.db, eventID)
+}
+
+// SaveConstraint adds a casting rule to the event, or replaces one when it has an ID. Its
+// players must be members of the event's group or walk-ins at the event, and its game in the lineup.
+func (s *LineupService) SaveConstraint(eventID string, constraint lineup.Constraint, userID string) (*lineup.Constraint, error) {
+	if err := constraint.Validate(); err != nil {
+		return nil, err
+	}
+	pool, err := s.pool(s.db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	for _, playerID := range constraint.PlayerIDs {
+		if _, ok := pool.input.Names[playerID]; !ok {
+			return nil, fmt.Errorf("%w: %s isn't a member or walk-in", lineup.ErrInvalidConstraint, playerID)
+		}
+	}
+	if _, ok := pool.gameNames[constraint.GameID]; constraint.GameID != "" && !ok {
+		return nil, fmt.Errorf("%w: the game isn't in the lineup", lineup.ErrInvalidConstraint)
+	}
+
+	var gameID interface{}
+	if constraint.GameID != "" {
+		gameID = constraint.GameID
+	}
+	playerIDs := strings.Join(constraint.PlayerIDs, ",")
+	if constraint.ID == "" {
+		constraint.ID = uuid.New().String()
+		_, err = s.db.Exec(`
+			INSERT INTO event_lineup_constraints (id, event_id, kind, player_ids, game_id, limit_value, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, constraint.ID, eventID, constraint.Kind, playerIDs, gameID, constraint.Limit, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error saving lineup constraint: %v", err)
+		}
+		return &constraint, nil
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE event_lineup_constraints SET kind = $1, player_ids = $2, game_id = $3, limit_value = $4
+		WHERE id = $5 AND event_id = $6
+	`, constraint.Kind, playerIDs, gameID, constraint.Limit, constraint.ID, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error saving lineup constraint: %v", err)
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return nil, ErrConstraintNotFound
+	}
+	return &constraint, nil
+}
+
+// DeleteConstraint removes a casting rule from the event
+func (s *LineupService) DeleteConstraint(eventID, constraintID string) error {
+	result, err := s.db.Exec(`
+		DELETE FROM event_lineup_constraints WHERE id = $1 AND event_id = $2
+	`, constraintID, eventID)
+	if err != nil {
+		return fmt.Errorf("error deleting lineup constraint: %v", err)
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return ErrConstraintNotFound
+	}
+	return nil
+}
+
+// assignments returns the event's current player assignments
+func (s *LineupService) assignments(eventID string) ([]lineup.Assignment, error) {
+	rows, err := s.db.Query(`
+		SELECT game_id, user_id FROM event_player_assignments
+		WHERE event_id = $1
+		ORDER BY created_at, game_id, user_id
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching assignments: %v", err)
+	}
+	defer rows.Close()
+
+	assignments := []lineup.Assignment{}
+	for rows.Next() {
+		var assignment lineup.Assignment
+		if err := rows.Scan(&assignment.GameID, &assignment.PlayerID); err != nil {
+			return nil, fmt.Errorf("error scanning assignment: %v", err)
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments, rows.Err()
+}
+
+// Violations returns the casting rules the event's current assignments break
+func (s *LineupService) Violations(eventID string) ([]lineup.Violation, error) {
+	pool, err := s.pool(s.db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.assignments(eventID)
+	if err != nil {
+		return nil, err
+	}
+	return lineup.Check(pool.input, assignments), nil
+}
+
+// CheckAssignment returns the casting rules putting the player in the game would break
+func (s *LineupService) CheckAssignment(eventID, gameID, playerID string) ([]lineup.Violation, error) {
+	pool, err := s.pool(s.db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.assignments(eventID)
+	if err != nil {
+		return nil, err
+	}
+	return lineup.Breaks(pool.input, assignments, lineup.Assignment{GameID: gameID, PlayerID: playerID}), nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"improv-app/internal/lineup"
+)
+
+func TestLineup_Constraints(t *testing.T) {
+	service := newLineupTestService(t)
+
+	invalid := []lineup.Constraint{
+		{Kind: lineup.ConstraintKeepApart, PlayerIDs: []string{"user123", "outsider"}},
+		{Kind: lineup.ConstraintNewcomerSlots, GameID: "game3", Limit: 1},
+		{Kind: lineup.ConstraintPair, PlayerIDs: []string{"user123"}},
+	}
+	for _, constraint := range invalid {
+		if _, err := service.SaveConstraint("public1", constraint, "user123"); !errors.Is(err, lineup.ErrInvalidConstraint) {
+			t.Errorf("Expected ErrInvalidConstraint for %+v, got %v", constraint, err)
+		}
+	}
+
+	apart, err := service.SaveConstraint("public1", lineup.Constraint{Kind: lineup.ConstraintKeepApart, PlayerIDs: []string{"user123", "dana"}}, "user123")
+	if err != nil {
+		t.Fatalf("Error saving constraint: %v", err)
+	}
+	if _, err := service.SaveConstraint("public1", lineup.Constraint{Kind: lineup.ConstraintMustPlay, PlayerIDs: []string{"walk1"}, GameID: "game1"}, "user123"); err != nil {
+		t.Fatalf("Error saving constraint: %v", err)
+	}
+
+	// Dana is only in Party Quirks, so only Wally's missing game is a violation
+	violations, err := service.Violations("public1")
+	if err != nil {
+		t.Fatalf("Error checking violations: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Message != "Wally Walker must play Freeze Tag" {
+		t.Errorf("Expected Wally's must-play broken, got %+v", violations)
+	}
+
+	broken, err := service.CheckAssignment("public1", "game2", "user123")
+	if err != nil {
+		t.Fatalf("Error checking assignment: %v", err)
+	}
+	if len(broken) != 1 || broken[0].ConstraintID != apart.ID || broken[0].Message != "Ada Admin and Dana Director are kept apart but are both in Party Quirks" {
+		t.Errorf("Expected Ada and Dana kept apart, got %+v", broken)
+	}
+
+	// The generator follows the rules
+	proposal, err := service.Generate("public1")
+	if err != nil {
+		t.Fatalf("Error generating lineup: %v", err)
+	}
+	games := map[string]map[string]bool{}
+	for _, assignment := range proposal.Assignments {
+		if games[assignment.GameID] == nil {
+			games[assignment.GameID] = map[string]bool{}
+		}
+		games[assignment.GameID][assignment.UserID] = true
+	}
+	for gameID, cast := range games {
+		if cast["user123"] && cast["dana"] {
+			t.Errorf("Expected Ada and Dana kept apart, both are in %s", gameID)
+		}
+	}
+	if !games["game1"]["walk1"] || len(proposal.Violations) != 0 {
+		t.Errorf("Expected Wally in Freeze Tag and no violations, got %v and %+v", games, proposal.Violations)
+	}
+
+	apart.PlayerIDs = []string{"dana", "walk1"}
+	if _, err := service.SaveConstraint("public1", *apart, "user123"); err != nil {
+		t.Fatalf("Error updating constraint: %v", err)
+	}
+	if err := service.DeleteConstraint("public1", apart.ID); err != nil {
+		t.Fatalf("Error deleting constraint: %v", err)
+	}
+	if err := service.DeleteConstraint("public1", apart.ID); !errors.Is(err, ErrConstraintNotFound) {
+		t.Errorf("Expected ErrConstraintNotFound deleting twice, got %v", err)
+	}
+	apart.Kind = lineup.ConstraintPair
+	if _, err := service.SaveConstraint("public1", *apart, "user123"); !errors.Is(err, ErrConstraintNotFound) {
+		t.Errorf("Expected ErrConstraintNotFound updating a deleted constraint, got %v", err)
+	}
+	if constraints, _ := service.Constraints("public1"); len(constraints) != 1 || constraints[0].Kind != lineup.ConstraintMustPlay {
+		t.Errorf("Expected only the must-play rule left, got %+v", constraints)
+	}
+}
+
+func TestLineup_Newcomers(t *testing.T) {
+	service := newLineupTestService(t)
+	// Ada played in an earlier show; Dana only in this one
+	seed(t, service.db,
+		`INSERT INTO events (id, group_id, title, start_time, end_time, created_by)
+		 VALUES ('old1', 'group123', 'Old Show', '2025-01-01 19:00:00', '2025-01-01 21:00:00', 'user123')`,
+		`INSERT INTO event_player_assignments (event_id, game_id, user_id) VALUES ('old1', 'game1', 'user123')`,
+	)
+
+	pool, err := service.pool(service.db, "public1")
+	if err != nil {
+		t.Fatalf("Error loading players: %v", err)
+	}
+	want := map[string]bool{"user123": false, "dana": true, "walk1": true}
+	for playerID, newcomer := range want {
+		if pool.players[playerID].IsNewcomer != newcomer {
+			t.Errorf("Expected %s newcomer = %v, got %+v", playerID, newcomer, pool.players[playerID])
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package services
+
+import "improv-app/internal/lineup"
+
+// LineupHealth is how well an event's assignments suit its players' game preferences
+type LineupHealth struct {
+	EventID string `json:"eventId"`
+	// OverallScore rates the lineup from 0 to 100
+	OverallScore int                   `json:"overallScore"`
+	Players      []lineup.PlayerHealth `json:"players"`
+	Suggestions  []string              `json:"suggestions"`
+}
+
+// Health scores the event's current assignments for every player who can be cast, as the
+// lineup page's game health panel does
+func (s *LineupService) Health(eventID string) (*LineupHealth, error) {
+	pool, err := s.pool(s.db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.assignments(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	players := lineup.Analyze(pool.input, assignments)
+	return &LineupHealth{
+		EventID:      eventID,
+		OverallScore: lineup.OverallScore(players),
+		Players:      players,
+		Suggestions:  lineup.Suggestions(pool.input, assignments, players),
+	}, nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"improv-app/internal/lineup"
+)
+
+func TestLineup_Health(t *testing.T) {
+	service := newLineupTestService(t)
+
+	// Only Dana is cast, in Party Quirks which they love. Eve was a no-show, so they aren't scored.
+	health, err := service.Health("public1")
+	if err != nil {
+		t.Fatalf("Error analyzing lineup health: %v", err)
+	}
+	if len(health.Players) != 3 {
+		t.Fatalf("Expected Ada, Dana and Wally scored, got %+v", health.Players)
+	}
+	ada, dana, wally := health.Players[0], health.Players[1], health.Players[2]
+	if ada.PlayerName != "Ada Admin" || len(ada.Problems) != 2 || ada.Problems[0].Type != lineup.ProblemTooFewGames || ada.Problems[1].Description != "Not assigned to 1 loved game(s): Freeze Tag" {
+		t.Errorf("Expected Ada uncast and missing Freeze Tag, got %+v", ada)
+	}
+	if dana.HappinessScore != 2 || len(dana.Problems) != 1 || dana.Problems[0].Description != "Assigned to 1 games, which is above the average of 0.3" {
+		t.Errorf("Expected Dana happy but above average, got %+v", dana)
+	}
+	if wally.UserID != "walk1" || len(wally.Problems) != 1 || wally.Problems[0].Severity != lineup.SeverityHigh {
+		t.Errorf("Expected Wally uncast, got %+v", wally)
+	}
+	if health.OverallScore != 23 {
+		t.Errorf("Expected an overall score of 23, got %d", health.OverallScore)
+	}
+	if len(health.Suggestions) != 5 || health.Suggestions[3] != `"Freeze Tag" needs at least 2 more player(s) to meet minimum requirements.` {
+		t.Errorf("Unexpected suggestions %q", health.Suggestions)
+	}
+
+	// Casting Ada in the game they can't stand is a problem of its own
+	if _, err := service.db.Exec(`INSERT INTO event_player_assignments (event_id, game_id, user_id) VALUES ('public1', 'game2', 'user123')`); err != nil {
+		t.Fatalf("Error assigning Ada: %v", err)
+	}
+	health, err = service.Health("public1")
+	if err != nil {
+		t.Fatalf("Error analyzing lineup health: %v", err)
+	}
+	if ada := health.Players[0]; ada.HappinessScore != -2 || ada.Problems[0].Type != lineup.ProblemDislikedGame {
+		t.Errorf("Expected Ada in a disliked game, got %+v", ada)
+	}
+
+	if _, err := service.Health("missing"); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected ErrEventNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"improv-app/internal/lineup"
+)
+
+var (
+	// ErrLineupChanged means the event's assignments changed after the proposal was generated
+	ErrLineupChanged = errors.New("lineup changed since the proposal was generated")
+	// ErrInvalidLineup means a proposal puts someone in a game that isn't in the lineup, or casts
+	// someone who isn't attending
+	ErrInvalidLineup = errors.New("invalid lineup")
+)
+
+// LineupPlayer is someone who can be cast: a member attending the event or a walk-in
+type LineupPlayer struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsWalkIn bool   `json:"isWalkIn"`
+	// IsNewcomer is a walk-in, or a member who hasn't played in one of the group's earlier events
+	IsNewcomer bool `json:"isNewcomer"`
+}
+
+// ProposedAssignment is a generated assignment with the names needed to show it
+type ProposedAssignment struct {
+	GameID   string `json:"gameId"`
+	GameName string `json:"gameName"`
+	UserID   string `json:"userId"`
+	Name     string `json:"name"`
+	IsWalkIn bool   `json:"isWalkIn"`
+}
+
+// LineupProposal is a generated cast for every game in an event's lineup
+type LineupProposal struct {
+	EventID     string               `json:"eventId"`
+	Assignments []ProposedAssignment `json:"assignments"`
+	Shortfalls  []lineup.Shortfall   `json:"shortfalls"`
+	// Violations are the event's casting rules the proposal couldn't keep
+	Violations []lineup.Violation `json:"violations"`
+	// Score sums the players' preference scores for their games
+	Score int `json:"score"`
+	// Version identifies the assignments the proposal replaces. Accepting it fails once they've changed.
+	Version string `json:"version"`
+}
+
+// lineupPool is an event's games and the players that can be cast into them
+type lineupPool struct {
+	input     lineup.Input
+	gameNames map[string]string
+	players   map[string]LineupPlayer
+}
+
+func (p *lineupPool) proposed(assignment lineup.Assignment) ProposedAssignment {
+	player := p.players[assignment.PlayerID]
+	return ProposedAssignment{
+		GameID:   assignment.GameID,
+		GameName: p.gameNames[assignment.GameID],
+		UserID:   player.ID,
+		Name:     player.Name,
+		IsWalkIn: player.IsWalkIn,
+	}
+}
+
+// LineupService generates casts for an event's games and replaces its assignments with them
+type LineupService struct {
+	db *sql.DB
+}
+
+func NewLineupService(db *sql.DB) *LineupService {
+	return &LineupService{db: db}
+}
+
+// pool loads the event's games in lineup order, the attending members and walk-ins who
+// weren't marked as no-shows, the members' preferences for the games and the event's
+// casting rules
+func (s *LineupService) pool(q queryer, eventID string) (*lineupPool, error) {
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS(SELECT 1 FROM events WHERE id = $1)`, eventID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error fetching event: %v", err)
+	}
+	if !exists {
+		return nil, ErrEventNotFound
+	}
+
+	pool := &lineupPool{
+		input: lineup.Input{
+			Preferences: map[string]map[string]string{},
+			Newcomers:   map[string]bool{},
+			Names:       map[string]string{},
+		},
+		gameNames: map[string]string{},
+		players:   map[string]LineupPlayer{},
+	}
+
+	rows, err := q.Query(`
+		SELECT g.id, g.name, g.min_players, g.max_players, eg.target_players
+		FROM event_games eg
+		JOIN games g ON eg.game_id = g.id
+		WHERE eg.event_id = $1
+		ORDER BY eg.order_index
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lineup: %v", err)
+	}
+	for rows.Next() {
+		var game lineup.Game
+		var name string
+		var targetPlayers sql.NullInt64
+		if err := rows.Scan(&game.ID, &name, &game.MinPlayers, &game.MaxPlayers, &targetPlayers); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning lineup game: %v", err)
+		}
+		if targetPlayers.Valid {
+			target := int(targetPlayers.Int64)
+			game.TargetPlayers = &target
+		}
+		pool.input.Games = append(pool.input.Games, game)
+		pool.gameNames[game.ID] = name
+		pool.input.Names[game.ID] = name
+	}
+	rows.Close()
+
+	rows, err = q.Query(`
+		SELECT u.id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), 0, NOT EXISTS (
+			SELECT 1 FROM event_player_assignments p
+			JOIN events o ON p.event_id = o.id
+			WHERE p.user_id = r.user_id AND o.group_id = e.group_id AND o.start_time < e.start_time
+		)
+		FROM event_rsvps r
+		JOIN events e ON r.event_id = e.id
+		JOIN group_members m ON m.group_id = e.group_id AND m.user_id = r.user_id
+		JOIN users u ON r.user_id = u.id
+		WHERE r.event_id = $1 AND r.status = 'attending'
+		AND NOT EXISTS (
+			SELECT 1 FROM event_attendance a
+			WHERE a.event_id = r.event_id AND a.attendee_id = r.user_id AND a.status = $2
+		)
+		UNION ALL
+		SELECT n.id, n.first_name, n.last_name, 1, 1
+		FROM non_registered_attendees n
+		WHERE n.event_id = $1
+		AND NOT EXISTS (
+			SELECT 1 FROM event_attendance a
+			WHERE a.event_id = n.event_id AND a.attendee_id = n.id AND a.status = $2
+		)
+		ORDER BY 4, 2, 3
+	`, eventID, AttendanceNoShow)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching players: %v", err)
+	}
+	for rows.Next() {
+		var player LineupPlayer
+		var firstName, lastName string
+		if err := rows.Scan(&player.ID, &firstName, &lastName, &player.IsWalkIn, &player.IsNewcomer); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning player: %v", err)
+		}
+		player.Name = firstName + " " + lastName
+		pool.input.Players = append(pool.input.Players, player.ID)
+		pool.players[player.ID] = player
+		pool.input.Newcomers[player.ID] = player.IsNewcomer
+	}
+	rows.Close()
+
+	// Rules can name members who aren't coming, and assignments can outlast an RSVP
+	rows, err = q.Query(`
+		SELECT u.id, COALESCE(u.first_name, ''), COALESCE(u.last_name, '')
+		FROM events e
+		JOIN group_members m ON m.group_id = e.group_id
+		JOIN users u ON m.user_id = u.id
+		WHERE e.id = $1
+		UNION ALL
+		SELECT id, first_name, last_name FROM non_registered_attendees WHERE event_id = $1
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching player names: %v", err)
+	}
+	for rows.Next() {
+		var id, firstName, lastName string
+		if err := rows.Scan(&id, &firstName, &lastName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning player name: %v", err)
+		}
+		pool.input.Names[id] = firstName + " " + lastName
+	}
+	rows.Close()
+
+	if pool.input.Constraints, err = s.constraints(q, eventID); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(`
+		SELECT ugp.user_id, ugp.game_id, ugp.status
+		FROM user_game_preferences ugp
+		JOIN event_games eg ON eg.game_id = ugp.game_id
+		WHERE eg.event_id = $1 AND ugp.status IS NOT NULL
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching game preferences: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID, gameID, status string
+		if err := rows.Scan(&userID, &gameID, &status); err != nil {
+			return nil, fmt.Errorf("error scanning game preference: %v", err)
+		}
+		if _, ok := pool.players[userID]; !ok {
+			continue
+		}
+		if pool.input.Preferences[userID] == nil {
+			pool.input.Preferences[userID] = map[string]string{}
+		}
+		pool.input.Preferences[userID][gameID] = status
+	}
+	return pool, rows.Err()
+}
+
+// version fingerprints the event's current assignments
+func (s *LineupService) version(q queryer, eventID string) (string, error) {
+	rows, err := q.Query(`
+		SELECT game_id, user_id FROM event_player_assignments
+		WHERE event_id = $1
+		ORDER BY game_id, user_id
+	`, eventID)
+	if err != nil {
+		return "", fmt.Errorf("error fetching assignments: %v", err)
+	}
+	defer rows.Close()
+
+	hash := sha256.New()
+	for rows.Next() {
+		var gameID, userID string
+		if err := rows.Scan(&gameID, &userID); err != nil {
+			return "", fmt.Errorf("error scanning assignment: %v", err)
+		}
+		fmt.Fprintf(hash, "%s:%s\n", gameID, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("error fetching assignments: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
+
+// EmptyLineupProposal is the proposal for an event with nothing to cast yet, such as an occurrence
+// that hasn't been saved. Its version matches an event without assignments, so accepting it works
+// once the event is saved.
+func EmptyLineupProposal(eventID string) *LineupProposal {
+	hash := sha256.Sum256(nil)
+	return &LineupProposal{
+		EventID:     eventID,
+		Assignments: []ProposedAssignment{},
+		Version:     hex.EncodeToString(hash[:])[:16],
+	}
+}
+
+// Generate proposes a cast for every game in the event's lineup. Nothing is saved until it's accepted.
+func (s *LineupService) Generate(eventID string) (*LineupProposal, error) {
+	pool, err := s.pool(s.db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.version(s.db, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := lineup.Generate(pool.input)
+	proposal := &LineupProposal{
+		EventID:     eventID,
+		Assignments: make([]ProposedAssignment, len(result.Assignments)),
+		Shortfalls:  result.Shortfalls,
+		Violations:  result.Violations,
+		Score:       result.Score,
+		Version:     version,
+	}
+	for i, assignment := range result.Assignments {
+		proposal.Assignments[i] = pool.proposed(assignment)
+	}
+	return proposal, nil
+}
+
+// Accept replaces all of the event's assignments with the proposal's, as long as they haven't
+// changed since its version. It returns the assignments that weren't already in the lineup.
+func (s *LineupService) Accept(eventID, version string, assignments []lineup.Assignment) ([]ProposedAssignment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	pool, err := s.pool(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.version(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if current != version {
+		return nil, ErrLineupChanged
+	}
+
+	seen := map[lineup.Assignment]bool{}
+	for _, assignment := range assignments {
+		if _, ok := pool.gameNames[assignment.GameID]; !ok {
+			return nil, fmt.Errorf("%w: game %s isn't in the lineup", ErrInvalidLineup, assignment.GameID)
+		}
+		if _, ok := pool.players[assignment.PlayerID]; !ok {
+			return nil, fmt.Errorf("%w: %s isn't attending", ErrInvalidLineup, assignment.PlayerID)
+		}
+		seen[assignment] = false
+	}
+
+	rows, err := tx.Query(`SELECT game_id, user_id FROM event_player_assignments WHERE event_id = $1`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching assignments: %v", err)
+	}
+	for rows.Next() {
+		var assignment lineup.Assignment
+		if err := rows.Scan(&assignment.GameID, &assignment.PlayerID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning assignment: %v", err)
+		}
+		if _, ok := seen[assignment]; ok {
+			seen[assignment] = true
+		}
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(`DELETE FROM event_player_assignments WHERE event_id = $1`, eventID); err != nil {
+		return nil, fmt.Errorf("error clearing assignments: %v", err)
+	}
+	added := []ProposedAssignment{}
+	for _, assignment := range assignments {
+		existed, ok := seen[assignment]
+		if !ok {
+			continue // A duplicate, already saved
+		}
+		delete(seen, assignment)
+		_, err := tx.Exec(`
+			INSERT INTO event_player_assignments (event_id, game_id, user_id)
+			VALUES ($1, $2, $3)
+		`, eventID, assignment.GameID, assignment.PlayerID)
+		if err != nil {
+			return nil, fmt.Errorf("error saving assignment: %v", err)
+		}
+		if !existed {
+			added = append(added, pool.proposed(assignment))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing lineup: %v", err)
+	}
+	return added, nil
+}
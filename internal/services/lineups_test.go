@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"improv-app/internal/lineup"
+)
+
+// newLineupTestService casts public1 from Ada, who loves Freeze Tag and can't stand Party Quirks,
+// Dana, who loves Party Quirks, and Wally, a walk-in. Eve was a no-show and the outsider isn't a member.
+func newLineupTestService(t *testing.T) *LineupService {
+	t.Helper()
+	testDB := newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedDana)
+	seed(t, testDB,
+		`INSERT INTO users (id, email, first_name, last_name) VALUES ('eve', 'eve@example.com', 'Eve', 'Absent')`,
+		`INSERT INTO group_members (group_id, user_id, role) VALUES ('group123', 'eve', 'member')`,
+		`INSERT INTO event_rsvps (event_id, user_id, status) VALUES
+			('public1', 'dana', 'attending'), ('public1', 'eve', 'attending'), ('public1', 'outsider', 'attending')`,
+		`INSERT INTO event_attendance (event_id, attendee_id, attendee_type, status, method, recorded_by)
+		 VALUES ('public1', 'eve', 'member', 'no-show', 'organizer', 'user123')`,
+		`INSERT INTO non_registered_attendees (id, event_id, first_name, last_name) VALUES ('walk1', 'public1', 'Wally', 'Walker')`,
+		`INSERT INTO games (id, name, min_players, max_players, created_by, group_id)
+		 VALUES ('game1', 'Freeze Tag', 2, 6, 'user123', 'group123'), ('game2', 'Party Quirks', 2, 4, 'user123', 'group123')`,
+		`INSERT INTO event_games (event_id, game_id, order_index, target_players) VALUES ('public1', 'game1', 0, 3), ('public1', 'game2', 1, NULL)`,
+		`INSERT INTO user_game_preferences (user_id, game_id, status) VALUES
+			('user123', 'game1', 'I Love playing this'), ('user123', 'game2', 'I dont like this game'),
+			('dana', 'game2', 'I Love playing this'), ('eve', 'game1', 'I Love playing this')`,
+		`INSERT INTO event_player_assignments (event_id, game_id, user_id) VALUES ('public1', 'game2', 'dana')`,
+	)
+	return NewLineupService(testDB)
+}
+
+func TestLineup_Generate(t *testing.T) {
+	service := newLineupTestService(t)
+
+	proposal, err := service.Generate("public1")
+	if err != nil {
+		t.Fatalf("Error generating lineup: %v", err)
+	}
+	want := []ProposedAssignment{
+		{GameID: "game1", GameName: "Freeze Tag", UserID: "user123", Name: "Ada Admin"},
+		{GameID: "game1", GameName: "Freeze Tag", UserID: "dana", Name: "Dana Director"},
+		{GameID: "game1", GameName: "Freeze Tag", UserID: "walk1", Name: "Wally Walker", IsWalkIn: true},
+		{GameID: "game2", GameName: "Party Quirks", UserID: "dana", Name: "Dana Director"},
+		{GameID: "game2", GameName: "Party Quirks", UserID: "walk1", Name: "Wally Walker", IsWalkIn: true},
+	}
+	if len(proposal.Assignments) != len(want) {
+		t.Fatalf("Expected %d assignments, got %+v", len(want), proposal.Assignments)
+	}
+	for i := range want {
+		if proposal.Assignments[i] != want[i] {
+			t.Errorf("Assignment %d: expected %+v, got %+v", i, want[i], proposal.Assignments[i])
+		}
+	}
+	if proposal.Score != 4 || len(proposal.Shortfalls) != 0 || proposal.Version == "" {
+		t.Errorf("Expected a score of 4, no shortfalls and a version, got %+v", proposal)
+	}
+
+	if _, err := service.Generate("missing"); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected ErrEventNotFound, got %v", err)
+	}
+}
+
+func TestLineup_Accept(t *testing.T) {
+	service := newLineupTestService(t)
+	proposal, err := service.Generate("public1")
+	if err != nil {
+		t.Fatalf("Error generating lineup: %v", err)
+	}
+	assignments := make([]lineup.Assignment, len(proposal.Assignments))
+	for i, assignment := range proposal.Assignments {
+		assignments[i] = lineup.Assignment{GameID: assignment.GameID, PlayerID: assignment.UserID}
+	}
+
+	added, err := service.Accept("public1", proposal.Version, assignments)
+	if err != nil {
+		t.Fatalf("Error accepting lineup: %v", err)
+	}
+	// Dana was already in Party Quirks
+	if len(added) != 4 {
+		t.Errorf("Expected 4 new assignments, got %v", added)
+	}
+	var count int
+	service.db.QueryRow(`SELECT COUNT(*) FROM event_player_assignments WHERE event_id = 'public1'`).Scan(&count)
+	if count != 5 {
+		t.Errorf("Expected 5 assignments saved, got %d", count)
+	}
+
+	// The lineup has moved on from the proposal
+	if _, err := service.Accept("public1", proposal.Version, assignments[:1]); !errors.Is(err, ErrLineupChanged) {
+		t.Errorf("Expected ErrLineupChanged, got %v", err)
+	}
+
+	// Nothing is saved when any assignment is invalid
+	version, err := service.version(service.db, "public1")
+	if err != nil {
+		t.Fatalf("Error fetching version: %v", err)
+	}
+	invalid := []lineup.Assignment{{GameID: "game1", PlayerID: "user123"}, {GameID: "game1", PlayerID: "outsider"}}
+	if _, err := service.Accept("public1", version, invalid); !errors.Is(err, ErrInvalidLineup) {
+		t.Errorf("Expected ErrInvalidLineup casting a non-member, got %v", err)
+	}
+	service.db.QueryRow(`SELECT COUNT(*) FROM event_player_assignments WHERE event_id = 'public1'`).Scan(&count)
+	if count != 5 {
+		t.Errorf("Expected the 5 assignments untouched, got %d", count)
+	}
+}
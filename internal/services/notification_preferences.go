@@ -0,0 +1,151 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Notification types members can control
+const (
+	NotificationNewEvent        = "new_event"
+	NotificationEventChanged    = "event_changed"
+	NotificationReminder        = "reminder"
+	NotificationLineupPublished = "lineup_published"
+	NotificationInvitation      = "invitation"
+	NotificationRSVPChange      = "rsvp_change"
+	NotificationRoleChanged     = "role_changed"
+	NotificationGameAssignment  = "game_assignment"
+	NotificationWeeklyDigest    = "weekly_digest"
+	NotificationWaitlist        = "waitlist"
+)
+
+// Channels notifications can be delivered through
+const (
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+)
+
+// NotificationTypes lists every notification type in display order
+var NotificationTypes = []string{
+	NotificationNewEvent,
+	NotificationEventChanged,
+	NotificationReminder,
+	NotificationLineupPublished,
+	NotificationInvitation,
+	NotificationRSVPChange,
+	NotificationWaitlist,
+	NotificationRoleChanged,
+	NotificationGameAssignment,
+	NotificationWeeklyDigest,
+}
+
+// IsEnabledByDefault reports whether a notification type is on for users who haven't set a preference.
+// The weekly digest is opt-in; everything else is opt-out.
+func IsEnabledByDefault(notificationType string) bool {
+	return notificationType != NotificationWeeklyDigest
+}
+
+// NotificationChannels lists every delivery channel
+var NotificationChannels = []string{ChannelEmail, ChannelInApp}
+
+// IsValidNotificationType checks if the type is a known notification type
+func IsValidNotificationType(notificationType string) bool {
+	for _, t := range NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidNotificationChannel checks if the channel is a known delivery channel
+func IsValidNotificationChannel(channel string) bool {
+	for _, c := range NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationPreference is a stored preference. An empty GroupID applies to all of the user's groups.
+type NotificationPreference struct {
+	GroupID          string `json:"groupId"`
+	NotificationType string `json:"notificationType"`
+	Channel          string `json:"channel"`
+	Enabled          bool   `json:"enabled"`
+}
+
+// NotificationPreferenceService stores and resolves per-user, per-group notification preferences
+type NotificationPreferenceService struct {
+	db *sql.DB
+}
+
+func NewNotificationPreferenceService(db *sql.DB) *NotificationPreferenceService {
+	return &NotificationPreferenceService{db: db}
+}
+
+// IsEnabled is the single check every notification sender consults before delivering.
+// A preference for the specific group wins over the user's all-groups preference,
+// and the type's default applies when the user hasn't set either.
+func (s *NotificationPreferenceService) IsEnabled(userID, groupID, notificationType, channel string) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRow(`
+		SELECT enabled
+		FROM notification_preferences
+		WHERE user_id = $1 AND group_id IN ($2, '') AND notification_type = $3 AND channel = $4
+		ORDER BY CASE WHEN group_id = '' THEN 1 ELSE 0 END
+		LIMIT 1
+	`, userID, groupID, notificationType, channel).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return IsEnabledByDefault(notificationType), nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error checking notification preference: %v", err)
+	}
+	return enabled, nil
+}
+
+// Set stores a preference for a group, or for all groups when groupID is empty
+func (s *NotificationPreferenceService) Set(userID, groupID, notificationType, channel string, enabled bool) error {
+	_, err := s.db.Exec(`
+		INSERT INTO notification_preferences (user_id, group_id, notification_type, channel, enabled, updated_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, group_id, notification_type, channel)
+		DO UPDATE SET enabled = $5, updated_at = CURRENT_TIMESTAMP
+	`, userID, groupID, notificationType, channel, enabled)
+	return err
+}
+
+// Clear removes a stored preference so the group falls back to the user's all-groups preference
+func (s *NotificationPreferenceService) Clear(userID, groupID, notificationType, channel string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM notification_preferences
+		WHERE user_id = $1 AND group_id = $2 AND notification_type = $3 AND channel = $4
+	`, userID, groupID, notificationType, channel)
+	return err
+}
+
+// List returns every preference the user has stored
+func (s *NotificationPreferenceService) List(userID string) ([]NotificationPreference, error) {
+	rows, err := s.db.Query(`
+		SELECT group_id, notification_type, channel, enabled
+		FROM notification_preferences
+		WHERE user_id = $1
+		ORDER BY group_id, notification_type, channel
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := []NotificationPreference{}
+	for rows.Next() {
+		var preference NotificationPreference
+		if err := rows.Scan(&preference.GroupID, &preference.NotificationType, &preference.Channel, &preference.Enabled); err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
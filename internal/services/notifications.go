@@ -0,0 +1,123 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Notification is an in-app notification shown in a user's notification center
+type Notification struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Content   string    `json:"content"`
+	RelatedID string    `json:"relatedId"`
+	IsRead    bool      `json:"isRead"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NotificationService stores and reads in-app notifications
+type NotificationService struct {
+	db *sql.DB
+}
+
+func NewNotificationService(db *sql.DB) *NotificationService {
+	return &NotificationService{db: db}
+}
+
+// Notify creates an in-app notification unless the user has turned this type off for the group
+func (s *NotificationService) Notify(userID, groupID, notificationType, content, relatedID string) error {
+	enabled, err := NewNotificationPreferenceService(s.db).IsEnabled(userID, groupID, notificationType, ChannelInApp)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO notifications (user_id, type, content, related_id, is_read, created_at)
+		VALUES ($1, $2, $3, $4, false, $5)
+	`, userID, notificationType, content, relatedID, time.Now())
+	if err != nil {
+		return fmt.Errorf("error creating notification: %v", err)
+	}
+	return nil
+}
+
+// List returns a page of the user's notifications, newest first, along with the total count
+func (s *NotificationService) List(userID string, unreadOnly bool, page, pageSize int) ([]Notification, int, error) {
+	var totalItems int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM notifications
+		WHERE user_id = $1 AND ($2 = FALSE OR is_read = FALSE)
+	`, userID, unreadOnly).Scan(&totalItems)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting notifications: %v", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, type, content, COALESCE(related_id, ''), is_read, created_at
+		FROM notifications
+		WHERE user_id = $1 AND ($2 = FALSE OR is_read = FALSE)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, userID, unreadOnly, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching notifications: %v", err)
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var notification Notification
+		err := rows.Scan(&notification.ID, &notification.Type, &notification.Content, &notification.RelatedID, &notification.IsRead, &notification.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error scanning notification: %v", err)
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, totalItems, nil
+}
+
+// UnreadCount returns how many unread notifications the user has
+func (s *NotificationService) UnreadCount(userID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM notifications
+		WHERE user_id = $1 AND is_read = FALSE
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting unread notifications: %v", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the user's notifications as read, reporting whether it exists
+func (s *NotificationService) MarkRead(userID string, notificationID int64) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE notifications SET is_read = TRUE
+		WHERE id = $1 AND user_id = $2
+	`, notificationID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error marking notification read: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error marking notification read: %v", err)
+	}
+	return affected > 0, nil
+}
+
+// MarkAllRead marks every unread notification for the user as read
+func (s *NotificationService) MarkAllRead(userID string) (int64, error) {
+	result, err := s.db.Exec(`
+		UPDATE notifications SET is_read = TRUE
+		WHERE user_id = $1 AND is_read = FALSE
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error marking notifications read: %v", err)
+	}
+	return result.RowsAffected()
+}
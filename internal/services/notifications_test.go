@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNotifications_NotifyFollowsPreferences(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	service := NewNotificationService(testDB)
+	preferences := NewNotificationPreferenceService(testDB)
+
+	// Role changes are muted for group123 only, and waitlist news everywhere
+	if err := preferences.Set("user123", "group123", NotificationRoleChanged, ChannelInApp, false); err != nil {
+		t.Fatalf("Error setting preference: %v", err)
+	}
+	if err := preferences.Set("user123", "", NotificationWaitlist, ChannelInApp, false); err != nil {
+		t.Fatalf("Error setting preference: %v", err)
+	}
+	// Turning off the email doesn't turn off the in-app notification
+	if err := preferences.Set("user123", "", NotificationInvitation, ChannelEmail, false); err != nil {
+		t.Fatalf("Error setting preference: %v", err)
+	}
+
+	sends := []struct{ groupID, notificationType, content string }{
+		{"group123", NotificationRoleChanged, "muted for this group"},
+		{"group456", NotificationRoleChanged, "another group's role change"},
+		{"group123", NotificationWaitlist, "muted everywhere"},
+		{"group123", NotificationInvitation, "an invitation"},
+	}
+	for _, send := range sends {
+		if err := service.Notify("user123", send.groupID, send.notificationType, send.content, "event1"); err != nil {
+			t.Fatalf("Error notifying: %v", err)
+		}
+	}
+
+	notifications, total, err := service.List("user123", false, 1, 10)
+	if err != nil {
+		t.Fatalf("Error listing notifications: %v", err)
+	}
+	if total != 2 || len(notifications) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d: %+v", total, notifications)
+	}
+	if notifications[0].Content != "an invitation" || notifications[1].Content != "another group's role change" {
+		t.Errorf("Expected the unmuted notifications, newest first, got %+v", notifications)
+	}
+	if notifications[0].Type != NotificationInvitation || notifications[0].RelatedID != "event1" || notifications[0].IsRead {
+		t.Errorf("Unexpected notification %+v", notifications[0])
+	}
+}
+
+func TestNotifications_ListPages(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedOutsider)
+	service := NewNotificationService(testDB)
+
+	for i := 1; i <= 5; i++ {
+		if err := service.Notify("user123", "group123", NotificationNewEvent, fmt.Sprintf("Show %d", i), ""); err != nil {
+			t.Fatalf("Error notifying: %v", err)
+		}
+	}
+	if err := service.Notify("outsider", "group123", NotificationNewEvent, "Someone else's", ""); err != nil {
+		t.Fatalf("Error notifying: %v", err)
+	}
+
+	tests := []struct {
+		page int
+		want []string
+	}{
+		{1, []string{"Show 5", "Show 4"}},
+		{2, []string{"Show 3", "Show 2"}},
+		{3, []string{"Show 1"}},
+		{4, []string{}},
+	}
+	for _, tt := range tests {
+		notifications, total, err := service.List("user123", false, tt.page, 2)
+		if err != nil {
+			t.Fatalf("Error listing page %d: %v", tt.page, err)
+		}
+		if total != 5 {
+			t.Errorf("Page %d: expected a total of 5, got %d", tt.page, total)
+		}
+		got := []string{}
+		for _, notification := range notifications {
+			got = append(got, notification.Content)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Page %d: expected %v, got %v", tt.page, tt.want, got)
+		}
+	}
+}
+
+func TestNotifications_MarkRead(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedOutsider)
+	service := NewNotificationService(testDB)
+
+	for _, content := range []string{"First", "Second", "Third"} {
+		if err := service.Notify("user123", "group123", NotificationNewEvent, content, ""); err != nil {
+			t.Fatalf("Error notifying: %v", err)
+		}
+	}
+	notifications, _, err := service.List("user123", false, 1, 10)
+	if err != nil {
+		t.Fatalf("Error listing notifications: %v", err)
+	}
+
+	// Only the owner can mark a notification read
+	if found, err := service.MarkRead("outsider", notifications[0].ID); err != nil || found {
+		t.Errorf("Expected someone else's notification not found, got %v, %v", found, err)
+	}
+	if found, err := service.MarkRead("user123", notifications[0].ID); err != nil || !found {
+		t.Fatalf("Expected the notification marked read, got %v, %v", found, err)
+	}
+	if count, err := service.UnreadCount("user123"); err != nil || count != 2 {
+		t.Errorf("Expected 2 unread, got %d, %v", count, err)
+	}
+
+	unread, total, err := service.List("user123", true, 1, 10)
+	if err != nil {
+		t.Fatalf("Error listing unread notifications: %v", err)
+	}
+	if total != 2 || len(unread) != 2 || unread[0].Content != "Second" {
+		t.Errorf("Expected Second and First unread, got %d: %+v", total, unread)
+	}
+
+	if marked, err := service.MarkAllRead("user123"); err != nil || marked != 2 {
+		t.Errorf("Expected 2 marked read, got %d, %v", marked, err)
+	}
+	if count, err := service.UnreadCount("user123"); err != nil || count != 0 {
+		t.Errorf("Expected nothing unread, got %d, %v", count, err)
+	}
+}
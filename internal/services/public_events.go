@@ -0,0 +1,282 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"improv-app/internal/query"
+	"improv-app/internal/recurrence"
+)
+
+// Who can see an event
+const (
+	// VisibilityPrivate events are only seen by the group's members
+	VisibilityPrivate = "private"
+	// VisibilityMembersAndFollowers events are also seen by the group's followers
+	VisibilityMembersAndFollowers = "members-and-followers"
+	// VisibilityPublic events are seen by anyone, signed in or not
+	VisibilityPublic = "public"
+)
+
+// IsValidVisibility reports whether visibility is one of the visibility options
+func IsValidVisibility(visibility string) bool {
+	return visibility == VisibilityPrivate || visibility == VisibilityMembersAndFollowers || visibility == VisibilityPublic
+}
+
+// GroupDefaultVisibility returns the visibility the group's new events get when none is chosen
+func GroupDefaultVisibility(db *sql.DB, groupID string) (string, error) {
+	var visibility string
+	err := db.QueryRow(`SELECT default_event_visibility FROM improv_groups WHERE id = $1`, groupID).Scan(&visibility)
+	if err != nil {
+		return "", fmt.Errorf("error fetching group default visibility: %v", err)
+	}
+	return visibility, nil
+}
+
+// PublicPerson is a member shown on a public event page, who opted in to showing their name
+type PublicPerson struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
+// PublicEvent is what anyone can see of a public event
+type PublicEvent struct {
+	ID          string    `json:"id"`
+	GroupID     string    `json:"groupId"`
+	GroupName   string    `json:"groupName"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	SeriesID    *string   `json:"seriesId,omitempty"`
+	Status      string    `json:"status"`
+	// TimeZone is the IANA zone the event is in. StartTime and EndTime are UTC.
+	TimeZone string `json:"timeZone"`
+	LocalTimes
+	// MC is only set when the MC shows their name publicly
+	MC             *PublicPerson `json:"mc,omitempty"`
+	AttendingCount int           `json:"attendingCount"`
+	// Attendees lists the attending members who show their names publicly
+	Attendees []PublicPerson `json:"attendees"`
+	SpotsLeft *int           `json:"spotsLeft,omitempty"`
+}
+
+// PublicEventService serves public events to people who aren't signed in
+type PublicEventService struct {
+	db *sql.DB
+}
+
+func NewPublicEventService(db *sql.DB) *PublicEventService {
+	return &PublicEventService{db: db}
+}
+
+// List returns the public events between from and to, soonest first, optionally for one group.
+// The details that need a lookup per event, like attendees, are left to Get.
+func (s *PublicEventService) List(groupID string, from, to time.Time) ([]PublicEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT e.id, e.group_id, g.name, e.title, COALESCE(e.description, ''), COALESCE(e.location, ''),
+		       e.start_time, e.end_time, e.series_id, e.status, e.time_zone
+		FROM events e
+		JOIN improv_groups g ON e.group_id = g.id
+		WHERE e.visibility = 'public' AND e.status != 'draft' AND ($1 = '' OR e.group_id = $1)
+		  AND julianday(e.end_time) >= julianday($2) AND julianday(e.start_time) < julianday($3)
+		  AND `+query.NotCancelledOccurrenceCondition+`
+	`, groupID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching public events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []PublicEvent{}
+	for rows.Next() {
+		var event PublicEvent
+		err := rows.Scan(&event.ID, &event.GroupID, &event.GroupName, &event.Title, &event.Description, &event.Location,
+			&event.StartTime, &event.EndTime, &event.SeriesID, &event.Status, &event.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning public event: %v", err)
+		}
+		event.setTimes()
+		event.Attendees = []PublicPerson{}
+		events = append(events, event)
+	}
+	rows.Close()
+
+	// Add the public series' occurrences that haven't been saved as events
+	seriesService := NewEventSeriesService(s.db)
+	seriesList, err := seriesService.list(`
+		SELECT `+eventSeriesColumns+`, g.name
+		FROM event_series s
+		JOIN improv_groups g ON s.group_id = g.id
+		WHERE s.visibility = 'public' AND ($1 = '' OR s.group_id = $1)
+	`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, series := range seriesList {
+		occurrences, err := seriesService.Occurrences(series, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, occurrence := range occurrences {
+			events = append(events, publicOccurrence(occurrence))
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartTime.Before(events[j].StartTime) })
+	return events, nil
+}
+
+// Get returns a public event or an unsaved occurrence of a public series,
+// or nil if there's no such event or it isn't public
+func (s *PublicEventService) Get(eventID string) (*PublicEvent, error) {
+	if seriesID, originalStart, ok := recurrence.ParseOccurrenceID(eventID); ok {
+		seriesService := NewEventSeriesService(s.db)
+		series, err := seriesService.Get(seriesID)
+		if err != nil || series == nil || series.Visibility != VisibilityPublic {
+			return nil, err
+		}
+		occurrences, err := seriesService.Occurrences(*series, originalStart, originalStart.Add(time.Second))
+		if err != nil {
+			return nil, err
+		}
+		if len(occurrences) == 1 {
+			if err := s.db.QueryRow(`SELECT name FROM improv_groups WHERE id = $1`, series.GroupID).Scan(&occurrences[0].GroupName); err != nil {
+				return nil, fmt.Errorf("error fetching group: %v", err)
+			}
+			event := publicOccurrence(occurrences[0])
+			event.SpotsLeft = series.Capacity
+			if event.MC, err = s.publicPerson(series.MCID); err != nil {
+				return nil, err
+			}
+			return &event, nil
+		}
+		// Occurrences that were saved as events are served as events
+		eventID, err = seriesService.existingOccurrence(s.db, seriesID, occurrenceKey(originalStart))
+		if err == ErrOccurrenceNotFound || err == ErrOccurrenceCancelled {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var event PublicEvent
+	var mcID *string
+	err := s.db.QueryRow(`
+		SELECT e.id, e.group_id, g.name, e.title, COALESCE(e.description, ''), COALESCE(e.location, ''),
+		       e.start_time, e.end_time, e.series_id, e.mc_id, e.status, e.time_zone
+		FROM events e
+		JOIN improv_groups g ON e.group_id = g.id
+		WHERE e.id = $1 AND e.visibility = 'public' AND e.status != 'draft' AND `+query.NotCancelledOccurrenceCondition+`
+	`, eventID).Scan(&event.ID, &event.GroupID, &event.GroupName, &event.Title, &event.Description, &event.Location,
+		&event.StartTime, &event.EndTime, &event.SeriesID, &mcID, &event.Status, &event.TimeZone)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching public event: %v", err)
+	}
+	event.setTimes()
+
+	if event.MC, err = s.publicPerson(mcID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT u.first_name, u.last_name, u.show_name_publicly
+		FROM event_rsvps r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.event_id = $1 AND r.status = 'attending'
+		ORDER BY u.first_name, u.last_name
+	`, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching public event attendees: %v", err)
+	}
+	defer rows.Close()
+
+	event.Attendees = []PublicPerson{}
+	for rows.Next() {
+		var person PublicPerson
+		var showName bool
+		if err := rows.Scan(&person.FirstName, &person.LastName, &showName); err != nil {
+			return nil, fmt.Errorf("error scanning public event attendee: %v", err)
+		}
+		event.AttendingCount++
+		if showName {
+			event.Attendees = append(event.Attendees, person)
+		}
+	}
+	rows.Close()
+
+	if event.SpotsLeft, err = NewRSVPService(s.db).SpotsLeft(event.ID); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// ShowsNamePublicly reports whether the user opted in to showing their name on public event pages
+func (s *PublicEventService) ShowsNamePublicly(userID string) (bool, error) {
+	var showName bool
+	err := s.db.QueryRow(`SELECT show_name_publicly FROM users WHERE id = $1`, userID).Scan(&showName)
+	if err != nil {
+		return false, fmt.Errorf("error fetching public name preference: %v", err)
+	}
+	return showName, nil
+}
+
+// SetShowsNamePublicly opts the user in to or out of showing their name on public event pages
+func (s *PublicEventService) SetShowsNamePublicly(userID string, showName bool) error {
+	_, err := s.db.Exec(`UPDATE users SET show_name_publicly = $1 WHERE id = $2`, showName, userID)
+	if err != nil {
+		return fmt.Errorf("error saving public name preference: %v", err)
+	}
+	return nil
+}
+
+// publicPerson returns the user's name if they show it publicly
+func (s *PublicEventService) publicPerson(userID *string) (*PublicPerson, error) {
+	if userID == nil {
+		return nil, nil
+	}
+	var person PublicPerson
+	err := s.db.QueryRow(`
+		SELECT first_name, last_name FROM users
+		WHERE id = $1 AND show_name_publicly = TRUE
+	`, *userID).Scan(&person.FirstName, &person.LastName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching public name: %v", err)
+	}
+	return &person, nil
+}
+
+func publicOccurrence(occurrence Occurrence) PublicEvent {
+	seriesID := occurrence.SeriesID
+	event := PublicEvent{
+		ID:          occurrence.ID,
+		GroupID:     occurrence.GroupID,
+		GroupName:   occurrence.GroupName,
+		Title:       occurrence.Title,
+		Description: occurrence.Description,
+		Location:    occurrence.Location,
+		StartTime:   occurrence.StartTime,
+		EndTime:     occurrence.EndTime,
+		SeriesID:    &seriesID,
+		Status:      occurrence.Status,
+		TimeZone:    occurrence.TimeZone,
+		Attendees:   []PublicPerson{},
+	}
+	event.setTimes()
+	return event
+}
+
+// setTimes puts the event's times in UTC and fills in its local times
+func (e *PublicEvent) setTimes() {
+	e.StartTime, e.EndTime = e.StartTime.UTC(), e.EndTime.UTC()
+	e.LocalTimes = NewLocalTimes(e.StartTime, e.EndTime, e.TimeZone)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"improv-app/internal/query"
+)
+
+var publicWindowStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPublicEvents_List(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedRehearsal)
+	series, err := NewEventSeriesService(testDB).Create(EventSeries{
+		GroupID:    "group123",
+		Title:      "Open Jam",
+		StartTime:  time.Date(2026, 2, 3, 19, 0, 0, 0, time.UTC),
+		EndTime:    time.Date(2026, 2, 3, 21, 0, 0, 0, time.UTC),
+		RRule:      "FREQ=WEEKLY;COUNT=2",
+		Visibility: VisibilityPublic,
+		CreatedBy:  "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error creating series: %v", err)
+	}
+
+	events, err := NewPublicEventService(testDB).List("", publicWindowStart, publicWindowStart.AddDate(0, 3, 0))
+	if err != nil {
+		t.Fatalf("Error listing public events: %v", err)
+	}
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	if len(events) != 3 || events[0].ID != "public1" || *events[1].SeriesID != series.ID || *events[2].SeriesID != series.ID {
+		t.Errorf("Expected the public event and both occurrences of the public series, soonest first, got %v", ids)
+	}
+
+	events, err = NewPublicEventService(testDB).List("other-group", publicWindowStart, publicWindowStart.AddDate(0, 3, 0))
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected no public events for another group, got %d (error: %v)", len(events), err)
+	}
+}
+
+func TestPublicEvents_Get(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedRehearsal)
+	service := NewPublicEventService(testDB)
+
+	event, err := service.Get("public1")
+	if err != nil || event == nil {
+		t.Fatalf("Error fetching public event: %v", err)
+	}
+	if event.AttendingCount != 1 || len(event.Attendees) != 0 || event.MC != nil {
+		t.Errorf("Expected the attendee to be counted but not named, got %+v", event)
+	}
+
+	if err := service.SetShowsNamePublicly("user123", true); err != nil {
+		t.Fatalf("Error opting in: %v", err)
+	}
+	event, err = service.Get("public1")
+	if err != nil || event == nil {
+		t.Fatalf("Error fetching public event: %v", err)
+	}
+	if len(event.Attendees) != 1 || event.Attendees[0].FirstName != "Ada" || event.MC == nil || event.MC.LastName != "Admin" {
+		t.Errorf("Expected the opted in member to be named, got %+v", event)
+	}
+
+	for _, visibility := range []string{VisibilityPrivate, VisibilityMembersAndFollowers} {
+		if _, err := testDB.Exec(`UPDATE events SET visibility = $1 WHERE id = 'private1'`, visibility); err != nil {
+			t.Fatalf("Error updating visibility: %v", err)
+		}
+		event, err = service.Get("private1")
+		if err != nil || event != nil {
+			t.Errorf("Expected a %s event not to be served publicly, got %+v (error: %v)", visibility, event, err)
+		}
+	}
+}
+
+func TestVisibleEventsCondition_Followers(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedOutsider, seedPublicShow, seedRehearsal)
+	_, err := testDB.Exec(`
+		INSERT INTO users (id, email) VALUES ('follower', 'follower@example.com');
+		INSERT INTO group_followers (group_id, user_id) VALUES ('group123', 'follower');
+		INSERT INTO events (id, group_id, title, start_time, end_time, created_by, visibility)
+		VALUES ('followers1', 'group123', 'Team Night', '2026-02-03 19:00:00', '2026-02-03 21:00:00', 'user123', 'members-and-followers');
+	`)
+	if err != nil {
+		t.Fatalf("Error seeding follower: %v", err)
+	}
+
+	visible := func(userID string) map[string]bool {
+		rows, err := testDB.Query(`SELECT e.id `+query.VisibleEventsFrom+` WHERE `+query.VisibleEventsCondition, userID)
+		if err != nil {
+			t.Fatalf("Error listing visible events: %v", err)
+		}
+		defer rows.Close()
+		ids := map[string]bool{}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("Error scanning event: %v", err)
+			}
+			ids[id] = true
+		}
+		return ids
+	}
+
+	if ids := visible("user123"); !ids["public1"] || !ids["private1"] || !ids["followers1"] {
+		t.Errorf("Expected members to see every event, got %v", ids)
+	}
+	if ids := visible("follower"); !ids["public1"] || ids["private1"] || !ids["followers1"] {
+		t.Errorf("Expected followers to see public and members-and-followers events, got %v", ids)
+	}
+	if ids := visible("outsider"); !ids["public1"] || ids["private1"] || ids["followers1"] {
+		t.Errorf("Expected outsiders to see only public events, got %v", ids)
+	}
+}
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"improv-app/internal/query"
+)
+
+// DefaultReminderOffsets are used when REMINDER_OFFSETS is not configured
+var DefaultReminderOffsets = []time.Duration{48 * time.Hour, 3 * time.Hour}
+
+// ReminderService emails members about upcoming events at configurable offsets before they start
+type ReminderService struct {
+	db           *sql.DB
+	emailService *EmailService
+	offsets      []time.Duration
+	// send delivers a reminder email. Tests replace it to see what would be sent.
+	send func(email, subject, body, unsubscribeURL string) error
+}
+
+// NewReminderService creates a ReminderService, sorting offsets from largest to smallest
+func NewReminderService(db *sql.DB, emailService *EmailService, offsets []time.Duration) *ReminderService {
+	sorted := append([]time.Duration{}, offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	return &ReminderService{
+		db:           db,
+		emailService: emailService,
+		offsets:      sorted,
+		send:         emailService.sendEmail,
+	}
+}
+
+// ParseReminderOffsets parses a comma separated list of durations such as "48h,3h"
+func ParseReminderOffsets(value string) ([]time.Duration, error) {
+	var offsets []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		offset, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reminder offset %q: %v", part, err)
+		}
+		if offset <= 0 {
+			return nil, fmt.Errorf("reminder offset %q must be positive", part)
+		}
+		offsets = append(offsets, offset)
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("no reminder offsets configured")
+	}
+	return offsets, nil
+}
+
+// Start checks for due reminders in the background every interval
+func (s *ReminderService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.SendDueReminders(time.Now()); err != nil {
+				log.Printf("Error sending event reminders: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// dueOffset returns the smallest offset whose reminder time has passed for an event starting at start.
+// Only the closest reminder is sent, so an event created a few hours before it starts
+// doesn't trigger every larger reminder at once.
+func dueOffset(start, now time.Time, offsets []time.Duration) (time.Duration, bool) {
+	if !start.After(now) {
+		return 0, false
+	}
+	var due time.Duration
+	found := false
+	for _, offset := range offsets {
+		if !start.Add(-offset).After(now) && (!found || offset < due) {
+			due = offset
+			found = true
+		}
+	}
+	return due, found
+}
+
+type reminderEvent struct {
+	ID          string
+	GroupID     string
+	GroupName   string
+	Title       string
+	Description string
+	Location    string
+	StartTime   time.Time
+}
+
+type reminderRecipient struct {
+	UserID    string
+	Email     string
+	FirstName string
+	Status    string
+}
+
+type reminderGame struct {
+	ID   string
+	Name string
+}
+
+// SendDueReminders sends every reminder that is due at now and hasn't been sent yet
+func (s *ReminderService) SendDueReminders(now time.Time) error {
+	if len(s.offsets) == 0 {
+		return nil
+	}
+
+	// Occurrences with a due reminder are saved first, so their reminders are claimed by event like any other
+	seriesService := NewEventSeriesService(s.db)
+	seriesList, err := seriesService.list(`
+		SELECT ` + eventSeriesColumns + `, g.name
+		FROM event_series s
+		JOIN improv_groups g ON s.group_id = g.id
+	`)
+	if err != nil {
+		return err
+	}
+	err = seriesService.materializeDue(seriesList, now, now.Add(s.offsets[0]), func(occurrence Occurrence) bool {
+		_, ok := dueOffset(occurrence.StartTime, now, s.offsets)
+		return ok
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only events inside the largest reminder window can have a due reminder
+	rows, err := s.db.Query(`
+		SELECT e.id, e.group_id, g.name, e.title, COALESCE(e.description, ''), COALESCE(e.location, ''), e.start_time, e.time_zone
+		FROM events e
+		JOIN improv_groups g ON e.group_id = g.id
+		WHERE e.status = 'scheduled' AND `+query.NotCancelledOccurrenceCondition+`
+		  AND julianday(e.start_time) > julianday($1)
+		  AND julianday(e.start_time) <= julianday($2)
+	`, now.UTC(), now.Add(s.offsets[0]).UTC())
+	if err != nil {
+		return fmt.Errorf("error fetching upcoming events: %v", err)
+	}
+
+	var events []reminderEvent
+	for rows.Next() {
+		var event reminderEvent
+		var timeZone string
+		err := rows.Scan(&event.ID, &event.GroupID, &event.GroupName, &event.Title, &event.Description, &event.Location, &event.StartTime, &timeZone)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning upcoming event: %v", err)
+		}
+		// Reminders show the time where the event is
+		event.StartTime = event.StartTime.In(EventLocation(timeZone))
+		events = append(events, event)
+	}
+	rows.Close()
+
+	for _, event := range events {
+		offset, ok := dueOffset(event.StartTime, now, s.offsets)
+		if !ok {
+			continue
+		}
+		if err := s.sendEventReminders(event, offset, now); err != nil {
+			log.Printf("Error sending reminders for event %s: %v", event.ID, err)
+		}
+	}
+	return nil
+}
+
+// sendEventReminders emails every attending or undecided member of the event's group.
+// The offset identifies the reminder so it's only sent once; the email says how long is actually left.
+func (s *ReminderService) sendEventReminders(event reminderEvent, offset time.Duration, now time.Time) error {
+	recipients, err := s.reminderRecipients(event)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	lineup, err := s.eventLineup(event.ID)
+	if err != nil {
+		return err
+	}
+
+	assignments, err := s.playerAssignments(event.ID)
+	if err != nil {
+		return err
+	}
+
+	preferences := NewNotificationPreferenceService(s.db)
+	offsetMinutes := int(offset / time.Minute)
+	for _, recipient := range recipients {
+		enabled, err := preferences.IsEnabled(recipient.UserID, event.GroupID, NotificationReminder, ChannelEmail)
+		if err != nil {
+			return err
+		}
+		if !enabled {
+			continue
+		}
+
+		// Claim the reminder before sending so a restart never sends it twice
+		result, err := s.db.Exec(`
+			INSERT OR IGNORE INTO event_reminders (event_id, user_id, offset_minutes)
+			VALUES ($1, $2, $3)
+		`, event.ID, recipient.UserID, offsetMinutes)
+		if err != nil {
+			return fmt.Errorf("error recording reminder: %v", err)
+		}
+		claimed, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error recording reminder: %v", err)
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		subject, body := buildReminderEmail(event, recipient, event.StartTime.Sub(now), lineup, assignments[recipient.UserID])
+		unsubscribeURL := UnsubscribeURL(UnsubscribeClaims{
+			UserID:           recipient.UserID,
+			GroupID:          event.GroupID,
+			NotificationType: NotificationReminder,
+			Channel:          ChannelEmail,
+		})
+		if err := s.send(recipient.Email, subject, body, unsubscribeURL); err != nil {
+			log.Printf("Error sending reminder for event %s to user %s: %v", event.ID, recipient.UserID, err)
+			// Release the claim so the next run retries
+			s.db.Exec(`
+				DELETE FROM event_reminders
+				WHERE event_id = $1 AND user_id = $2 AND offset_minutes = $3
+			`, event.ID, recipient.UserID, offsetMinutes)
+			continue
+		}
+		log.Printf("Sent %s reminder for event %s to user %s", offset, event.ID, recipient.UserID)
+	}
+	return nil
+}
+
+// reminderRecipients returns group members who are attending or haven't responded
+func (s *ReminderService) reminderRecipients(event reminderEvent) ([]reminderRecipient, error) {
+	rows, err := s.db.Query(`
+		SELECT u.id, u.email, u.first_name, COALESCE(r.status, 'awaiting-response') as status
+		FROM group_members gm
+		JOIN users u ON gm.user_id = u.id
+		LEFT JOIN event_rsvps r ON r.event_id = $1 AND r.user_id = u.id
+		WHERE gm.group_id = $2
+		  AND COALESCE(r.status, 'awaiting-response') IN ('attending', 'awaiting-response')
+	`, event.ID, event.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reminder recipients: %v", err)
+	}
+	defer rows.Close()
+
+	var recipients []reminderRecipient
+	for rows.Next() {
+		var recipient reminderRecipient
+		if err := rows.Scan(&recipient.UserID, &recipient.Email, &recipient.FirstName, &recipient.Status); err != nil {
+			return nil, fmt.Errorf("error scanning reminder recipient: %v", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// eventLineup returns the event's games in running order
+func (s *ReminderService) eventLineup(eventID string) ([]reminderGame, error) {
+	rows, err := s.db.Query(`
+		SELECT g.id, g.name
+		FROM event_games eg
+		JOIN games g ON eg.game_id = g.id
+		WHERE eg.event_id = $1
+		ORDER BY eg.order_index
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event lineup: %v", err)
+	}
+	defer rows.Close()
+
+	var lineup []reminderGame
+	for rows.Next() {
+		var game reminderGame
+		if err := rows.Scan(&game.ID, &game.Name); err != nil {
+			return nil, fmt.Errorf("error scanning event lineup: %v", err)
+		}
+		lineup = append(lineup, game)
+	}
+	return lineup, nil
+}
+
+// playerAssignments maps each user to the games they are assigned to in the event
+func (s *ReminderService) playerAssignments(eventID string) (map[string][]string, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, game_id
+		FROM event_player_assignments
+		WHERE event_id = $1
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching player assignments: %v", err)
+	}
+	defer rows.Close()
+
+	assignments := make(map[string][]string)
+	for rows.Next() {
+		var userID, gameID string
+		if err := rows.Scan(&userID, &gameID); err != nil {
+			return nil, fmt.Errorf("error scanning player assignment: %v", err)
+		}
+		assignments[userID] = append(assignments[userID], gameID)
+	}
+	return assignments, nil
+}
+
+// formatTimeUntil turns the time left before an event into a phrase like "in 3 hours" or "in 2 days".
+// Reminders go out shortly after they're due, so the time is rounded rather than truncated.
+func formatTimeUntil(until time.Duration) string {
+	switch {
+	case until >= 48*time.Hour:
+		return fmt.Sprintf("in %d days", int(until.Round(24*time.Hour)/(24*time.Hour)))
+	case until >= 90*time.Minute:
+		return fmt.Sprintf("in %d hours", int(until.Round(time.Hour)/time.Hour))
+	case until >= 45*time.Minute:
+		return "in 1 hour"
+	case until >= 90*time.Second:
+		return fmt.Sprintf("in %d minutes", int(until.Round(time.Minute)/time.Minute))
+	default:
+		return "in 1 minute"
+	}
+}
+
+// buildReminderEmail renders the subject and plain text body of a reminder for an event starting after until
+func buildReminderEmail(event reminderEvent, recipient reminderRecipient, until time.Duration, lineup []reminderGame, assignedGameIDs []string) (string, string) {
+	subject := fmt.Sprintf("Reminder: %s starts %s", event.Title, formatTimeUntil(until))
+
+	var b strings.Builder
+	if recipient.FirstName != "" {
+		fmt.Fprintf(&b, "Hi %s,\n\n", recipient.FirstName)
+	} else {
+		b.WriteString("Hi,\n\n")
+	}
+	fmt.Fprintf(&b, "%s (%s) starts %s.\n\n", event.Title, event.GroupName, formatTimeUntil(until))
+	fmt.Fprintf(&b, "When: %s\n", event.StartTime.Format("Monday, January 2 at 3:04 PM MST"))
+	if event.Location != "" {
+		fmt.Fprintf(&b, "Where: %s\n", event.Location)
+	}
+	if event.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", event.Description)
+	}
+
+	if len(lineup) > 0 {
+		assigned := make(map[string]bool)
+		for _, gameID := range assignedGameIDs {
+			assigned[gameID] = true
+		}
+
+		b.WriteString("\nLineup:\n")
+		for i, game := range lineup {
+			marker := ""
+			if assigned[game.ID] {
+				marker = " (you're in this one)"
+			}
+			fmt.Fprintf(&b, "  %d. %s%s\n", i+1, game.Name, marker)
+		}
+	}
+
+	if len(assignedGameIDs) > 0 {
+		fmt.Fprintf(&b, "\nYou're playing in %d game(s).\n", len(assignedGameIDs))
+	}
+
+	eventURL := fmt.Sprintf("%s/events/%s", os.Getenv("FRONTEND_URL"), event.ID)
+	if recipient.Status == "awaiting-response" {
+		fmt.Fprintf(&b, "\nYou haven't RSVP'd yet. Let the group know if you're coming:\n%s\n", eventURL)
+	} else {
+		fmt.Fprintf(&b, "\nEvent details:\n%s\n", eventURL)
+	}
+	return subject, b.String()
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReminderOffsets(t *testing.T) {
+	offsets, err := ParseReminderOffsets("48h, 3h")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(offsets) != 2 || offsets[0] != 48*time.Hour || offsets[1] != 3*time.Hour {
+		t.Errorf("Expected [48h 3h], got %v", offsets)
+	}
+
+	if _, err := ParseReminderOffsets("soon"); err == nil {
+		t.Errorf("Expected an error for an invalid duration")
+	}
+
+	if _, err := ParseReminderOffsets("-3h"); err == nil {
+		t.Errorf("Expected an error for a negative duration")
+	}
+
+	if _, err := ParseReminderOffsets(""); err == nil {
+		t.Errorf("Expected an error when no offsets are configured")
+	}
+}
+
+func TestDueOffset(t *testing.T) {
+	offsets := []time.Duration{48 * time.Hour, 3 * time.Hour}
+	start := time.Date(2025, 6, 13, 20, 0, 0, 0, time.UTC)
+
+	// Before any reminder window
+	if _, ok := dueOffset(start, start.Add(-72*time.Hour), offsets); ok {
+		t.Errorf("Expected no reminder to be due 72 hours before the event")
+	}
+
+	// Inside the 48 hour window only
+	offset, ok := dueOffset(start, start.Add(-24*time.Hour), offsets)
+	if !ok || offset != 48*time.Hour {
+		t.Errorf("Expected the 48h reminder to be due, got %v (due=%v)", offset, ok)
+	}
+
+	// Both windows have passed, only the closest reminder is sent
+	offset, ok = dueOffset(start, start.Add(-time.Hour), offsets)
+	if !ok || offset != 3*time.Hour {
+		t.Errorf("Expected the 3h reminder to be due, got %v (due=%v)", offset, ok)
+	}
+
+	// Event already started
+	if _, ok := dueOffset(start, start.Add(time.Minute), offsets); ok {
+		t.Errorf("Expected no reminder once the event has started")
+	}
+}
+
+func TestBuildReminderEmail(t *testing.T) {
+	event := reminderEvent{
+		ID:        "event-1",
+		GroupName: "The Cage",
+		Title:     "Friday Show",
+		Location:  "The Annex",
+		StartTime: time.Date(2025, 6, 13, 20, 0, 0, 0, time.UTC),
+	}
+	lineup := []reminderGame{{ID: "g1", Name: "Freeze Tag"}, {ID: "g2", Name: "Party Quirks"}}
+
+	subject, body := buildReminderEmail(event, reminderRecipient{FirstName: "Sam", Status: "attending"}, 3*time.Hour, lineup, []string{"g2"})
+
+	if subject != "Reminder: Friday Show starts in 3 hours" {
+		t.Errorf("Unexpected subject: %s", subject)
+	}
+	if !strings.Contains(body, "2. Party Quirks (you're in this one)") {
+		t.Errorf("Expected assignment to be highlighted in lineup, got: %s", body)
+	}
+	if strings.Contains(body, "Freeze Tag (you're in this one)") {
+		t.Errorf("Expected only assigned games to be highlighted, got: %s", body)
+	}
+	if strings.Contains(body, "haven't RSVP'd") {
+		t.Errorf("Expected no RSVP prompt for an attending member, got: %s", body)
+	}
+
+	// The email says how long is actually left, not which reminder it is
+	subject, _ = buildReminderEmail(event, reminderRecipient{Status: "attending"}, 30*time.Hour, nil, nil)
+	if subject != "Reminder: Friday Show starts in 30 hours" {
+		t.Errorf("Unexpected subject: %s", subject)
+	}
+
+	_, body = buildReminderEmail(event, reminderRecipient{Status: "awaiting-response"}, 48*time.Hour, nil, nil)
+	if !strings.Contains(body, "haven't RSVP'd") {
+		t.Errorf("Expected an RSVP prompt for an undecided member, got: %s", body)
+	}
+}
+
+func TestSendDueReminders(t *testing.T) {
+	// Dana hasn't responded and Otto turned reminder emails off
+	testDB := newTestDB(t, seedGroup, seedPublicShow, []string{
+		`INSERT INTO users (id, email, first_name) VALUES ('dana', 'dana@example.com', 'Dana'), ('otto', 'otto@example.com', 'Otto')`,
+		`INSERT INTO group_members (group_id, user_id, role) VALUES ('group123', 'dana', 'member'), ('group123', 'otto', 'member')`,
+	})
+	if err := NewNotificationPreferenceService(testDB).Set("otto", "group123", NotificationReminder, ChannelEmail, false); err != nil {
+		t.Fatalf("Error opting out: %v", err)
+	}
+
+	service := NewReminderService(testDB, NewEmailService(testDB), []time.Duration{48 * time.Hour, 3 * time.Hour})
+	sent := map[string][]string{}
+	service.send = func(email, subject, body, unsubscribeURL string) error {
+		sent[email] = append(sent[email], subject)
+		return nil
+	}
+
+	// The show was created 6 hours before it starts, which is inside the 48 hour window
+	start := time.Date(2026, 2, 1, 19, 0, 0, 0, time.UTC)
+	if err := service.SendDueReminders(start.Add(-6 * time.Hour)); err != nil {
+		t.Fatalf("Error sending reminders: %v", err)
+	}
+	want := "Reminder: Public Show starts in 6 hours"
+	if len(sent["admin@example.com"]) != 1 || sent["admin@example.com"][0] != want || len(sent["dana@example.com"]) != 1 {
+		t.Fatalf("Expected Ada and Dana reminded with %q, got %v", want, sent)
+	}
+	if len(sent["otto@example.com"]) != 0 {
+		t.Errorf("Expected nothing sent to Otto, who opted out, got %v", sent["otto@example.com"])
+	}
+
+	// Running again, as after a restart, sends nothing new
+	if err := service.SendDueReminders(start.Add(-6*time.Hour + time.Minute)); err != nil {
+		t.Fatalf("Error sending reminders: %v", err)
+	}
+	if len(sent["admin@example.com"]) != 1 || len(sent["dana@example.com"]) != 1 {
+		t.Errorf("Expected no reminder sent twice, got %v", sent)
+	}
+
+	// The 3 hour reminder is still sent once it's due
+	if err := service.SendDueReminders(start.Add(-150 * time.Minute)); err != nil {
+		t.Fatalf("Error sending reminders: %v", err)
+	}
+	if len(sent["dana@example.com"]) != 2 || sent["dana@example.com"][1] != "Reminder: Public Show starts in 3 hours" {
+		t.Errorf("Expected Dana's 3 hour reminder, got %v", sent["dana@example.com"])
+	}
+	if len(sent["otto@example.com"]) != 0 {
+		t.Errorf("Expected nothing sent to Otto, who opted out, got %v", sent["otto@example.com"])
+	}
+}
+
+func TestSendDueReminders_SeriesOccurrences(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	start := time.Date(2026, 3, 3, 19, 0, 0, 0, time.UTC)
+	seriesService := NewEventSeriesService(testDB)
+	series, err := seriesService.Create(EventSeries{
+		GroupID:   "group123",
+		Title:     "Tuesday Jam",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=4",
+		CreatedBy: "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error creating series: %v", err)
+	}
+
+	// The third jam was saved, then the series was cancelled from it on
+	if _, err := seriesService.Materialize(series.ID, start.AddDate(0, 0, 14)); err != nil {
+		t.Fatalf("Error saving occurrence: %v", err)
+	}
+	saved, err := seriesService.End(series.ID, start.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("Error ending series: %v", err)
+	}
+	for _, eventID := range saved {
+		if _, err := NewEventStatusService(testDB).Transition(eventID, EventStatusCancelled, ""); err != nil {
+			t.Fatalf("Error cancelling occurrence: %v", err)
+		}
+	}
+
+	service := NewReminderService(testDB, NewEmailService(testDB), []time.Duration{3 * time.Hour})
+	var sent []string
+	service.send = func(email, subject, body, unsubscribeURL string) error {
+		sent = append(sent, subject)
+		return nil
+	}
+
+	// The second jam hasn't been saved, but it's still reminded about
+	if err := service.SendDueReminders(start.AddDate(0, 0, 7).Add(-2 * time.Hour)); err != nil {
+		t.Fatalf("Error sending reminders: %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "Reminder: Tuesday Jam starts in 2 hours" {
+		t.Fatalf("Expected a reminder for the second jam, got %v", sent)
+	}
+
+	// The cancelled jams aren't
+	for _, week := range []int{2, 3} {
+		if err := service.SendDueReminders(start.AddDate(0, 0, 7*week).Add(-2 * time.Hour)); err != nil {
+			t.Fatalf("Error sending reminders: %v", err)
+		}
+	}
+	if len(sent) != 1 {
+		t.Errorf("Expected no reminders for the cancelled jams, got %v", sent)
+	}
+}
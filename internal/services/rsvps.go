@@ -0,0 +1,226 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RSVP statuses. Members can't choose waitlisted, it's what attending becomes when the event is full.
+const (
+	RSVPAttending        = "attending"
+	RSVPMaybe            = "maybe"
+	RSVPDeclined         = "declined"
+	RSVPAwaitingResponse = "awaiting-response"
+	RSVPWaitlisted       = "waitlisted"
+)
+
+// RSVPChange is the outcome of setting an RSVP
+type RSVPChange struct {
+	// Status is the status that was saved, which is waitlisted if attending didn't fit
+	Status string `json:"status"`
+	// WaitlistPosition is 1 for the next member to get a spot, or 0 if not waitlisted
+	WaitlistPosition int `json:"waitlistPosition,omitempty"`
+	// Promoted lists the members moved off the waitlist because a spot opened up
+	Promoted []string `json:"-"`
+}
+
+// RSVPService records RSVPs and keeps attendance within an event's capacity
+type RSVPService struct {
+	db *sql.DB
+}
+
+func NewRSVPService(db *sql.DB) *RSVPService {
+	return &RSVPService{db: db}
+}
+
+// Set records a member's RSVP. Attending a full event puts them on the waitlist
+// unless override is set, which organizers use to let someone in anyway.
+// A spot given up by an attendee goes to the first member on the waitlist.
+func (s *RSVPService) Set(eventID, userID, status string, override bool) (*RSVPChange, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	change, err := setRSVP(tx, eventID, userID, status, override)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error saving RSVP: %v", err)
+	}
+
+	if change.Status == RSVPWaitlisted {
+		if change.WaitlistPosition, err = s.WaitlistPosition(eventID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return change, nil
+}
+
+// setRSVP records a member's RSVP as Set does, within the caller's transaction
+func setRSVP(q queryer, eventID, userID, status string, override bool) (*RSVPChange, error) {
+	var previous sql.NullString
+	var previousPosition sql.NullInt64
+	err := q.QueryRow(`
+		SELECT status, waitlist_position FROM event_rsvps
+		WHERE event_id = $1 AND user_id = $2
+	`, eventID, userID).Scan(&previous, &previousPosition)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error fetching RSVP: %v", err)
+	}
+
+	if status == RSVPAttending && previous.String != RSVPAttending && !override {
+		full, err := isFull(q, eventID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if full {
+			status = RSVPWaitlisted
+		}
+	}
+
+	var position interface{}
+	if status == RSVPWaitlisted {
+		if previous.String == RSVPWaitlisted && previousPosition.Valid {
+			// Asking again doesn't lose your place
+			position = previousPosition.Int64
+		} else {
+			var next int64
+			err := q.QueryRow(`
+				SELECT COALESCE(MAX(waitlist_position), 0) + 1 FROM event_rsvps WHERE event_id = $1
+			`, eventID).Scan(&next)
+			if err != nil {
+				return nil, fmt.Errorf("error fetching waitlist: %v", err)
+			}
+			position = next
+		}
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO event_rsvps (event_id, user_id, status, waitlist_position)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id, user_id) DO UPDATE SET status = excluded.status, waitlist_position = excluded.waitlist_position
+	`, eventID, userID, status, position)
+	if err != nil {
+		return nil, fmt.Errorf("error saving RSVP: %v", err)
+	}
+
+	change := &RSVPChange{Status: status}
+	if previous.String == RSVPAttending && status != RSVPAttending {
+		if change.Promoted, err = promoteWaitlist(q, eventID); err != nil {
+			return nil, err
+		}
+	}
+	return change, nil
+}
+
+// PromoteWaitlist fills open spots from the waitlist, such as after the capacity
+// goes up or a walk-in is removed, and returns the members who got a spot
+func (s *RSVPService) PromoteWaitlist(eventID string) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	promoted, err := promoteWaitlist(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error promoting waitlist: %v", err)
+	}
+	return promoted, nil
+}
+
+// WaitlistPosition returns the member's place on the waitlist, starting at 1, or 0 if they aren't on it
+func (s *RSVPService) WaitlistPosition(eventID, userID string) (int, error) {
+	var position int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM event_rsvps w
+		JOIN event_rsvps r ON r.event_id = $1 AND r.user_id = $2 AND r.status = 'waitlisted'
+		WHERE w.event_id = r.event_id AND w.status = 'waitlisted' AND w.waitlist_position <= r.waitlist_position
+	`, eventID, userID).Scan(&position)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching waitlist position: %v", err)
+	}
+	return position, nil
+}
+
+// SpotsLeft returns how many more members can attend, or nil if the event has no capacity
+func (s *RSVPService) SpotsLeft(eventID string) (*int, error) {
+	var spotsLeft sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT e.capacity -
+		       (SELECT COUNT(*) FROM event_rsvps r WHERE r.event_id = e.id AND r.status = 'attending') -
+		       (SELECT COUNT(*) FROM non_registered_attendees n WHERE n.event_id = e.id)
+		FROM events e
+		WHERE e.id = $1
+	`, eventID).Scan(&spotsLeft)
+	if err != nil {
+		return nil, fmt.Errorf("error checking event capacity: %v", err)
+	}
+	if !spotsLeft.Valid {
+		return nil, nil
+	}
+	// Organizers can go over capacity, which leaves no spots rather than a negative number
+	left := int(max(spotsLeft.Int64, 0))
+	return &left, nil
+}
+
+// isFull reports whether the event has no spot left for the user. Walk-ins take spots too.
+func isFull(q queryer, eventID, userID string) (bool, error) {
+	var capacity sql.NullInt64
+	var taken int64
+	err := q.QueryRow(`
+		SELECT e.capacity,
+		       (SELECT COUNT(*) FROM event_rsvps r WHERE r.event_id = $1 AND r.status = 'attending' AND r.user_id != $2) +
+		       (SELECT COUNT(*) FROM non_registered_attendees n WHERE n.event_id = $1)
+		FROM events e
+		WHERE e.id = $1
+	`, eventID, userID).Scan(&capacity, &taken)
+	if err != nil {
+		return false, fmt.Errorf("error checking event capacity: %v", err)
+	}
+	return capacity.Valid && taken >= capacity.Int64, nil
+}
+
+// promoteWaitlist moves members from the front of the waitlist to attending while there's room
+func promoteWaitlist(q queryer, eventID string) ([]string, error) {
+	var promoted []string
+	for {
+		var userID string
+		err := q.QueryRow(`
+			SELECT user_id FROM event_rsvps
+			WHERE event_id = $1 AND status = 'waitlisted'
+			ORDER BY waitlist_position
+			LIMIT 1
+		`, eventID).Scan(&userID)
+		if err == sql.ErrNoRows {
+			return promoted, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error fetching waitlist: %v", err)
+		}
+
+		full, err := isFull(q, eventID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if full {
+			return promoted, nil
+		}
+
+		_, err = q.Exec(`
+			UPDATE event_rsvps SET status = 'attending', waitlist_position = NULL
+			WHERE event_id = $1 AND user_id = $2
+		`, eventID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error promoting from waitlist: %v", err)
+		}
+		promoted = append(promoted, userID)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// newRSVPTestDB seeds an event with room for two and three members who want to attend
+func newRSVPTestDB(t *testing.T) *sql.DB {
+	return newTestDB(t, seedGroup, []string{
+		`INSERT INTO users (id, email) VALUES ('user2', 'two@example.com'), ('user3', 'three@example.com'), ('user4', 'four@example.com')`,
+		`INSERT INTO events (id, group_id, title, start_time, end_time, created_by, capacity)
+		 VALUES ('event1', 'group123', 'Harold Night', '2026-02-01 19:00:00', '2026-02-01 21:00:00', 'user123', 2)`,
+	})
+}
+
+func assertRSVP(t *testing.T, testDB *sql.DB, userID, want string) {
+	t.Helper()
+	var status string
+	err := testDB.QueryRow(`SELECT status FROM event_rsvps WHERE event_id = 'event1' AND user_id = $1`, userID).Scan(&status)
+	if err != nil {
+		t.Fatalf("Error fetching RSVP for %s: %v", userID, err)
+	}
+	if status != want {
+		t.Errorf("Expected %s to be %s, got %s", userID, want, status)
+	}
+}
+
+func TestRSVP_WaitlistsBeyondCapacity(t *testing.T) {
+	testDB := newRSVPTestDB(t)
+	service := NewRSVPService(testDB)
+
+	for _, userID := range []string{"user123", "user2"} {
+		change, err := service.Set("event1", userID, RSVPAttending, false)
+		if err != nil {
+			t.Fatalf("Error setting RSVP: %v", err)
+		}
+		if change.Status != RSVPAttending {
+			t.Errorf("Expected %s to get a spot, got %s", userID, change.Status)
+		}
+	}
+
+	for i, userID := range []string{"user3", "user4"} {
+		change, err := service.Set("event1", userID, RSVPAttending, false)
+		if err != nil {
+			t.Fatalf("Error setting RSVP: %v", err)
+		}
+		if change.Status != RSVPWaitlisted || change.WaitlistPosition != i+1 {
+			t.Errorf("Expected %s to be waitlisted at %d, got %+v", userID, i+1, change)
+		}
+	}
+
+	// Asking again keeps your place
+	change, err := service.Set("event1", "user3", RSVPAttending, false)
+	if err != nil {
+		t.Fatalf("Error setting RSVP: %v", err)
+	}
+	if change.WaitlistPosition != 1 {
+		t.Errorf("Expected user3 to stay first on the waitlist, got %d", change.WaitlistPosition)
+	}
+
+	spotsLeft, err := service.SpotsLeft("event1")
+	if err != nil || spotsLeft == nil || *spotsLeft != 0 {
+		t.Errorf("Expected no spots left, got %v (error: %v)", spotsLeft, err)
+	}
+}
+
+func TestRSVP_PromotesWhenAttendeeDeclines(t *testing.T) {
+	testDB := newRSVPTestDB(t)
+	service := NewRSVPService(testDB)
+
+	for _, userID := range []string{"user123", "user2", "user3", "user4"} {
+		if _, err := service.Set("event1", userID, RSVPAttending, false); err != nil {
+			t.Fatalf("Error setting RSVP: %v", err)
+		}
+	}
+
+	change, err := service.Set("event1", "user2", RSVPDeclined, false)
+	if err != nil {
+		t.Fatalf("Error declining: %v", err)
+	}
+	if strings.Join(change.Promoted, ",") != "user3" {
+		t.Errorf("Expected the first on the waitlist to be promoted, got %v", change.Promoted)
+	}
+	assertRSVP(t, testDB, "user3", RSVPAttending)
+	assertRSVP(t, testDB, "user4", RSVPWaitlisted)
+
+	position, err := service.WaitlistPosition("event1", "user4")
+	if err != nil || position != 1 {
+		t.Errorf("Expected user4 to move up to first, got %d (error: %v)", position, err)
+	}
+}
+
+func TestRSVP_OrganizerOverride(t *testing.T) {
+	testDB := newRSVPTestDB(t)
+	service := NewRSVPService(testDB)
+
+	for _, userID := range []string{"user123", "user2"} {
+		if _, err := service.Set("event1", userID, RSVPAttending, false); err != nil {
+			t.Fatalf("Error setting RSVP: %v", err)
+		}
+	}
+
+	change, err := service.Set("event1", "user3", RSVPAttending, true)
+	if err != nil {
+		t.Fatalf("Error setting RSVP: %v", err)
+	}
+	if change.Status != RSVPAttending {
+		t.Errorf("Expected the override to seat user3, got %s", change.Status)
+	}
+
+	// Over capacity, so one decline doesn't open a spot for the waitlist
+	if _, err := service.Set("event1", "user4", RSVPAttending, false); err != nil {
+		t.Fatalf("Error setting RSVP: %v", err)
+	}
+	change, err = service.Set("event1", "user2", RSVPDeclined, false)
+	if err != nil {
+		t.Fatalf("Error declining: %v", err)
+	}
+	if len(change.Promoted) != 0 {
+		t.Errorf("Expected nobody to be promoted, got %v", change.Promoted)
+	}
+	assertRSVP(t, testDB, "user4", RSVPWaitlisted)
+}
+
+func TestRSVP_WalkInsCountAgainstCapacity(t *testing.T) {
+	testDB := newRSVPTestDB(t)
+	service := NewRSVPService(testDB)
+
+	_, err := testDB.Exec(`
+		INSERT INTO non_registered_attendees (id, event_id, first_name, last_name)
+		VALUES ('walkin1', 'event1', 'Walk', 'In')
+	`)
+	if err != nil {
+		t.Fatalf("Error adding walk-in: %v", err)
+	}
+
+	for _, userID := range []string{"user123", "user2"} {
+		if _, err := service.Set("event1", userID, RSVPAttending, false); err != nil {
+			t.Fatalf("Error setting RSVP: %v", err)
+		}
+	}
+	assertRSVP(t, testDB, "user2", RSVPWaitlisted)
+
+	if _, err := testDB.Exec(`DELETE FROM non_registered_attendees WHERE id = 'walkin1'`); err != nil {
+		t.Fatalf("Error removing walk-in: %v", err)
+	}
+	promoted, err := service.PromoteWaitlist("event1")
+	if err != nil {
+		t.Fatalf("Error promoting waitlist: %v", err)
+	}
+	if strings.Join(promoted, ",") != "user2" {
+		t.Errorf("Expected user2 to get the walk-in's spot, got %v", promoted)
+	}
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Segment types in a run-of-show
+const (
+	SegmentIntro       = "intro"
+	SegmentGame        = "game"
+	SegmentBreak       = "break"
+	SegmentAudienceBit = "audience-bit"
+)
+
+var (
+	// ErrInvalidSegment means a segment has an unknown type, a negative length or no title
+	ErrInvalidSegment = errors.New("invalid segment")
+	// ErrSegmentNotFound means the game isn't in the event's lineup or the segment isn't the event's
+	ErrSegmentNotFound = errors.New("segment not found")
+	// ErrShowStarted means the show is already running or has run
+	ErrShowStarted = errors.New("show already started")
+	// ErrShowNotRunning means there is no segment running to move on from
+	ErrShowNotRunning = errors.New("show not running")
+	// ErrEmptyRunOfShow means there is nothing in the running order to start
+	ErrEmptyRunOfShow = errors.New("run-of-show is empty")
+)
+
+// IsValidSegmentType reports whether segmentType is a kind of run-of-show segment
+func IsValidSegmentType(segmentType string) bool {
+	switch segmentType {
+	case SegmentIntro, SegmentGame, SegmentBreak, SegmentAudienceBit:
+		return true
+	}
+	return false
+}
+
+// Segment is the plan for an intro, break or audience bit between the lineup's games
+type Segment struct {
+	Type           string
+	Title          string
+	PlannedMinutes *int
+	// BeforeGame is the place in the lineup the segment runs before, 0 for the start. Places
+	// past the last game put it at the end. It stays at that place as games are moved around.
+	BeforeGame int
+}
+
+// Validate checks the segment's type, title and length
+func (s Segment) Validate() error {
+	switch {
+	case !IsValidSegmentType(s.Type):
+		return fmt.Errorf("%w: type must be intro, game, break or audience-bit", ErrInvalidSegment)
+	case strings.TrimSpace(s.Title) == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidSegment)
+	case s.PlannedMinutes != nil && *s.PlannedMinutes < 0:
+		return fmt.Errorf("%w: planned minutes can't be negative", ErrInvalidSegment)
+	case s.BeforeGame < 0:
+		return fmt.Errorf("%w: place in the lineup can't be negative", ErrInvalidSegment)
+	}
+	return nil
+}
+
+// RunOfShowItem is a game or segment in the running order with its planned and actual timings
+type RunOfShowItem struct {
+	// ID is the game's ID for lineup games and the segment's otherwise
+	ID          string  `json:"id"`
+	GameID      *string `json:"gameId,omitempty"`
+	SegmentType string  `json:"segmentType"`
+	Title       string  `json:"title"`
+	// BeforeGame is where a segment sits in the lineup, see Segment
+	BeforeGame     *int       `json:"beforeGame,omitempty"`
+	PlannedMinutes *int       `json:"plannedMinutes,omitempty"`
+	PlannedStart   time.Time  `json:"plannedStart"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	EndedAt        *time.Time `json:"endedAt,omitempty"`
+	// ActualSeconds is how long the item ran, once it has ended
+	ActualSeconds *int `json:"actualSeconds,omitempty"`
+	// OverrunSeconds is how much longer than planned the item ran, negative when it ran short
+	OverrunSeconds *int `json:"overrunSeconds,omitempty"`
+}
+
+// RunOfShow is an event's running order. Planned starts follow on from the event's start time,
+// counting items without a planned length as taking no time.
+type RunOfShow struct {
+	EventID        string          `json:"eventId"`
+	StartTime      time.Time       `json:"startTime"`
+	PlannedMinutes int             `json:"plannedMinutes"`
+	PlannedEnd     time.Time       `json:"plannedEnd"`
+	Items          []RunOfShowItem `json:"items"`
+	// Live is set while an item is running
+	Live          bool    `json:"live"`
+	CurrentItemID *string `json:"currentItemId,omitempty"`
+	// Finished is set once every item has run
+	Finished bool `json:"finished"`
+	// OverrunSeconds adds up the overruns of the items that have ended with a planned length
+	OverrunSeconds int `json:"overrunSeconds"`
+}
+
+// RunOfShowService plans events' running orders and times them as the show runs
+type RunOfShowService struct {
+	db *sql.DB
+}
+
+func NewRunOfShowService(db *sql.DB) *RunOfShowService {
+	return &RunOfShowService{db: db}
+}
+
+// Get returns the event's running order: its lineup games in order with the segments between them
+func (s *RunOfShowService) Get(eventID string) (*RunOfShow, error) {
+	show := &RunOfShow{EventID: eventID, Items: []RunOfShowItem{}}
+	err := s.db.QueryRow(`SELECT start_time FROM events WHERE id = $1`, eventID).Scan(&show.StartTime)
+	if err == sql.ErrNoRows {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event: %v", err)
+	}
+	show.StartTime = show.StartTime.UTC()
+
+	rows, err := s.db.Query(`
+		SELECT eg.game_id, g.name, eg.segment_type, eg.planned_minutes, eg.started_at, eg.ended_at
+		FROM event_games eg
+		JOIN games g ON eg.game_id = g.id
+		WHERE eg.event_id = $1
+		ORDER BY eg.order_index
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lineup: %v", err)
+	}
+	var games []RunOfShowItem
+	for rows.Next() {
+		var item RunOfShowItem
+		var startedAt, endedAt sql.NullTime
+		if err := rows.Scan(&item.ID, &item.Title, &item.SegmentType, &item.PlannedMinutes, &startedAt, &endedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning lineup game: %v", err)
+		}
+		gameID := item.ID
+		item.GameID = &gameID
+		setActuals(&item, startedAt, endedAt)
+		games = append(games, item)
+	}
+	rows.Close()
+
+	rows, err = s.db.Query(`
+		SELECT id, segment_type, title, planned_minutes, before_game, started_at, ended_at
+		FROM event_segments
+		WHERE event_id = $1
+		ORDER BY before_game, position, created_at
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching segments: %v", err)
+	}
+	var segments []RunOfShowItem
+	for rows.Next() {
+		var item RunOfShowItem
+		var beforeGame int
+		var startedAt, endedAt sql.NullTime
+		if err := rows.Scan(&item.ID, &item.SegmentType, &item.Title, &item.PlannedMinutes, &beforeGame, &startedAt, &endedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning segment: %v", err)
+		}
+		item.BeforeGame = &beforeGame
+		setActuals(&item, startedAt, endedAt)
+		segments = append(segments, item)
+	}
+	rows.Close()
+
+	// Segments go before the game at their place, and those past the last game go at the end
+	next := 0
+	for i, game := range games {
+		for next < len(segments) && *segments[next].BeforeGame <= i {
+			show.Items = append(show.Items, segments[next])
+			next++
+		}
+		show.Items = append(show.Items, game)
+	}
+	show.Items = append(show.Items, segments[next:]...)
+
+	plannedStart := show.StartTime
+	show.Finished = len(show.Items) > 0
+	for i := range show.Items {
+		item := &show.Items[i]
+		item.PlannedStart = plannedStart
+		if item.PlannedMinutes != nil {
+			show.PlannedMinutes += *item.PlannedMinutes
+			plannedStart = plannedStart.Add(time.Duration(*item.PlannedMinutes) * time.Minute)
+		}
+		if item.StartedAt != nil && item.EndedAt == nil {
+			show.Live = true
+			show.CurrentItemID = &item.ID
+		}
+		if item.EndedAt == nil {
+			show.Finished = false
+		}
+		if item.OverrunSeconds != nil {
+			show.OverrunSeconds += *item.OverrunSeconds
+		}
+	}
+	show.PlannedEnd = plannedStart
+	return show, nil
+}
+
+// setActuals fills in how long an item ran and how far over its plan, once it has ended
+func setActuals(item *RunOfShowItem, startedAt, endedAt sql.NullTime) {
+	if startedAt.Valid {
+		started := startedAt.Time.UTC()
+		item.StartedAt = &started
+	}
+	if !endedAt.Valid {
+		return
+	}
+	ended := endedAt.Time.UTC()
+	item.EndedAt = &ended
+	if item.StartedAt == nil {
+		return
+	}
+	actual := int(ended.Sub(*item.StartedAt).Round(time.Second).Seconds())
+	item.ActualSeconds = &actual
+	if item.PlannedMinutes != nil {
+		overrun := actual - *item.PlannedMinutes*60
+		item.OverrunSeconds = &overrun
+	}
+}
+
+// PlanGame sets the segment type and planned length of a game in the event's lineup
+func (s *RunOfShowService) PlanGame(eventID, gameID, segmentType string, plannedMinutes *int) error {
+	if segmentType == "" {
+		segmentType = SegmentGame
+	}
+	if !IsValidSegmentType(segmentType) {
+		return fmt.Errorf("%w: type must be intro, game, break or audience-bit", ErrInvalidSegment)
+	}
+	if plannedMinutes != nil && *plannedMinutes < 0 {
+		return fmt.Errorf("%w: planned minutes can't be negative", ErrInvalidSegment)
+	}
+	result, err := s.db.Exec(`
+		UPDATE event_games SET segment_type = $1, planned_minutes = $2
+		WHERE event_id = $3 AND game_id = $4
+	`, segmentType, plannedMinutes, eventID, gameID)
+	if err != nil {
+		return fmt.Errorf("error planning game: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSegmentNotFound
+	}
+	return nil
+}
+
+// AddSegment adds an intro, break or audience bit to the running order and returns its ID.
+// It goes after any segments already at its place.
+func (s *RunOfShowService) AddSegment(eventID string, segment Segment) (string, error) {
+	if err := segment.Validate(); err != nil {
+		return "", err
+	}
+	segmentID := uuid.New().String()
+	_, err := s.db.Exec(`
+		INSERT INTO event_segments (id, event_id, segment_type, title, planned_minutes, before_game, position)
+		SELECT $1, $2, $3, $4, $5, $6, COALESCE(MAX(position) + 1, 0)
+		FROM event_segments WHERE event_id = $2 AND before_game = $6
+	`, segmentID, eventID, segment.Type, strings.TrimSpace(segment.Title), segment.PlannedMinutes, segment.BeforeGame)
+	if err != nil {
+		return "", fmt.Errorf("error adding segment: %v", err)
+	}
+	return segmentID, nil
+}
+
+// UpdateSegment replaces a segment's plan, moving it to the end of its new place if that changed
+func (s *RunOfShowService) UpdateSegment(eventID, segmentID string, segment Segment) error {
+	if err := segment.Validate(); err != nil {
+		return err
+	}
+	result, err := s.db.Exec(`
+		UPDATE event_segments
+		SET segment_type = $1, title = $2, planned_minutes = $3,
+		    position = CASE WHEN before_game = $4 THEN position ELSE (
+		        SELECT COALESCE(MAX(position) + 1, 0) FROM event_segments WHERE event_id = $5 AND before_game = $4
+		    ) END,
+		    before_game = $4
+		WHERE id = $6 AND event_id = $5
+	`, segment.Type, strings.TrimSpace(segment.Title), segment.PlannedMinutes, segment.BeforeGame, eventID, segmentID)
+	if err != nil {
+		return fmt.Errorf("error updating segment: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSegmentNotFound
+	}
+	return nil
+}
+
+// DeleteSegment removes a segment from the running order
+func (s *RunOfShowService) DeleteSegment(eventID, segmentID string) error {
+	result, err := s.db.Exec(`DELETE FROM event_segments WHERE id = $1 AND event_id = $2`, segmentID, eventID)
+	if err != nil {
+		return fmt.Errorf("error deleting segment: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSegmentNotFound
+	}
+	return nil
+}
+
+// Start starts the show clock on the first item of the running order
+func (s *RunOfShowService) Start(eventID string, at time.Time) (*RunOfShow, error) {
+	show, err := s.Get(eventID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range show.Items {
+		if item.StartedAt != nil {
+			return nil, ErrShowStarted
+		}
+	}
+	if len(show.Items) == 0 {
+		return nil, ErrEmptyRunOfShow
+	}
+	if err := s.setTiming(eventID, show.Items[0], "started_at", at); err != nil {
+		return nil, err
+	}
+	return s.Get(eventID)
+}
+
+// Advance ends the running item and starts the next one that hasn't run, if there is one
+func (s *RunOfShowService) Advance(eventID string, at time.Time) (*RunOfShow, error) {
+	show, err := s.Get(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !show.Live {
+		return nil, ErrShowNotRunning
+	}
+
+	var current, next *RunOfShowItem
+	for i := range show.Items {
+		item := &show.Items[i]
+		switch {
+		case show.CurrentItemID != nil && item.ID == *show.CurrentItemID:
+			current = item
+		case current != nil && next == nil && item.StartedAt == nil:
+			next = item
+		}
+	}
+	if err := s.setTiming(eventID, *current, "ended_at", at); err != nil {
+		return nil, err
+	}
+	if next != nil {
+		if err := s.setTiming(eventID, *next, "started_at", at); err != nil {
+			return nil, err
+		}
+	}
+	return s.Get(eventID)
+}
+
+// Reset clears the actual timings so the show can be run again
+func (s *RunOfShowService) Reset(eventID string) error {
+	for _, table := range []string{"event_games", "event_segments"} {
+		_, err := s.db.Exec(`UPDATE `+table+` SET started_at = NULL, ended_at = NULL WHERE event_id = $1`, eventID)
+		if err != nil {
+			return fmt.Errorf("error resetting %s timings: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// setTiming records when an item started or ended
+func (s *RunOfShowService) setTiming(eventID string, item RunOfShowItem, column string, at time.Time) error {
+	var err error
+	if item.GameID != nil {
+		_, err = s.db.Exec(`UPDATE event_games SET `+column+` = $1 WHERE event_id = $2 AND game_id = $3`, at.UTC(), eventID, *item.GameID)
+	} else {
+		_, err = s.db.Exec(`UPDATE event_segments SET `+column+` = $1 WHERE event_id = $2 AND id = $3`, at.UTC(), eventID, item.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("error timing %s: %v", item.Title, err)
+	}
+	return nil
+}
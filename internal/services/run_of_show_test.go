@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newRunOfShowTestService(t *testing.T) *RunOfShowService {
+	t.Helper()
+	testDB := newTestDB(t, seedGroup, seedPublicShow)
+	_, err := testDB.Exec(`
+		INSERT INTO games (id, name, min_players, max_players, created_by, group_id)
+		VALUES ('game1', 'Freeze Tag', 2, 6, 'user123', 'group123'), ('game2', 'Party Quirks', 4, 4, 'user123', 'group123');
+		INSERT INTO event_games (event_id, game_id, order_index) VALUES ('public1', 'game1', 0), ('public1', 'game2', 1);
+	`)
+	if err != nil {
+		t.Fatalf("Error seeding lineup: %v", err)
+	}
+	return NewRunOfShowService(testDB)
+}
+
+func minutes(n int) *int {
+	return &n
+}
+
+func itemTitles(show *RunOfShow) []string {
+	titles := make([]string, len(show.Items))
+	for i, item := range show.Items {
+		titles[i] = item.Title
+	}
+	return titles
+}
+
+func TestRunOfShow_PlannedStarts(t *testing.T) {
+	service := newRunOfShowTestService(t)
+
+	if err := service.PlanGame("public1", "game1", "", minutes(10)); err != nil {
+		t.Fatalf("Error planning game: %v", err)
+	}
+	if err := service.PlanGame("public1", "game2", SegmentAudienceBit, minutes(15)); err != nil {
+		t.Fatalf("Error planning game: %v", err)
+	}
+	if err := service.PlanGame("public1", "game3", SegmentGame, minutes(5)); !errors.Is(err, ErrSegmentNotFound) {
+		t.Errorf("Expected ErrSegmentNotFound for a game outside the lineup, got %v", err)
+	}
+	for _, segment := range []Segment{
+		{Type: SegmentBreak, Title: "Intermission", PlannedMinutes: minutes(20), BeforeGame: 1},
+		{Type: SegmentIntro, Title: "Welcome", PlannedMinutes: minutes(5), BeforeGame: 0},
+		{Type: SegmentAudienceBit, Title: "Raffle", BeforeGame: 9},
+	} {
+		if _, err := service.AddSegment("public1", segment); err != nil {
+			t.Fatalf("Error adding segment %s: %v", segment.Title, err)
+		}
+	}
+	if _, err := service.AddSegment("public1", Segment{Type: "encore", Title: "Encore"}); !errors.Is(err, ErrInvalidSegment) {
+		t.Errorf("Expected ErrInvalidSegment for an unknown type, got %v", err)
+	}
+
+	show, err := service.Get("public1")
+	if err != nil {
+		t.Fatalf("Error fetching run-of-show: %v", err)
+	}
+	want := []struct {
+		title string
+		start string
+	}{
+		{"Welcome", "19:00"},
+		{"Freeze Tag", "19:05"},
+		{"Intermission", "19:15"},
+		{"Party Quirks", "19:35"},
+		{"Raffle", "19:50"},
+	}
+	if len(show.Items) != len(want) {
+		t.Fatalf("Expected %d items, got %v", len(want), itemTitles(show))
+	}
+	for i, w := range want {
+		item := show.Items[i]
+		if item.Title != w.title || item.PlannedStart.Format("15:04") != w.start {
+			t.Errorf("Item %d: expected %s at %s, got %s at %s", i, w.title, w.start, item.Title, item.PlannedStart.Format("15:04"))
+		}
+	}
+	if show.Items[3].SegmentType != SegmentAudienceBit || show.PlannedMinutes != 50 || show.PlannedEnd.Format("15:04") != "19:50" {
+		t.Errorf("Expected 50 planned minutes ending at 19:50, got %d ending at %s", show.PlannedMinutes, show.PlannedEnd.Format("15:04"))
+	}
+
+	// Segments stay at their place as games move around
+	if _, err := service.db.Exec(`UPDATE event_games SET order_index = 1 - order_index WHERE event_id = 'public1'`); err != nil {
+		t.Fatalf("Error reordering games: %v", err)
+	}
+	show, _ = service.Get("public1")
+	if show.Items[1].Title != "Party Quirks" || show.Items[2].Title != "Intermission" {
+		t.Errorf("Expected the intermission to stay after the first game, got %v", itemTitles(show))
+	}
+}
+
+func TestRunOfShow_LiveTimings(t *testing.T) {
+	service := newRunOfShowTestService(t)
+	service.PlanGame("public1", "game1", SegmentGame, minutes(10))
+	service.PlanGame("public1", "game2", SegmentGame, minutes(10))
+	breakID, err := service.AddSegment("public1", Segment{Type: SegmentBreak, Title: "Intermission", PlannedMinutes: minutes(15), BeforeGame: 1})
+	if err != nil {
+		t.Fatalf("Error adding segment: %v", err)
+	}
+
+	if _, err := service.Advance("public1", time.Now()); !errors.Is(err, ErrShowNotRunning) {
+		t.Errorf("Expected ErrShowNotRunning before the show starts, got %v", err)
+	}
+	start := time.Date(2026, 2, 1, 19, 2, 0, 0, time.UTC)
+	show, err := service.Start("public1", start)
+	if err != nil {
+		t.Fatalf("Error starting show: %v", err)
+	}
+	if !show.Live || show.CurrentItemID == nil || *show.CurrentItemID != "game1" {
+		t.Fatalf("Expected the first game running, got %+v", show)
+	}
+	if _, err := service.Start("public1", start); !errors.Is(err, ErrShowStarted) {
+		t.Errorf("Expected ErrShowStarted starting twice, got %v", err)
+	}
+
+	// Freeze Tag runs 2 minutes over, the break a minute short and Party Quirks on time
+	for _, at := range []time.Time{start.Add(12 * time.Minute), start.Add(26 * time.Minute)} {
+		if show, err = service.Advance("public1", at); err != nil {
+			t.Fatalf("Error advancing show: %v", err)
+		}
+	}
+	if *show.CurrentItemID != "game2" {
+		t.Fatalf("Expected the second game running, got %v", *show.CurrentItemID)
+	}
+	if show, err = service.Advance("public1", start.Add(36*time.Minute)); err != nil {
+		t.Fatalf("Error advancing show: %v", err)
+	}
+	if show.Live || !show.Finished {
+		t.Fatalf("Expected the show over, got live=%v finished=%v", show.Live, show.Finished)
+	}
+	overruns := map[string]int{"game1": 120, breakID: -60, "game2": 0}
+	for _, item := range show.Items {
+		if item.OverrunSeconds == nil || *item.OverrunSeconds != overruns[item.ID] {
+			t.Errorf("Expected %s to overrun by %ds, got %v", item.Title, overruns[item.ID], item.OverrunSeconds)
+		}
+	}
+	if show.OverrunSeconds != 60 {
+		t.Errorf("Expected the show to overrun by 60s, got %d", show.OverrunSeconds)
+	}
+
+	if err := service.Reset("public1"); err != nil {
+		t.Fatalf("Error resetting show: %v", err)
+	}
+	if show, _ = service.Get("public1"); show.Finished || show.Items[0].StartedAt != nil {
+		t.Errorf("Expected the timings cleared, got %+v", show.Items[0])
+	}
+}
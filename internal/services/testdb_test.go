@@ -0,0 +1,28 @@
+package services
+
+import "improv-app/internal/testutil"
+
+// The test database helpers and group123 are shared with other packages' tests, in testutil.
+// Tests seed the fixtures they need and add their own rows with seed.
+var (
+	newTestDB = testutil.NewDB
+	seed      = testutil.Seed
+
+	// seedGroup is Ada Admin and group123, the group they run
+	seedGroup = testutil.SeedGroup
+	// seedOutsider is a user who isn't in any group
+	seedOutsider = []string{
+		`INSERT INTO users (id, email) VALUES ('outsider', 'outsider@example.com')`,
+	}
+	// seedPublicShow is group123's public show, public1, which Ada MCs and is attending
+	seedPublicShow = []string{
+		`INSERT INTO events (id, group_id, title, description, location, start_time, end_time, created_by, visibility, mc_id)
+		 VALUES ('public1', 'group123', 'Public Show', 'Come one, come all', 'Main Stage', '2026-02-01 19:00:00', '2026-02-01 21:00:00', 'user123', 'public', 'user123')`,
+		`INSERT INTO event_rsvps (event_id, user_id, status) VALUES ('public1', 'user123', 'attending')`,
+	}
+	// seedRehearsal is group123's private rehearsal, private1, the night after the show
+	seedRehearsal = []string{
+		`INSERT INTO events (id, group_id, title, start_time, end_time, created_by, visibility)
+		 VALUES ('private1', 'group123', 'Rehearsal', '2026-02-02 19:00:00', '2026-02-02 21:00:00', 'user123', 'private')`,
+	}
+)
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnknownTimeZone means a time zone isn't an IANA zone name like America/Chicago
+var ErrUnknownTimeZone = errors.New("unknown time zone")
+
+// localTimeLayouts are accepted for times given without an offset, which are read in the event's zone
+var localTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// LoadTimeZone loads an IANA time zone. An empty name is UTC.
+func LoadTimeZone(name string) (*time.Location, error) {
+	// "Local" would be the server's zone, which means nothing to a group
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTimeZone, name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTimeZone, name)
+	}
+	return loc, nil
+}
+
+// EventLocation returns an event's zone, or UTC if it's unknown
+func EventLocation(name string) *time.Location {
+	loc, err := LoadTimeZone(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// GroupTimeZone returns the zone the group's new events are in when none is chosen
+func GroupTimeZone(db *sql.DB, groupID string) (string, error) {
+	var zone string
+	err := db.QueryRow(`SELECT time_zone FROM improv_groups WHERE id = $1`, groupID).Scan(&zone)
+	if err != nil {
+		return "", fmt.Errorf("error fetching group time zone: %v", err)
+	}
+	return zone, nil
+}
+
+// ParseEventTime parses an event time for storage, returning it in UTC. Times with an offset
+// are taken as they are; times without one are wall-clock times in loc. A wall-clock time
+// skipped when the clocks go forward is moved forward with them, so 2:30 AM becomes 3:30 AM.
+func ParseEventTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	for _, layout := range localTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			continue
+		}
+		wall, _ := time.Parse(layout, value)
+		local := t.In(loc)
+		shown := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+		return t.Add(wall.Sub(shown)).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// LocalTimes is an event's start and end in its own time zone, to go alongside its UTC times
+type LocalTimes struct {
+	StartTimeLocal string `json:"startTimeLocal"`
+	EndTimeLocal   string `json:"endTimeLocal"`
+}
+
+// NewLocalTimes formats start and end as RFC3339 in the zone
+func NewLocalTimes(start, end time.Time, zone string) LocalTimes {
+	loc := EventLocation(zone)
+	return LocalTimes{
+		StartTimeLocal: start.In(loc).Format(time.RFC3339),
+		EndTimeLocal:   end.In(loc).Format(time.RFC3339),
+	}
+}
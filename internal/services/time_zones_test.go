@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseEventTime(t *testing.T) {
+	chicago, err := LoadTimeZone("America/Chicago")
+	if err != nil {
+		t.Fatalf("Error loading zone: %v", err)
+	}
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"offset is kept", "2026-03-07T19:00:00-08:00", "2026-03-08T03:00:00Z"},
+		{"before spring forward", "2026-03-07T19:00", "2026-03-08T01:00:00Z"},
+		{"after spring forward", "2026-03-08T19:00:00", "2026-03-09T00:00:00Z"},
+		{"skipped hour moves forward", "2026-03-08T02:30", "2026-03-08T08:30:00Z"},
+		{"before fall back", "2026-10-31T19:00", "2026-11-01T00:00:00Z"},
+		{"after fall back", "2026-11-01T19:00", "2026-11-02T01:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEventTime(tt.value, chicago)
+			if err != nil {
+				t.Fatalf("Error parsing %s: %v", tt.value, err)
+			}
+			if got.Location() != time.UTC || got.Format(time.RFC3339) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got.Format(time.RFC3339))
+			}
+		})
+	}
+
+	if _, err := ParseEventTime("next Friday", chicago); err == nil {
+		t.Error("Expected an error for an unparseable time")
+	}
+	for _, zone := range []string{"Mars/Olympus", "Local"} {
+		if _, err := LoadTimeZone(zone); !errors.Is(err, ErrUnknownTimeZone) {
+			t.Errorf("Expected ErrUnknownTimeZone for %s, got %v", zone, err)
+		}
+	}
+}
+
+func TestNewLocalTimes_AcrossDST(t *testing.T) {
+	// The same 7 PM show a week apart, either side of the November change
+	before := NewLocalTimes(time.Date(2026, 10, 29, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 29, 2, 0, 0, 0, time.UTC), "America/Chicago")
+	after := NewLocalTimes(time.Date(2026, 11, 6, 1, 0, 0, 0, time.UTC), time.Date(2026, 11, 6, 3, 0, 0, 0, time.UTC), "America/Chicago")
+	if before.StartTimeLocal != "2026-10-28T19:00:00-05:00" || before.EndTimeLocal != "2026-10-28T21:00:00-05:00" {
+		t.Errorf("Unexpected local times before the change: %+v", before)
+	}
+	if after.StartTimeLocal != "2026-11-05T19:00:00-06:00" || after.EndTimeLocal != "2026-11-05T21:00:00-06:00" {
+		t.Errorf("Unexpected local times after the change: %+v", after)
+	}
+
+	// Events without a known zone are shown in UTC
+	utc := NewLocalTimes(time.Date(2026, 11, 6, 1, 0, 0, 0, time.UTC), time.Date(2026, 11, 6, 3, 0, 0, 0, time.UTC), "")
+	if utc.StartTimeLocal != "2026-11-06T01:00:00Z" {
+		t.Errorf("Expected UTC local times, got %+v", utc)
+	}
+}
+
+func TestEventSeries_OccurrencesKeepWallClockAcrossDST(t *testing.T) {
+	service := NewEventSeriesService(newTestDB(t, seedGroup))
+	chicago, _ := LoadTimeZone("America/Chicago")
+	start := time.Date(2026, 10, 22, 19, 0, 0, 0, chicago)
+	series, err := service.Create(EventSeries{
+		GroupID:   "group123",
+		Title:     "Thursday Jam",
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=3",
+		TimeZone:  "America/Chicago",
+		CreatedBy: "user123",
+	})
+	if err != nil {
+		t.Fatalf("Error creating series: %v", err)
+	}
+
+	eventID, err := service.MaterializeFirst(*series, nil)
+	if err != nil {
+		t.Fatalf("Error saving first occurrence: %v", err)
+	}
+	var savedStart time.Time
+	var timeZone string
+	if err := service.db.QueryRow(`SELECT start_time, time_zone FROM events WHERE id = $1`, eventID).Scan(&savedStart, &timeZone); err != nil {
+		t.Fatalf("Error fetching saved occurrence: %v", err)
+	}
+	if timeZone != "America/Chicago" || !savedStart.Equal(start) || savedStart.Location() != time.UTC {
+		t.Errorf("Expected the saved occurrence in UTC with the series' zone, got %v in %s", savedStart, timeZone)
+	}
+
+	occurrences, err := service.Occurrences(*series, time.Time{}, start.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("Error expanding series: %v", err)
+	}
+	var local []string
+	for _, occurrence := range occurrences {
+		local = append(local, NewLocalTimes(occurrence.StartTime, occurrence.EndTime, occurrence.TimeZone).StartTimeLocal)
+	}
+	assertStarts(t, local, "2026-10-29T19:00:00-05:00", "2026-11-05T19:00:00-06:00")
+}
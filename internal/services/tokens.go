@@ -0,0 +1,16 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// generateToken returns a random hex token for secrets and URLs that mustn't be guessable,
+// like webhook signing secrets, calendar feed URLs and magic links
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// UnsubscribeClaims identifies the preference an unsubscribe link turns off
+type UnsubscribeClaims struct {
+	UserID           string
+	GroupID          string
+	NotificationType string
+	Channel          string
+}
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// unsubscribeSecret signs unsubscribe links, falling back to the session secret
+func unsubscribeSecret() []byte {
+	if secret := os.Getenv("UNSUBSCRIBE_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	return []byte(os.Getenv("SESSION_SECRET"))
+}
+
+func signUnsubscribePayload(payload string) string {
+	mac := hmac.New(sha256.New, unsubscribeSecret())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateUnsubscribeToken creates a signed token that turns off one notification preference
+func GenerateUnsubscribeToken(claims UnsubscribeClaims) string {
+	payload := strings.Join([]string{claims.UserID, claims.GroupID, claims.NotificationType, claims.Channel}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signUnsubscribePayload(payload)
+}
+
+// ParseUnsubscribeToken verifies a token's signature and returns its claims
+func ParseUnsubscribeToken(token string) (UnsubscribeClaims, error) {
+	encodedPayload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return UnsubscribeClaims{}, ErrInvalidUnsubscribeToken
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return UnsubscribeClaims{}, ErrInvalidUnsubscribeToken
+	}
+	payload := string(payloadBytes)
+
+	if !hmac.Equal([]byte(signature), []byte(signUnsubscribePayload(payload))) {
+		return UnsubscribeClaims{}, ErrInvalidUnsubscribeToken
+	}
+
+	parts := strings.Split(payload, "|")
+	if len(parts) != 4 || parts[0] == "" || !IsValidNotificationType(parts[2]) || !IsValidNotificationChannel(parts[3]) {
+		return UnsubscribeClaims{}, ErrInvalidUnsubscribeToken
+	}
+
+	return UnsubscribeClaims{
+		UserID:           parts[0],
+		GroupID:          parts[1],
+		NotificationType: parts[2],
+		Channel:          parts[3],
+	}, nil
+}
+
+// UnsubscribeURL returns the one-click unsubscribe link for a preference
+func UnsubscribeURL(claims UnsubscribeClaims) string {
+	return fmt.Sprintf("%s/api/unsubscribe?token=%s", os.Getenv("BASE_URL"), url.QueryEscape(GenerateUnsubscribeToken(claims)))
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUnsubscribeToken_RoundTrip(t *testing.T) {
+	os.Setenv("UNSUBSCRIBE_SECRET", "test-secret")
+	defer os.Unsetenv("UNSUBSCRIBE_SECRET")
+
+	claims := UnsubscribeClaims{
+		UserID:           "user123",
+		GroupID:          "group123",
+		NotificationType: NotificationReminder,
+		Channel:          ChannelEmail,
+	}
+
+	parsed, err := ParseUnsubscribeToken(GenerateUnsubscribeToken(claims))
+	if err != nil {
+		t.Fatalf("Expected token to parse, got error: %v", err)
+	}
+	if parsed != claims {
+		t.Errorf("Expected claims %+v, got %+v", claims, parsed)
+	}
+}
+
+func TestUnsubscribeToken_Tampered(t *testing.T) {
+	os.Setenv("UNSUBSCRIBE_SECRET", "test-secret")
+	defer os.Unsetenv("UNSUBSCRIBE_SECRET")
+
+	token := GenerateUnsubscribeToken(UnsubscribeClaims{
+		UserID:           "user123",
+		NotificationType: NotificationReminder,
+		Channel:          ChannelEmail,
+	})
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// Swap in another user's payload while keeping the original signature
+	other := GenerateUnsubscribeToken(UnsubscribeClaims{
+		UserID:           "user456",
+		NotificationType: NotificationReminder,
+		Channel:          ChannelEmail,
+	})
+	otherPayload, _, _ := strings.Cut(other, ".")
+
+	if _, err := ParseUnsubscribeToken(otherPayload + "." + signature); err != ErrInvalidUnsubscribeToken {
+		t.Errorf("Expected tampered payload to be rejected, got %v", err)
+	}
+	if _, err := ParseUnsubscribeToken(payload); err != ErrInvalidUnsubscribeToken {
+		t.Errorf("Expected unsigned token to be rejected, got %v", err)
+	}
+
+	// A token signed with a different secret must not verify
+	os.Setenv("UNSUBSCRIBE_SECRET", "another-secret")
+	if _, err := ParseUnsubscribeToken(token); err != ErrInvalidUnsubscribeToken {
+		t.Errorf("Expected token signed with another secret to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidVenue means a venue is missing its name or has impossible details
+var ErrInvalidVenue = errors.New("invalid venue")
+
+// Venue is a place a group performs or rehearses
+type Venue struct {
+	ID        string   `json:"id"`
+	GroupID   string   `json:"groupId"`
+	Name      string   `json:"name"`
+	Address   string   `json:"address"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	// Capacity is how many people the room holds, which events can use as their own limit
+	Capacity           *int      `json:"capacity,omitempty"`
+	AccessibilityNotes string    `json:"accessibilityNotes"`
+	LoadInInstructions string    `json:"loadInInstructions"`
+	CreatedBy          string    `json:"createdBy"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+// Validate checks the venue has a name, and coordinates and capacity that make sense
+func (v Venue) Validate() error {
+	switch {
+	case strings.TrimSpace(v.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidVenue)
+	case (v.Latitude == nil) != (v.Longitude == nil):
+		return fmt.Errorf("%w: latitude and longitude go together", ErrInvalidVenue)
+	case v.Latitude != nil && (*v.Latitude < -90 || *v.Latitude > 90):
+		return fmt.Errorf("%w: latitude must be between -90 and 90", ErrInvalidVenue)
+	case v.Longitude != nil && (*v.Longitude < -180 || *v.Longitude > 180):
+		return fmt.Errorf("%w: longitude must be between -180 and 180", ErrInvalidVenue)
+	case v.Capacity != nil && *v.Capacity < 1:
+		return fmt.Errorf("%w: capacity must be at least 1", ErrInvalidVenue)
+	}
+	return nil
+}
+
+// VenueSuggestion is a set of free-text locations that look like the same place
+type VenueSuggestion struct {
+	// Name is the most used spelling
+	Name      string   `json:"name"`
+	Locations []string `json:"locations"`
+	// EventCount is how many events and series use one of the locations
+	EventCount int `json:"eventCount"`
+	// VenueID is set when the locations look like a venue the group already has
+	VenueID *string `json:"venueId,omitempty"`
+}
+
+// VenueService keeps groups' venue catalogues
+type VenueService struct {
+	db *sql.DB
+}
+
+func NewVenueService(db *sql.DB) *VenueService {
+	return &VenueService{db: db}
+}
+
+const venueColumns = `
+	id, group_id, name, address, latitude, longitude, capacity, accessibility_notes, load_in_instructions, created_by, created_at
+`
+
+func scanVenue(scanner interface{ Scan(...interface{}) error }) (Venue, error) {
+	var venue Venue
+	err := scanner.Scan(&venue.ID, &venue.GroupID, &venue.Name, &venue.Address, &venue.Latitude, &venue.Longitude,
+		&venue.Capacity, &venue.AccessibilityNotes, &venue.LoadInInstructions, &venue.CreatedBy, &venue.CreatedAt)
+	return venue, err
+}
+
+// List returns the group's venues by name
+func (s *VenueService) List(groupID string) ([]Venue, error) {
+	rows, err := s.db.Query(`
+		SELECT `+venueColumns+`
+		FROM venues
+		WHERE group_id = $1
+		ORDER BY name
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching venues: %v", err)
+	}
+	defer rows.Close()
+
+	venues := []Venue{}
+	for rows.Next() {
+		venue, err := scanVenue(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning venue: %v", err)
+		}
+		venues = append(venues, venue)
+	}
+	return venues, nil
+}
+
+// Get returns one of the group's venues, or nil if it doesn't exist
+func (s *VenueService) Get(groupID, venueID string) (*Venue, error) {
+	venue, err := scanVenue(s.db.QueryRow(`
+		SELECT `+venueColumns+`
+		FROM venues
+		WHERE id = $1 AND group_id = $2
+	`, venueID, groupID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching venue: %v", err)
+	}
+	return &venue, nil
+}
+
+// Save creates the venue when it has no ID, otherwise updates it
+func (s *VenueService) Save(venue Venue) (*Venue, error) {
+	if err := venue.Validate(); err != nil {
+		return nil, err
+	}
+	var err error
+	if venue.ID == "" {
+		venue.ID = uuid.New().String()
+		_, err = s.db.Exec(`
+			INSERT INTO venues (id, group_id, name, address, latitude, longitude, capacity, accessibility_notes, load_in_instructions, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, venue.ID, venue.GroupID, venue.Name, venue.Address, venue.Latitude, venue.Longitude, venue.Capacity,
+			venue.AccessibilityNotes, venue.LoadInInstructions, venue.CreatedBy)
+	} else {
+		_, err = s.db.Exec(`
+			UPDATE venues
+			SET name = $1, address = $2, latitude = $3, longitude = $4, capacity = $5, accessibility_notes = $6, load_in_instructions = $7
+			WHERE id = $8 AND group_id = $9
+		`, venue.Name, venue.Address, venue.Latitude, venue.Longitude, venue.Capacity, venue.AccessibilityNotes,
+			venue.LoadInInstructions, venue.ID, venue.GroupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error saving venue: %v", err)
+	}
+	return s.Get(venue.GroupID, venue.ID)
+}
+
+// Delete removes a venue. Its events and series keep their location text.
+func (s *VenueService) Delete(groupID, venueID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"events", "event_series"} {
+		_, err := tx.Exec(`UPDATE `+table+` SET venue_id = NULL WHERE venue_id = $1 AND group_id = $2`, venueID, groupID)
+		if err != nil {
+			return fmt.Errorf("error unlinking venue from %s: %v", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM venues WHERE id = $1 AND group_id = $2`, venueID, groupID); err != nil {
+		return fmt.Errorf("error deleting venue: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error deleting venue: %v", err)
+	}
+	return nil
+}
+
+// LinkLocations points the group's events and series at the venue when their location is one of
+// the given spellings and they don't have a venue yet. It returns how many were linked.
+func (s *VenueService) LinkLocations(groupID, venueID string, locations []string) (int64, error) {
+	if len(locations) == 0 {
+		return 0, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var linked int64
+	for _, table := range []string{"events", "event_series"} {
+		for _, location := range locations {
+			result, err := tx.Exec(`
+				UPDATE `+table+` SET venue_id = $1
+				WHERE group_id = $2 AND venue_id IS NULL AND TRIM(location) = $3
+			`, venueID, groupID, strings.TrimSpace(location))
+			if err != nil {
+				return 0, fmt.Errorf("error linking %s to venue: %v", table, err)
+			}
+			count, err := result.RowsAffected()
+			if err != nil {
+				return 0, fmt.Errorf("error linking %s to venue: %v", table, err)
+			}
+			linked += count
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error linking locations to venue: %v", err)
+	}
+	return linked, nil
+}
+
+// Suggest groups the free-text locations of the group's events and series that have no venue
+// into likely venues, so "The Annex", "annex theatre" and "Annex Theater" become one suggestion.
+// Suggestions are sorted by how many events they cover.
+func (s *VenueService) Suggest(groupID string) ([]VenueSuggestion, error) {
+	rows, err := s.db.Query(`
+		SELECT TRIM(location), COUNT(*) FROM (
+			SELECT location FROM events WHERE group_id = $1 AND venue_id IS NULL
+			UNION ALL
+			SELECT location FROM event_series WHERE group_id = $1 AND venue_id IS NULL
+		)
+		WHERE TRIM(COALESCE(location, '')) != ''
+		GROUP BY TRIM(location)
+		ORDER BY COUNT(*) DESC, TRIM(location)
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching event locations: %v", err)
+	}
+	defer rows.Close()
+
+	type cluster struct {
+		key        string
+		suggestion VenueSuggestion
+		topCount   int
+	}
+	var clusters []*cluster
+	for rows.Next() {
+		var location string
+		var count int
+		if err := rows.Scan(&location, &count); err != nil {
+			return nil, fmt.Errorf("error scanning event location: %v", err)
+		}
+		key := normalizeLocation(location)
+		var match *cluster
+		for _, c := range clusters {
+			if sameLocation(c.key, key) {
+				match = c
+				break
+			}
+		}
+		if match == nil {
+			match = &cluster{key: key}
+			clusters = append(clusters, match)
+		}
+		match.suggestion.Locations = append(match.suggestion.Locations, location)
+		match.suggestion.EventCount += count
+		// Rows come most used first, so the first spelling is the most common
+		if count > match.topCount {
+			match.suggestion.Name = location
+			match.topCount = count
+		}
+	}
+	rows.Close()
+
+	venues, err := s.List(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []VenueSuggestion{}
+	for _, c := range clusters {
+		for _, venue := range venues {
+			if sameLocation(c.key, normalizeLocation(venue.Name)) {
+				venueID := venue.ID
+				c.suggestion.VenueID = &venueID
+				break
+			}
+		}
+		sort.Strings(c.suggestion.Locations)
+		suggestions = append(suggestions, c.suggestion)
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].EventCount > suggestions[j].EventCount })
+	return suggestions, nil
+}
+
+// locationSpellings maps words that are spelled several ways to one spelling
+var locationSpellings = map[string]string{
+	"theatre": "theater",
+	"centre":  "center",
+	"st":      "street",
+	"ave":     "avenue",
+	"rd":      "road",
+	"&":       "and",
+}
+
+// normalizeLocation lowercases a location, drops punctuation and a leading "the",
+// and evens out common spelling differences
+func normalizeLocation(location string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(location) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '&':
+			b.WriteString(" & ")
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	words := strings.Fields(b.String())
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	}
+	for i, word := range words {
+		if spelling, ok := locationSpellings[word]; ok {
+			words[i] = spelling
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// sameLocation reports whether two normalized locations are probably the same place:
+// equal, one naming the other with extra words like "theater", or differing by a typo
+func sameLocation(a, b string) bool {
+	if a == b {
+		return true
+	}
+	shorter, longer := a, b
+	if len(shorter) > len(longer) {
+		shorter, longer = longer, shorter
+	}
+	if len(shorter) >= 4 && strings.HasPrefix(longer, shorter+" ") {
+		return true
+	}
+	allowed := 1
+	if len(shorter) >= 10 {
+		allowed = 2
+	}
+	return len(shorter) >= 4 && editDistance(a, b) <= allowed
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVenue_Validate(t *testing.T) {
+	lat, lng, zero := 47.6, -122.3, 0
+	outOfRange := 91.0
+	tests := []struct {
+		name  string
+		venue Venue
+		valid bool
+	}{
+		{"name only", Venue{Name: "The Annex"}, true},
+		{"coordinates", Venue{Name: "The Annex", Latitude: &lat, Longitude: &lng}, true},
+		{"no name", Venue{Name: " "}, false},
+		{"latitude without longitude", Venue{Name: "The Annex", Latitude: &lat}, false},
+		{"latitude out of range", Venue{Name: "The Annex", Latitude: &outOfRange, Longitude: &lng}, false},
+		{"zero capacity", Venue{Name: "The Annex", Capacity: &zero}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.venue.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Expected a valid venue, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidVenue) {
+				t.Errorf("Expected ErrInvalidVenue, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVenues_SuggestAndLink(t *testing.T) {
+	testDB := newTestDB(t, seedGroup, seedPublicShow)
+	_, err := testDB.Exec(`
+		INSERT INTO events (id, group_id, title, location, start_time, end_time, created_by) VALUES
+			('annex1', 'group123', 'Show', 'The Annex Theatre', '2026-03-01 19:00:00', '2026-03-01 21:00:00', 'user123'),
+			('annex2', 'group123', 'Show', 'The Annex Theatre', '2026-03-08 19:00:00', '2026-03-08 21:00:00', 'user123'),
+			('annex3', 'group123', 'Show', 'annex theater', '2026-03-15 19:00:00', '2026-03-15 21:00:00', 'user123'),
+			('annex4', 'group123', 'Show', 'Anex Theatre', '2026-03-22 19:00:00', '2026-03-22 21:00:00', 'user123'),
+			('park', 'group123', 'Jam', 'Cal Anderson Park', '2026-03-29 19:00:00', '2026-03-29 21:00:00', 'user123'),
+			('blank', 'group123', 'Jam', '', '2026-04-05 19:00:00', '2026-04-05 21:00:00', 'user123');
+	`)
+	if err != nil {
+		t.Fatalf("Error seeding events: %v", err)
+	}
+	service := NewVenueService(testDB)
+
+	suggestions, err := service.Suggest("group123")
+	if err != nil {
+		t.Fatalf("Error suggesting venues: %v", err)
+	}
+	// Main Stage comes from the seeded events, so there are three places
+	if len(suggestions) != 3 {
+		t.Fatalf("Expected 3 suggestions, got %+v", suggestions)
+	}
+	annex := suggestions[0]
+	if annex.Name != "The Annex Theatre" || annex.EventCount != 4 || len(annex.Locations) != 3 || annex.VenueID != nil {
+		t.Errorf("Expected the Annex spellings together under the most used one, got %+v", annex)
+	}
+
+	venue, err := service.Save(Venue{GroupID: "group123", Name: "Annex Theatre", Address: "600 Pine St", CreatedBy: "user123"})
+	if err != nil {
+		t.Fatalf("Error saving venue: %v", err)
+	}
+	// The suggestion now points at the venue it looks like
+	if suggestions, err = service.Suggest("group123"); err != nil || suggestions[0].VenueID == nil || *suggestions[0].VenueID != venue.ID {
+		t.Errorf("Expected the suggestion to match the new venue, got %+v (error: %v)", suggestions, err)
+	}
+
+	linked, err := service.LinkLocations("group123", venue.ID, annex.Locations)
+	if err != nil || linked != 4 {
+		t.Fatalf("Expected 4 events linked, got %d (error: %v)", linked, err)
+	}
+	if suggestions, err = service.Suggest("group123"); err != nil || len(suggestions) != 2 {
+		t.Errorf("Expected linked locations to drop out of the suggestions, got %+v (error: %v)", suggestions, err)
+	}
+
+	if err := service.Delete("group123", venue.ID); err != nil {
+		t.Fatalf("Error deleting venue: %v", err)
+	}
+	var stillLinked int
+	testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE venue_id IS NOT NULL`).Scan(&stillLinked)
+	if stillLinked != 0 {
+		t.Errorf("Expected events to be unlinked from the deleted venue, %d still linked", stillLinked)
+	}
+	if deleted, err := service.Get("group123", venue.ID); err != nil || deleted != nil {
+		t.Errorf("Expected the venue to be gone, got %+v (error: %v)", deleted, err)
+	}
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Group policies for linking walk-ins to the accounts that match their email
+const (
+	// WalkInLinkingAuto links matching walk-ins as soon as the account signs in
+	WalkInLinkingAuto = "auto"
+	// WalkInLinkingConfirm waits for an admin or organizer to confirm each match
+	WalkInLinkingConfirm = "confirm"
+)
+
+// Walk-in link statuses
+const (
+	WalkInLinkPending  = "pending"
+	WalkInLinkLinked   = "linked"
+	WalkInLinkRejected = "rejected"
+)
+
+var (
+	// ErrWalkInMatchNotFound means there's no pending match for the walk-in in the group
+	ErrWalkInMatchNotFound = errors.New("walk-in match not found")
+	// ErrNoWalkInHistory means the user hasn't been linked to a walk-in in the group
+	ErrNoWalkInHistory = errors.New("no walk-in history in this group")
+)
+
+// IsValidWalkInLinking reports whether policy is one of the walk-in linking policies
+func IsValidWalkInLinking(policy string) bool {
+	return policy == WalkInLinkingAuto || policy == WalkInLinkingConfirm
+}
+
+// WalkInMatch is a walk-in matched by email to an account
+type WalkInMatch struct {
+	AttendeeID     string    `json:"attendeeId"`
+	EventID        string    `json:"eventId"`
+	EventTitle     string    `json:"eventTitle"`
+	EventStartTime time.Time `json:"eventStartTime"`
+	GroupID        string    `json:"groupId"`
+	FirstName      string    `json:"firstName"`
+	LastName       string    `json:"lastName"`
+	Email          string    `json:"email"`
+	UserID         string    `json:"userId"`
+	UserName       string    `json:"userName"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// WalkInGroup is a group someone came to as a walk-in but hasn't joined
+type WalkInGroup struct {
+	GroupID   string `json:"groupId"`
+	GroupName string `json:"groupName"`
+	// Events is how many of the group's events they came to
+	Events int `json:"events"`
+}
+
+// DuplicateWalkIn is a walk-in already at the event with the same email or name
+type DuplicateWalkIn struct {
+	ID        string  `json:"id"`
+	FirstName string  `json:"firstName"`
+	LastName  string  `json:"lastName"`
+	Email     *string `json:"email,omitempty"`
+}
+
+// WalkInLinkService links walk-ins to accounts and moves their history onto the account
+type WalkInLinkService struct {
+	db *sql.DB
+}
+
+func NewWalkInLinkService(db *sql.DB) *WalkInLinkService {
+	return &WalkInLinkService{db: db}
+}
+
+// normalizeEmail is how emails are compared, since walk-in emails are typed in by hand
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// walkInCandidate is an unlinked walk-in whose email matches an account
+type walkInCandidate struct {
+	attendeeID string
+	eventID    string
+	groupID    string
+	firstName  string
+	lastName   string
+	email      string
+	userID     string
+	policy     string
+}
+
+// MatchUser matches the walk-ins with the user's email to the account, linking them
+// or leaving them for an admin to confirm as each group's policy says. It's only called
+// once the user has verified their email. It returns how many were linked and how many
+// are waiting.
+func (s *WalkInLinkService) MatchUser(userID string) (int, int, error) {
+	rows, err := s.db.Query(`
+		SELECT n.id, n.event_id, e.group_id, n.first_name, n.last_name, n.email, u.id, g.walk_in_linking
+		FROM non_registered_attendees n
+		JOIN users u ON LOWER(TRIM(n.email)) = LOWER(TRIM(u.email))
+		JOIN events e ON n.event_id = e.id
+		JOIN improv_groups g ON e.group_id = g.id
+		WHERE u.id = $1
+		  AND NOT EXISTS (SELECT 1 FROM walk_in_links l WHERE l.attendee_id = n.id)
+	`, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error matching walk-ins: %v", err)
+	}
+	var candidates []walkInCandidate
+	for rows.Next() {
+		var c walkInCandidate
+		if err := rows.Scan(&c.attendeeID, &c.eventID, &c.groupID, &c.firstName, &c.lastName, &c.email, &c.userID, &c.policy); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("error scanning walk-in match: %v", err)
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+
+	linked, pending := 0, 0
+	for _, c := range candidates {
+		status := WalkInLinkPending
+		if c.policy == WalkInLinkingAuto {
+			status = WalkInLinkLinked
+		}
+		if err := s.record(c, status); err != nil {
+			return linked, pending, err
+		}
+		if status == WalkInLinkLinked {
+			linked++
+		} else {
+			pending++
+		}
+	}
+	return linked, pending, nil
+}
+
+// record saves the match, and links the walk-in straight away when status is linked
+func (s *WalkInLinkService) record(c walkInCandidate, status string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO walk_in_links (attendee_id, event_id, group_id, user_id, first_name, last_name, email, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, c.attendeeID, c.eventID, c.groupID, c.userID, c.firstName, c.lastName, normalizeEmail(c.email), status)
+	if err != nil {
+		return fmt.Errorf("error saving walk-in match: %v", err)
+	}
+	var promoted []string
+	if status == WalkInLinkLinked {
+		if promoted, err = linkWalkIn(tx, c.attendeeID, c.eventID, c.userID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error saving walk-in match: %v", err)
+	}
+	s.notifyPromoted(c.eventID, c.groupID, promoted)
+	return nil
+}
+
+// linkWalkIn moves a walk-in's place at the event onto the account: they keep their game
+// assignments and attendance, the walk-in is removed, and the account RSVPs attending. The
+// walk-in's spot is freed like any other, so the waitlist gets it first and the account is
+// waitlisted if the event is still full. It returns the members moved off the waitlist.
+func linkWalkIn(q queryer, attendeeID, eventID, userID string) ([]string, error) {
+	_, err := q.Exec(`
+		UPDATE OR IGNORE event_player_assignments SET user_id = $1
+		WHERE event_id = $2 AND user_id = $3
+	`, userID, eventID, attendeeID)
+	if err != nil {
+		return nil, fmt.Errorf("error moving walk-in game assignments: %v", err)
+	}
+
+	// An account already checked in keeps its own attendance
+	_, err = q.Exec(`
+		UPDATE OR IGNORE event_attendance SET attendee_id = $1, attendee_type = 'member'
+		WHERE event_id = $2 AND attendee_id = $3
+	`, userID, eventID, attendeeID)
+	if err != nil {
+		return nil, fmt.Errorf("error moving walk-in attendance: %v", err)
+	}
+
+	// Whatever was left behind as a duplicate goes with the walk-in
+	for _, statement := range []string{
+		`DELETE FROM event_player_assignments WHERE event_id = $1 AND user_id = $2`,
+		`DELETE FROM event_attendance WHERE event_id = $1 AND attendee_id = $2`,
+		`DELETE FROM non_registered_attendees WHERE event_id = $1 AND id = $2`,
+	} {
+		if _, err := q.Exec(statement, eventID, attendeeID); err != nil {
+			return nil, fmt.Errorf("error removing walk-in: %v", err)
+		}
+	}
+
+	promoted, err := promoteWaitlist(q, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := setRSVP(q, eventID, userID, RSVPAttending, false); err != nil {
+		return nil, fmt.Errorf("error moving walk-in RSVP: %v", err)
+	}
+	return promoted, nil
+}
+
+// notifyPromoted tells the members moved off the waitlist when a linked walk-in's spot opened up
+func (s *WalkInLinkService) notifyPromoted(eventID, groupID string, promoted []string) {
+	if len(promoted) == 0 {
+		return
+	}
+	var title string
+	if err := s.db.QueryRow(`SELECT title FROM events WHERE id = $1`, eventID).Scan(&title); err != nil {
+		log.Printf("Error fetching event %s for waitlist notifications: %v", eventID, err)
+		return
+	}
+	notificationService := NewNotificationService(s.db)
+	for _, userID := range promoted {
+		content := fmt.Sprintf("A spot opened up for %s, so you've been moved off the waitlist and are now attending", title)
+		if err := notificationService.Notify(userID, groupID, NotificationWaitlist, content, eventID); err != nil {
+			log.Printf("Error creating waitlist notification for user %s: %v", userID, err)
+		}
+	}
+}
+
+// Confirm links a pending match
+func (s *WalkInLinkService) Confirm(groupID, attendeeID, resolvedBy string) error {
+	return s.resolve(groupID, attendeeID, resolvedBy, WalkInLinkLinked)
+}
+
+// Reject turns down a pending match, leaving the walk-in as it was
+func (s *WalkInLinkService) Reject(groupID, attendeeID, resolvedBy string) error {
+	return s.resolve(groupID, attendeeID, resolvedBy, WalkInLinkRejected)
+}
+
+func (s *WalkInLinkService) resolve(groupID, attendeeID, resolvedBy, status string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var eventID, userID string
+	err = tx.QueryRow(`
+		SELECT event_id, user_id FROM walk_in_links
+		WHERE attendee_id = $1 AND group_id = $2 AND status = 'pending'
+	`, attendeeID, groupID).Scan(&eventID, &userID)
+	if err == sql.ErrNoRows {
+		return ErrWalkInMatchNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching walk-in match: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE walk_in_links SET status = $1, resolved_by = $2, resolved_at = $3
+		WHERE attendee_id = $4
+	`, status, resolvedBy, time.Now().UTC(), attendeeID)
+	if err != nil {
+		return fmt.Errorf("error updating walk-in match: %v", err)
+	}
+	var promoted []string
+	if status == WalkInLinkLinked {
+		if promoted, err = linkWalkIn(tx, attendeeID, eventID, userID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error updating walk-in match: %v", err)
+	}
+	s.notifyPromoted(eventID, groupID, promoted)
+	return nil
+}
+
+// DiscardPending drops a walk-in's match that's still waiting, for when the walk-in is removed
+func (s *WalkInLinkService) DiscardPending(attendeeID string) error {
+	_, err := s.db.Exec(`DELETE FROM walk_in_links WHERE attendee_id = $1 AND status = 'pending'`, attendeeID)
+	if err != nil {
+		return fmt.Errorf("error discarding walk-in match: %v", err)
+	}
+	return nil
+}
+
+// Pending returns the group's matches waiting for confirmation, oldest event first
+func (s *WalkInLinkService) Pending(groupID string) ([]WalkInMatch, error) {
+	rows, err := s.db.Query(`
+		SELECT l.attendee_id, l.event_id, e.title, e.start_time, l.group_id, l.first_name, l.last_name, l.email,
+		       l.user_id, TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), l.status, l.created_at
+		FROM walk_in_links l
+		JOIN events e ON l.event_id = e.id
+		JOIN users u ON l.user_id = u.id
+		WHERE l.group_id = $1 AND l.status = 'pending'
+		ORDER BY e.start_time, l.last_name, l.first_name
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching walk-in matches: %v", err)
+	}
+	defer rows.Close()
+
+	matches := []WalkInMatch{}
+	for rows.Next() {
+		var m WalkInMatch
+		err := rows.Scan(&m.AttendeeID, &m.EventID, &m.EventTitle, &m.EventStartTime, &m.GroupID, &m.FirstName, &m.LastName, &m.Email,
+			&m.UserID, &m.UserName, &m.Status, &m.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning walk-in match: %v", err)
+		}
+		m.EventStartTime = m.EventStartTime.UTC()
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+// GroupsToJoin returns the groups the user came to as a linked walk-in but isn't a member of
+func (s *WalkInLinkService) GroupsToJoin(userID string) ([]WalkInGroup, error) {
+	rows, err := s.db.Query(`
+		SELECT g.id, g.name, COUNT(DISTINCT l.event_id)
+		FROM walk_in_links l
+		JOIN improv_groups g ON l.group_id = g.id
+		WHERE l.user_id = $1 AND l.status = 'linked'
+		  AND NOT EXISTS (SELECT 1 FROM group_members m WHERE m.group_id = g.id AND m.user_id = $1)
+		GROUP BY g.id, g.name
+		ORDER BY g.name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching walk-in groups: %v", err)
+	}
+	defer rows.Close()
+
+	groups := []WalkInGroup{}
+	for rows.Next() {
+		var group WalkInGroup
+		if err := rows.Scan(&group.GroupID, &group.GroupName, &group.Events); err != nil {
+			return nil, fmt.Errorf("error scanning walk-in group: %v", err)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// Join adds the user to a group they came to as a linked walk-in, as a member
+func (s *WalkInLinkService) Join(userID, groupID string) error {
+	var hasHistory bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM walk_in_links WHERE user_id = $1 AND group_id = $2 AND status = 'linked')
+	`, userID, groupID).Scan(&hasHistory)
+	if err != nil {
+		return fmt.Errorf("error checking walk-in history: %v", err)
+	}
+	if !hasHistory {
+		return ErrNoWalkInHistory
+	}
+
+	_, err = s.db.Exec(`
+		INSERT OR IGNORE INTO group_members (group_id, user_id, role) VALUES ($1, $2, 'member')
+	`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("error joining group: %v", err)
+	}
+	return nil
+}
+
+// FindDuplicates returns the event's walk-ins with the same email, or the same name, ignoring case
+func (s *WalkInLinkService) FindDuplicates(eventID, firstName, lastName, email string) ([]DuplicateWalkIn, error) {
+	rows, err := s.db.Query(`
+		SELECT id, first_name, last_name, email FROM non_registered_attendees
+		WHERE event_id = $1
+		  AND (($2 != '' AND LOWER(TRIM(email)) = $2)
+		       OR (LOWER(TRIM(first_name)) = $3 AND LOWER(TRIM(last_name)) = $4))
+		ORDER BY created_at
+	`, eventID, normalizeEmail(email), strings.ToLower(strings.TrimSpace(firstName)), strings.ToLower(strings.TrimSpace(lastName)))
+	if err != nil {
+		return nil, fmt.Errorf("error checking for duplicate walk-ins: %v", err)
+	}
+	defer rows.Close()
+
+	duplicates := []DuplicateWalkIn{}
+	for rows.Next() {
+		var duplicate DuplicateWalkIn
+		if err := rows.Scan(&duplicate.ID, &duplicate.FirstName, &duplicate.LastName, &duplicate.Email); err != nil {
+			return nil, fmt.Errorf("error scanning walk-in: %v", err)
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+	return duplicates, nil
+}
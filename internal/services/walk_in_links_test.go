@@ -0,0 +1,171 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// newWalkInTestDB adds Walt, a walk-in at public1 with a game and a check-in, to the public
+// show, and Walt's account under a differently typed email
+func newWalkInTestDB(t *testing.T) *sql.DB {
+	return newTestDB(t, seedGroup, seedPublicShow, []string{
+		`INSERT INTO non_registered_attendees (id, event_id, first_name, last_name, email) VALUES ('walkin1', 'public1', 'Walt', 'Walker', ' Walt@Example.com')`,
+		`INSERT INTO event_player_assignments (event_id, game_id, user_id) VALUES ('public1', 'game1', 'walkin1')`,
+		`INSERT INTO event_attendance (event_id, attendee_id, attendee_type, status, checked_in_at, method, recorded_by)
+		 VALUES ('public1', 'walkin1', 'walk-in', 'late', '2026-02-01 19:20:00', 'organizer', 'user123')`,
+		`INSERT INTO users (id, email, first_name, last_name) VALUES ('walt', 'walt@example.com', 'Walt', 'Walker')`,
+	})
+}
+
+func TestWalkInLinks_AutoLinkMovesHistory(t *testing.T) {
+	testDB := newWalkInTestDB(t)
+	service := NewWalkInLinkService(testDB)
+
+	linked, pending, err := service.MatchUser("walt")
+	if err != nil {
+		t.Fatalf("Error matching walk-ins: %v", err)
+	}
+	if linked != 1 || pending != 0 {
+		t.Fatalf("Expected 1 linked walk-in, got %d linked and %d pending", linked, pending)
+	}
+
+	var rsvpStatus, assignedGame string
+	if err := testDB.QueryRow(`SELECT status FROM event_rsvps WHERE event_id = 'public1' AND user_id = 'walt'`).Scan(&rsvpStatus); err != nil || rsvpStatus != RSVPAttending {
+		t.Errorf("Expected an attending RSVP, got %q (%v)", rsvpStatus, err)
+	}
+	if err := testDB.QueryRow(`SELECT game_id FROM event_player_assignments WHERE event_id = 'public1' AND user_id = 'walt'`).Scan(&assignedGame); err != nil || assignedGame != "game1" {
+		t.Errorf("Expected the game assignment moved, got %q (%v)", assignedGame, err)
+	}
+	attendance, err := NewAttendanceService(testDB).Get("public1", "walt")
+	if err != nil || attendance == nil || attendance.Status != AttendanceLate || attendance.AttendeeType != AttendeeMember {
+		t.Errorf("Expected the late check-in moved, got %+v (%v)", attendance, err)
+	}
+	var walkIns int
+	testDB.QueryRow(`SELECT COUNT(*) FROM non_registered_attendees`).Scan(&walkIns)
+	if walkIns != 0 {
+		t.Errorf("Expected the walk-in removed, got %d", walkIns)
+	}
+
+	// Walt can join the group they came to, and no other
+	groups, err := service.GroupsToJoin("walt")
+	if err != nil {
+		t.Fatalf("Error fetching groups to join: %v", err)
+	}
+	if len(groups) != 1 || groups[0].GroupID != "group123" || groups[0].Events != 1 {
+		t.Errorf("Expected group123 to join, got %+v", groups)
+	}
+	if err := service.Join("walt", "group456"); !errors.Is(err, ErrNoWalkInHistory) {
+		t.Errorf("Expected ErrNoWalkInHistory, got %v", err)
+	}
+	if err := service.Join("walt", "group123"); err != nil {
+		t.Fatalf("Error joining group: %v", err)
+	}
+	if groups, _ := service.GroupsToJoin("walt"); len(groups) != 0 {
+		t.Errorf("Expected no groups left to join, got %+v", groups)
+	}
+
+	// Signing in again doesn't match anything new
+	if linked, pending, _ := service.MatchUser("walt"); linked != 0 || pending != 0 {
+		t.Errorf("Expected nothing new to match, got %d linked and %d pending", linked, pending)
+	}
+}
+
+func TestWalkInLinks_LinkingKeepsTheWaitlist(t *testing.T) {
+	testDB := newWalkInTestDB(t)
+	// Ada and the walk-in fill the show, and Wren is ahead of Walt on the waitlist
+	seed(t, testDB,
+		`UPDATE events SET capacity = 2 WHERE id = 'public1'`,
+		`INSERT INTO users (id, email, first_name, last_name) VALUES ('wren', 'wren@example.com', 'Wren', 'Waiting')`,
+		`INSERT INTO group_members (group_id, user_id, role) VALUES ('group123', 'wren', 'member')`,
+		`INSERT INTO event_rsvps (event_id, user_id, status, waitlist_position) VALUES ('public1', 'wren', 'waitlisted', 1), ('public1', 'walt', 'waitlisted', 2)`,
+	)
+
+	if linked, _, err := NewWalkInLinkService(testDB).MatchUser("walt"); err != nil || linked != 1 {
+		t.Fatalf("Expected 1 linked walk-in, got %d (%v)", linked, err)
+	}
+
+	// The walk-in's spot goes to the front of the waitlist, not to Walt
+	statuses := map[string]string{}
+	rows, err := testDB.Query(`SELECT user_id, status FROM event_rsvps WHERE event_id = 'public1'`)
+	if err != nil {
+		t.Fatalf("Error fetching RSVPs: %v", err)
+	}
+	for rows.Next() {
+		var userID, status string
+		rows.Scan(&userID, &status)
+		statuses[userID] = status
+	}
+	rows.Close()
+	if statuses["user123"] != RSVPAttending || statuses["wren"] != RSVPAttending || statuses["walt"] != RSVPWaitlisted {
+		t.Errorf("Expected Wren promoted and Walt still waitlisted, got %v", statuses)
+	}
+	if position, _ := NewRSVPService(testDB).WaitlistPosition("public1", "walt"); position != 1 {
+		t.Errorf("Expected Walt first on the waitlist, got %d", position)
+	}
+	var notified int
+	testDB.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = 'wren' AND type = $1`, NotificationWaitlist).Scan(&notified)
+	if notified != 1 {
+		t.Errorf("Expected Wren told about the spot, got %d notifications", notified)
+	}
+}
+
+func TestWalkInLinks_ConfirmAndReject(t *testing.T) {
+	testDB := newWalkInTestDB(t)
+	if _, err := testDB.Exec(`UPDATE improv_groups SET walk_in_linking = 'confirm' WHERE id = 'group123'`); err != nil {
+		t.Fatalf("Error updating group: %v", err)
+	}
+	service := NewWalkInLinkService(testDB)
+
+	if _, pending, err := service.MatchUser("walt"); err != nil || pending != 1 {
+		t.Fatalf("Expected a pending match, got %d (%v)", pending, err)
+	}
+	matches, err := service.Pending("group123")
+	if err != nil {
+		t.Fatalf("Error fetching pending matches: %v", err)
+	}
+	if len(matches) != 1 || matches[0].UserID != "walt" || matches[0].Email != "walt@example.com" || matches[0].EventTitle != "Public Show" {
+		t.Fatalf("Unexpected pending matches %+v", matches)
+	}
+	var rsvps int
+	testDB.QueryRow(`SELECT COUNT(*) FROM event_rsvps WHERE user_id = 'walt'`).Scan(&rsvps)
+	if rsvps != 0 {
+		t.Error("Expected nothing moved before the match is confirmed")
+	}
+
+	if err := service.Reject("group123", "walkin1", "user123"); err != nil {
+		t.Fatalf("Error rejecting match: %v", err)
+	}
+	if err := service.Confirm("group123", "walkin1", "user123"); !errors.Is(err, ErrWalkInMatchNotFound) {
+		t.Errorf("Expected a rejected match to stay rejected, got %v", err)
+	}
+	var walkIns int
+	testDB.QueryRow(`SELECT COUNT(*) FROM non_registered_attendees`).Scan(&walkIns)
+	if walkIns != 1 {
+		t.Errorf("Expected the walk-in kept after rejecting, got %d", walkIns)
+	}
+}
+
+func TestWalkInLinks_FindDuplicates(t *testing.T) {
+	service := NewWalkInLinkService(newWalkInTestDB(t))
+	tests := []struct {
+		name                       string
+		firstName, lastName, email string
+		want                       int
+	}{
+		{"same email", "W", "W", "WALT@example.com ", 1},
+		{"same name", " walt", "WALKER", "", 1},
+		{"someone else", "Wanda", "Walker", "wanda@example.com", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duplicates, err := service.FindDuplicates("public1", tt.firstName, tt.lastName, tt.email)
+			if err != nil {
+				t.Fatalf("Error finding duplicates: %v", err)
+			}
+			if len(duplicates) != tt.want {
+				t.Errorf("Expected %d duplicates, got %+v", tt.want, duplicates)
+			}
+		})
+	}
+}
@@ -0,0 +1,565 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types groups can subscribe to
+const (
+	WebhookEventCreated       = "event.created"
+	WebhookEventUpdated       = "event.updated"
+	WebhookEventStatusChanged = "event.status_changed"
+	WebhookRSVPChanged        = "rsvp.changed"
+	WebhookLineupChanged      = "lineup.changed"
+	WebhookMemberJoined       = "member.joined"
+	WebhookGameAddedToLibrary = "game.added_to_library"
+	// WebhookTest is only sent by the send test endpoint
+	WebhookTest = "webhook.test"
+)
+
+const (
+	webhookSignatureHeader     = "X-Improv-Signature"
+	webhookTimestampHeader     = "X-Improv-Timestamp"
+	webhookEventHeader         = "X-Improv-Event"
+	webhookDeliveryHeader      = "X-Improv-Delivery"
+	maxWebhookAttempts         = 6
+	maxWebhookResponseBodySize = 1024
+	webhookDeliveryLease       = time.Minute
+)
+
+// WebhookEvents lists every event type a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventCreated,
+	WebhookEventUpdated,
+	WebhookEventStatusChanged,
+	WebhookRSVPChanged,
+	WebhookLineupChanged,
+	WebhookMemberJoined,
+	WebhookGameAddedToLibrary,
+}
+
+// IsValidWebhookEvent checks if the event type can be subscribed to
+func IsValidWebhookEvent(eventType string) bool {
+	for _, e := range WebhookEvents {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrWebhookAddressNotAllowed means a webhook URL points at a loopback, private or link-local address
+var ErrWebhookAddressNotAllowed = errors.New("webhook address not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range, which net.IP.IsPrivate leaves out
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicAddress reports whether webhooks may be sent to the IP. Loopback, private, link-local
+// and shared addresses are the server's own network, which group admins mustn't reach through it.
+func IsPublicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// CheckWebhookHost rejects localhost and IPs that aren't public. Other names are checked once
+// they're resolved, each time a webhook is delivered.
+func CheckWebhookHost(host string) error {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicAddress(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+	}
+	return nil
+}
+
+// newWebhookClient returns a client that only connects to public addresses. The check runs on
+// the resolved address, so a public name can't point inside the network, and on every redirect.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicAddress(ip) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		// No proxy, which would connect to the receiver on the client's behalf, unchecked
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Webhook is a URL registered by a group to receive events
+type Webhook struct {
+	ID        string    `json:"id"`
+	GroupID   string    `json:"groupId"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Subscribed reports whether the webhook wants an event type. No events means all of them.
+func (w Webhook) Subscribed(eventType string) bool {
+	if len(w.Events) == 0 || eventType == WebhookTest {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records an attempt to send one event to one webhook
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	WebhookID      string     `json:"webhookId"`
+	EventType      string     `json:"eventType"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"lastStatusCode,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// webhookPayload is the JSON body every webhook receives
+type webhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	GroupID   string      `json:"groupId"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookService queues group events for registered webhooks and delivers them
+type WebhookService struct {
+	db     *sql.DB
+	client *http.Client
+}
+
+func NewWebhookService(db *sql.DB) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		client: newWebhookClient(),
+	}
+}
+
+// SignWebhookPayload returns the signature sent in the X-Improv-Signature header.
+// Receivers recompute HMAC-SHA256(secret, timestamp + "." + body) and compare.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is how long to wait before retrying after the given number of failed attempts
+func webhookBackoff(attempts int) time.Duration {
+	switch attempts {
+	case 1:
+		return 30 * time.Second
+	case 2:
+		return 2 * time.Minute
+	case 3:
+		return 10 * time.Minute
+	case 4:
+		return time.Hour
+	default:
+		return 6 * time.Hour
+	}
+}
+
+// Emit queues an event for every active webhook in the group subscribed to it.
+// Delivery happens in the background worker, so handlers never wait on a receiver.
+func (s *WebhookService) Emit(groupID, eventType string, data interface{}) error {
+	webhooks, err := s.List(groupID)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Active || !webhook.Subscribed(eventType) {
+			continue
+		}
+		if _, err := s.queueDelivery(webhook, eventType, data, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueDelivery stores a pending delivery of an event to a webhook
+func (s *WebhookService) queueDelivery(webhook Webhook, eventType string, data interface{}, now time.Time) (string, error) {
+	deliveryID := uuid.New().String()
+	payload, err := json.Marshal(webhookPayload{
+		ID:        deliveryID,
+		Type:      eventType,
+		GroupID:   webhook.GroupID,
+		CreatedAt: now.UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding webhook payload: %v", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO webhook_deliveries (id, webhook_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, 'pending', 0, $5, $5)
+	`, deliveryID, webhook.ID, eventType, string(payload), now.UTC())
+	if err != nil {
+		return "", fmt.Errorf("error queueing webhook delivery: %v", err)
+	}
+	return deliveryID, nil
+}
+
+// Start delivers pending webhooks in the background every interval
+func (s *WebhookService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.DeliverPending(time.Now()); err != nil {
+				log.Printf("Error delivering webhooks: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// DeliverPending attempts every pending delivery that is due at now
+func (s *WebhookService) DeliverPending(now time.Time) error {
+	rows, err := s.db.Query(`
+		SELECT id FROM webhook_deliveries
+		WHERE status = 'pending' AND julianday(next_attempt_at) <= julianday($1)
+		ORDER BY created_at
+	`, now.UTC())
+	if err != nil {
+		return fmt.Errorf("error fetching pending webhook deliveries: %v", err)
+	}
+
+	var deliveryIDs []string
+	for rows.Next() {
+		var deliveryID string
+		if err := rows.Scan(&deliveryID); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning webhook delivery: %v", err)
+		}
+		deliveryIDs = append(deliveryIDs, deliveryID)
+	}
+	rows.Close()
+
+	for _, deliveryID := range deliveryIDs {
+		if err := s.attemptDelivery(deliveryID, now); err != nil {
+			log.Printf("Error delivering webhook %s: %v", deliveryID, err)
+		}
+	}
+	return nil
+}
+
+// attemptDelivery sends a delivery once and records the outcome, scheduling a retry on failure
+func (s *WebhookService) attemptDelivery(deliveryID string, now time.Time) error {
+	// Push the next attempt out while this one is in flight so the worker
+	// and the send test endpoint never deliver the same attempt twice
+	result, err := s.db.Exec(`
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $1
+		WHERE id = $2 AND status = 'pending' AND julianday(next_attempt_at) <= julianday($3)
+	`, now.Add(webhookDeliveryLease).UTC(), deliveryID, now.UTC())
+	if err != nil {
+		return fmt.Errorf("error claiming webhook delivery: %v", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error claiming webhook delivery: %v", err)
+	}
+	if claimed == 0 {
+		return nil
+	}
+
+	var url, secret, eventType, payload string
+	var attempts int
+	err = s.db.QueryRow(`
+		SELECT w.url, w.secret, d.event_type, d.payload, d.attempts
+		FROM webhook_deliveries d
+		JOIN group_webhooks w ON d.webhook_id = w.id
+		WHERE d.id = $1
+	`, deliveryID).Scan(&url, &secret, &eventType, &payload, &attempts)
+	if err != nil {
+		return fmt.Errorf("error fetching webhook delivery: %v", err)
+	}
+
+	statusCode, sendErr := s.send(url, secret, deliveryID, eventType, []byte(payload), now)
+	attempts++
+
+	if sendErr == nil {
+		_, err = s.db.Exec(`
+			UPDATE webhook_deliveries
+			SET status = 'delivered', attempts = $1, last_status_code = $2, last_error = NULL,
+				next_attempt_at = NULL, delivered_at = $3
+			WHERE id = $4
+		`, attempts, statusCode, now.UTC(), deliveryID)
+		return err
+	}
+
+	status := "pending"
+	var nextAttemptAt interface{} = now.Add(webhookBackoff(attempts)).UTC()
+	if attempts >= maxWebhookAttempts {
+		status = "failed"
+		nextAttemptAt = nil
+	}
+	_, err = s.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, last_status_code = $3, last_error = $4, next_attempt_at = $5
+		WHERE id = $6
+	`, status, attempts, statusCode, sendErr.Error(), nextAttemptAt, deliveryID)
+	return err
+}
+
+// send posts a signed payload, treating anything but a 2xx response as a failure
+func (s *WebhookService) send(url, secret, deliveryID, eventType string, body []byte, now time.Time) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Improv-App-Webhooks/1.0")
+	req.Header.Set(webhookEventHeader, eventType)
+	req.Header.Set(webhookDeliveryHeader, deliveryID)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, SignWebhookPayload(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBodySize))
+		return resp.StatusCode, fmt.Errorf("receiver responded %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return resp.StatusCode, nil
+}
+
+// SendTest queues a test event for a webhook and delivers it immediately
+func (s *WebhookService) SendTest(webhook Webhook) (*WebhookDelivery, error) {
+	now := time.Now()
+	deliveryID, err := s.queueDelivery(webhook, WebhookTest, map[string]string{
+		"message": "This is a test event from Improv App",
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attemptDelivery(deliveryID, now); err != nil {
+		return nil, err
+	}
+	return s.GetDelivery(deliveryID)
+}
+
+// Create registers a new webhook with a freshly generated secret
+func (s *WebhookService) Create(groupID, url string, events []string, createdBy string) (*Webhook, error) {
+	secret, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("error generating webhook secret: %v", err)
+	}
+
+	webhookID := uuid.New().String()
+	_, err = s.db.Exec(`
+		INSERT INTO group_webhooks (id, group_id, url, secret, events, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, TRUE, $6)
+	`, webhookID, groupID, url, secret, strings.Join(events, ","), createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("error creating webhook: %v", err)
+	}
+	return s.Get(groupID, webhookID)
+}
+
+// Update changes a webhook's URL, subscribed events and whether it's active
+func (s *WebhookService) Update(groupID, webhookID, url string, events []string, active bool) (*Webhook, error) {
+	_, err := s.db.Exec(`
+		UPDATE group_webhooks
+		SET url = $1, events = $2, active = $3
+		WHERE id = $4 AND group_id = $5
+	`, url, strings.Join(events, ","), active, webhookID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating webhook: %v", err)
+	}
+	return s.Get(groupID, webhookID)
+}
+
+// Delete removes a webhook and its delivery log
+func (s *WebhookService) Delete(groupID, webhookID string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM webhook_deliveries
+		WHERE webhook_id IN (SELECT id FROM group_webhooks WHERE id = $1 AND group_id = $2)
+	`, webhookID, groupID)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook deliveries: %v", err)
+	}
+	_, err = s.db.Exec(`
+		DELETE FROM group_webhooks WHERE id = $1 AND group_id = $2
+	`, webhookID, groupID)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook: %v", err)
+	}
+	return nil
+}
+
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (Webhook, error) {
+	var webhook Webhook
+	var events string
+	err := scanner.Scan(&webhook.ID, &webhook.GroupID, &webhook.URL, &webhook.Secret, &events, &webhook.Active, &webhook.CreatedBy, &webhook.CreatedAt)
+	if err != nil {
+		return webhook, err
+	}
+	webhook.Events = []string{}
+	if events != "" {
+		webhook.Events = strings.Split(events, ",")
+	}
+	return webhook, nil
+}
+
+// Get returns one of the group's webhooks, or nil if it doesn't exist
+func (s *WebhookService) Get(groupID, webhookID string) (*Webhook, error) {
+	webhook, err := scanWebhook(s.db.QueryRow(`
+		SELECT id, group_id, url, secret, COALESCE(events, ''), active, created_by, created_at
+		FROM group_webhooks
+		WHERE id = $1 AND group_id = $2
+	`, webhookID, groupID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching webhook: %v", err)
+	}
+	return &webhook, nil
+}
+
+// List returns every webhook registered for the group
+func (s *WebhookService) List(groupID string) ([]Webhook, error) {
+	rows, err := s.db.Query(`
+		SELECT id, group_id, url, secret, COALESCE(events, ''), active, created_by, created_at
+		FROM group_webhooks
+		WHERE group_id = $1
+		ORDER BY created_at
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching webhooks: %v", err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook: %v", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+const webhookDeliveryColumns = `
+	id, webhook_id, event_type, payload, status, attempts, COALESCE(last_status_code, 0),
+	COALESCE(last_error, ''), next_attempt_at, created_at, delivered_at
+`
+
+func scanWebhookDelivery(scanner interface{ Scan(...interface{}) error }) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	var nextAttemptAt, deliveredAt sql.NullTime
+	err := scanner.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventType, &delivery.Payload, &delivery.Status,
+		&delivery.Attempts, &delivery.LastStatusCode, &delivery.LastError, &nextAttemptAt, &delivery.CreatedAt, &deliveredAt)
+	if err != nil {
+		return delivery, err
+	}
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
+
+// GetDelivery returns a single delivery
+func (s *WebhookService) GetDelivery(deliveryID string) (*WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(s.db.QueryRow(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE id = $1
+	`, deliveryID))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching webhook delivery: %v", err)
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns a page of a webhook's deliveries, newest first, along with the total count
+func (s *WebhookService) ListDeliveries(webhookID string, page, pageSize int) ([]WebhookDelivery, int, error) {
+	var totalItems int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1
+	`, webhookID).Scan(&totalItems)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting webhook deliveries: %v", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, webhookID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error scanning webhook delivery: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, totalItems, nil
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records requests and responds with the queued status codes, then 200
+type webhookReceiver struct {
+	mu        sync.Mutex
+	requests  []receivedWebhook
+	responses []int
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func (rec *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.requests = append(rec.requests, receivedWebhook{header: r.Header.Clone(), body: body})
+
+	status := http.StatusOK
+	if len(rec.responses) > 0 {
+		status = rec.responses[0]
+		rec.responses = rec.responses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rec *webhookReceiver) received() []receivedWebhook {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]receivedWebhook{}, rec.requests...)
+}
+
+// newLocalWebhookService delivers to the test receivers on loopback, which real webhooks can't reach
+func newLocalWebhookService(testDB *sql.DB) *WebhookService {
+	service := NewWebhookService(testDB)
+	service.client = &http.Client{Timeout: 10 * time.Second}
+	return service
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"type":"event.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if signature := SignWebhookPayload("secret", "1700000000", body); signature != expected {
+		t.Errorf("Expected signature %s, got %s", expected, signature)
+	}
+
+	if SignWebhookPayload("other", "1700000000", body) == expected {
+		t.Errorf("Expected a different secret to produce a different signature")
+	}
+}
+
+func TestWebhookService_DeliversSignedSubscribedEvents(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	service := newLocalWebhookService(testDB)
+	webhook, err := service.Create("group123", server.URL, []string{WebhookEventCreated}, "user123")
+	if err != nil {
+		t.Fatalf("Error creating webhook: %v", err)
+	}
+
+	if err := service.Emit("group123", WebhookEventCreated, map[string]string{"eventId": "event123"}); err != nil {
+		t.Fatalf("Error emitting event: %v", err)
+	}
+	// Not subscribed, so this should never be delivered
+	if err := service.Emit("group123", WebhookRSVPChanged, map[string]string{"eventId": "event123"}); err != nil {
+		t.Fatalf("Error emitting event: %v", err)
+	}
+
+	if err := service.DeliverPending(time.Now()); err != nil {
+		t.Fatalf("Error delivering webhooks: %v", err)
+	}
+
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(requests))
+	}
+
+	request := requests[0]
+	if request.header.Get("X-Improv-Event") != WebhookEventCreated {
+		t.Errorf("Expected event header %s, got %s", WebhookEventCreated, request.header.Get("X-Improv-Event"))
+	}
+
+	timestamp := request.header.Get("X-Improv-Timestamp")
+	if signature := request.header.Get("X-Improv-Signature"); signature != SignWebhookPayload(webhook.Secret, timestamp, request.body) {
+		t.Errorf("Signature %s doesn't match the payload", signature)
+	}
+
+	var payload struct {
+		ID      string            `json:"id"`
+		Type    string            `json:"type"`
+		GroupID string            `json:"groupId"`
+		Data    map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(request.body, &payload); err != nil {
+		t.Fatalf("Error decoding payload: %v", err)
+	}
+	if payload.Type != WebhookEventCreated || payload.GroupID != "group123" || payload.Data["eventId"] != "event123" {
+		t.Errorf("Unexpected payload: %s", request.body)
+	}
+
+	delivery, err := service.GetDelivery(payload.ID)
+	if err != nil {
+		t.Fatalf("Error fetching delivery: %v", err)
+	}
+	if delivery.Status != "delivered" || delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusOK {
+		t.Errorf("Expected delivered after 1 attempt with 200, got %s after %d with %d", delivery.Status, delivery.Attempts, delivery.LastStatusCode)
+	}
+}
+
+func TestWebhookService_RetriesFailedDeliveries(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	receiver := &webhookReceiver{responses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	service := newLocalWebhookService(testDB)
+	webhook, err := service.Create("group123", server.URL, nil, "user123")
+	if err != nil {
+		t.Fatalf("Error creating webhook: %v", err)
+	}
+	if err := service.Emit("group123", WebhookMemberJoined, map[string]string{"userId": "user456"}); err != nil {
+		t.Fatalf("Error emitting event: %v", err)
+	}
+
+	now := time.Now()
+	if err := service.DeliverPending(now); err != nil {
+		t.Fatalf("Error delivering webhooks: %v", err)
+	}
+
+	deliveries, total, err := service.ListDeliveries(webhook.ID, 1, 10)
+	if err != nil || total != 1 {
+		t.Fatalf("Expected 1 delivery, got %d (err=%v)", total, err)
+	}
+	failed := deliveries[0]
+	if failed.Status != "pending" || failed.Attempts != 1 || failed.LastStatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected pending retry after a 500, got %s after %d with %d", failed.Status, failed.Attempts, failed.LastStatusCode)
+	}
+	if failed.LastError == "" {
+		t.Errorf("Expected the failure to be logged")
+	}
+
+	// Nothing is due again until the backoff has passed
+	if err := service.DeliverPending(now.Add(time.Second)); err != nil {
+		t.Fatalf("Error delivering webhooks: %v", err)
+	}
+	if len(receiver.received()) != 1 {
+		t.Errorf("Expected no retry before the backoff, got %d requests", len(receiver.received()))
+	}
+
+	if err := service.DeliverPending(now.Add(webhookBackoff(1) + time.Second)); err != nil {
+		t.Fatalf("Error delivering webhooks: %v", err)
+	}
+	if len(receiver.received()) != 2 {
+		t.Fatalf("Expected a retry after the backoff, got %d requests", len(receiver.received()))
+	}
+
+	delivered, err := service.GetDelivery(failed.ID)
+	if err != nil {
+		t.Fatalf("Error fetching delivery: %v", err)
+	}
+	if delivered.Status != "delivered" || delivered.Attempts != 2 {
+		t.Errorf("Expected delivered after 2 attempts, got %s after %d", delivered.Status, delivered.Attempts)
+	}
+}
+
+func TestWebhookService_SendTestDeliversImmediately(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	service := newLocalWebhookService(testDB)
+	webhook, err := service.Create("group123", server.URL, []string{WebhookLineupChanged}, "user123")
+	if err != nil {
+		t.Fatalf("Error creating webhook: %v", err)
+	}
+
+	delivery, err := service.SendTest(*webhook)
+	if err != nil {
+		t.Fatalf("Error sending test: %v", err)
+	}
+	if delivery.Status != "delivered" || delivery.EventType != WebhookTest {
+		t.Errorf("Expected delivered test event, got %s %s", delivery.Status, delivery.EventType)
+	}
+
+	// The worker must not pick the test up a second time
+	if err := service.DeliverPending(time.Now()); err != nil {
+		t.Fatalf("Error delivering webhooks: %v", err)
+	}
+	if len(receiver.received()) != 1 {
+		t.Errorf("Expected exactly 1 request, got %d", len(receiver.received()))
+	}
+}
+
+func TestCheckWebhookHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{"hooks.example.com", true},
+		{"93.184.216.34", true},
+		{"localhost", false},
+		{"api.localhost", false},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"192.168.0.10", false},
+		{"172.16.5.4", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+	}
+	for _, tt := range tests {
+		err := CheckWebhookHost(tt.host)
+		if tt.allowed && err != nil {
+			t.Errorf("Expected %s allowed, got %v", tt.host, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrWebhookAddressNotAllowed) {
+			t.Errorf("Expected %s not allowed, got %v", tt.host, err)
+		}
+	}
+}
+
+func TestWebhookService_RefusesPrivateAddresses(t *testing.T) {
+	testDB := newTestDB(t, seedGroup)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	// The receiver is on loopback, which is refused when connecting
+	service := NewWebhookService(testDB)
+	webhook, err := service.Create("group123", server.URL, []string{WebhookEventCreated}, "user123")
+	if err != nil {
+		t.Fatalf("Error creating webhook: %v", err)
+	}
+
+	delivery, err := service.SendTest(*webhook)
+	if err != nil {
+		t.Fatalf("Error sending test: %v", err)
+	}
+	if len(receiver.received()) != 0 {
+		t.Errorf("Expected nothing sent to a loopback address")
+	}
+	if delivery.Status != "pending" || !strings.Contains(delivery.LastError, ErrWebhookAddressNotAllowed.Error()) {
+		t.Errorf("Expected the delivery refused, got %+v", delivery)
+	}
+}
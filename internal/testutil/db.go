@@ -0,0 +1,40 @@
+// Package testutil holds the test database helpers shared by the packages' tests
+package testutil
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"improv-app/internal/db"
+)
+
+// SeedGroup is Ada Admin and group123, the group they run. Most tests start from it.
+var SeedGroup = []string{
+	`INSERT INTO users (id, email, first_name, last_name) VALUES ('user123', 'admin@example.com', 'Ada', 'Admin')`,
+	`INSERT INTO improv_groups (id, name, description, created_by) VALUES ('group123', 'Test Group', '', 'user123')`,
+	`INSERT INTO group_members (group_id, user_id, role) VALUES ('group123', 'user123', 'admin')`,
+}
+
+// NewDB creates a fresh, migrated database seeded with the fixtures, in order
+func NewDB(t *testing.T, fixtures ...[]string) *sql.DB {
+	t.Helper()
+	t.Setenv("DATABASE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	testDB := db.InitDB()
+	t.Cleanup(func() { testDB.Close() })
+
+	for _, fixture := range fixtures {
+		Seed(t, testDB, fixture...)
+	}
+	return testDB
+}
+
+// Seed runs the statements against the test database, failing the test if any of them fail
+func Seed(t *testing.T, testDB *sql.DB, statements ...string) {
+	t.Helper()
+	for _, statement := range statements {
+		if _, err := testDB.Exec(statement); err != nil {
+			t.Fatalf("Error seeding test database: %v\n%s", err, statement)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"improv-app/internal/config"
 	"improv-app/internal/db"
@@ -27,6 +28,13 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush passes flushes through so streaming responses reach the client
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func main() {
 	env := os.Getenv("ENV")
 	if env == "" {
@@ -46,13 +54,43 @@ func main() {
 	// Initialize services
 	emailService := services.NewEmailService(sqlDB)
 
+	// Start background jobs
+	reminderOffsets := services.DefaultReminderOffsets
+	if value := os.Getenv("REMINDER_OFFSETS"); value != "" {
+		offsets, err := services.ParseReminderOffsets(value)
+		if err != nil {
+			log.Fatalf("Invalid REMINDER_OFFSETS: %v", err)
+		}
+		reminderOffsets = offsets
+	}
+	reminderService := services.NewReminderService(sqlDB, emailService, reminderOffsets)
+	reminderService.Start(time.Minute)
+	digestService := services.NewDigestService(sqlDB, emailService)
+	digestService.Start(15 * time.Minute)
+	webhookService := services.NewWebhookService(sqlDB)
+	webhookService.Start(10 * time.Second)
+	chatService := services.NewChatService(sqlDB)
+	chatService.Start(15 * time.Minute)
+	eventStatusService := services.NewEventStatusService(sqlDB)
+	eventStatusService.Start(5 * time.Minute)
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(emailService)
+	authHandler := handlers.NewAuthHandler(sqlDB, emailService)
 	groupHandler := handlers.NewGroupHandler(sqlDB)
 	invitationHandler := handlers.NewInvitationHandler(sqlDB)
 	eventHandler := handlers.NewEventHandler(sqlDB)
 	gameHandler := handlers.NewGameHandler(sqlDB)
 	rsvpHandler := handlers.NewRSVPHandler(sqlDB)
+	notificationPreferenceHandler := handlers.NewNotificationPreferenceHandler(sqlDB)
+	notificationHandler := handlers.NewNotificationHandler(sqlDB)
+	webhookHandler := handlers.NewWebhookHandler(sqlDB)
+	chatIntegrationHandler := handlers.NewChatIntegrationHandler(sqlDB)
+	eventTemplateHandler := handlers.NewEventTemplateHandler(sqlDB)
+	venueHandler := handlers.NewVenueHandler(sqlDB)
+	calendarFeedHandler := handlers.NewCalendarFeedHandler(sqlDB)
+	walkInHandler := handlers.NewWalkInHandler(sqlDB)
+	eventRoleHandler := handlers.NewEventRoleHandler(sqlDB)
+	publicEventHandler := handlers.NewPublicEventHandler(sqlDB)
 
 	r := mux.NewRouter()
 	api := r.PathPrefix("/api").Subrouter()
@@ -97,6 +135,34 @@ func main() {
 	api.HandleFunc("/auth/me", middleware.RequireAuthAPI(sqlDB, authHandler.GetCurrentUser)).Methods("GET")
 	api.HandleFunc("/profile", middleware.RequireAuthAPI(sqlDB, authHandler.Profile)).Methods("GET", "PUT")
 
+	// Notification preference routes
+	api.HandleFunc("/notification-preferences", middleware.RequireAuthAPI(sqlDB, notificationPreferenceHandler.Get)).Methods("GET")
+	api.HandleFunc("/notification-preferences", middleware.RequireAuthAPI(sqlDB, notificationPreferenceHandler.Update)).Methods("PUT")
+	// Unsubscribe links are signed, so they work without a session
+	api.HandleFunc("/unsubscribe", notificationPreferenceHandler.UnsubscribePage).Methods("GET")
+	api.HandleFunc("/unsubscribe", notificationPreferenceHandler.Unsubscribe).Methods("POST")
+
+	// Notification center routes
+	api.HandleFunc("/notifications", middleware.RequireAuthAPI(sqlDB, notificationHandler.List)).Methods("GET")
+	api.HandleFunc("/notifications/unread-count", middleware.RequireAuthAPI(sqlDB, notificationHandler.UnreadCount)).Methods("GET")
+	api.HandleFunc("/notifications/read-all", middleware.RequireAuthAPI(sqlDB, notificationHandler.MarkAllRead)).Methods("POST")
+	api.HandleFunc("/notifications/{id}/read", middleware.RequireAuthAPI(sqlDB, notificationHandler.MarkRead)).Methods("POST")
+
+	// Calendar feed routes
+	api.HandleFunc("/me/calendar-feed", middleware.RequireAuthAPI(sqlDB, calendarFeedHandler.GetUserFeed)).Methods("GET")
+	api.HandleFunc("/me/calendar-feed/regenerate", middleware.RequireAuthAPI(sqlDB, calendarFeedHandler.RegenerateUserFeed)).Methods("POST")
+
+	// Scheduling conflicts across the user's groups
+	api.HandleFunc("/me/conflicts", middleware.RequireAuthAPI(sqlDB, eventHandler.MyConflicts)).Methods("GET")
+	api.HandleFunc("/groups/{id}/calendar-feed", middleware.RequireAuthAPI(sqlDB, calendarFeedHandler.GetGroupFeed)).Methods("GET")
+	api.HandleFunc("/groups/{id}/calendar-feed/regenerate", middleware.RequireAuthAPI(sqlDB, calendarFeedHandler.RegenerateGroupFeed)).Methods("POST")
+	// Feed URLs carry a secret token, since calendar apps can't sign in
+	api.HandleFunc("/calendar/{token:[0-9a-f]+}.ics", calendarFeedHandler.Serve).Methods("GET")
+
+	// Public event pages, for people who aren't signed in
+	api.HandleFunc("/public/events", publicEventHandler.List).Methods("GET")
+	api.HandleFunc("/public/events/{id}", publicEventHandler.Get).Methods("GET")
+
 	// Group member management routes
 	api.HandleFunc("/groups/invites", middleware.RequireAuthAPI(sqlDB, invitationHandler.ListInvitations)).Methods("GET")
 	api.HandleFunc("/groups/invites/accept", middleware.RequireAuthAPI(sqlDB, invitationHandler.AcceptInvitation)).Methods("POST")
@@ -126,32 +192,110 @@ func main() {
 	api.HandleFunc("/groups/{id}/games/library/{gameId}", middleware.RequireAuthAPI(sqlDB, groupHandler.AddGameToLibrary)).Methods("POST")
 	api.HandleFunc("/groups/{id}/games/library/{gameId}", middleware.RequireAuthAPI(sqlDB, groupHandler.RemoveGameFromLibrary)).Methods("DELETE")
 
+	// Group webhook routes
+	api.HandleFunc("/groups/{id}/webhooks", middleware.RequireAuthAPI(sqlDB, webhookHandler.List)).Methods("GET")
+	api.HandleFunc("/groups/{id}/webhooks", middleware.RequireAuthAPI(sqlDB, webhookHandler.Create)).Methods("POST")
+	api.HandleFunc("/groups/{id}/webhooks/{webhookId}", middleware.RequireAuthAPI(sqlDB, webhookHandler.Update)).Methods("PUT")
+	api.HandleFunc("/groups/{id}/webhooks/{webhookId}", middleware.RequireAuthAPI(sqlDB, webhookHandler.Delete)).Methods("DELETE")
+	api.HandleFunc("/groups/{id}/webhooks/{webhookId}/deliveries", middleware.RequireAuthAPI(sqlDB, webhookHandler.ListDeliveries)).Methods("GET")
+	api.HandleFunc("/groups/{id}/webhooks/{webhookId}/test", middleware.RequireAuthAPI(sqlDB, webhookHandler.SendTest)).Methods("POST")
+
+	// Group chat integration routes
+	api.HandleFunc("/groups/{id}/chat-integrations", middleware.RequireAuthAPI(sqlDB, chatIntegrationHandler.List)).Methods("GET")
+	api.HandleFunc("/groups/{id}/chat-integrations", middleware.RequireAuthAPI(sqlDB, chatIntegrationHandler.Create)).Methods("POST")
+	api.HandleFunc("/groups/{id}/chat-integrations/{integrationId}", middleware.RequireAuthAPI(sqlDB, chatIntegrationHandler.Update)).Methods("PUT")
+	api.HandleFunc("/groups/{id}/chat-integrations/{integrationId}", middleware.RequireAuthAPI(sqlDB, chatIntegrationHandler.Delete)).Methods("DELETE")
+	api.HandleFunc("/groups/{id}/chat-integrations/{integrationId}/test", middleware.RequireAuthAPI(sqlDB, chatIntegrationHandler.SendTest)).Methods("POST")
+
+	// Group event template routes
+	api.HandleFunc("/groups/{id}/event-templates", middleware.RequireAuthAPI(sqlDB, eventTemplateHandler.List)).Methods("GET")
+	api.HandleFunc("/groups/{id}/event-templates", middleware.RequireAuthAPI(sqlDB, eventTemplateHandler.Create)).Methods("POST")
+	api.HandleFunc("/groups/{id}/event-templates/{templateId}", middleware.RequireAuthAPI(sqlDB, eventTemplateHandler.Get)).Methods("GET")
+	api.HandleFunc("/groups/{id}/event-templates/{templateId}", middleware.RequireAuthAPI(sqlDB, eventTemplateHandler.Update)).Methods("PUT")
+	api.HandleFunc("/groups/{id}/event-templates/{templateId}", middleware.RequireAuthAPI(sqlDB, eventTemplateHandler.Delete)).Methods("DELETE")
+
+	// Group venue routes
+	api.HandleFunc("/groups/{id}/venues", middleware.RequireAuthAPI(sqlDB, venueHandler.List)).Methods("GET")
+	api.HandleFunc("/groups/{id}/venues", middleware.RequireAuthAPI(sqlDB, venueHandler.Create)).Methods("POST")
+	api.HandleFunc("/groups/{id}/venue-suggestions", middleware.RequireAuthAPI(sqlDB, venueHandler.Suggestions)).Methods("GET")
+	api.HandleFunc("/groups/{id}/venues/{venueId}", middleware.RequireAuthAPI(sqlDB, venueHandler.Get)).Methods("GET")
+	api.HandleFunc("/groups/{id}/venues/{venueId}", middleware.RequireAuthAPI(sqlDB, venueHandler.Update)).Methods("PUT")
+	api.HandleFunc("/groups/{id}/venues/{venueId}", middleware.RequireAuthAPI(sqlDB, venueHandler.Delete)).Methods("DELETE")
+	api.HandleFunc("/groups/{id}/venues/{venueId}/events", middleware.RequireAuthAPI(sqlDB, eventHandler.ListForVenue)).Methods("GET")
+
 	// Event routes
 	api.HandleFunc("/events", middleware.RequireAuthAPI(sqlDB, eventHandler.ListAll)).Methods("GET")
 	api.HandleFunc("/events", middleware.RequireAuthAPI(sqlDB, eventHandler.Create)).Methods("POST")
-	api.HandleFunc("/events/{id}", middleware.RequireAuthAPI(sqlDB, eventHandler.Get)).Methods("GET")
-	api.HandleFunc("/events/{id}", middleware.RequireAuthAPI(sqlDB, eventHandler.Update)).Methods("PUT")
+	api.HandleFunc("/events/{id}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.Get))).Methods("GET")
+	api.HandleFunc("/events/{id}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.Update))).Methods("PUT")
+	api.HandleFunc("/events/{id}/clone", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.Clone))).Methods("POST")
+	api.HandleFunc("/events/{id}/status", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.UpdateStatus))).Methods("PUT")
+	api.HandleFunc("/events/{id}/stream", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.Stream))).Methods("GET")
+	api.HandleFunc("/events/{id}/occurrence", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.CancelOccurrence))).Methods("DELETE")
 	api.HandleFunc("/groups/{id}/events", middleware.RequireAuthAPI(sqlDB, eventHandler.List)).Methods("GET", "POST")
+	api.HandleFunc("/groups/{id}/events/import", middleware.RequireAuthAPI(sqlDB, eventHandler.Import)).Methods("POST")
 	// Event game management routes
-	api.HandleFunc("/events/{id}/games", middleware.RequireAuthAPI(sqlDB, eventHandler.GetEventGames)).Methods("GET")
-	api.HandleFunc("/events/{id}/games", middleware.RequireAuthAPI(sqlDB, eventHandler.AddGameToEvent)).Methods("POST")
-	api.HandleFunc("/events/{id}/games/{gameId}", middleware.RequireAuthAPI(sqlDB, eventHandler.RemoveGameFromEvent)).Methods("DELETE")
-	api.HandleFunc("/events/{id}/games/{gameId}/order", middleware.RequireAuthAPI(sqlDB, eventHandler.UpdateGameOrder)).Methods("PUT")
+	api.HandleFunc("/events/{id}/games", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetEventGames))).Methods("GET")
+	api.HandleFunc("/events/{id}/games", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.AddGameToEvent))).Methods("POST")
+	api.HandleFunc("/events/{id}/games/{gameId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.RemoveGameFromEvent))).Methods("DELETE")
+	api.HandleFunc("/events/{id}/games/{gameId}/order", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.UpdateGameOrder))).Methods("PUT")
+	api.HandleFunc("/events/{id}/lineup/finalize", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.FinalizeLineup))).Methods("POST")
+	api.HandleFunc("/events/{id}/lineup/generate", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GenerateLineup))).Methods("POST")
+	api.HandleFunc("/events/{id}/lineup/accept", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.AcceptLineup))).Methods("POST")
+	api.HandleFunc("/events/{id}/lineup/constraints", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetLineupConstraints))).Methods("GET")
+	api.HandleFunc("/events/{id}/lineup/constraints", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.CreateLineupConstraint))).Methods("POST")
+	api.HandleFunc("/events/{id}/lineup/constraints/{constraintId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.UpdateLineupConstraint))).Methods("PUT")
+	api.HandleFunc("/events/{id}/lineup/constraints/{constraintId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.DeleteLineupConstraint))).Methods("DELETE")
+	api.HandleFunc("/events/{id}/health", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetEventHealth))).Methods("GET")
 	// Player assignment routes
-	api.HandleFunc("/events/{id}/players", middleware.RequireAuthAPI(sqlDB, eventHandler.GetEventPlayers)).Methods("GET")
-	api.HandleFunc("/events/{id}/games/{gameId}/players", middleware.RequireAuthAPI(sqlDB, eventHandler.AssignPlayerToGame)).Methods("POST")
-	api.HandleFunc("/events/{id}/games/{gameId}/players/{userId}", middleware.RequireAuthAPI(sqlDB, eventHandler.RemovePlayerFromGame)).Methods("DELETE")
-	api.HandleFunc("/events/{id}/preferences", middleware.RequireAuthAPI(sqlDB, eventHandler.GetUserGamePreferences)).Methods("GET")
+	api.HandleFunc("/events/{id}/players", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetEventPlayers))).Methods("GET")
+	api.HandleFunc("/events/{id}/games/{gameId}/players", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.AssignPlayerToGame))).Methods("POST")
+	api.HandleFunc("/events/{id}/games/{gameId}/players/{userId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.RemovePlayerFromGame))).Methods("DELETE")
+	api.HandleFunc("/events/{id}/preferences", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetUserGamePreferences))).Methods("GET")
+	// Run-of-show routes
+	api.HandleFunc("/events/{id}/run-of-show", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetRunOfShow))).Methods("GET")
+	api.HandleFunc("/events/{id}/run-of-show/games/{gameId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.PlanRunOfShowGame))).Methods("PUT")
+	api.HandleFunc("/events/{id}/run-of-show/segments", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.AddRunOfShowSegment))).Methods("POST")
+	api.HandleFunc("/events/{id}/run-of-show/segments/{segmentId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.UpdateRunOfShowSegment))).Methods("PUT")
+	api.HandleFunc("/events/{id}/run-of-show/segments/{segmentId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.DeleteRunOfShowSegment))).Methods("DELETE")
+	api.HandleFunc("/events/{id}/run-of-show/start", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.StartShow))).Methods("POST")
+	api.HandleFunc("/events/{id}/run-of-show/advance", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.AdvanceShow))).Methods("POST")
+	api.HandleFunc("/events/{id}/run-of-show/reset", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.ResetShow))).Methods("POST")
 	// Event RSVP routes
-	api.HandleFunc("/events/{id}/rsvp", middleware.RequireAuthAPI(sqlDB, rsvpHandler.SubmitRSVP)).Methods("POST")
-	api.HandleFunc("/events/{id}/rsvp/me", middleware.RequireAuthAPI(sqlDB, rsvpHandler.GetCurrentUserRSVP)).Methods("GET")
-	api.HandleFunc("/events/{id}/rsvp/{userId}", middleware.RequireAuthAPI(sqlDB, rsvpHandler.UpdateUserRSVP)).Methods("PUT")
+	api.HandleFunc("/events/{id}/rsvp", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(rsvpHandler.SubmitRSVP))).Methods("POST")
+	api.HandleFunc("/events/{id}/rsvp/me", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(rsvpHandler.GetCurrentUserRSVP))).Methods("GET")
+	api.HandleFunc("/events/{id}/rsvp/{userId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(rsvpHandler.UpdateUserRSVP))).Methods("PUT")
 
 	// Non-registered attendees (walk-ins) routes
-	api.HandleFunc("/events/{id}/non-registered-attendees", middleware.RequireAuthAPI(sqlDB, eventHandler.GetNonRegisteredAttendees)).Methods("GET")
-	api.HandleFunc("/events/{id}/non-registered-attendees", middleware.RequireAuthAPI(sqlDB, eventHandler.AddNonRegisteredAttendee)).Methods("POST")
-	api.HandleFunc("/events/{id}/non-registered-attendees/{attendeeId}", middleware.RequireAuthAPI(sqlDB, eventHandler.UpdateNonRegisteredAttendee)).Methods("PUT")
-	api.HandleFunc("/events/{id}/non-registered-attendees/{attendeeId}", middleware.RequireAuthAPI(sqlDB, eventHandler.DeleteNonRegisteredAttendee)).Methods("DELETE")
+	api.HandleFunc("/events/{id}/non-registered-attendees", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetNonRegisteredAttendees))).Methods("GET")
+	api.HandleFunc("/events/{id}/non-registered-attendees", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.AddNonRegisteredAttendee))).Methods("POST")
+	api.HandleFunc("/events/{id}/non-registered-attendees/{attendeeId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.UpdateNonRegisteredAttendee))).Methods("PUT")
+	api.HandleFunc("/events/{id}/non-registered-attendees/{attendeeId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.DeleteNonRegisteredAttendee))).Methods("DELETE")
+
+	// Attendance routes
+	api.HandleFunc("/events/{id}/attendance", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetAttendance))).Methods("GET")
+	api.HandleFunc("/events/{id}/attendance/no-shows", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.MarkNoShows))).Methods("POST")
+	api.HandleFunc("/events/{id}/attendance/{attendeeId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.RecordAttendance))).Methods("PUT")
+	api.HandleFunc("/events/{id}/attendance/{attendeeId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.ClearAttendance))).Methods("DELETE")
+	api.HandleFunc("/events/{id}/check-in-code", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetCheckInCode))).Methods("GET")
+	api.HandleFunc("/events/{id}/check-in", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.SelfCheckIn))).Methods("POST")
+	api.HandleFunc("/groups/{id}/attendance", middleware.RequireAuthAPI(sqlDB, eventHandler.GroupAttendance)).Methods("GET")
+
+	// Walk-in account linking routes
+	api.HandleFunc("/groups/{id}/walk-in-matches", middleware.RequireAuthAPI(sqlDB, walkInHandler.ListMatches)).Methods("GET")
+	api.HandleFunc("/groups/{id}/walk-in-matches/{attendeeId}/confirm", middleware.RequireAuthAPI(sqlDB, walkInHandler.ConfirmMatch)).Methods("POST")
+	api.HandleFunc("/groups/{id}/walk-in-matches/{attendeeId}/reject", middleware.RequireAuthAPI(sqlDB, walkInHandler.RejectMatch)).Methods("POST")
+	api.HandleFunc("/me/walk-in-groups", middleware.RequireAuthAPI(sqlDB, walkInHandler.GroupsToJoin)).Methods("GET")
+	api.HandleFunc("/me/walk-in-groups/{groupId}/join", middleware.RequireAuthAPI(sqlDB, walkInHandler.JoinGroup)).Methods("POST")
+
+	// Event crew routes
+	api.HandleFunc("/groups/{id}/event-roles", middleware.RequireAuthAPI(sqlDB, eventRoleHandler.List)).Methods("GET")
+	api.HandleFunc("/groups/{id}/event-roles", middleware.RequireAuthAPI(sqlDB, eventRoleHandler.Create)).Methods("POST")
+	api.HandleFunc("/groups/{id}/event-roles/{roleId}", middleware.RequireAuthAPI(sqlDB, eventRoleHandler.Update)).Methods("PUT")
+	api.HandleFunc("/groups/{id}/event-roles/{roleId}", middleware.RequireAuthAPI(sqlDB, eventRoleHandler.Delete)).Methods("DELETE")
+	api.HandleFunc("/events/{id}/crew", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.GetCrew))).Methods("GET")
+	api.HandleFunc("/events/{id}/crew", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.AssignCrew))).Methods("POST")
+	api.HandleFunc("/events/{id}/crew/{userId}/{roleId}", middleware.RequireAuthAPI(sqlDB, eventHandler.ResolveOccurrence(eventHandler.RemoveCrew))).Methods("DELETE")
 
 	// Game routes
 	api.HandleFunc("/games", gameHandler.List).Methods("GET")